@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -10,34 +12,40 @@ import (
 	"time"
 
 	"comfy-tg-bot/internal/admin"
+	"comfy-tg-bot/internal/bugreport"
 	"comfy-tg-bot/internal/comfyui"
 	"comfy-tg-bot/internal/config"
+	"comfy-tg-bot/internal/erasure"
+	"comfy-tg-bot/internal/favorites"
+	"comfy-tg-bot/internal/gallery"
+	"comfy-tg-bot/internal/history"
 	"comfy-tg-bot/internal/image"
 	"comfy-tg-bot/internal/limiter"
+	"comfy-tg-bot/internal/metrics"
+	"comfy-tg-bot/internal/prompt"
+	"comfy-tg-bot/internal/server"
 	"comfy-tg-bot/internal/settings"
+	"comfy-tg-bot/internal/state"
+	"comfy-tg-bot/internal/stats"
 	"comfy-tg-bot/internal/telegram"
+	"comfy-tg-bot/internal/usage"
+	"comfy-tg-bot/internal/whisper"
 )
 
 func main() {
+	configPath := flag.String("config", "", "path to config file (default: search ./, ./configs, /etc/comfy-tg-bot)")
+	flag.Parse()
+
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.Load(*configPath)
 	if err != nil {
 		slog.Error("failed to load config", "error", err)
 		os.Exit(1)
 	}
 
-	// Initialize logger
-	var logLevel slog.Level
-	switch cfg.Logging.Level {
-	case "debug":
-		logLevel = slog.LevelDebug
-	case "warn":
-		logLevel = slog.LevelWarn
-	case "error":
-		logLevel = slog.LevelError
-	default:
-		logLevel = slog.LevelInfo
-	}
+	// Initialize logger; the level can change on config reload
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(parseLogLevel(cfg.Logging.Level))
 
 	opts := &slog.HandlerOptions{
 		Level: logLevel,
@@ -60,23 +68,56 @@ func main() {
 	// WaitGroup for tracking active goroutines
 	var wg sync.WaitGroup
 
-	// Initialize ComfyUI client
-	comfyClient, err := comfyui.NewClient(cfg.ComfyUI, logger)
+	// Initialize ComfyUI backends
+	comfyClient, err := comfyui.NewBackendPool(cfg.ComfyUI, logger)
 	if err != nil {
 		logger.Error("failed to create comfyui client", "error", err)
 		os.Exit(1)
 	}
 
+	// Validate workflow node types against the server's installed nodes
+	if err := comfyClient.ValidateWorkflow(rootCtx); err != nil {
+		logger.Error("workflow validation failed", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize image processor
-	imageProcessor := image.NewProcessor(cfg.Image.JPEGQuality)
+	imageProcessor := image.NewProcessor(cfg.Image.JPEGQuality, cfg.Image.WebPQuality, cfg.Image.Preserve16bit)
+	imageProcessor.SetUploadLimits(cfg.Image.MaxPhotoBytes, cfg.Image.MaxDocumentBytes)
 
-	// Initialize user limiter (0 = no global limit, just per-user)
-	userLimiter := limiter.NewUserLimiter(0)
+	// Initialize the generation limiter
+	var memoryGuard *limiter.MemoryGuard
+	if cfg.Telegram.MaxHeapMB > 0 {
+		memoryGuard = limiter.NewMemoryGuard(cfg.Telegram.MaxHeapMB, logger)
+	}
+	var genLimiter limiter.Limiter
+	var userLimiter *limiter.UserLimiter
+	if cfg.Limiter.Type == "token_bucket" {
+		bucketLimiter := limiter.NewTokenBucketLimiter(cfg.Limiter.Rate, cfg.Limiter.Burst, cfg.Limiter.MaxConcurrent)
+		bucketLimiter.SetExempt(cfg.Telegram.AdminUsers)
+		if memoryGuard != nil {
+			bucketLimiter.SetMemoryGuard(memoryGuard)
+		}
+		genLimiter = bucketLimiter
+	} else {
+		// 0 = no global limit, just per-user
+		userLimiter = limiter.NewUserLimiter(cfg.Limiter.MaxConcurrent)
+		userLimiter.SetQueue(cfg.Limiter.MaxQueueDepth)
+		userLimiter.SetExempt(cfg.Telegram.AdminUsers)
+		if memoryGuard != nil {
+			userLimiter.SetMemoryGuard(memoryGuard)
+		}
+		if cfg.Limiter.CooldownSeconds > 0 {
+			userLimiter.SetCooldown(time.Duration(cfg.Limiter.CooldownSeconds) * time.Second)
+		}
+		genLimiter = userLimiter
+	}
 
 	// Initialize settings store
 	settingsDefaults := settings.DefaultSettings{
-		SendOriginal:   cfg.Settings.SendOriginal,
-		SendCompressed: cfg.Settings.SendCompressed,
+		SendOriginal:        cfg.Settings.SendOriginal,
+		SendCompressed:      cfg.Settings.SendCompressed,
+		DisableLinkPreviews: cfg.Telegram.DisableLinkPreviews,
 	}
 	settingsStore, err := settings.NewSQLiteStore(cfg.Settings.DatabasePath, settingsDefaults)
 	if err != nil {
@@ -93,12 +134,106 @@ func main() {
 	}
 	defer adminStore.Close()
 
+	// Initialize generation history store (uses same database directory)
+	historyStore, err := history.NewSQLiteStore(cfg.Settings.DatabasePath)
+	if err != nil {
+		logger.Error("failed to create history store", "error", err)
+		os.Exit(1)
+	}
+	defer historyStore.Close()
+
+	// Initialize favorite prompts store (uses same database directory)
+	favoriteStore, err := favorites.NewSQLiteStore(cfg.Settings.DatabasePath)
+	if err != nil {
+		logger.Error("failed to create favorites store", "error", err)
+		os.Exit(1)
+	}
+	defer favoriteStore.Close()
+
+	// Initialize public gallery store (uses same database directory)
+	galleryStore, err := gallery.NewSQLiteStore(cfg.Settings.DatabasePath)
+	if err != nil {
+		logger.Error("failed to create gallery store", "error", err)
+		os.Exit(1)
+	}
+	defer galleryStore.Close()
+
+	// Initialize bug report store (uses same database directory)
+	bugReportStore, err := bugreport.NewSQLiteStore(cfg.Settings.DatabasePath)
+	if err != nil {
+		logger.Error("failed to create bug report store", "error", err)
+		os.Exit(1)
+	}
+	defer bugReportStore.Close()
+
+	// Initialize generation stats store (uses same database directory)
+	statsStore, err := stats.NewSQLiteStore(cfg.Settings.DatabasePath)
+	if err != nil {
+		logger.Error("failed to create stats store", "error", err)
+		os.Exit(1)
+	}
+	defer statsStore.Close()
+
+	// Initialize daily usage store (uses same database directory)
+	usageStore, err := usage.NewSQLiteStore(cfg.Settings.DatabasePath)
+	if err != nil {
+		logger.Error("failed to create usage store", "error", err)
+		os.Exit(1)
+	}
+	defer usageStore.Close()
+	quota := usage.NewQuota(usageStore, cfg.Quota.MaxDailyPerUser, logger)
+
+	// Initialize bot state store (uses same database directory)
+	stateStore, err := state.NewSQLiteStore(cfg.Settings.DatabasePath)
+	if err != nil {
+		logger.Error("failed to create state store", "error", err)
+		os.Exit(1)
+	}
+	defer stateStore.Close()
+
+	// Initialize user data eraser (uses same database directory)
+	eraser, err := erasure.NewSQLiteEraser(cfg.Settings.DatabasePath)
+	if err != nil {
+		logger.Error("failed to create eraser", "error", err)
+		os.Exit(1)
+	}
+	defer eraser.Close()
+
+	// Load prompt suggestion vocabulary
+	vocab, err := prompt.LoadVocabulary(cfg.Prompt.VocabularyPath)
+	if err != nil {
+		logger.Error("failed to load vocabulary", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize database disk usage monitor
+	diskMonitor := admin.NewDiskMonitor([]string{cfg.Settings.DatabasePath}, cfg.Settings.DiskAlertThresholdMB, logger)
+
+	// Initialize prompt token estimator (nil disables warnings)
+	var tokenizer *prompt.Tokenizer
+	if cfg.ComfyUI.Tokenizer.Enabled {
+		tokenizer = prompt.NewTokenizer(cfg.ComfyUI.Tokenizer.MaxTokens)
+	}
+
 	// Initialize Telegram bot
-	bot, err := telegram.NewBot(cfg.Telegram, comfyClient, imageProcessor, userLimiter, settingsStore, adminStore, logger)
+	bot, err := telegram.NewBot(cfg.Telegram, comfyClient, imageProcessor, genLimiter, settingsStore, adminStore, diskMonitor, historyStore, favoriteStore, galleryStore, bugReportStore, statsStore, quota, eraser, vocab, tokenizer, logger)
 	if err != nil {
 		logger.Error("failed to create telegram bot", "error", err)
 		os.Exit(1)
 	}
+	if userLimiter != nil {
+		userLimiter.SetQueueNotifier(bot.NotifyQueuePosition)
+		bot.SetDurationTracker(limiter.NewDurationTracker(cfg.Limiter.DurationWindowSize))
+	}
+	bot.SetMaxRemoteQueueDepth(cfg.ComfyUI.MaxRemoteQueueDepth)
+	bot.SetStateStore(stateStore)
+	bot.SetDrainTimeout(cfg.Shutdown.DrainTimeout)
+	bot.SetMaxImageSize(cfg.Image.MaxWidth, cfg.Image.MaxHeight)
+	bot.SetMaxUserTimeout(cfg.ComfyUI.MaxUserTimeoutSeconds)
+	bot.SetInterruptEnabled(cfg.ComfyUI.EnableInterrupt)
+	if transcriber := whisper.NewClient(cfg.Whisper); transcriber != nil {
+		bot.SetTranscriber(transcriber)
+	}
 
 	// Start bot in goroutine
 	wg.Add(1)
@@ -109,24 +244,161 @@ func main() {
 		}
 	}()
 
+	// Export Prometheus metrics if enabled
+	var metricsHandler http.Handler
+	if cfg.Metrics.Enabled {
+		botMetrics := metrics.New(genLimiter)
+		bot.SetMetrics(botMetrics)
+
+		metricsCIDRs, err := server.ParseCIDRs(cfg.Metrics.AllowedCIDRs)
+		if err != nil {
+			logger.Error("invalid metrics allowlist", "error", err)
+			os.Exit(1)
+		}
+		metricsHandler = server.AllowCIDRs(metricsCIDRs, logger, botMetrics.Handler())
+
+		if cfg.Metrics.ListenAddr != "" {
+			mux := http.NewServeMux()
+			mux.Handle("GET /metrics", metricsHandler)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := server.Run(rootCtx, cfg.Metrics.ListenAddr, mux, logger); err != nil {
+					logger.Error("metrics server error", "error", err)
+				}
+			}()
+		}
+	}
+
+	// Serve liveness and readiness probes, plus metrics unless they have
+	// their own address
+	if cfg.Server.ListenAddr != "" {
+		healthCIDRs, err := server.ParseCIDRs(cfg.Health.AllowedCIDRs)
+		if err != nil {
+			logger.Error("invalid health allowlist", "error", err)
+			os.Exit(1)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/", server.AllowCIDRs(healthCIDRs, logger,
+			server.HealthHandler(bot.Healthy, comfyClient.CheckHealth)))
+		if metricsHandler != nil && cfg.Metrics.ListenAddr == "" {
+			mux.Handle("GET /metrics", metricsHandler)
+		}
+		if cfg.Admin.WebhookSecret != "" {
+			mux.Handle("POST /webhook/", server.AdminWebhookHandler(cfg.Admin.WebhookSecret, bot, logger))
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := server.Run(rootCtx, cfg.Server.ListenAddr, mux, logger); err != nil {
+				logger.Error("http server error", "error", err)
+			}
+		}()
+	}
+
+	// Reset daily usage counts at midnight UTC
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		quota.Run(rootCtx)
+	}()
+
+	// Start database disk usage monitoring
+	diskMonitor.SetNotifier(bot.NotifyAdmin)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		diskMonitor.Run(rootCtx)
+	}()
+
+	// Remove temporary access once it expires
+	expirySweeper := admin.NewExpirySweeper(adminStore, logger)
+	if cfg.Telegram.NotifyAccessExpiry {
+		expirySweeper.SetNotifier(bot.NotifyAccessExpired)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		expirySweeper.Run(rootCtx)
+	}()
+
+	// Delete access requests no admin has answered
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		bot.RunPendingExpiry(rootCtx, time.Duration(cfg.Admin.PendingExpiryHours)*time.Hour)
+	}()
+
+	// Re-check unhealthy backends when load balancing over several
+	if comfyClient.Size() > 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			comfyClient.Run(rootCtx)
+		}()
+	}
+
+	// Start ComfyUI auto-recovery if configured
+	if cfg.ComfyUI.AutoRecovery.Enabled {
+		recoverer := comfyui.NewRecoverer(cfg.ComfyUI.AutoRecovery, comfyClient, logger)
+		recoverer.SetNotifier(bot.NotifyAdmin)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recoverer.Run(rootCtx)
+		}()
+	}
+
+	// Reload workflow files when they change on disk if configured
+	if cfg.ComfyUI.AutoReloadWorkflow {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := comfyClient.WatchWorkflows(rootCtx); err != nil {
+				logger.Error("workflow watcher stopped", "error", err)
+			}
+		}()
+	}
+
 	logger.Info("bot started",
 		"allowed_users", cfg.Telegram.AllowedUsers,
-		"admin_user", cfg.Telegram.AdminUser,
+		"admin_users", cfg.Telegram.AdminUsers,
 		"comfyui_url", cfg.ComfyUI.BaseURL,
+		"comfyui_backends", comfyClient.Size(),
 	)
 
-	// Wait for shutdown signal
+	// Wait for shutdown signal; SIGHUP reloads the workflow template and
+	// the hot-reloadable config keys
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	sig := <-sigCh
+	var sig os.Signal
+	for sig = range sigCh {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		logger.Info("reload signal received")
+		if err := comfyClient.ReloadWorkflow(); err != nil {
+			logger.Error("failed to reload workflow", "error", err)
+		}
+		reloadConfig(*configPath, cfg, bot, imageProcessor, logLevel, logger)
+	}
 	logger.Info("shutdown signal received", "signal", sig)
 
+	// Requests still waiting for a slot are turned away; running
+	// generations are drained by the bot
+	if userLimiter != nil {
+		userLimiter.CloseQueue()
+	}
+
 	// Cancel root context to signal all goroutines
 	rootCancel()
 
-	// Wait for graceful shutdown with timeout
-	shutdownTimeout := 30 * time.Second
+	// Wait for graceful shutdown with timeout; the bot needs the drain
+	// timeout plus a few seconds for cancelled requests to clean up
+	shutdownTimeout := cfg.Shutdown.DrainTimeout + 10*time.Second
 	done := make(chan struct{})
 	go func() {
 		wg.Wait()
@@ -140,3 +412,50 @@ func main() {
 		logger.Warn("shutdown timeout exceeded, forcing exit")
 	}
 }
+
+// parseLogLevel maps a logging.level value to a slog level
+func parseLogLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// reloadConfig loads the config file again and applies the keys that can
+// change at runtime: telegram.allowed_users, image.jpeg_quality and
+// logging.level. Changes to other keys need a restart; the ones most
+// likely to be edited by mistake are logged. startup is the config the
+// bot was started with.
+func reloadConfig(path string, startup *config.Config, bot *telegram.Bot, processor *image.Processor, logLevel *slog.LevelVar, logger *slog.Logger) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		logger.Error("failed to reload config, keeping current settings", "error", err)
+		return
+	}
+
+	if cfg.Telegram.BotToken != startup.Telegram.BotToken {
+		logger.Warn("telegram.bot_token changed; restart the bot to apply it")
+	}
+	if cfg.Settings.DatabasePath != startup.Settings.DatabasePath {
+		logger.Warn("settings.database_path changed; restart the bot to apply it")
+	}
+	if cfg.Logging.JSONFormat != startup.Logging.JSONFormat {
+		logger.Warn("logging.json_format changed; restart the bot to apply it")
+	}
+
+	bot.UpdateAllowedUsers(cfg.Telegram.AllowedUsers)
+	processor.SetQuality(cfg.Image.JPEGQuality)
+	logLevel.Set(parseLogLevel(cfg.Logging.Level))
+
+	logger.Info("config reloaded",
+		"allowed_users", len(cfg.Telegram.AllowedUsers),
+		"jpeg_quality", cfg.Image.JPEGQuality,
+		"log_level", logLevel.Level(),
+	)
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Lifetimes of cached approval lookups. Negative results are kept only
+// briefly, so an approval made outside this store is picked up quickly.
+const (
+	defaultApprovalCacheTTL  = 60 * time.Second
+	negativeApprovalCacheTTL = 5 * time.Second
+)
+
+// approvalEntry is a cached IsApproved or IsGroupApproved result
+type approvalEntry struct {
+	approved bool
+	expires  time.Time
+}
+
+// approvalCache remembers recent approval lookups, since the whitelist
+// checks approval on every update
+type approvalCache struct {
+	// ttl is how long positive results are kept (<= 0 = no caching)
+	ttl atomic.Int64
+	// generation changes on every invalidation, so a lookup that raced
+	// with one does not cache its stale result
+	generation atomic.Uint64
+
+	users  sync.Map // user ID -> approvalEntry
+	groups sync.Map // group ID -> approvalEntry
+}
+
+// newApprovalCache creates a cache keeping positive results for ttl
+func newApprovalCache(ttl time.Duration) *approvalCache {
+	c := &approvalCache{}
+	c.ttl.Store(int64(ttl))
+	return c
+}
+
+// get returns a cached result for id, if one has not expired
+func (c *approvalCache) get(m *sync.Map, id int64) (approved, ok bool) {
+	v, found := m.Load(id)
+	if !found {
+		return false, false
+	}
+	entry := v.(approvalEntry)
+	if !time.Now().Before(entry.expires) {
+		m.CompareAndDelete(id, v)
+		return false, false
+	}
+	return entry.approved, true
+}
+
+// put caches a lookup result started at generation gen, unless the cache
+// was invalidated since. Positive results are kept no later than until,
+// when temporary access ends (nil = permanent).
+func (c *approvalCache) put(m *sync.Map, gen uint64, id int64, approved bool, until *time.Time) {
+	ttl := time.Duration(c.ttl.Load())
+	if ttl <= 0 {
+		return
+	}
+	if !approved {
+		ttl = min(ttl, negativeApprovalCacheTTL)
+	}
+
+	expires := time.Now().Add(ttl)
+	if approved && until != nil && until.Before(expires) {
+		expires = *until
+	}
+	if c.generation.Load() == gen {
+		m.Store(id, approvalEntry{approved: approved, expires: expires})
+	}
+}
+
+// forget drops the cached result for id
+func (c *approvalCache) forget(m *sync.Map, id int64) {
+	c.generation.Add(1)
+	m.Delete(id)
+}
+
+// clear drops every cached result
+func (c *approvalCache) clear() {
+	c.generation.Add(1)
+	c.users.Clear()
+	c.groups.Clear()
+}
@@ -0,0 +1,126 @@
+package admin
+
+import (
+	"testing"
+	"time"
+)
+
+// isApproved calls IsApproved, failing the test on error
+func isApproved(t *testing.T, s *SQLiteStore, userID int64) bool {
+	t.Helper()
+	ok, err := s.IsApproved(userID)
+	if err != nil {
+		t.Fatalf("IsApproved(%d): %v", userID, err)
+	}
+	return ok
+}
+
+// deleteApprovedRow removes a user's approval behind the store's back, so
+// only a cached result can still report them approved
+func deleteApprovedRow(t *testing.T, s *SQLiteStore, userID int64) {
+	t.Helper()
+	if _, err := s.db.Exec("DELETE FROM approved_users WHERE user_id = ?", userID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func approve(t *testing.T, s *SQLiteStore, userID int64, expiresAt *time.Time) {
+	t.Helper()
+	err := s.AddApproved(ApprovedUser{UserID: userID, ApprovedAt: time.Now(), ApprovedBy: 9, ExpiresAt: expiresAt})
+	if err != nil {
+		t.Fatalf("AddApproved: %v", err)
+	}
+}
+
+func TestApprovalCacheHit(t *testing.T) {
+	s := newTestStore(t)
+	s.SetCacheTTL(time.Minute)
+	approve(t, s, 1, nil)
+
+	if !isApproved(t, s, 1) {
+		t.Fatal("approved user not approved")
+	}
+	deleteApprovedRow(t, s, 1)
+	if !isApproved(t, s, 1) {
+		t.Error("lookup went to the database instead of the cache")
+	}
+
+	s.ForgetApproval(1)
+	if isApproved(t, s, 1) {
+		t.Error("ForgetApproval left the cached approval")
+	}
+}
+
+func TestApprovalCacheExpiry(t *testing.T) {
+	s := newTestStore(t)
+	s.SetCacheTTL(50 * time.Millisecond)
+	approve(t, s, 1, nil)
+
+	isApproved(t, s, 1)
+	deleteApprovedRow(t, s, 1)
+	time.Sleep(60 * time.Millisecond)
+	if isApproved(t, s, 1) {
+		t.Error("cached approval outlived the TTL")
+	}
+}
+
+func TestApprovalCacheHonoursTemporaryAccess(t *testing.T) {
+	s := newTestStore(t)
+	s.SetCacheTTL(time.Minute)
+	until := time.Now().Add(50 * time.Millisecond)
+	approve(t, s, 1, &until)
+
+	if !isApproved(t, s, 1) {
+		t.Fatal("temporarily approved user not approved")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if isApproved(t, s, 1) {
+		t.Error("cached approval outlived the user's access")
+	}
+}
+
+func TestApprovalCacheInvalidation(t *testing.T) {
+	s := newTestStore(t)
+	s.SetCacheTTL(time.Minute)
+
+	// A cached "not approved" must not hide a new approval
+	if isApproved(t, s, 1) {
+		t.Fatal("unknown user approved")
+	}
+	approve(t, s, 1, nil)
+	if !isApproved(t, s, 1) {
+		t.Error("approval not seen after AddApproved")
+	}
+
+	if err := s.RemoveApproved(1, 9); err != nil {
+		t.Fatalf("RemoveApproved: %v", err)
+	}
+	if isApproved(t, s, 1) {
+		t.Error("revoked user still approved")
+	}
+
+	if err := s.AddApprovedGroup(ApprovedGroup{GroupID: -100, ApprovedAt: time.Now(), ApprovedBy: 9}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.IsGroupApproved(-100); err != nil || !ok {
+		t.Fatalf("IsGroupApproved = %v, %v, want approved", ok, err)
+	}
+	if err := s.RemoveApprovedGroup(-100, 9); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.IsGroupApproved(-100); err != nil || ok {
+		t.Errorf("IsGroupApproved after revoke = %v, %v, want not approved", ok, err)
+	}
+}
+
+func TestApprovalCacheDisabled(t *testing.T) {
+	s := newTestStore(t)
+	s.SetCacheTTL(0)
+	approve(t, s, 1, nil)
+
+	isApproved(t, s, 1)
+	deleteApprovedRow(t, s, 1)
+	if isApproved(t, s, 1) {
+		t.Error("lookup was cached with caching disabled")
+	}
+}
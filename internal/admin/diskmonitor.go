@@ -0,0 +1,141 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// diskCheckInterval is how often database file sizes are checked
+	diskCheckInterval = time.Hour
+	// diskAlertCooldown limits how often the admin is alerted
+	diskAlertCooldown = 6 * time.Hour
+)
+
+// FileUsage is the size of one database file on disk
+type FileUsage struct {
+	Path  string
+	Bytes int64
+}
+
+// DiskMonitor watches SQLite database files (including WAL and shared
+// memory files) and alerts the admin when they grow too large
+type DiskMonitor struct {
+	paths     []string
+	threshold int64
+	logger    *slog.Logger
+
+	mu        sync.Mutex
+	notify    func(text string)
+	lastAlert time.Time
+}
+
+// NewDiskMonitor creates a monitor for the given database paths.
+// The -wal and -shm companions of each path are included automatically.
+func NewDiskMonitor(dbPaths []string, thresholdMB int, logger *slog.Logger) *DiskMonitor {
+	var paths []string
+	seen := make(map[string]struct{})
+	for _, p := range dbPaths {
+		for _, candidate := range []string{p, p + "-wal", p + "-shm"} {
+			if _, ok := seen[candidate]; ok {
+				continue
+			}
+			seen[candidate] = struct{}{}
+			paths = append(paths, candidate)
+		}
+	}
+
+	return &DiskMonitor{
+		paths:     paths,
+		threshold: int64(thresholdMB) * 1024 * 1024,
+		logger:    logger,
+	}
+}
+
+// SetNotifier sets the function used to alert the admin
+func (m *DiskMonitor) SetNotifier(notify func(text string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notify = notify
+}
+
+// Usage returns the size of each existing database file and the total
+func (m *DiskMonitor) Usage() ([]FileUsage, int64) {
+	var usage []FileUsage
+	var total int64
+	for _, p := range m.paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				m.logger.Warn("failed to stat database file", "error", err, "path", p)
+			}
+			continue
+		}
+		usage = append(usage, FileUsage{Path: p, Bytes: info.Size()})
+		total += info.Size()
+	}
+	return usage, total
+}
+
+// Summary formats current usage, e.g. "settings.db: 120MB, total: 165MB"
+func (m *DiskMonitor) Summary() string {
+	usage, total := m.Usage()
+
+	parts := make([]string, 0, len(usage)+1)
+	for _, u := range usage {
+		parts = append(parts, fmt.Sprintf("%s: %s", filepath.Base(u.Path), formatMB(u.Bytes)))
+	}
+	parts = append(parts, "total: "+formatMB(total))
+	return strings.Join(parts, ", ")
+}
+
+// Run checks disk usage hourly until ctx is cancelled
+func (m *DiskMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(diskCheckInterval)
+	defer ticker.Stop()
+
+	m.check()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+// check alerts the admin if usage exceeds the threshold, at most once
+// per diskAlertCooldown
+func (m *DiskMonitor) check() {
+	_, total := m.Usage()
+	if m.threshold <= 0 || total < m.threshold {
+		return
+	}
+
+	m.mu.Lock()
+	if time.Since(m.lastAlert) < diskAlertCooldown {
+		m.mu.Unlock()
+		return
+	}
+	m.lastAlert = time.Now()
+	notify := m.notify
+	m.mu.Unlock()
+
+	summary := m.Summary()
+	m.logger.Warn("database disk usage above threshold", "usage", summary, "threshold_bytes", m.threshold)
+
+	if notify != nil {
+		notify(fmt.Sprintf("Database disk usage is above %s:\n%s", formatMB(m.threshold), summary))
+	}
+}
+
+func formatMB(bytes int64) string {
+	return fmt.Sprintf("%dMB", (bytes+512*1024)/(1024*1024))
+}
@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// expirySweepInterval is how often expired temporary access is removed
+const expirySweepInterval = 5 * time.Minute
+
+// SystemActor is recorded as performed_by for actions the bot takes on
+// its own, such as removing expired access
+const SystemActor int64 = 0
+
+// ExpirySweeper removes approved users whose temporary access has ended
+type ExpirySweeper struct {
+	store  Store
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	notify func(user ApprovedUser)
+}
+
+// NewExpirySweeper creates a sweeper for the given store
+func NewExpirySweeper(store Store, logger *slog.Logger) *ExpirySweeper {
+	return &ExpirySweeper{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// SetNotifier sets the function called for each user whose access was
+// removed (nil = no notifications)
+func (s *ExpirySweeper) SetNotifier(notify func(user ApprovedUser)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notify = notify
+}
+
+// Run sweeps expired users every few minutes until ctx is cancelled
+func (s *ExpirySweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(expirySweepInterval)
+	defer ticker.Stop()
+
+	s.sweep()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+// sweep revokes every expired user and notifies them
+func (s *ExpirySweeper) sweep() {
+	expired, err := s.store.ListExpired()
+	if err != nil {
+		s.logger.Error("failed to list expired users", "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	notify := s.notify
+	s.mu.Unlock()
+
+	for _, user := range expired {
+		if err := s.store.RemoveApproved(user.UserID, SystemActor); err != nil {
+			s.logger.Error("failed to remove expired user", "error", err, "user_id", user.UserID)
+			continue
+		}
+		s.logger.Info("temporary access expired", "user_id", user.UserID, "expired_at", user.ExpiresAt)
+
+		if notify != nil {
+			notify(user)
+		}
+	}
+}
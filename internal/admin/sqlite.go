@@ -8,47 +8,21 @@ import (
 	"time"
 
 	_ "modernc.org/sqlite"
-)
-
-// SQLiteStore implements Store using SQLite for persistence
-type SQLiteStore struct {
-	db *sql.DB
-}
-
-// NewSQLiteStore creates a new SQLite-backed admin store
-func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
-	// Ensure directory exists
-	dir := filepath.Dir(dbPath)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return nil, fmt.Errorf("create database directory: %w", err)
-		}
-	}
-
-	db, err := sql.Open("sqlite", dbPath+"?_busy_timeout=5000&_journal_mode=WAL")
-	if err != nil {
-		return nil, fmt.Errorf("open database: %w", err)
-	}
 
-	// SQLite works best with a single writer
-	db.SetMaxOpenConns(1)
+	appdb "comfy-tg-bot/internal/db"
+)
 
-	// Create approved_users table
-	_, err = db.Exec(`
+// migrations defines the admin schema; append new versions, never edit old ones
+var migrations = []appdb.Migration{
+	{Version: 1, SQL: `
 		CREATE TABLE IF NOT EXISTS approved_users (
 			user_id INTEGER PRIMARY KEY,
 			username TEXT,
 			approved_at DATETIME NOT NULL,
 			approved_by INTEGER NOT NULL
 		)
-	`)
-	if err != nil {
-		db.Close()
-		return nil, fmt.Errorf("create approved_users table: %w", err)
-	}
-
-	// Create pending_requests table
-	_, err = db.Exec(`
+	`},
+	{Version: 2, SQL: `
 		CREATE TABLE IF NOT EXISTS pending_requests (
 			user_id INTEGER PRIMARY KEY,
 			username TEXT,
@@ -58,28 +32,16 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 			notified_at DATETIME,
 			admin_msg_id INTEGER
 		)
-	`)
-	if err != nil {
-		db.Close()
-		return nil, fmt.Errorf("create pending_requests table: %w", err)
-	}
-
-	// Create approved_groups table
-	_, err = db.Exec(`
+	`},
+	{Version: 3, SQL: `
 		CREATE TABLE IF NOT EXISTS approved_groups (
 			group_id INTEGER PRIMARY KEY,
 			title TEXT,
 			approved_at DATETIME NOT NULL,
 			approved_by INTEGER NOT NULL
 		)
-	`)
-	if err != nil {
-		db.Close()
-		return nil, fmt.Errorf("create approved_groups table: %w", err)
-	}
-
-	// Create pending_group_requests table
-	_, err = db.Exec(`
+	`},
+	{Version: 4, SQL: `
 		CREATE TABLE IF NOT EXISTS pending_group_requests (
 			group_id INTEGER PRIMARY KEY,
 			title TEXT,
@@ -87,62 +49,383 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 			notified_at DATETIME,
 			admin_msg_id INTEGER
 		)
-	`)
+	`},
+	{Version: 5, SQL: `
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			action TEXT NOT NULL,
+			target_id INTEGER NOT NULL,
+			target_type TEXT NOT NULL,
+			performed_by INTEGER NOT NULL,
+			timestamp DATETIME NOT NULL,
+			notes TEXT
+		)
+	`},
+	{Version: 6, SQL: `
+		ALTER TABLE approved_users ADD COLUMN expires_at DATETIME
+	`},
+	{Version: 7, SQL: `
+		CREATE TABLE IF NOT EXISTS banned_users (
+			user_id INTEGER PRIMARY KEY,
+			banned_at DATETIME NOT NULL,
+			banned_by INTEGER NOT NULL,
+			reason TEXT
+		)
+	`},
+	{Version: 8, SQL: `
+		CREATE TABLE IF NOT EXISTS user_chat_ids (
+			user_id INTEGER PRIMARY KEY,
+			chat_id INTEGER NOT NULL,
+			updated_at DATETIME NOT NULL
+		)
+	`},
+	{Version: 9, SQL: `
+		CREATE TABLE IF NOT EXISTS user_workflow_permissions (
+			user_id INTEGER NOT NULL,
+			workflow_name TEXT NOT NULL,
+			PRIMARY KEY (user_id, workflow_name)
+		)
+	`},
+}
+
+// SQLiteStore implements Store using SQLite for persistence
+type SQLiteStore struct {
+	db    *sql.DB
+	cache *approvalCache
+}
+
+// NewSQLiteStore creates a new SQLite-backed admin store
+func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	// Ensure directory exists
+	dir := filepath.Dir(dbPath)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath+"?_busy_timeout=5000&_journal_mode=WAL")
 	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite works best with a single writer
+	db.SetMaxOpenConns(1)
+
+	if err := appdb.RunMigrations(db, "admin", migrations); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("create pending_group_requests table: %w", err)
+		return nil, err
 	}
 
-	return &SQLiteStore{db: db}, nil
+	return &SQLiteStore{db: db, cache: newApprovalCache(defaultApprovalCacheTTL)}, nil
+}
+
+// SetCacheTTL sets how long IsApproved and IsGroupApproved results are
+// cached, dropping everything cached so far. Negative results are kept for
+// at most 5s (0 = no caching).
+func (s *SQLiteStore) SetCacheTTL(d time.Duration) {
+	s.cache.ttl.Store(int64(d))
+	s.cache.clear()
 }
 
-// IsApproved checks if a user has been approved
+// ForgetApproval drops the cached approval of a user whose records were
+// deleted outside this store
+func (s *SQLiteStore) ForgetApproval(userID int64) {
+	s.cache.forget(&s.cache.users, userID)
+}
+
+// IsApproved checks if a user has been approved and their access has not
+// expired
 func (s *SQLiteStore) IsApproved(userID int64) (bool, error) {
-	var exists int
+	if approved, ok := s.cache.get(&s.cache.users, userID); ok {
+		return approved, nil
+	}
+	gen := s.cache.generation.Load()
+
+	var expiresAt sql.NullTime
 	err := s.db.QueryRow(
-		"SELECT 1 FROM approved_users WHERE user_id = ?",
-		userID,
-	).Scan(&exists)
+		"SELECT expires_at FROM approved_users WHERE user_id = ? AND (expires_at IS NULL OR expires_at > ?)",
+		userID, time.Now().UTC(),
+	).Scan(&expiresAt)
 
 	if err == sql.ErrNoRows {
+		s.cache.put(&s.cache.users, gen, userID, false, nil)
 		return false, nil
 	}
 	if err != nil {
 		return false, fmt.Errorf("check approved status: %w", err)
 	}
+
+	var until *time.Time
+	if expiresAt.Valid {
+		until = &expiresAt.Time
+	}
+	s.cache.put(&s.cache.users, gen, userID, true, until)
 	return true, nil
 }
 
 // AddApproved adds a user to the approved list
 func (s *SQLiteStore) AddApproved(user ApprovedUser) error {
-	_, err := s.db.Exec(`
-		INSERT INTO approved_users (user_id, username, approved_at, approved_by)
-		VALUES (?, ?, ?, ?)
+	entry := AuditEntry{
+		Action:      AuditApprove,
+		TargetID:    user.UserID,
+		TargetType:  TargetUser,
+		PerformedBy: user.ApprovedBy,
+		Timestamp:   user.ApprovedAt,
+		Notes:       user.Username,
+	}
+	_, err := s.execAudited(entry, `
+		INSERT INTO approved_users (user_id, username, approved_at, approved_by, expires_at)
+		VALUES (?, ?, ?, ?, ?)
 		ON CONFLICT(user_id) DO UPDATE SET
 			username = excluded.username,
 			approved_at = excluded.approved_at,
-			approved_by = excluded.approved_by
-	`, user.UserID, user.Username, user.ApprovedAt, user.ApprovedBy)
+			approved_by = excluded.approved_by,
+			expires_at = excluded.expires_at
+	`, user.UserID, user.Username, user.ApprovedAt, user.ApprovedBy, utcTime(user.ExpiresAt))
 
+	s.cache.forget(&s.cache.users, user.UserID)
 	if err != nil {
 		return fmt.Errorf("add approved user: %w", err)
 	}
+	if !user.Expired(time.Now()) {
+		s.cache.put(&s.cache.users, s.cache.generation.Load(), user.UserID, true, user.ExpiresAt)
+	}
 	return nil
 }
 
-// RemoveApproved removes a user from the approved list
-func (s *SQLiteStore) RemoveApproved(userID int64) error {
-	_, err := s.db.Exec("DELETE FROM approved_users WHERE user_id = ?", userID)
+// RemoveApproved removes a user from the approved list, recording the
+// revocation by performedBy in the audit log
+func (s *SQLiteStore) RemoveApproved(userID, performedBy int64) error {
+	entry := AuditEntry{
+		Action:      AuditRevoke,
+		TargetID:    userID,
+		TargetType:  TargetUser,
+		PerformedBy: performedBy,
+		Timestamp:   time.Now(),
+	}
+	_, err := s.execAudited(entry, "DELETE FROM approved_users WHERE user_id = ?", userID)
+	s.cache.forget(&s.cache.users, userID)
 	if err != nil {
 		return fmt.Errorf("remove approved user: %w", err)
 	}
 	return nil
 }
 
+// GetApproved retrieves an approved user, including expired ones, or nil
+// if the user is not approved
+func (s *SQLiteStore) GetApproved(userID int64) (*ApprovedUser, error) {
+	rows, err := s.db.Query(`
+		SELECT user_id, COALESCE(username, ''), approved_at, approved_by, expires_at
+		FROM approved_users WHERE user_id = ?
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get approved user: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanApprovedUsers(rows)
+	if err != nil || len(users) == 0 {
+		return nil, err
+	}
+	return &users[0], nil
+}
+
+// ListExpired returns approved users whose temporary access has ended
+func (s *SQLiteStore) ListExpired() ([]ApprovedUser, error) {
+	rows, err := s.db.Query(`
+		SELECT user_id, COALESCE(username, ''), approved_at, approved_by, expires_at
+		FROM approved_users
+		WHERE expires_at IS NOT NULL AND expires_at <= ?
+		ORDER BY expires_at
+	`, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("query expired users: %w", err)
+	}
+	defer rows.Close()
+
+	return scanApprovedUsers(rows)
+}
+
+// CountApproved returns the number of dynamically approved users
+func (s *SQLiteStore) CountApproved() (int, error) {
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM approved_users").Scan(&count); err != nil {
+		return 0, fmt.Errorf("count approved users: %w", err)
+	}
+	return count, nil
+}
+
+// ListApproved returns a page of approved users, newest first, along with
+// the total number of approved users
+func (s *SQLiteStore) ListApproved(offset, limit int) ([]ApprovedUser, int, error) {
+	total, err := s.CountApproved()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT user_id, COALESCE(username, ''), approved_at, approved_by, expires_at
+		FROM approved_users
+		ORDER BY approved_at DESC
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query approved users: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanApprovedUsers(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// ListAllApproved returns every approved user, including expired ones,
+// newest first
+func (s *SQLiteStore) ListAllApproved() ([]ApprovedUser, error) {
+	rows, err := s.db.Query(`
+		SELECT user_id, COALESCE(username, ''), approved_at, approved_by, expires_at
+		FROM approved_users
+		ORDER BY approved_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query approved users: %w", err)
+	}
+	defer rows.Close()
+
+	return scanApprovedUsers(rows)
+}
+
+// RecordChatID remembers the private chat a user last used the bot in
+func (s *SQLiteStore) RecordChatID(userID, chatID int64) error {
+	_, err := s.db.Exec(`
+		INSERT INTO user_chat_ids (user_id, chat_id, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			chat_id = excluded.chat_id,
+			updated_at = excluded.updated_at
+	`, userID, chatID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("record chat id: %w", err)
+	}
+	return nil
+}
+
+// ListChatIDs returns every recorded private chat ID keyed by user ID
+func (s *SQLiteStore) ListChatIDs() (map[int64]int64, error) {
+	rows, err := s.db.Query("SELECT user_id, chat_id FROM user_chat_ids")
+	if err != nil {
+		return nil, fmt.Errorf("query chat ids: %w", err)
+	}
+	defer rows.Close()
+
+	chatIDs := make(map[int64]int64)
+	for rows.Next() {
+		var userID, chatID int64
+		if err := rows.Scan(&userID, &chatID); err != nil {
+			return nil, fmt.Errorf("scan chat id: %w", err)
+		}
+		chatIDs[userID] = chatID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate chat ids: %w", err)
+	}
+	return chatIDs, nil
+}
+
+// GetUserWorkflows returns the workflows a user is restricted to, sorted
+// by name, or nil if the user may use every workflow
+func (s *SQLiteStore) GetUserWorkflows(userID int64) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT workflow_name FROM user_workflow_permissions
+		WHERE user_id = ?
+		ORDER BY workflow_name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query user workflows: %w", err)
+	}
+	defer rows.Close()
+
+	var workflows []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan user workflow: %w", err)
+		}
+		workflows = append(workflows, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user workflows: %w", err)
+	}
+	return workflows, nil
+}
+
+// SetUserWorkflows replaces the workflows a user is restricted to. An
+// empty list lifts the restriction.
+func (s *SQLiteStore) SetUserWorkflows(userID int64, workflows []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM user_workflow_permissions WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("clear user workflows: %w", err)
+	}
+	for _, name := range workflows {
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO user_workflow_permissions (user_id, workflow_name)
+			VALUES (?, ?)
+		`, userID, name)
+		if err != nil {
+			return fmt.Errorf("insert user workflow: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// utcTime converts t to UTC so stored expiry times compare correctly as
+// text regardless of the server's time zone
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// scanApprovedUsers reads approved_users rows selected in column order
+func scanApprovedUsers(rows *sql.Rows) ([]ApprovedUser, error) {
+	var users []ApprovedUser
+	for rows.Next() {
+		var u ApprovedUser
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&u.UserID, &u.Username, &u.ApprovedAt, &u.ApprovedBy, &expiresAt); err != nil {
+			return nil, fmt.Errorf("scan approved user: %w", err)
+		}
+		if expiresAt.Valid {
+			u.ExpiresAt = &expiresAt.Time
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate approved users: %w", err)
+	}
+
+	return users, nil
+}
+
 // GetPending retrieves a pending request by user ID
 func (s *SQLiteStore) GetPending(userID int64) (*PendingRequest, error) {
 	var req PendingRequest
 	var notifiedAt sql.NullTime
+	var adminMsgID sql.NullInt64
 
 	err := s.db.QueryRow(`
 		SELECT user_id, username, first_name, chat_id, requested_at, notified_at, admin_msg_id
@@ -154,7 +437,7 @@ func (s *SQLiteStore) GetPending(userID int64) (*PendingRequest, error) {
 		&req.ChatID,
 		&req.RequestedAt,
 		&notifiedAt,
-		&req.AdminMsgID,
+		&adminMsgID,
 	)
 
 	if err == sql.ErrNoRows {
@@ -167,6 +450,7 @@ func (s *SQLiteStore) GetPending(userID int64) (*PendingRequest, error) {
 	if notifiedAt.Valid {
 		req.NotifiedAt = &notifiedAt.Time
 	}
+	req.AdminMsgID = int(adminMsgID.Int64)
 
 	return &req, nil
 }
@@ -189,13 +473,43 @@ func (s *SQLiteStore) AddPending(req PendingRequest) error {
 	return nil
 }
 
-// RemovePending removes a pending request
-func (s *SQLiteStore) RemovePending(userID int64) error {
-	_, err := s.db.Exec("DELETE FROM pending_requests WHERE user_id = ?", userID)
+// RemovePending rejects a pending request on behalf of performedBy,
+// recording it in the audit log. Reports whether this call removed it.
+func (s *SQLiteStore) RemovePending(userID, performedBy int64) (bool, error) {
+	pending, err := s.GetPending(userID)
 	if err != nil {
-		return fmt.Errorf("remove pending request: %w", err)
+		return false, err
 	}
-	return nil
+	entry := AuditEntry{
+		Action:      AuditReject,
+		TargetID:    userID,
+		TargetType:  TargetUser,
+		PerformedBy: performedBy,
+		Timestamp:   time.Now(),
+	}
+	if pending != nil {
+		entry.Notes = pending.Username
+	}
+
+	removed, err := s.execAudited(entry, "DELETE FROM pending_requests WHERE user_id = ?", userID)
+	if err != nil {
+		return false, fmt.Errorf("remove pending request: %w", err)
+	}
+	return removed, nil
+}
+
+// ClaimPending removes a pending request, reporting whether this call
+// removed it, so that only one of several admins acts on a request
+func (s *SQLiteStore) ClaimPending(userID int64) (bool, error) {
+	res, err := s.db.Exec("DELETE FROM pending_requests WHERE user_id = ?", userID)
+	if err != nil {
+		return false, fmt.Errorf("claim pending request: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("claim pending request: %w", err)
+	}
+	return n > 0, nil
 }
 
 // UpdatePendingNotified marks a pending request as notified
@@ -212,6 +526,478 @@ func (s *SQLiteStore) UpdatePendingNotified(userID int64, msgID int) error {
 	return nil
 }
 
+// Ping verifies the database connection is alive
+func (s *SQLiteStore) Ping() error {
+	return s.db.Ping()
+}
+
+// ListAllPending returns all pending user requests, oldest first
+func (s *SQLiteStore) ListAllPending() ([]PendingRequest, error) {
+	rows, err := s.db.Query(`
+		SELECT user_id, username, first_name, chat_id, requested_at, notified_at, admin_msg_id
+		FROM pending_requests
+		ORDER BY requested_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query pending requests: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPendingRequests(rows)
+}
+
+// ListPending returns a page of pending user requests, oldest first, along
+// with the total number of pending user requests
+func (s *SQLiteStore) ListPending(offset, limit int) ([]PendingRequest, int, error) {
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM pending_requests").Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count pending requests: %w", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT user_id, username, first_name, chat_id, requested_at, notified_at, admin_msg_id
+		FROM pending_requests
+		ORDER BY requested_at
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query pending requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests, err := scanPendingRequests(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+// RejectAllPending deletes all pending user and group requests on behalf
+// of performedBy, recording each rejection in the audit log, and returns
+// exactly the requests it removed
+func (s *SQLiteStore) RejectAllPending(performedBy int64) ([]PendingRequest, []PendingGroupRequest, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	users, groups, err := takePending(tx, "1 = 1")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := auditPending(tx, AuditReject, performedBy, users, groups); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return users, groups, nil
+}
+
+// ExpirePending deletes pending user and group requests made more than
+// olderThan ago, recording each expiry in the audit log, and returns them
+func (s *SQLiteStore) ExpirePending(olderThan time.Duration) ([]PendingRequest, []PendingGroupRequest, error) {
+	cutoff := time.Now().Add(-olderThan)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	users, groups, err := takePending(tx, "requested_at < ?", cutoff)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := auditPending(tx, AuditExpire, SystemActor, users, groups); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return users, groups, nil
+}
+
+// takePending deletes the pending user and group requests matching where
+// within tx and returns them, oldest first
+func takePending(tx *sql.Tx, where string, args ...any) ([]PendingRequest, []PendingGroupRequest, error) {
+	rows, err := tx.Query(`
+		SELECT user_id, username, first_name, chat_id, requested_at, notified_at, admin_msg_id
+		FROM pending_requests
+		WHERE `+where+`
+		ORDER BY requested_at
+	`, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query pending requests: %w", err)
+	}
+	users, err := scanPendingRequests(rows)
+	rows.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err = tx.Query(`
+		SELECT group_id, title, requested_at, notified_at, admin_msg_id
+		FROM pending_group_requests
+		WHERE `+where+`
+		ORDER BY requested_at
+	`, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query pending group requests: %w", err)
+	}
+	groups, err := scanPendingGroupRequests(rows)
+	rows.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM pending_requests WHERE "+where, args...); err != nil {
+		return nil, nil, fmt.Errorf("delete pending requests: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM pending_group_requests WHERE "+where, args...); err != nil {
+		return nil, nil, fmt.Errorf("delete pending group requests: %w", err)
+	}
+	return users, groups, nil
+}
+
+// auditPending records action by performedBy on each pending request
+// within tx
+func auditPending(tx *sql.Tx, action string, performedBy int64, users []PendingRequest, groups []PendingGroupRequest) error {
+	now := time.Now()
+	for _, req := range users {
+		if err := insertAudit(tx, AuditEntry{
+			Action:      action,
+			TargetID:    req.UserID,
+			TargetType:  TargetUser,
+			PerformedBy: performedBy,
+			Timestamp:   now,
+			Notes:       req.Username,
+		}); err != nil {
+			return err
+		}
+	}
+	for _, req := range groups {
+		if err := insertAudit(tx, AuditEntry{
+			Action:      action,
+			TargetID:    req.GroupID,
+			TargetType:  TargetGroup,
+			PerformedBy: performedBy,
+			Timestamp:   now,
+			Notes:       req.Title,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetStale returns notified pending requests last notified before the given time
+func (s *SQLiteStore) GetStale(before time.Time) ([]PendingRequest, error) {
+	rows, err := s.db.Query(`
+		SELECT user_id, username, first_name, chat_id, requested_at, notified_at, admin_msg_id
+		FROM pending_requests
+		WHERE notified_at < ? AND notified_at IS NOT NULL
+		ORDER BY notified_at
+	`, before)
+	if err != nil {
+		return nil, fmt.Errorf("query stale requests: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPendingRequests(rows)
+}
+
+// scanPendingRequests reads pending_requests rows selected in column order
+func scanPendingRequests(rows *sql.Rows) ([]PendingRequest, error) {
+	var requests []PendingRequest
+	for rows.Next() {
+		var req PendingRequest
+		var notifiedAt sql.NullTime
+		var adminMsgID sql.NullInt64
+		if err := rows.Scan(
+			&req.UserID,
+			&req.Username,
+			&req.FirstName,
+			&req.ChatID,
+			&req.RequestedAt,
+			&notifiedAt,
+			&adminMsgID,
+		); err != nil {
+			return nil, fmt.Errorf("scan pending request: %w", err)
+		}
+		if notifiedAt.Valid {
+			req.NotifiedAt = &notifiedAt.Time
+		}
+		req.AdminMsgID = int(adminMsgID.Int64)
+		requests = append(requests, req)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pending requests: %w", err)
+	}
+
+	return requests, nil
+}
+
+// execAudited runs a statement and, if it changed any rows, records entry
+// in the audit log in the same transaction. Reports whether rows changed.
+func (s *SQLiteStore) execAudited(entry AuditEntry, query string, args ...any) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	if err := insertAudit(tx, entry); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// insertAudit records entry in the audit log within tx
+func insertAudit(tx *sql.Tx, entry AuditEntry) error {
+	_, err := tx.Exec(`
+		INSERT INTO audit_log (action, target_id, target_type, performed_by, timestamp, notes)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, entry.Action, entry.TargetID, entry.TargetType, entry.PerformedBy, entry.Timestamp, entry.Notes)
+	if err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// Ban stops a user from using the bot or requesting access, removing any
+// approval or pending request, and records it in the audit log
+func (s *SQLiteStore) Ban(userID, bannedBy int64, reason string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, err = tx.Exec(`
+		INSERT INTO banned_users (user_id, banned_at, banned_by, reason)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			banned_at = excluded.banned_at,
+			banned_by = excluded.banned_by,
+			reason = excluded.reason
+	`, userID, now, bannedBy, reason)
+	if err != nil {
+		return fmt.Errorf("ban user: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM approved_users WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("remove approved user: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM pending_requests WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("remove pending request: %w", err)
+	}
+
+	entry := AuditEntry{
+		Action:      AuditBan,
+		TargetID:    userID,
+		TargetType:  TargetUser,
+		PerformedBy: bannedBy,
+		Timestamp:   now,
+		Notes:       reason,
+	}
+	if err := insertAudit(tx, entry); err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	s.cache.forget(&s.cache.users, userID)
+	if err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Unban lifts a ban, recording it by performedBy in the audit log.
+// Reports whether the user was banned.
+func (s *SQLiteStore) Unban(userID, performedBy int64) (bool, error) {
+	entry := AuditEntry{
+		Action:      AuditUnban,
+		TargetID:    userID,
+		TargetType:  TargetUser,
+		PerformedBy: performedBy,
+		Timestamp:   time.Now(),
+	}
+	unbanned, err := s.execAudited(entry, "DELETE FROM banned_users WHERE user_id = ?", userID)
+	if err != nil {
+		return false, fmt.Errorf("unban user: %w", err)
+	}
+	return unbanned, nil
+}
+
+// IsBanned checks if a user has been banned
+func (s *SQLiteStore) IsBanned(userID int64) (bool, error) {
+	var exists int
+	err := s.db.QueryRow(
+		"SELECT 1 FROM banned_users WHERE user_id = ?",
+		userID,
+	).Scan(&exists)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("check banned status: %w", err)
+	}
+	return true, nil
+}
+
+// GetAuditLog returns a page of audit log entries, newest first
+func (s *SQLiteStore) GetAuditLog(offset, limit int) ([]AuditEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT id, action, target_id, target_type, performed_by, timestamp, COALESCE(notes, '')
+		FROM audit_log
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Action, &e.TargetID, &e.TargetType, &e.PerformedBy, &e.Timestamp, &e.Notes); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Export serialises every approved user and group as a versioned JSON backup
+func (s *SQLiteStore) Export() ([]byte, error) {
+	users, err := s.ListAllApproved()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT group_id, COALESCE(title, ''), approved_at, approved_by
+		FROM approved_groups
+		ORDER BY approved_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query approved groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []ApprovedGroup
+	for rows.Next() {
+		var g ApprovedGroup
+		if err := rows.Scan(&g.GroupID, &g.Title, &g.ApprovedAt, &g.ApprovedBy); err != nil {
+			return nil, fmt.Errorf("scan approved group: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate approved groups: %w", err)
+	}
+
+	return marshalExport(users, groups)
+}
+
+// Import upserts the users and groups from a backup in a single
+// transaction on behalf of performedBy, recording each in the audit log and
+// leaving records not in the backup untouched
+func (s *SQLiteStore) Import(data []byte, performedBy int64) error {
+	users, groups, err := unmarshalExport(data)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, u := range users {
+		_, err := tx.Exec(`
+			INSERT INTO approved_users (user_id, username, approved_at, approved_by, expires_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(user_id) DO UPDATE SET
+				username = excluded.username,
+				approved_at = excluded.approved_at,
+				approved_by = excluded.approved_by,
+				expires_at = excluded.expires_at
+		`, u.UserID, u.Username, u.ApprovedAt, u.ApprovedBy, utcTime(u.ExpiresAt))
+		if err != nil {
+			return fmt.Errorf("import approved user %d: %w", u.UserID, err)
+		}
+		if err := insertAudit(tx, AuditEntry{
+			Action:      AuditImport,
+			TargetID:    u.UserID,
+			TargetType:  TargetUser,
+			PerformedBy: performedBy,
+			Timestamp:   time.Now(),
+			Notes:       u.Username,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, g := range groups {
+		_, err := tx.Exec(`
+			INSERT INTO approved_groups (group_id, title, approved_at, approved_by)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(group_id) DO UPDATE SET
+				title = excluded.title,
+				approved_at = excluded.approved_at,
+				approved_by = excluded.approved_by
+		`, g.GroupID, g.Title, g.ApprovedAt, g.ApprovedBy)
+		if err != nil {
+			return fmt.Errorf("import approved group %d: %w", g.GroupID, err)
+		}
+		if err := insertAudit(tx, AuditEntry{
+			Action:      AuditImport,
+			TargetID:    g.GroupID,
+			TargetType:  TargetGroup,
+			PerformedBy: performedBy,
+			Timestamp:   time.Now(),
+			Notes:       g.Title,
+		}); err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	s.cache.clear()
+	if err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
 // Close releases database resources
 func (s *SQLiteStore) Close() error {
 	return s.db.Close()
@@ -219,6 +1005,11 @@ func (s *SQLiteStore) Close() error {
 
 // IsGroupApproved checks if a group has been approved
 func (s *SQLiteStore) IsGroupApproved(groupID int64) (bool, error) {
+	if approved, ok := s.cache.get(&s.cache.groups, groupID); ok {
+		return approved, nil
+	}
+	gen := s.cache.generation.Load()
+
 	var exists int
 	err := s.db.QueryRow(
 		"SELECT 1 FROM approved_groups WHERE group_id = ?",
@@ -226,17 +1017,27 @@ func (s *SQLiteStore) IsGroupApproved(groupID int64) (bool, error) {
 	).Scan(&exists)
 
 	if err == sql.ErrNoRows {
+		s.cache.put(&s.cache.groups, gen, groupID, false, nil)
 		return false, nil
 	}
 	if err != nil {
 		return false, fmt.Errorf("check group approved status: %w", err)
 	}
+	s.cache.put(&s.cache.groups, gen, groupID, true, nil)
 	return true, nil
 }
 
 // AddApprovedGroup adds a group to the approved list
 func (s *SQLiteStore) AddApprovedGroup(group ApprovedGroup) error {
-	_, err := s.db.Exec(`
+	entry := AuditEntry{
+		Action:      AuditApprove,
+		TargetID:    group.GroupID,
+		TargetType:  TargetGroup,
+		PerformedBy: group.ApprovedBy,
+		Timestamp:   group.ApprovedAt,
+		Notes:       group.Title,
+	}
+	_, err := s.execAudited(entry, `
 		INSERT INTO approved_groups (group_id, title, approved_at, approved_by)
 		VALUES (?, ?, ?, ?)
 		ON CONFLICT(group_id) DO UPDATE SET
@@ -245,15 +1046,26 @@ func (s *SQLiteStore) AddApprovedGroup(group ApprovedGroup) error {
 			approved_by = excluded.approved_by
 	`, group.GroupID, group.Title, group.ApprovedAt, group.ApprovedBy)
 
+	s.cache.forget(&s.cache.groups, group.GroupID)
 	if err != nil {
 		return fmt.Errorf("add approved group: %w", err)
 	}
+	s.cache.put(&s.cache.groups, s.cache.generation.Load(), group.GroupID, true, nil)
 	return nil
 }
 
-// RemoveApprovedGroup removes a group from the approved list
-func (s *SQLiteStore) RemoveApprovedGroup(groupID int64) error {
-	_, err := s.db.Exec("DELETE FROM approved_groups WHERE group_id = ?", groupID)
+// RemoveApprovedGroup removes a group from the approved list, recording
+// the revocation by performedBy in the audit log
+func (s *SQLiteStore) RemoveApprovedGroup(groupID, performedBy int64) error {
+	entry := AuditEntry{
+		Action:      AuditRevoke,
+		TargetID:    groupID,
+		TargetType:  TargetGroup,
+		PerformedBy: performedBy,
+		Timestamp:   time.Now(),
+	}
+	_, err := s.execAudited(entry, "DELETE FROM approved_groups WHERE group_id = ?", groupID)
+	s.cache.forget(&s.cache.groups, groupID)
 	if err != nil {
 		return fmt.Errorf("remove approved group: %w", err)
 	}
@@ -264,6 +1076,7 @@ func (s *SQLiteStore) RemoveApprovedGroup(groupID int64) error {
 func (s *SQLiteStore) GetPendingGroup(groupID int64) (*PendingGroupRequest, error) {
 	var req PendingGroupRequest
 	var notifiedAt sql.NullTime
+	var adminMsgID sql.NullInt64
 
 	err := s.db.QueryRow(`
 		SELECT group_id, title, requested_at, notified_at, admin_msg_id
@@ -273,7 +1086,7 @@ func (s *SQLiteStore) GetPendingGroup(groupID int64) (*PendingGroupRequest, erro
 		&req.Title,
 		&req.RequestedAt,
 		&notifiedAt,
-		&req.AdminMsgID,
+		&adminMsgID,
 	)
 
 	if err == sql.ErrNoRows {
@@ -286,6 +1099,7 @@ func (s *SQLiteStore) GetPendingGroup(groupID int64) (*PendingGroupRequest, erro
 	if notifiedAt.Valid {
 		req.NotifiedAt = &notifiedAt.Time
 	}
+	req.AdminMsgID = int(adminMsgID.Int64)
 
 	return &req, nil
 }
@@ -328,3 +1142,52 @@ func (s *SQLiteStore) UpdatePendingGroupNotified(groupID int64, msgID int) error
 	}
 	return nil
 }
+
+// ListPendingGroups returns a page of pending group requests, oldest first,
+// along with the total number of pending group requests
+func (s *SQLiteStore) ListPendingGroups(offset, limit int) ([]PendingGroupRequest, int, error) {
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM pending_group_requests").Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count pending group requests: %w", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT group_id, title, requested_at, notified_at, admin_msg_id
+		FROM pending_group_requests
+		ORDER BY requested_at
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query pending group requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests, err := scanPendingGroupRequests(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+// scanPendingGroupRequests reads pending_group_requests rows selected in
+// column order
+func scanPendingGroupRequests(rows *sql.Rows) ([]PendingGroupRequest, error) {
+	var requests []PendingGroupRequest
+	for rows.Next() {
+		var req PendingGroupRequest
+		var notifiedAt sql.NullTime
+		var adminMsgID sql.NullInt64
+		if err := rows.Scan(&req.GroupID, &req.Title, &req.RequestedAt, &notifiedAt, &adminMsgID); err != nil {
+			return nil, fmt.Errorf("scan pending group request: %w", err)
+		}
+		if notifiedAt.Valid {
+			req.NotifiedAt = &notifiedAt.Time
+		}
+		req.AdminMsgID = int(adminMsgID.Int64)
+		requests = append(requests, req)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pending group requests: %w", err)
+	}
+	return requests, nil
+}
@@ -0,0 +1,178 @@
+package admin
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "admin.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// auditActions counts the audit entries by "action target_type"
+func auditActions(t *testing.T, s *SQLiteStore) map[string]int {
+	t.Helper()
+	entries, err := s.GetAuditLog(0, 100)
+	if err != nil {
+		t.Fatalf("GetAuditLog: %v", err)
+	}
+	got := make(map[string]int)
+	for _, e := range entries {
+		got[e.Action+" "+e.TargetType]++
+	}
+	return got
+}
+
+func TestRejectAllPendingAudits(t *testing.T) {
+	s := newTestStore(t)
+	for _, id := range []int64{1, 2} {
+		if err := s.AddPending(PendingRequest{UserID: id, Username: "u", ChatID: id, RequestedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.AddPendingGroup(PendingGroupRequest{GroupID: -100, Title: "g", RequestedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	users, groups, err := s.RejectAllPending(42)
+	if err != nil {
+		t.Fatalf("RejectAllPending: %v", err)
+	}
+	if len(users) != 2 || len(groups) != 1 || users[0].ChatID == 0 {
+		t.Errorf("rejected users %v groups %v, want both users with chat IDs and the group", users, groups)
+	}
+	if pending, err := s.ListAllPending(); err != nil || len(pending) != 0 {
+		t.Errorf("pending after reject all = %v, %v, want none", pending, err)
+	}
+
+	entries, err := s.GetAuditLog(0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d audit entries, want 3", len(entries))
+	}
+	for _, e := range entries {
+		if e.Action != AuditReject || e.PerformedBy != 42 {
+			t.Errorf("audit entry %+v, want reject by 42", e)
+		}
+	}
+}
+
+func TestExpirePendingAudits(t *testing.T) {
+	s := newTestStore(t)
+	old := time.Now().Add(-48 * time.Hour)
+	if err := s.AddPending(PendingRequest{UserID: 1, ChatID: 1, RequestedAt: old}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddPending(PendingRequest{UserID: 2, ChatID: 2, RequestedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddPendingGroup(PendingGroupRequest{GroupID: -100, RequestedAt: old}); err != nil {
+		t.Fatal(err)
+	}
+
+	users, groups, err := s.ExpirePending(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("ExpirePending: %v", err)
+	}
+	if len(users) != 1 || users[0].UserID != 1 || len(groups) != 1 {
+		t.Fatalf("expired users %v groups %v, want user 1 and one group", users, groups)
+	}
+
+	got := auditActions(t, s)
+	if got["expire user"] != 1 || got["expire group"] != 1 || len(got) != 2 {
+		t.Errorf("audit entries %v, want one user and one group expiry", got)
+	}
+	if pending, err := s.ListAllPending(); err != nil || len(pending) != 1 || pending[0].UserID != 2 {
+		t.Errorf("pending after expiry = %v, %v, want only user 2", pending, err)
+	}
+}
+
+func TestImportAudits(t *testing.T) {
+	data, err := marshalExport(
+		[]ApprovedUser{{UserID: 1, Username: "a", ApprovedAt: time.Now(), ApprovedBy: 9}},
+		[]ApprovedGroup{{GroupID: -100, Title: "g", ApprovedAt: time.Now(), ApprovedBy: 9}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestStore(t)
+	if err := s.Import(data, 42); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	entries, err := s.GetAuditLog(0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want 2", len(entries))
+	}
+	for _, e := range entries {
+		if e.Action != AuditImport || e.PerformedBy != 42 {
+			t.Errorf("audit entry %+v, want import by 42", e)
+		}
+	}
+	if ok, err := s.IsApproved(1); err != nil || !ok {
+		t.Errorf("IsApproved(1) = %v, %v after import", ok, err)
+	}
+}
+
+func TestClaimPendingOnce(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.AddPending(PendingRequest{UserID: 1, ChatID: 1, RequestedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var claimed atomic.Int32
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := s.ClaimPending(1)
+			if err != nil {
+				t.Errorf("ClaimPending: %v", err)
+			}
+			if ok {
+				claimed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := claimed.Load(); got != 1 {
+		t.Errorf("%d admins claimed the request, want 1", got)
+	}
+}
+
+func TestGetPendingBeforeNotified(t *testing.T) {
+	// A request whose admin notification failed has no admin message
+	s := newTestStore(t)
+	if err := s.AddPending(PendingRequest{UserID: 1, ChatID: 1, RequestedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddPendingGroup(PendingGroupRequest{GroupID: -100, RequestedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := s.GetPending(1)
+	if err != nil || req == nil || req.AdminMsgID != 0 || req.NotifiedAt != nil {
+		t.Errorf("GetPending = %+v, %v, want an unnotified request", req, err)
+	}
+	group, err := s.GetPendingGroup(-100)
+	if err != nil || group == nil || group.AdminMsgID != 0 {
+		t.Errorf("GetPendingGroup = %+v, %v, want an unnotified request", group, err)
+	}
+}
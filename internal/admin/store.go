@@ -1,6 +1,15 @@
 package admin
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// Errors returned when a user cannot be approved
+var (
+	ErrUserBanned           = errors.New("user is banned")
+	ErrApprovedLimitReached = errors.New("approved user limit reached")
+)
 
 // ApprovedUser represents a dynamically approved user
 type ApprovedUser struct {
@@ -8,6 +17,13 @@ type ApprovedUser struct {
 	Username   string
 	ApprovedAt time.Time
 	ApprovedBy int64
+	// ExpiresAt ends temporary access (nil = permanent)
+	ExpiresAt *time.Time
+}
+
+// Expired reports whether temporary access has ended at now
+func (u *ApprovedUser) Expired(now time.Time) bool {
+	return u.ExpiresAt != nil && !now.Before(*u.ExpiresAt)
 }
 
 // PendingRequest tracks users waiting for admin approval
@@ -38,16 +54,86 @@ type PendingGroupRequest struct {
 	AdminMsgID  int
 }
 
+// Audit actions recorded in the audit log
+const (
+	AuditApprove = "approve"
+	AuditReject  = "reject"
+	AuditRevoke  = "revoke"
+	AuditBan     = "ban"
+	AuditUnban   = "unban"
+	// AuditExpire is a pending request removed after waiting too long
+	AuditExpire = "expire"
+	// AuditImport is a user or group restored from a backup
+	AuditImport = "import"
+)
+
+// Audit target types
+const (
+	TargetUser  = "user"
+	TargetGroup = "group"
+)
+
+// AuditEntry records an admin action on a user or group
+type AuditEntry struct {
+	ID          int64
+	Action      string
+	TargetID    int64
+	TargetType  string
+	PerformedBy int64
+	Timestamp   time.Time
+	// Notes holds context such as the username or group title
+	Notes string
+}
+
 // Store defines the interface for admin persistence
 type Store interface {
-	// IsApproved checks if a user has been approved
+	// IsApproved checks if a user has been approved and their access has
+	// not expired
 	IsApproved(userID int64) (bool, error)
 
-	// AddApproved adds a user to the approved list
+	// ForgetApproval drops any cached approval of a user whose records were
+	// deleted outside the store, e.g. by erasure
+	ForgetApproval(userID int64)
+
+	// GetApproved retrieves an approved user, including expired ones, or
+	// nil if the user is not approved
+	GetApproved(userID int64) (*ApprovedUser, error)
+
+	// AddApproved adds a user to the approved list and records the
+	// approval in the audit log
 	AddApproved(user ApprovedUser) error
 
-	// RemoveApproved removes a user from the approved list
-	RemoveApproved(userID int64) error
+	// RemoveApproved removes a user from the approved list, recording the
+	// revocation by performedBy in the audit log
+	RemoveApproved(userID, performedBy int64) error
+
+	// CountApproved returns the number of dynamically approved users
+	CountApproved() (int, error)
+
+	// ListExpired returns approved users whose temporary access has ended
+	ListExpired() ([]ApprovedUser, error)
+
+	// ListApproved returns a page of approved users, newest first, along
+	// with the total number of approved users
+	ListApproved(offset, limit int) ([]ApprovedUser, int, error)
+
+	// ListAllApproved returns every approved user, including expired ones,
+	// newest first
+	ListAllApproved() ([]ApprovedUser, error)
+
+	// RecordChatID remembers the private chat a user last used the bot in
+	RecordChatID(userID, chatID int64) error
+
+	// ListChatIDs returns every recorded private chat ID keyed by user ID
+	ListChatIDs() (map[int64]int64, error)
+
+	// GetUserWorkflows returns the workflows a user is restricted to, or
+	// nil if the user may use every workflow
+	GetUserWorkflows(userID int64) ([]string, error)
+
+	// SetUserWorkflows replaces the workflows a user is restricted to. An
+	// empty list lifts the restriction.
+	SetUserWorkflows(userID int64, workflows []string) error
 
 	// GetPending retrieves a pending request by user ID
 	GetPending(userID int64) (*PendingRequest, error)
@@ -55,20 +141,48 @@ type Store interface {
 	// AddPending adds a new pending request
 	AddPending(req PendingRequest) error
 
-	// RemovePending removes a pending request
-	RemovePending(userID int64) error
+	// RemovePending rejects a pending request on behalf of performedBy,
+	// recording it in the audit log. Reports whether this call removed it,
+	// so that only one of several admins acts on a request.
+	RemovePending(userID, performedBy int64) (bool, error)
+
+	// ClaimPending removes a pending request, reporting whether this call
+	// removed it, so that only one of several admins acts on a request
+	ClaimPending(userID int64) (bool, error)
 
 	// UpdatePendingNotified marks a pending request as notified
 	UpdatePendingNotified(userID int64, msgID int) error
 
+	// ListAllPending returns all pending user requests, oldest first
+	ListAllPending() ([]PendingRequest, error)
+
+	// ListPending returns a page of pending user requests, oldest first,
+	// along with the total number of pending user requests
+	ListPending(offset, limit int) ([]PendingRequest, int, error)
+
+	// RejectAllPending deletes all pending user and group requests on
+	// behalf of performedBy, recording each in the audit log, and returns
+	// exactly the requests it removed so only those requesters are told
+	RejectAllPending(performedBy int64) ([]PendingRequest, []PendingGroupRequest, error)
+
+	// ExpirePending deletes pending user and group requests made more than
+	// olderThan ago, recording each in the audit log, and returns them so
+	// the requesters and admins can be told
+	ExpirePending(olderThan time.Duration) ([]PendingRequest, []PendingGroupRequest, error)
+
+	// GetStale returns notified pending requests last notified before the given time
+	GetStale(before time.Time) ([]PendingRequest, error)
+
 	// IsGroupApproved checks if a group has been approved
 	IsGroupApproved(groupID int64) (bool, error)
 
-	// AddApprovedGroup adds a group to the approved list
+	// AddApprovedGroup adds a group to the approved list and records the
+	// approval in the audit log
 	AddApprovedGroup(group ApprovedGroup) error
 
-	// RemoveApprovedGroup removes a group from the approved list
-	RemoveApprovedGroup(groupID int64) error
+	// RemoveApprovedGroup removes a group from the approved list, recording
+	// the revocation by performedBy in the audit log
+	RemoveApprovedGroup(groupID, performedBy int64) error
 
 	// GetPendingGroup retrieves a pending group request by group ID
 	GetPendingGroup(groupID int64) (*PendingGroupRequest, error)
@@ -82,6 +196,36 @@ type Store interface {
 	// UpdatePendingGroupNotified marks a pending group request as notified
 	UpdatePendingGroupNotified(groupID int64, msgID int) error
 
+	// ListPendingGroups returns a page of pending group requests, oldest
+	// first, along with the total number of pending group requests
+	ListPendingGroups(offset, limit int) ([]PendingGroupRequest, int, error)
+
+	// Ban stops a user from using the bot or requesting access, removing
+	// any approval or pending request, and records it in the audit log
+	Ban(userID, bannedBy int64, reason string) error
+
+	// Unban lifts a ban, recording it by performedBy in the audit log.
+	// Reports whether the user was banned.
+	Unban(userID, performedBy int64) (bool, error)
+
+	// IsBanned checks if a user has been banned
+	IsBanned(userID int64) (bool, error)
+
+	// GetAuditLog returns a page of audit log entries, newest first
+	GetAuditLog(offset, limit int) ([]AuditEntry, error)
+
+	// Export serialises every approved user and group as a versioned JSON
+	// backup
+	Export() ([]byte, error)
+
+	// Import upserts the users and groups from a backup written by Export
+	// in a single transaction, recording each in the audit log as done by
+	// performedBy. Existing records not in the backup are kept.
+	Import(data []byte, performedBy int64) error
+
+	// Ping verifies the underlying database is reachable
+	Ping() error
+
 	// Close releases resources
 	Close() error
 }
@@ -0,0 +1,142 @@
+package bugreport
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+
+	appdb "comfy-tg-bot/internal/db"
+)
+
+// migrations defines the bugreport schema; append new versions, never edit old ones
+var migrations = []appdb.Migration{
+	{Version: 1, SQL: `
+		CREATE TABLE IF NOT EXISTS bug_reports (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			username TEXT,
+			description TEXT NOT NULL,
+			last_error_message TEXT,
+			settings_snapshot TEXT,
+			submitted_at DATETIME NOT NULL
+		)
+	`},
+}
+
+// SQLiteStore implements BugReportStore using SQLite for persistence
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore creates a new SQLite-backed bug report store
+func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	// Ensure directory exists
+	dir := filepath.Dir(dbPath)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite works best with a single writer
+	db.SetMaxOpenConns(1)
+
+	if err := appdb.RunMigrations(db, "bugreport", migrations); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Submit stores a new report and returns its ID
+func (s *SQLiteStore) Submit(report Report) (int64, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO bug_reports (user_id, username, description, last_error_message, settings_snapshot, submitted_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, report.UserID, report.Username, report.Description, report.LastErrorMessage,
+		report.SettingsSnapshot, report.SubmittedAt)
+	if err != nil {
+		return 0, fmt.Errorf("submit bug report: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("get bug report id: %w", err)
+	}
+	return id, nil
+}
+
+// Get retrieves a report by ID, returning nil if it does not exist
+func (s *SQLiteStore) Get(id int64) (*Report, error) {
+	var r Report
+	err := s.db.QueryRow(`
+		SELECT id, user_id, username, description, last_error_message, settings_snapshot, submitted_at
+		FROM bug_reports WHERE id = ?
+	`, id).Scan(
+		&r.ID,
+		&r.UserID,
+		&r.Username,
+		&r.Description,
+		&r.LastErrorMessage,
+		&r.SettingsSnapshot,
+		&r.SubmittedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get bug report: %w", err)
+	}
+	return &r, nil
+}
+
+// List returns the most recent reports, newest first
+func (s *SQLiteStore) List(limit int) ([]Report, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, username, description, last_error_message, settings_snapshot, submitted_at
+		FROM bug_reports
+		ORDER BY id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list bug reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []Report
+	for rows.Next() {
+		var r Report
+		if err := rows.Scan(
+			&r.ID,
+			&r.UserID,
+			&r.Username,
+			&r.Description,
+			&r.LastErrorMessage,
+			&r.SettingsSnapshot,
+			&r.SubmittedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan bug report: %w", err)
+		}
+		reports = append(reports, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate bug reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+// Close releases database resources
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
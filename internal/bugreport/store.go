@@ -0,0 +1,26 @@
+package bugreport
+
+import "time"
+
+// Report is a bug report submitted by a user
+type Report struct {
+	ID               int64
+	UserID           int64
+	Username         string
+	Description      string
+	LastErrorMessage string
+	SettingsSnapshot string
+	SubmittedAt      time.Time
+}
+
+// BugReportStore defines the interface for bug report persistence
+type BugReportStore interface {
+	// Submit stores a new report and returns its ID
+	Submit(report Report) (int64, error)
+	// Get retrieves a report by ID, returning nil if it does not exist
+	Get(id int64) (*Report, error)
+	// List returns the most recent reports, newest first
+	List(limit int) ([]Report, error)
+	// Close releases resources
+	Close() error
+}
@@ -0,0 +1,32 @@
+package comfyui
+
+// AspectRatio is an output shape users can pick for workflows with
+// {{WIDTH}} and {{HEIGHT}} placeholders
+type AspectRatio struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// AspectRatios are the selectable shapes, sized for SDXL-class models at
+// roughly one megapixel with sides divisible by 64
+var AspectRatios = []AspectRatio{
+	{Name: "1:1", Width: 1024, Height: 1024},
+	{Name: "16:9", Width: 1344, Height: 768},
+	{Name: "9:16", Width: 768, Height: 1344},
+	{Name: "4:3", Width: 1152, Height: 896},
+	{Name: "3:4", Width: 896, Height: 1152},
+}
+
+// DefaultAspectRatio is used when the user has not chosen one
+var DefaultAspectRatio = AspectRatios[0]
+
+// FindAspectRatio looks up an aspect ratio by name, e.g. "16:9"
+func FindAspectRatio(name string) (AspectRatio, bool) {
+	for _, ar := range AspectRatios {
+		if ar.Name == name {
+			return ar, true
+		}
+	}
+	return AspectRatio{}, false
+}
@@ -0,0 +1,39 @@
+package comfyui
+
+import "net/http"
+
+// authTransport adds the configured API key as a bearer token to requests
+// for the ComfyUI host, for servers behind an authenticating proxy.
+// Requests to other hosts, e.g. after a redirect, are sent without it.
+type authTransport struct {
+	apiKey string
+	// host is the ComfyUI host[:port] the key belongs to
+	host string
+	// base sends the request (nil = http.DefaultTransport)
+	base http.RoundTripper
+}
+
+// RoundTrip sends req, adding the Authorization header to a copy when it
+// is for the ComfyUI host
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == t.host {
+		// A RoundTripper must not modify the caller's request
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// authHeader returns the headers that authenticate a WebSocket handshake,
+// or nil without an API key
+func authHeader(apiKey string) http.Header {
+	if apiKey == "" {
+		return nil
+	}
+	return http.Header{"Authorization": {"Bearer " + apiKey}}
+}
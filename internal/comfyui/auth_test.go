@@ -0,0 +1,43 @@
+package comfyui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestAuthTransportOnlyAuthenticatesComfyUIHost(t *testing.T) {
+	var otherAuth string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherAuth = r.Header.Get("Authorization")
+	}))
+	defer other.Close()
+
+	var comfyAuth string
+	comfy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		comfyAuth = r.Header.Get("Authorization")
+		http.Redirect(w, r, other.URL+"/elsewhere", http.StatusFound)
+	}))
+	defer comfy.Close()
+
+	u, _ := url.Parse(comfy.URL)
+	client := &http.Client{Transport: &authTransport{apiKey: "secret", host: u.Host}}
+
+	req, _ := http.NewRequest("GET", comfy.URL+"/system_stats", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if comfyAuth != "Bearer secret" {
+		t.Errorf("ComfyUI Authorization = %q, want %q", comfyAuth, "Bearer secret")
+	}
+	if otherAuth != "" {
+		t.Errorf("redirect target got Authorization %q, want none", otherAuth)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Error("RoundTrip modified the caller's request")
+	}
+}
@@ -3,15 +3,23 @@ package comfyui
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"time"
 
+	"github.com/google/uuid"
+
 	"comfy-tg-bot/internal/config"
+	apperrors "comfy-tg-bot/internal/errors"
 )
 
 // Client handles communication with the ComfyUI API
@@ -19,49 +27,271 @@ type Client struct {
 	baseURL    string
 	wsURL      string
 	httpClient *http.Client
-	workflow   *WorkflowManager
-	logger     *slog.Logger
+	// tlsConfig applies to https:// and wss:// URLs (nil = defaults)
+	tlsConfig *tls.Config
+	// apiKey is sent as a bearer token with every request (empty = none)
+	apiKey   string
+	workflow *WorkflowManager
+	// workflows holds every selectable workflow by name, including the
+	// default under DefaultWorkflow
+	workflows map[string]*WorkflowManager
+	logger    *slog.Logger
+
+	// retry applies to queueing prompts and downloading outputs
+	retry RetryPolicy
+	// breaker fails QueuePrompt fast while ComfyUI is unreachable
+	breaker *CircuitBreaker
+
+	// HTTP polling fallback for networks that block WebSockets
+	forcePolling bool
+	pollInterval time.Duration
+
+	// Installed node types used to validate workflows
+	objectInfo        *ObjectInfoCache
+	unknownNodePolicy string
+
+	// checkpoints caches ListCheckpoints results
+	checkpoints *checkpointCache
 }
 
+// DefaultWorkflow is the name of the workflow loaded from workflow_path
+const DefaultWorkflow = "default"
+
 // NewClient creates a new ComfyUI client
 func NewClient(cfg config.ComfyUIConfig, logger *slog.Logger) (*Client, error) {
-	workflow, err := NewWorkflowManager(cfg.WorkflowPath)
+	workflows, err := loadWorkflows(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := newTLSConfig(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(cfg, cfg.BaseURL, cfg.WebSocketURL, tlsConfig, workflows, logger), nil
+}
+
+// loadWorkflows loads the default workflow and every named one
+func loadWorkflows(cfg config.ComfyUIConfig) (map[string]*WorkflowManager, error) {
+	workflow, err := NewWorkflowManager(cfg.WorkflowPath, cfg.BackupOnReload)
 	if err != nil {
 		return nil, fmt.Errorf("load workflow: %w", err)
 	}
 
+	workflows := map[string]*WorkflowManager{DefaultWorkflow: workflow}
+	for name, path := range cfg.Workflows {
+		wm, err := NewWorkflowManager(path, cfg.BackupOnReload)
+		if err != nil {
+			return nil, fmt.Errorf("load workflow %q: %w", name, err)
+		}
+		workflows[name] = wm
+	}
+	return workflows, nil
+}
+
+// newClient creates a client for the server at baseURL using already
+// loaded workflows, so several clients can share the same templates
+func newClient(cfg config.ComfyUIConfig, baseURL, wsURL string, tlsConfig *tls.Config, workflows map[string]*WorkflowManager, logger *slog.Logger) *Client {
+	transport := newHTTPTransport(tlsConfig)
+	if cfg.APIKey != "" {
+		var host string
+		if u, err := url.Parse(baseURL); err == nil {
+			host = u.Host
+		}
+		transport = &authTransport{apiKey: cfg.APIKey, host: host, base: transport}
+	}
+
 	return &Client{
-		baseURL: cfg.BaseURL,
-		wsURL:   cfg.WebSocketURL,
+		baseURL: baseURL,
+		wsURL:   wsURL,
 		httpClient: &http.Client{
-			Timeout: cfg.Timeout,
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+		},
+		tlsConfig: tlsConfig,
+		apiKey:    cfg.APIKey,
+		workflow:  workflows[DefaultWorkflow],
+		workflows: workflows,
+		logger:    logger,
+		retry: RetryPolicy{
+			MaxAttempts:  cfg.RetryAttempts,
+			InitialDelay: cfg.RetryInitialDelay,
 		},
-		workflow: workflow,
-		logger:   logger,
-	}, nil
+		breaker: NewCircuitBreaker(cfg.CircuitBreakerThreshold,
+			time.Duration(cfg.CircuitBreakerResetSeconds)*time.Second),
+		forcePolling: cfg.ForceHTTPPolling,
+		pollInterval: time.Duration(cfg.PollingIntervalMs) * time.Millisecond,
+
+		objectInfo:        &ObjectInfoCache{},
+		unknownNodePolicy: cfg.UnknownNodePolicy,
+
+		checkpoints: &checkpointCache{},
+	}
+}
+
+// ReloadWorkflow re-reads every workflow template from disk
+func (c *Client) ReloadWorkflow() error {
+	for _, name := range c.WorkflowNames() {
+		backupPath, err := c.workflows[name].Reload()
+		if err != nil {
+			return fmt.Errorf("reload workflow %q: %w", name, err)
+		}
+		if backupPath != "" {
+			c.logger.Info("backed up previous workflow", "workflow", name, "path", backupPath)
+		}
+	}
+	c.logger.Info("workflow reloaded")
+	return nil
 }
 
-// GenerateImage is the main entry point for image generation
-func (c *Client) GenerateImage(ctx context.Context, prompt string) ([]byte, error) {
+// WorkflowNames returns the selectable workflow names in sorted order
+func (c *Client) WorkflowNames() []string {
+	names := make([]string, 0, len(c.workflows))
+	for name := range c.workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasWorkflow reports whether a workflow with the given name is loaded
+func (c *Client) HasWorkflow(name string) bool {
+	_, ok := c.workflows[name]
+	return ok
+}
+
+// WorkflowBackups lists the default workflow template backups, newest first
+func (c *Client) WorkflowBackups() ([]string, error) {
+	return c.workflow.Backups()
+}
+
+// RollbackWorkflow restores the named backup of the default workflow and
+// reloads it
+func (c *Client) RollbackWorkflow(name string) error {
+	backupPath, err := c.workflow.Restore(name)
+	if err != nil {
+		return fmt.Errorf("rollback workflow: %w", err)
+	}
+	if backupPath != "" {
+		c.logger.Info("backed up previous workflow", "path", backupPath)
+	}
+	c.logger.Info("workflow rolled back", "backup", name)
+	return nil
+}
+
+// GenerateOptions customizes a single generation
+type GenerateOptions struct {
+	// Progress receives step updates; may be nil and is not called when HTTP polling is used
+	Progress ProgressCallback
+	// RandomSeed replaces the workflow's seed inputs with a random value
+	RandomSeed bool
+	// Seed fixes {{SEED}} and every seed input for reproducible results
+	// (nil = random {{SEED}}, workflow seed inputs unchanged). Ignored if
+	// RandomSeed is set.
+	Seed *int64
+	// Workflow selects a named workflow (empty = DefaultWorkflow)
+	Workflow string
+	// NegativePrompt fills the {{NEGATIVE_PROMPT}} placeholder, if present
+	NegativePrompt string
+	// Params overrides whitelisted sampler inputs (see InjectableParams)
+	Params map[string]string
+	// MaxQueueWait drops the prompt if it is still pending in the ComfyUI
+	// queue after this long (0 = wait indefinitely)
+	MaxQueueWait time.Duration
+	// OnQueued is called with the ComfyUI prompt ID once the prompt is queued
+	OnQueued func(promptID string)
+	// ReferenceImage fills the {{REFERENCE_IMAGE}} placeholder with the
+	// name of an image already uploaded to ComfyUI
+	ReferenceImage string
+	// AspectRatio fills the {{WIDTH}} and {{HEIGHT}} placeholders
+	// (nil = DefaultAspectRatio)
+	AspectRatio *AspectRatio
+}
+
+// Output is the result of a generation
+type Output struct {
+	Data []byte
+	// Video is set when the workflow produced a video instead of an image
+	Video *VideoOutput
+	// Seed is the sampler seed the workflow ran with (-1 if unknown)
+	Seed int64
+	// Filename is the output file's name on the ComfyUI server
+	Filename string
+}
+
+// IsVideo reports whether the output is a video
+func (o *Output) IsVideo() bool {
+	return o.Video != nil
+}
+
+// GenerateImage is the main entry point for image generation. Workflows
+// with video nodes (AnimateDiff etc.) return their first video instead.
+func (c *Client) GenerateImage(ctx context.Context, prompt string, opts GenerateOptions) (*Output, error) {
 	// Create execution monitor with unique client ID
 	monitor := NewExecutionMonitor(c.wsURL, c.logger)
+	monitor.SetTLSConfig(c.tlsConfig)
+	monitor.SetAPIKey(c.apiKey)
+	if c.forcePolling {
+		monitor.EnablePolling(c, c.pollInterval)
+	}
 
 	// Prepare workflow
-	workflow, err := c.workflow.PrepareWorkflow(prompt)
+	wm, err := c.workflowManager(opts.Workflow)
+	if err != nil {
+		return nil, err
+	}
+	aspect := opts.AspectRatio
+	if aspect == nil {
+		aspect = &DefaultAspectRatio
+	}
+	extra := map[string]string{
+		ReferenceImagePlaceholder: opts.ReferenceImage,
+		WidthPlaceholder:          strconv.Itoa(aspect.Width),
+		HeightPlaceholder:         strconv.Itoa(aspect.Height),
+	}
+	fixedSeed := opts.Seed != nil && !opts.RandomSeed
+	if fixedSeed {
+		extra[SeedPlaceholder] = strconv.FormatInt(*opts.Seed, 10)
+	}
+	workflow, err := wm.PrepareWorkflowFull(prompt, opts.NegativePrompt, extra)
 	if err != nil {
 		return nil, fmt.Errorf("prepare workflow: %w", err)
 	}
 
+	switch {
+	case opts.RandomSeed:
+		RandomizeSeeds(workflow)
+	case fixedSeed:
+		SetSeeds(workflow, *opts.Seed)
+	}
+	if len(opts.Params) > 0 {
+		ApplyParams(workflow, opts.Params)
+	}
+	seed := WorkflowSeed(workflow)
+
 	// Queue the prompt
 	promptID, err := c.QueuePrompt(ctx, workflow, monitor.GetClientID())
 	if err != nil {
 		return nil, fmt.Errorf("queue prompt: %w", err)
 	}
 
-	c.logger.Debug("prompt queued", "prompt_id", promptID)
+	c.logger.Debug("prompt queued", "prompt_id", promptID, "seed", seed)
+	if opts.OnQueued != nil {
+		opts.OnQueued(promptID)
+	}
+
+	waitCtx := ctx
+	if opts.MaxQueueWait > 0 {
+		var cancel context.CancelCauseFunc
+		waitCtx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		go c.expireIfQueued(waitCtx, cancel, promptID, time.Now(), opts.MaxQueueWait)
+	}
 
 	// Wait for completion
-	if err := monitor.WaitForCompletion(ctx, promptID, nil); err != nil {
+	if err := monitor.WaitForCompletion(waitCtx, promptID, opts.Progress); err != nil {
+		if cause := context.Cause(waitCtx); errors.Is(cause, apperrors.ErrQueueExpired) {
+			return nil, cause
+		}
 		return nil, fmt.Errorf("wait for completion: %w", err)
 	}
 
@@ -77,48 +307,224 @@ func (c *Client) GenerateImage(ctx context.Context, prompt string) ([]byte, erro
 		return nil, fmt.Errorf("prompt not found in history")
 	}
 
+	// Prefer video outputs, since video workflows usually also save frames
+	for _, output := range entry.Outputs {
+		if videos := output.VideoOutputs(); len(videos) > 0 {
+			video := videos[0]
+			data, err := c.GetVideo(ctx, video.Filename, video.Subfolder, video.Type)
+			if err != nil {
+				return nil, err
+			}
+			return &Output{Data: data, Video: &video, Seed: seed, Filename: video.Filename}, nil
+		}
+	}
+
 	// Find first image in outputs
 	for _, output := range entry.Outputs {
 		if len(output.Images) > 0 {
 			img := output.Images[0]
-			return c.GetImage(ctx, img.Filename, img.Subfolder, img.Type)
+			data, err := c.GetImage(ctx, img.Filename, img.Subfolder, img.Type)
+			if err != nil {
+				return nil, err
+			}
+			return &Output{Data: data, Seed: seed, Filename: img.Filename}, nil
 		}
 	}
 
 	return nil, fmt.Errorf("no output image found")
 }
 
-// QueuePrompt sends a prompt to ComfyUI
-func (c *Client) QueuePrompt(ctx context.Context, workflow map[string]any, clientID string) (string, error) {
-	req := PromptRequest{
-		Prompt:   workflow,
-		ClientID: clientID,
+// GenerateImageFromReference uploads imageData to ComfyUI and runs an
+// img2img generation with it filling the {{REFERENCE_IMAGE}} placeholder.
+// Returns ErrReferenceUnsupported if the workflow has no such placeholder.
+func (c *Client) GenerateImageFromReference(ctx context.Context, prompt string, imageData []byte, opts GenerateOptions) (*Output, error) {
+	if !c.SupportsReference(opts.Workflow) {
+		return nil, apperrors.ErrReferenceUnsupported
 	}
 
-	body, err := json.Marshal(req)
+	upload, err := c.UploadImage(ctx, referenceFilename(imageData), imageData, UploadTypeInput)
 	if err != nil {
-		return "", fmt.Errorf("marshal request: %w", err)
+		return nil, fmt.Errorf("upload reference image: %w", err)
+	}
+	name := upload.LoadName()
+	c.logger.Debug("reference image uploaded", "name", name, "size", len(imageData))
+
+	opts.ReferenceImage = name
+	return c.GenerateImage(ctx, prompt, opts)
+}
+
+// SupportsDimensions reports whether the named workflow (empty = default)
+// takes its output size from an aspect ratio
+func (c *Client) SupportsDimensions(workflow string) bool {
+	wm, err := c.workflowManager(workflow)
+	return err == nil && wm.SupportsDimensions()
+}
+
+// SupportsReference reports whether the named workflow (empty = default)
+// accepts a reference image
+func (c *Client) SupportsReference(workflow string) bool {
+	wm, err := c.workflowManager(workflow)
+	return err == nil && wm.SupportsReference()
+}
+
+// workflowManager returns the named workflow (empty = default)
+func (c *Client) workflowManager(name string) (*WorkflowManager, error) {
+	if name == "" {
+		return c.workflow, nil
 	}
+	wm, ok := c.workflows[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown workflow %q", name)
+	}
+	return wm, nil
+}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/prompt", bytes.NewReader(body))
+// referenceFilename returns a unique upload name with an extension
+// matching the image data
+func referenceFilename(data []byte) string {
+	ext := ".png"
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/webp":
+		ext = ".webp"
+	case "image/gif":
+		ext = ".gif"
+	}
+	return "tg-reference-" + uuid.New().String() + ext
+}
+
+// UploadImage uploads an image to one of ComfyUI's directories, selected
+// by imageType: UploadTypeInput, UploadTypeOutput or UploadTypeTemp
+func (c *Client) UploadImage(ctx context.Context, filename string, data []byte, imageType string) (*UploadResponse, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("image data is empty")
+	}
+	switch imageType {
+	case UploadTypeInput, UploadTypeOutput, UploadTypeTemp:
+	default:
+		return nil, fmt.Errorf("invalid upload type %q: must be %q, %q or %q",
+			imageType, UploadTypeInput, UploadTypeOutput, UploadTypeTemp)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("image", filename)
 	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create form file: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, fmt.Errorf("write form file: %w", err)
+	}
+	if err := form.WriteField("type", imageType); err != nil {
+		return nil, fmt.Errorf("write form field: %w", err)
+	}
+	if err := form.WriteField("overwrite", "true"); err != nil {
+		return nil, fmt.Errorf("write form field: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("close form: %w", err)
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/upload/image", &body)
 	if err != nil {
-		return "", fmt.Errorf("send request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("read response: %w", err)
+		return nil, fmt.Errorf("read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("server returned %d: %s", resp.StatusCode, string(respBody))
+		return nil, fmt.Errorf("server returned %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var upload UploadResponse
+	if err := json.Unmarshal(respBody, &upload); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	if upload.Name == "" {
+		return nil, fmt.Errorf("response has no image name")
+	}
+	return &upload, nil
+}
+
+// QueuePrompt sends a prompt to ComfyUI. While the circuit breaker is open
+// it fails fast with ErrComfyUIUnavailable instead.
+func (c *Client) QueuePrompt(ctx context.Context, workflow map[string]any, clientID string) (string, error) {
+	if !c.breaker.Allow() {
+		return "", fmt.Errorf("circuit breaker open: %w", apperrors.ErrComfyUIUnavailable)
+	}
+
+	promptID, err := c.queuePrompt(ctx, workflow, clientID)
+	switch {
+	case ctx.Err() != nil:
+		c.breaker.Abandon()
+	case err != nil && isTransient(err):
+		c.breaker.Failure()
+		if c.breaker.State() == CircuitOpen {
+			c.logger.Warn("comfyui circuit breaker open", "error", err)
+		}
+	default:
+		// Any response, even a rejected prompt, shows the server is up
+		c.breaker.Success()
+	}
+	return promptID, err
+}
+
+// CircuitState returns the state of the QueuePrompt circuit breaker
+func (c *Client) CircuitState() CircuitState {
+	return c.breaker.State()
+}
+
+// queuePrompt implements QueuePrompt
+func (c *Client) queuePrompt(ctx context.Context, workflow map[string]any, clientID string) (string, error) {
+	req := PromptRequest{
+		Prompt:   workflow,
+		ClientID: clientID,
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	// Queueing is not idempotent: only resend when ComfyUI cannot have
+	// queued the prompt already
+	var respBody []byte
+	err = retryIf(ctx, c.retry, c.logger, "queue prompt", isResendable, func() error {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/prompt", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return fmt.Errorf("send request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		respBody, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return &statusError{code: resp.StatusCode, body: string(respBody)}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 
 	var promptResp PromptResponse
@@ -156,6 +562,12 @@ func (c *Client) GetHistory(ctx context.Context, promptID string) (HistoryRespon
 	return history, nil
 }
 
+// GetVideo downloads a video from ComfyUI. ComfyUI serves every output
+// file through /view, so this shares the image download path.
+func (c *Client) GetVideo(ctx context.Context, filename, subfolder, videoType string) ([]byte, error) {
+	return c.GetImage(ctx, filename, subfolder, videoType)
+}
+
 // GetImage downloads an image from ComfyUI
 func (c *Client) GetImage(ctx context.Context, filename, subfolder, imgType string) ([]byte, error) {
 	params := url.Values{}
@@ -169,7 +581,38 @@ func (c *Client) GetImage(ctx context.Context, filename, subfolder, imgType stri
 
 	reqURL := fmt.Sprintf("%s/view?%s", c.baseURL, params.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	var data []byte
+	err := retry(ctx, c.retry, c.logger, "get image", func() error {
+		req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("send request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return &statusError{code: resp.StatusCode}
+		}
+
+		data, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetQueue retrieves the current ComfyUI execution queue
+func (c *Client) GetQueue(ctx context.Context) (*QueueResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/queue", nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -184,7 +627,122 @@ func (c *Client) GetImage(ctx context.Context, filename, subfolder, imgType stri
 		return nil, fmt.Errorf("server returned %d", resp.StatusCode)
 	}
 
-	return io.ReadAll(resp.Body)
+	var queue QueueResponse
+	if err := json.NewDecoder(resp.Body).Decode(&queue); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	return &queue, nil
+}
+
+// GetQueueStatus returns how many prompts ComfyUI is running and has pending
+func (c *Client) GetQueueStatus(ctx context.Context) (*QueueStatus, error) {
+	queue, err := c.GetQueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &QueueStatus{Pending: len(queue.QueuePending), Running: len(queue.QueueRunning)}, nil
+}
+
+// Interrupt stops the workflow ComfyUI is currently executing. promptIDs
+// only matter to BackendPool, since a Client has a single server.
+func (c *Client) Interrupt(ctx context.Context, promptIDs ...string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/interrupt", nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// DeleteQueued removes a pending prompt from the ComfyUI queue
+func (c *Client) DeleteQueued(ctx context.Context, promptID string) error {
+	body, err := json.Marshal(map[string][]string{"delete": {promptID}})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/queue", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// CancelPrompt stops promptID: it is removed from the queue while pending,
+// or interrupted if ComfyUI is currently executing it. A prompt that has
+// already finished is left alone.
+func (c *Client) CancelPrompt(ctx context.Context, promptID string) error {
+	queue, err := c.GetQueue(ctx)
+	if err != nil {
+		return fmt.Errorf("get queue: %w", err)
+	}
+
+	// /interrupt stops whatever is running, so only use it for our prompt
+	running, pending := queue.Contains(promptID)
+	switch {
+	case pending:
+		if err := c.DeleteQueued(ctx, promptID); err != nil {
+			return fmt.Errorf("delete queued prompt: %w", err)
+		}
+	case running:
+		if err := c.Interrupt(ctx); err != nil {
+			return fmt.Errorf("interrupt prompt: %w", err)
+		}
+	}
+	return nil
+}
+
+// expireIfQueued removes promptID from the queue and cancels the wait with
+// ErrQueueExpired if it has not started running after maxWait
+func (c *Client) expireIfQueued(ctx context.Context, cancel context.CancelCauseFunc, promptID string, queuedAt time.Time, maxWait time.Duration) {
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+	}
+
+	queue, err := c.GetQueue(ctx)
+	if err != nil {
+		c.logger.Debug("queue check failed", "error", err, "prompt_id", promptID)
+		return
+	}
+	if _, pending := queue.Contains(promptID); !pending {
+		return
+	}
+
+	if err := c.DeleteQueued(ctx, promptID); err != nil {
+		c.logger.Warn("failed to remove expired prompt from queue", "error", err, "prompt_id", promptID)
+	}
+
+	waited := time.Since(queuedAt).Truncate(time.Second)
+	c.logger.Warn("queued prompt expired", "prompt_id", promptID, "waited", waited, "max_wait", maxWait)
+	cancel(fmt.Errorf("prompt %s waited %s: %w", promptID, waited, apperrors.ErrQueueExpired))
 }
 
 // CheckHealth verifies ComfyUI is accessible
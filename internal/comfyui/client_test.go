@@ -0,0 +1,99 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// uploadRequest is what the test server recorded of an /upload/image call
+type uploadRequest struct {
+	method   string
+	filename string
+	data     []byte
+	fields   map[string]string
+}
+
+func TestUploadImage(t *testing.T) {
+	for _, imageType := range []string{UploadTypeInput, UploadTypeOutput, UploadTypeTemp} {
+		t.Run(imageType, func(t *testing.T) {
+			var got uploadRequest
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/upload/image" {
+					http.NotFound(w, r)
+					return
+				}
+				got.method = r.Method
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				file, header, err := r.FormFile("image")
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				defer file.Close()
+				got.filename = header.Filename
+				got.data, _ = io.ReadAll(file)
+				got.fields = make(map[string]string)
+				for k, v := range r.MultipartForm.Value {
+					got.fields[k] = v[0]
+				}
+				json.NewEncoder(w).Encode(UploadResponse{Name: header.Filename, Type: r.FormValue("type")})
+			}))
+			defer srv.Close()
+
+			data := []byte("\x89PNG fake image")
+			resp, err := testClient(srv.URL, time.Second).UploadImage(context.Background(), "ref.png", data, imageType)
+			if err != nil {
+				t.Fatalf("UploadImage: %v", err)
+			}
+
+			if got.method != http.MethodPost || got.filename != "ref.png" || string(got.data) != string(data) {
+				t.Errorf("server got %s of %q with %q", got.method, got.filename, got.data)
+			}
+			want := map[string]string{"type": imageType, "overwrite": "true"}
+			if len(got.fields) != len(want) {
+				t.Errorf("form fields = %v, want %v", got.fields, want)
+			}
+			for k, v := range want {
+				if got.fields[k] != v {
+					t.Errorf("form field %q = %q, want %q", k, got.fields[k], v)
+				}
+			}
+			if resp.Name != "ref.png" || resp.Type != imageType {
+				t.Errorf("response = %+v", resp)
+			}
+		})
+	}
+}
+
+func TestUploadImageRejects(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"name": ""}`))
+	}))
+	defer srv.Close()
+	c := testClient(srv.URL, time.Second)
+	ctx := context.Background()
+
+	if _, err := c.UploadImage(ctx, "a.png", nil, UploadTypeInput); err == nil {
+		t.Error("expected an error for empty data")
+	}
+	if _, err := c.UploadImage(ctx, "a.png", []byte("x"), "models"); err == nil {
+		t.Error("expected an error for an unknown upload type")
+	}
+	if calls != 0 {
+		t.Errorf("server called %d times for invalid uploads, want 0", calls)
+	}
+
+	if _, err := c.UploadImage(ctx, "a.png", []byte("x"), UploadTypeInput); err == nil {
+		t.Error("expected an error for a response without a name")
+	}
+}
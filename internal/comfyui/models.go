@@ -0,0 +1,125 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// checkpointLoaderClass is the node type whose ckpt_name input lists
+	// the installed checkpoints
+	checkpointLoaderClass = "CheckpointLoaderSimple"
+	// checkpointCacheTTL is how long ListCheckpoints reuses a fetched list
+	checkpointCacheTTL = 60 * time.Second
+)
+
+// checkpointCache holds the last checkpoint list fetched from the server
+type checkpointCache struct {
+	mu      sync.Mutex
+	names   []string
+	fetched time.Time
+}
+
+// GetObjectInfo fetches every installed node type from GET /object_info
+func (c *Client) GetObjectInfo(ctx context.Context) (map[string]NodeInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/object_info", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %d", resp.StatusCode)
+	}
+
+	var info map[string]NodeInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	return info, nil
+}
+
+// ListCheckpoints returns the checkpoints the server's checkpoint loader
+// can load. Results are cached for a minute.
+func (c *Client) ListCheckpoints(ctx context.Context) ([]string, error) {
+	c.checkpoints.mu.Lock()
+	defer c.checkpoints.mu.Unlock()
+
+	if c.checkpoints.names != nil && time.Since(c.checkpoints.fetched) < checkpointCacheTTL {
+		return c.checkpoints.names, nil
+	}
+
+	info, err := c.GetObjectInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loader, ok := info[checkpointLoaderClass]
+	if !ok {
+		return nil, fmt.Errorf("comfyui has no %s node", checkpointLoaderClass)
+	}
+	names, err := comboOptions(loader.Input.Required["ckpt_name"])
+	if err != nil {
+		return nil, fmt.Errorf("parse %s ckpt_name: %w", checkpointLoaderClass, err)
+	}
+
+	c.checkpoints.names = names
+	c.checkpoints.fetched = time.Now()
+	return names, nil
+}
+
+// comboOptions returns the allowed values of a combo input spec. ComfyUI
+// sends either [["a", "b"], {...}] or, in newer versions,
+// ["COMBO", {"options": ["a", "b"]}].
+func comboOptions(spec json.RawMessage) ([]string, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("input not found")
+	}
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal(spec, &parts); err != nil || len(parts) == 0 {
+		return nil, fmt.Errorf("unexpected input spec %s", spec)
+	}
+
+	var options []string
+	if err := json.Unmarshal(parts[0], &options); err == nil {
+		return options, nil
+	}
+
+	var typeName string
+	if err := json.Unmarshal(parts[0], &typeName); err == nil && typeName == "COMBO" && len(parts) > 1 {
+		var extra struct {
+			Options []string `json:"options"`
+		}
+		if err := json.Unmarshal(parts[1], &extra); err == nil && extra.Options != nil {
+			return extra.Options, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected input spec %s", spec)
+}
+
+// UsesCheckpointLoader reports whether the named workflow (empty = default)
+// loads its model with a checkpoint loader
+func (c *Client) UsesCheckpointLoader(workflow string) bool {
+	wm, err := c.workflowManager(workflow)
+	return err == nil && wm.UsesNode(checkpointLoaderClass)
+}
+
+// Checkpoint returns the checkpoint the named workflow (empty = default)
+// loads, or "" if it doesn't load a fixed checkpoint
+func (c *Client) Checkpoint(workflow string) string {
+	wm, err := c.workflowManager(workflow)
+	if err != nil {
+		return ""
+	}
+	return wm.Checkpoint()
+}
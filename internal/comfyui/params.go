@@ -0,0 +1,137 @@
+package comfyui
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// InjectableParam describes a workflow input users may override
+type InjectableParam struct {
+	// Input is the node input name the value is written to
+	Input string
+	// Description is shown to users listing the available parameters
+	Description string
+	// parse validates a raw value and converts it to its JSON type
+	parse func(raw string) (any, error)
+}
+
+// identPattern matches sampler and scheduler names as ComfyUI spells them
+var identPattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// InjectableParams is the whitelist of parameters users may set, keyed by
+// the name used in commands
+var InjectableParams = map[string]InjectableParam{
+	"steps": {
+		Input:       "steps",
+		Description: "sampling steps (1-150)",
+		parse:       parseIntRange(1, 150),
+	},
+	"cfg": {
+		Input:       "cfg",
+		Description: "guidance scale (0-30)",
+		parse:       parseFloatRange(0, 30),
+	},
+	"sampler": {
+		Input:       "sampler_name",
+		Description: "sampler name, e.g. euler",
+		parse:       parseIdent,
+	},
+	"scheduler": {
+		Input:       "scheduler",
+		Description: "scheduler name, e.g. karras",
+		parse:       parseIdent,
+	},
+	"denoise": {
+		Input:       "denoise",
+		Description: "denoise strength (0-1)",
+		parse:       parseFloatRange(0, 1),
+	},
+}
+
+// InjectableParamNames returns the whitelisted parameter names in sorted order
+func InjectableParamNames() []string {
+	names := make([]string, 0, len(InjectableParams))
+	for name := range InjectableParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateParam checks that name is whitelisted and value is acceptable for it
+func ValidateParam(name, value string) error {
+	param, ok := InjectableParams[name]
+	if !ok {
+		return fmt.Errorf("unknown parameter %q", name)
+	}
+	if _, err := param.parse(value); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// ApplyParams writes the given parameters into every node that already has
+// the matching input. Invalid or unknown parameters are skipped.
+func ApplyParams(workflow map[string]any, params map[string]string) {
+	for name, raw := range params {
+		param, ok := InjectableParams[name]
+		if !ok {
+			continue
+		}
+		value, err := param.parse(raw)
+		if err != nil {
+			continue
+		}
+		for _, node := range workflow {
+			nodeMap, ok := node.(map[string]any)
+			if !ok {
+				continue
+			}
+			inputs, ok := nodeMap["inputs"].(map[string]any)
+			if !ok {
+				continue
+			}
+			// Linked inputs are arrays; only replace literal values
+			if _, exists := inputs[param.Input]; exists {
+				if _, linked := inputs[param.Input].([]any); !linked {
+					inputs[param.Input] = value
+				}
+			}
+		}
+	}
+}
+
+func parseIntRange(min, max int) func(string) (any, error) {
+	return func(raw string) (any, error) {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be a whole number")
+		}
+		if n < min || n > max {
+			return nil, fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return n, nil
+	}
+}
+
+func parseFloatRange(min, max float64) func(string) (any, error) {
+	return func(raw string) (any, error) {
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		if f < min || f > max {
+			return nil, fmt.Errorf("must be between %g and %g", min, max)
+		}
+		return f, nil
+	}
+}
+
+func parseIdent(raw string) (any, error) {
+	if !identPattern.MatchString(raw) {
+		return nil, fmt.Errorf("must contain only lowercase letters, digits and underscores")
+	}
+	return raw, nil
+}
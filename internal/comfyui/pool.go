@@ -0,0 +1,469 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"comfy-tg-bot/internal/config"
+	apperrors "comfy-tg-bot/internal/errors"
+)
+
+// backendHealthInterval is how often Run re-checks every backend
+const backendHealthInterval = 30 * time.Second
+
+// cancelledOwnerRetention is how long the backend of a prompt whose
+// generation was cancelled is remembered, so a following Interrupt only
+// stops that backend
+const cancelledOwnerRetention = 30 * time.Second
+
+// BackendPool spreads generations over several ComfyUI servers using
+// weighted round-robin. It offers the same methods as Client; every backend
+// shares the same workflow templates.
+type BackendPool struct {
+	backends []*poolBackend
+	logger   *slog.Logger
+
+	// mu guards the round-robin weights and health of every backend
+	mu sync.Mutex
+
+	// owners maps the ID of each prompt being generated to the
+	// *poolBackend it was queued on, for CancelPrompt and Interrupt
+	owners sync.Map
+}
+
+// poolBackend is one server in a BackendPool
+type poolBackend struct {
+	client *Client
+	weight int
+	// current is the smooth weighted round-robin counter
+	current int
+	// healthy is cleared when a health check or generation fails and set
+	// again when one succeeds
+	healthy bool
+}
+
+// NewBackendPool creates a pool from comfyui.backends, or a single-backend
+// pool from base_url and websocket_url if no backends are configured
+func NewBackendPool(cfg config.ComfyUIConfig, logger *slog.Logger) (*BackendPool, error) {
+	workflows, err := loadWorkflows(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := newTLSConfig(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	backends := cfg.Backends
+	if len(backends) == 0 {
+		backends = []config.BackendConfig{{BaseURL: cfg.BaseURL, WebSocketURL: cfg.WebSocketURL}}
+	}
+
+	pool := &BackendPool{logger: logger}
+	for _, b := range backends {
+		backendLogger := logger
+		if len(backends) > 1 {
+			backendLogger = logger.With("backend", b.BaseURL)
+		}
+		pool.backends = append(pool.backends, &poolBackend{
+			client:  newClient(cfg, b.BaseURL, b.WebSocketURL, tlsConfig, workflows, backendLogger),
+			weight:  max(b.Weight, 1),
+			healthy: true,
+		})
+	}
+	return pool, nil
+}
+
+// Size returns the number of backends in the pool
+func (p *BackendPool) Size() int {
+	return len(p.backends)
+}
+
+// primary returns the first backend's client, used for workflow management
+// since the templates are shared by every backend
+func (p *BackendPool) primary() *Client {
+	return p.backends[0].client
+}
+
+// next picks the backend for a generation using smooth weighted
+// round-robin, skipping those already tried. Unhealthy backends and those
+// whose circuit breaker is open are only used when nothing else is left.
+// Returns nil once every backend has been tried.
+func (p *BackendPool) next(tried map[*poolBackend]bool) *poolBackend {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var candidates []*poolBackend
+	for _, b := range p.backends {
+		if !tried[b] && b.healthy && b.client.CircuitState() != CircuitOpen {
+			candidates = append(candidates, b)
+		}
+	}
+	if len(candidates) == 0 {
+		for _, b := range p.backends {
+			if !tried[b] {
+				candidates = append(candidates, b)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	var best *poolBackend
+	total := 0
+	for _, b := range candidates {
+		b.current += b.weight
+		total += b.weight
+		if best == nil || b.current > best.current {
+			best = b
+		}
+	}
+	best.current -= total
+	return best
+}
+
+// setHealthy records a backend's health, logging changes
+func (p *BackendPool) setHealthy(b *poolBackend, healthy bool, err error) {
+	p.mu.Lock()
+	changed := b.healthy != healthy
+	b.healthy = healthy
+	p.mu.Unlock()
+
+	if !changed || len(p.backends) == 1 {
+		return
+	}
+	if healthy {
+		p.logger.Info("comfyui backend healthy again", "backend", b.client.baseURL)
+	} else {
+		p.logger.Warn("comfyui backend unhealthy", "backend", b.client.baseURL, "error", err)
+	}
+}
+
+// shouldFailover reports whether a generation error means the backend
+// itself is failing (connection errors, 5xx responses or an open circuit
+// breaker), so the generation should be retried elsewhere
+func shouldFailover(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return errors.Is(err, apperrors.ErrComfyUIUnavailable) || isTransient(err)
+}
+
+// generate runs fn on one backend after another until it succeeds, fails
+// for a reason other than the backend, or every backend has been tried.
+// Once a backend has queued the prompt it is never run elsewhere, so a
+// failure after that point, e.g. downloading the output, does not run the
+// generation twice.
+func (p *BackendPool) generate(ctx context.Context, opts GenerateOptions, fn func(c *Client, opts GenerateOptions) (*Output, error)) (*Output, error) {
+	tried := make(map[*poolBackend]bool, len(p.backends))
+	for {
+		b := p.next(tried)
+		if b == nil {
+			return nil, fmt.Errorf("all %d comfyui backends failed: %w", len(p.backends), apperrors.ErrComfyUIUnavailable)
+		}
+		tried[b] = true
+
+		var queued []string
+		attemptOpts := opts
+		attemptOpts.OnQueued = func(promptID string) {
+			queued = append(queued, promptID)
+			p.owners.Store(promptID, b)
+			if opts.OnQueued != nil {
+				opts.OnQueued(promptID)
+			}
+		}
+
+		out, err := fn(b.client, attemptOpts)
+		p.forgetOwners(ctx, queued)
+		if err == nil {
+			p.setHealthy(b, true, nil)
+			return out, nil
+		}
+		if !shouldFailover(ctx, err) {
+			return nil, err
+		}
+
+		p.setHealthy(b, false, err)
+		if len(queued) > 0 || len(tried) == len(p.backends) {
+			return nil, err
+		}
+		p.logger.Warn("comfyui backend failed, retrying on another backend",
+			"backend", b.client.baseURL, "error", err)
+	}
+}
+
+// forgetOwners drops the owners of finished prompts. If the generation was
+// cancelled they are kept for a while, since the caller may interrupt the
+// prompt next.
+func (p *BackendPool) forgetOwners(ctx context.Context, promptIDs []string) {
+	forget := func() {
+		for _, id := range promptIDs {
+			p.owners.Delete(id)
+		}
+	}
+	if ctx.Err() != nil && len(promptIDs) > 0 {
+		time.AfterFunc(cancelledOwnerRetention, forget)
+		return
+	}
+	forget()
+}
+
+// GenerateImage generates on the next backend, retrying on another one if
+// it fails with a connection error or 5xx response before the prompt is
+// queued
+func (p *BackendPool) GenerateImage(ctx context.Context, prompt string, opts GenerateOptions) (*Output, error) {
+	return p.generate(ctx, opts, func(c *Client, opts GenerateOptions) (*Output, error) {
+		return c.GenerateImage(ctx, prompt, opts)
+	})
+}
+
+// GenerateImageFromReference uploads imageData to the next backend and runs
+// an img2img generation there, retrying on another backend like
+// GenerateImage
+func (p *BackendPool) GenerateImageFromReference(ctx context.Context, prompt string, imageData []byte, opts GenerateOptions) (*Output, error) {
+	if !p.SupportsReference(opts.Workflow) {
+		return nil, apperrors.ErrReferenceUnsupported
+	}
+	return p.generate(ctx, opts, func(c *Client, opts GenerateOptions) (*Output, error) {
+		return c.GenerateImageFromReference(ctx, prompt, imageData, opts)
+	})
+}
+
+// CheckHealth checks every backend and records the results. It fails only
+// if no backend is healthy, with an error summarising each failure.
+func (p *BackendPool) CheckHealth(ctx context.Context) error {
+	errs := make([]error, len(p.backends))
+	var wg sync.WaitGroup
+	for i, b := range p.backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = b.client.CheckHealth(ctx)
+		}()
+	}
+	wg.Wait()
+
+	var failures []string
+	for i, b := range p.backends {
+		p.setHealthy(b, errs[i] == nil, errs[i])
+		if errs[i] != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", b.client.baseURL, errs[i]))
+		}
+	}
+
+	switch {
+	case len(failures) == 0:
+		return nil
+	case len(p.backends) == 1:
+		return errs[0]
+	case len(failures) < len(p.backends):
+		p.logger.Warn("some comfyui backends are unhealthy",
+			"unhealthy", len(failures), "total", len(p.backends), "errors", strings.Join(failures, "; "))
+		return nil
+	default:
+		return fmt.Errorf("all %d backends unhealthy: %s", len(p.backends), strings.Join(failures, "; "))
+	}
+}
+
+// Run re-checks every backend's health periodically so backends marked
+// unhealthy rejoin the pool once they recover. Blocks until ctx is cancelled.
+func (p *BackendPool) Run(ctx context.Context) {
+	ticker := time.NewTicker(backendHealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.CheckHealth(ctx)
+		}
+	}
+}
+
+// CircuitState returns the least restrictive circuit breaker state of any
+// backend, i.e. closed if any backend accepts requests normally
+func (p *BackendPool) CircuitState() CircuitState {
+	state := CircuitOpen
+	for _, b := range p.backends {
+		switch b.client.CircuitState() {
+		case CircuitClosed:
+			return CircuitClosed
+		case CircuitHalfOpen:
+			state = CircuitHalfOpen
+		}
+	}
+	return state
+}
+
+// GetQueue combines the queues of every reachable backend. It fails only if
+// no backend's queue could be retrieved.
+func (p *BackendPool) GetQueue(ctx context.Context) (*QueueResponse, error) {
+	combined := &QueueResponse{}
+	var errs []error
+	for _, b := range p.backends {
+		queue, err := b.client.GetQueue(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.client.baseURL, err))
+			continue
+		}
+		combined.QueueRunning = append(combined.QueueRunning, queue.QueueRunning...)
+		combined.QueuePending = append(combined.QueuePending, queue.QueuePending...)
+	}
+	if len(errs) == len(p.backends) {
+		return nil, errors.Join(errs...)
+	}
+	return combined, nil
+}
+
+// GetQueueStatus counts the prompts queued on every reachable backend
+func (p *BackendPool) GetQueueStatus(ctx context.Context) (*QueueStatus, error) {
+	queue, err := p.GetQueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &QueueStatus{Pending: len(queue.QueuePending), Running: len(queue.QueueRunning)}, nil
+}
+
+// Interrupt stops whatever the backends that queued promptIDs are currently
+// executing. Every backend is interrupted if no prompt IDs are given or the
+// owner of one is not known.
+func (p *BackendPool) Interrupt(ctx context.Context, promptIDs ...string) error {
+	targets := p.promptOwners(promptIDs)
+	if len(targets) == 0 {
+		targets = p.backends
+	}
+
+	var errs []error
+	for _, b := range targets {
+		if err := b.client.Interrupt(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.client.baseURL, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// promptOwners returns the backends that queued promptIDs, or nil if the
+// owner of any of them is not known
+func (p *BackendPool) promptOwners(promptIDs []string) []*poolBackend {
+	var owners []*poolBackend
+	for _, id := range promptIDs {
+		owner, ok := p.owners.Load(id)
+		if !ok {
+			return nil
+		}
+		if b := owner.(*poolBackend); !slices.Contains(owners, b) {
+			owners = append(owners, b)
+		}
+	}
+	return owners
+}
+
+// CancelPrompt stops promptID on the backend that queued it. If the owner
+// is not known, every backend is asked and the call succeeds if any of
+// them accepted the cancellation.
+func (p *BackendPool) CancelPrompt(ctx context.Context, promptID string) error {
+	if owner, ok := p.owners.Load(promptID); ok {
+		return owner.(*poolBackend).client.CancelPrompt(ctx, promptID)
+	}
+
+	var errs []error
+	for _, b := range p.backends {
+		if err := b.client.CancelPrompt(ctx, promptID); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.client.baseURL, err))
+		}
+	}
+	if len(errs) < len(p.backends) {
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateWorkflow checks the workflows against the nodes installed on
+// every backend
+func (p *BackendPool) ValidateWorkflow(ctx context.Context) error {
+	for _, b := range p.backends {
+		if err := b.client.ValidateWorkflow(ctx); err != nil {
+			if len(p.backends) == 1 {
+				return err
+			}
+			return fmt.Errorf("backend %s: %w", b.client.baseURL, err)
+		}
+	}
+	return nil
+}
+
+// GetObjectInfo fetches the node types installed on the primary backend
+func (p *BackendPool) GetObjectInfo(ctx context.Context) (map[string]NodeInfo, error) {
+	return p.primary().GetObjectInfo(ctx)
+}
+
+// ListCheckpoints returns the checkpoints installed on the primary backend;
+// every backend is expected to have the same models
+func (p *BackendPool) ListCheckpoints(ctx context.Context) ([]string, error) {
+	return p.primary().ListCheckpoints(ctx)
+}
+
+// UsesCheckpointLoader reports whether the named workflow (empty = default)
+// loads its model with a checkpoint loader
+func (p *BackendPool) UsesCheckpointLoader(workflow string) bool {
+	return p.primary().UsesCheckpointLoader(workflow)
+}
+
+// Checkpoint returns the checkpoint the named workflow (empty = default)
+// loads, or "" if it doesn't load a fixed checkpoint
+func (p *BackendPool) Checkpoint(workflow string) string {
+	return p.primary().Checkpoint(workflow)
+}
+
+// ReloadWorkflow re-reads every workflow template from disk
+func (p *BackendPool) ReloadWorkflow() error {
+	return p.primary().ReloadWorkflow()
+}
+
+// WatchWorkflows reloads workflow templates when their files change on
+// disk. Blocks until ctx is cancelled.
+func (p *BackendPool) WatchWorkflows(ctx context.Context) error {
+	return p.primary().WatchWorkflows(ctx)
+}
+
+// WorkflowNames returns the selectable workflow names in sorted order
+func (p *BackendPool) WorkflowNames() []string {
+	return p.primary().WorkflowNames()
+}
+
+// HasWorkflow reports whether a workflow with the given name is loaded
+func (p *BackendPool) HasWorkflow(name string) bool {
+	return p.primary().HasWorkflow(name)
+}
+
+// WorkflowBackups lists the default workflow template backups, newest first
+func (p *BackendPool) WorkflowBackups() ([]string, error) {
+	return p.primary().WorkflowBackups()
+}
+
+// RollbackWorkflow restores the named backup of the default workflow and
+// reloads it
+func (p *BackendPool) RollbackWorkflow(name string) error {
+	return p.primary().RollbackWorkflow(name)
+}
+
+// SupportsDimensions reports whether the named workflow (empty = default)
+// takes its output size from an aspect ratio
+func (p *BackendPool) SupportsDimensions(workflow string) bool {
+	return p.primary().SupportsDimensions(workflow)
+}
+
+// SupportsReference reports whether the named workflow (empty = default)
+// accepts a reference image
+func (p *BackendPool) SupportsReference(workflow string) bool {
+	return p.primary().SupportsReference(workflow)
+}
@@ -0,0 +1,169 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testPool(clients ...*Client) *BackendPool {
+	p := &BackendPool{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	for _, c := range clients {
+		p.backends = append(p.backends, &poolBackend{client: c, weight: 1, healthy: true})
+	}
+	return p
+}
+
+func TestPoolGenerateFailsOverBeforeQueueing(t *testing.T) {
+	p := testPool(testClient("http://a", time.Second), testClient("http://b", time.Second))
+
+	var attempts []string
+	out, err := p.generate(context.Background(), GenerateOptions{}, func(c *Client, opts GenerateOptions) (*Output, error) {
+		attempts = append(attempts, c.baseURL)
+		if len(attempts) == 1 {
+			return nil, &statusError{code: http.StatusBadGateway}
+		}
+		opts.OnQueued("p1")
+		return &Output{Filename: "ok.png"}, nil
+	})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if out.Filename != "ok.png" || len(attempts) != 2 {
+		t.Errorf("got %v after %d attempts, want ok.png after 2", out, len(attempts))
+	}
+}
+
+func TestPoolGenerateDoesNotRerunQueuedPrompt(t *testing.T) {
+	p := testPool(testClient("http://a", time.Second), testClient("http://b", time.Second))
+
+	var queuedIDs []string
+	attempts := 0
+	_, err := p.generate(context.Background(), GenerateOptions{
+		OnQueued: func(id string) { queuedIDs = append(queuedIDs, id) },
+	}, func(c *Client, opts GenerateOptions) (*Output, error) {
+		attempts++
+		opts.OnQueued(fmt.Sprintf("p%d", attempts))
+		// The prompt ran, but downloading its output failed
+		return nil, fmt.Errorf("get image: %w", &statusError{code: http.StatusInternalServerError})
+	})
+	if err == nil {
+		t.Fatal("expected the download error")
+	}
+	if attempts != 1 {
+		t.Errorf("generation ran %d times, want 1", attempts)
+	}
+	if len(queuedIDs) != 1 {
+		t.Errorf("caller's OnQueued saw %v, want one prompt", queuedIDs)
+	}
+}
+
+func TestPoolCancelPromptUsesOwner(t *testing.T) {
+	owner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"queue_running": [], "queue_pending": []}`)
+	}))
+	defer owner.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	p := testPool(testClient(owner.URL, time.Second), testClient(down.URL, time.Second))
+	// Make the owner the backend the round-robin picks first
+	p.backends[1].healthy = false
+
+	var cancelErr error
+	_, err := p.generate(context.Background(), GenerateOptions{}, func(c *Client, opts GenerateOptions) (*Output, error) {
+		opts.OnQueued("p1")
+		cancelErr = p.CancelPrompt(context.Background(), "p1")
+		return &Output{}, nil
+	})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if cancelErr != nil {
+		t.Errorf("CancelPrompt with an unrelated backend down = %v, want nil", cancelErr)
+	}
+	if _, ok := p.owners.Load("p1"); ok {
+		t.Error("owner still recorded after the generation finished")
+	}
+}
+
+func TestPoolCancelPromptUnknownOwner(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"queue_running": [], "queue_pending": []}`)
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	if err := testPool(testClient(up.URL, time.Second), testClient(down.URL, time.Second)).
+		CancelPrompt(context.Background(), "p1"); err != nil {
+		t.Errorf("CancelPrompt = %v, want nil when one backend answered", err)
+	}
+
+	err := testPool(testClient(down.URL, time.Second)).CancelPrompt(context.Background(), "p1")
+	var statusErr *statusError
+	if err == nil || errors.As(err, &statusErr) {
+		t.Errorf("CancelPrompt = %v, want a connection error", err)
+	}
+}
+
+func TestPoolInterruptUsesOwner(t *testing.T) {
+	var interrupted []string
+	var mu sync.Mutex
+	newBackend := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/interrupt" {
+				mu.Lock()
+				interrupted = append(interrupted, name)
+				mu.Unlock()
+			}
+		}))
+	}
+	a, b := newBackend("a"), newBackend("b")
+	defer a.Close()
+	defer b.Close()
+
+	p := testPool(testClient(a.URL, time.Second), testClient(b.URL, time.Second))
+	// Make a the backend the round-robin picks first
+	p.backends[1].healthy = false
+
+	// A cancelled generation keeps its owner for the interrupt that follows
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := p.generate(ctx, GenerateOptions{}, func(c *Client, opts GenerateOptions) (*Output, error) {
+		opts.OnQueued("p1")
+		cancel()
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("generate = %v, want context.Canceled", err)
+	}
+
+	tests := []struct {
+		name      string
+		promptIDs []string
+		want      []string
+	}{
+		{"known owner", []string{"p1"}, []string{"a"}},
+		{"unknown owner", []string{"p1", "p2"}, []string{"a", "b"}},
+		{"no prompt IDs", nil, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interrupted = nil
+			if err := p.Interrupt(context.Background(), tt.promptIDs...); err != nil {
+				t.Fatalf("Interrupt: %v", err)
+			}
+			if !slices.Equal(interrupted, tt.want) {
+				t.Errorf("interrupted %v, want %v", interrupted, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"comfy-tg-bot/internal/config"
+)
+
+const (
+	// maxRecoveriesPerHour limits restart attempts to avoid restart loops
+	maxRecoveriesPerHour = 3
+	// recoveryHealthWait is how long to wait for ComfyUI to come back after a restart
+	recoveryHealthWait = 60 * time.Second
+)
+
+// HealthChecker reports whether ComfyUI is reachable, e.g. a Client or
+// BackendPool
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// Notifier delivers recovery status messages (e.g. to the Telegram admin)
+type Notifier func(text string)
+
+// Recoverer restarts ComfyUI over SSH after repeated health check failures
+type Recoverer struct {
+	cfg    config.AutoRecoveryConfig
+	client HealthChecker
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	notify   Notifier
+	attempts []time.Time
+}
+
+// NewRecoverer creates a new recoverer for the given client
+func NewRecoverer(cfg config.AutoRecoveryConfig, client HealthChecker, logger *slog.Logger) *Recoverer {
+	return &Recoverer{
+		cfg:    cfg,
+		client: client,
+		logger: logger,
+	}
+}
+
+// SetNotifier sets the function used to report recovery attempts
+func (r *Recoverer) SetNotifier(n Notifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notify = n
+}
+
+// Run checks ComfyUI health periodically and attempts recovery after
+// FailureThreshold consecutive failures. Blocks until ctx is cancelled.
+func (r *Recoverer) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.client.CheckHealth(ctx); err != nil {
+			failures++
+			r.logger.Warn("comfyui health check failed", "error", err, "consecutive_failures", failures)
+		} else {
+			failures = 0
+			continue
+		}
+
+		if failures < r.cfg.FailureThreshold {
+			continue
+		}
+
+		if !r.allowAttempt() {
+			r.logger.Warn("comfyui recovery rate limit reached, skipping restart")
+			continue
+		}
+
+		r.sendNotification(fmt.Sprintf(
+			"ComfyUI failed %d consecutive health checks. Attempting restart via SSH on %s...",
+			failures, r.cfg.SSHHost))
+
+		if err := r.recover(ctx); err != nil {
+			r.logger.Error("comfyui recovery failed", "error", err)
+			r.sendNotification(fmt.Sprintf("ComfyUI recovery failed: %v", err))
+		} else {
+			r.logger.Info("comfyui recovered")
+			r.sendNotification("ComfyUI recovery succeeded, service is healthy again.")
+		}
+		failures = 0
+	}
+}
+
+// allowAttempt records an attempt if fewer than maxRecoveriesPerHour
+// were made in the last hour
+func (r *Recoverer) allowAttempt() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := time.Now().Add(-time.Hour)
+	recent := r.attempts[:0]
+	for _, t := range r.attempts {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	r.attempts = recent
+
+	if len(r.attempts) >= maxRecoveriesPerHour {
+		return false
+	}
+	r.attempts = append(r.attempts, time.Now())
+	return true
+}
+
+// recover runs the restart command and waits for health to return
+func (r *Recoverer) recover(ctx context.Context) error {
+	if err := r.runRestartCommand(ctx); err != nil {
+		return err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, recoveryHealthWait)
+	defer cancel()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-waitCtx.Done():
+			return fmt.Errorf("comfyui not healthy %s after restart", recoveryHealthWait)
+		case <-ticker.C:
+			if err := r.client.CheckHealth(waitCtx); err == nil {
+				return nil
+			}
+		}
+	}
+}
+
+// runRestartCommand dials SSH and executes the configured restart command
+func (r *Recoverer) runRestartCommand(ctx context.Context) error {
+	// Never restart over an unverified connection
+	if r.cfg.KnownHostsPath == "" {
+		return fmt.Errorf("known_hosts_path is not set, refusing to connect without host key verification")
+	}
+
+	key, err := os.ReadFile(r.cfg.SSHKeyPath)
+	if err != nil {
+		return fmt.Errorf("read ssh key: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("parse ssh key: %w", err)
+	}
+
+	hostKeyCallback, err := knownhosts.New(r.cfg.KnownHostsPath)
+	if err != nil {
+		return fmt.Errorf("load known hosts: %w", err)
+	}
+
+	addr := r.cfg.SSHHost
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	sshCfg := &ssh.ClientConfig{
+		User:            r.cfg.SSHUser,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         10 * time.Second,
+	}
+
+	dialer := net.Dialer{Timeout: sshCfg.Timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("dial ssh: %w", err)
+	}
+
+	conn, chans, reqs, err := ssh.NewClientConn(netConn, addr, sshCfg)
+	if err != nil {
+		netConn.Close()
+		return fmt.Errorf("ssh handshake: %w", err)
+	}
+	client := ssh.NewClient(conn, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("open ssh session: %w", err)
+	}
+	defer session.Close()
+
+	r.logger.Info("running comfyui restart command", "host", addr)
+
+	// CombinedOutput does not take a context, so close the session to
+	// abort the command on shutdown
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			session.Close()
+		case <-done:
+		}
+	}()
+
+	output, err := session.CombinedOutput(r.cfg.RestartCommand)
+	if ctx.Err() != nil {
+		return fmt.Errorf("restart command aborted: %w", ctx.Err())
+	}
+	if err != nil {
+		return fmt.Errorf("restart command failed: %w: %s", err, string(output))
+	}
+
+	return nil
+}
+
+func (r *Recoverer) sendNotification(text string) {
+	r.mu.Lock()
+	notify := r.notify
+	r.mu.Unlock()
+
+	if notify != nil {
+		notify(text)
+	}
+}
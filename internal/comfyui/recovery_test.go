@@ -0,0 +1,26 @@
+package comfyui
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"comfy-tg-bot/internal/config"
+)
+
+func TestRunRestartCommandRequiresKnownHosts(t *testing.T) {
+	r := NewRecoverer(config.AutoRecoveryConfig{
+		Enabled:        true,
+		SSHHost:        "127.0.0.1:1",
+		SSHUser:        "comfy",
+		SSHKeyPath:     "/nonexistent/id_ed25519",
+		RestartCommand: "true",
+	}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	err := r.runRestartCommand(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "known_hosts_path") {
+		t.Fatalf("runRestartCommand() error = %v, want a known_hosts_path error", err)
+	}
+}
@@ -0,0 +1,103 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"time"
+)
+
+// maxRetryDelay caps the backoff between attempts
+const maxRetryDelay = 30 * time.Second
+
+// RetryPolicy controls how requests are retried on transient failures
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	// (1 or less = no retries)
+	MaxAttempts int
+	// InitialDelay is the wait before the first retry; it doubles after
+	// each further failure
+	InitialDelay time.Duration
+}
+
+// statusError reports an unexpected HTTP status from ComfyUI
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("server returned %d", e.code)
+	}
+	return fmt.Sprintf("server returned %d: %s", e.code, e.body)
+}
+
+// isTransient reports whether err is worth retrying: a connection error,
+// an HTTP client timeout or a 5xx response
+func isTransient(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= 500
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// isResendable reports whether a request that failed with err can be sent
+// again without risking doing its work twice: the connection was never
+// established, or ComfyUI answered with a 5xx error. A timeout or broken
+// connection after the request was written is not resendable, since the
+// server may already have acted on it.
+func isResendable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= 500
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retry calls attempt until it succeeds, fails with a non-transient error
+// or runs out of attempts, backing off exponentially in between. It stops
+// as soon as ctx is done.
+func retry(ctx context.Context, policy RetryPolicy, logger *slog.Logger, op string, attempt func() error) error {
+	return retryIf(ctx, policy, logger, op, isTransient, attempt)
+}
+
+// retryIf is retry with retryable deciding which errors are retried
+func retryIf(ctx context.Context, policy RetryPolicy, logger *slog.Logger, op string, retryable func(error) bool, attempt func() error) error {
+	delay := policy.InitialDelay
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || n >= policy.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		logger.Debug("comfyui request failed, retrying",
+			"op", op, "attempt", n, "max_attempts", policy.MaxAttempts, "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
@@ -0,0 +1,94 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testClient(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: timeout},
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		retry:      RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond},
+		breaker:    NewCircuitBreaker(5, time.Minute),
+	}
+}
+
+func TestQueuePromptRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"prompt_id": "abc"}`)
+	}))
+	defer srv.Close()
+
+	id, err := testClient(srv.URL, time.Second).QueuePrompt(context.Background(), map[string]any{}, "client")
+	if err != nil {
+		t.Fatalf("QueuePrompt: %v", err)
+	}
+	if id != "abc" || calls.Load() != 2 {
+		t.Errorf("got prompt %q after %d calls, want abc after 2", id, calls.Load())
+	}
+}
+
+func TestQueuePromptDoesNotResendAfterTimeout(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// The prompt reached the server but the answer is too slow
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	_, err := testClient(srv.URL, 50*time.Millisecond).QueuePrompt(context.Background(), map[string]any{}, "client")
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("prompt sent %d times, want 1", n)
+	}
+}
+
+func TestIsResendable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	_, dialErr := http.Get("http://" + addr)
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"dial error", dialErr, true},
+		{"server error", &statusError{code: 502}, true},
+		{"client error", &statusError{code: 400}, false},
+		{"timeout", fmt.Errorf("send request: %w", context.DeadlineExceeded), false},
+		{"other", errors.New("unexpected EOF"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isResendable(tt.err); got != tt.want {
+				t.Errorf("isResendable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package comfyui
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"comfy-tg-bot/internal/config"
+)
+
+// newTLSConfig builds the TLS settings for https:// and wss:// ComfyUI
+// URLs, or returns nil to use Go's defaults
+func newTLSConfig(cfg config.ComfyUIConfig, logger *slog.Logger) (*tls.Config, error) {
+	if cfg.TLSCACertPath == "" && !cfg.TLSSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if cfg.TLSCACertPath != "" {
+		pem, err := os.ReadFile(cfg.TLSCACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("read comfyui ca cert: %w", err)
+		}
+		// Trust the given CAs in addition to the system ones
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("comfyui ca cert %s contains no PEM certificates", cfg.TLSCACertPath)
+		}
+		tlsConfig.RootCAs = roots
+	}
+	if cfg.TLSSkipVerify {
+		logger.Warn("TLS certificate verification is disabled for ComfyUI connections")
+		tlsConfig.InsecureSkipVerify = true
+	}
+	return tlsConfig, nil
+}
+
+// newHTTPTransport returns a transport using tlsConfig, or nil for
+// http.DefaultTransport
+func newHTTPTransport(tlsConfig *tls.Config) http.RoundTripper {
+	if tlsConfig == nil {
+		return nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport
+}
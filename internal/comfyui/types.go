@@ -37,6 +37,14 @@ type HistoryEntry struct {
 // NodeOutput contains output data from a node
 type NodeOutput struct {
 	Images []ImageOutput `json:"images,omitempty"`
+	Videos []VideoOutput `json:"videos,omitempty"`
+	// Gifs is used by VideoHelperSuite's Video Combine node for all formats
+	Gifs []VideoOutput `json:"gifs,omitempty"`
+}
+
+// VideoOutputs returns the node's video outputs regardless of the key used
+func (o NodeOutput) VideoOutputs() []VideoOutput {
+	return append(append([]VideoOutput{}, o.Videos...), o.Gifs...)
 }
 
 // ImageOutput describes an output image
@@ -46,6 +54,38 @@ type ImageOutput struct {
 	Type      string `json:"type"`
 }
 
+// Directories an image can be uploaded to with UploadImage
+const (
+	UploadTypeInput  = "input"
+	UploadTypeOutput = "output"
+	UploadTypeTemp   = "temp"
+)
+
+// UploadResponse is returned by /upload/image
+type UploadResponse struct {
+	Name      string `json:"name"`
+	Subfolder string `json:"subfolder"`
+	Type      string `json:"type"`
+}
+
+// LoadName returns the name LoadImage nodes use for an uploaded input image
+func (u *UploadResponse) LoadName() string {
+	// LoadImage expects subfolder/name for images outside the input root
+	if u.Subfolder != "" {
+		return u.Subfolder + "/" + u.Name
+	}
+	return u.Name
+}
+
+// VideoOutput describes an output video or animation
+type VideoOutput struct {
+	Filename  string `json:"filename"`
+	Subfolder string `json:"subfolder"`
+	Type      string `json:"type"`
+	// Format is a MIME-like type such as "video/h264-mp4" or "image/gif"
+	Format string `json:"format"`
+}
+
 // ExecutionStatus indicates the status of an execution
 type ExecutionStatus struct {
 	StatusStr string `json:"status_str"`
@@ -71,6 +111,59 @@ type ProgressData struct {
 	PromptID string `json:"prompt_id"`
 }
 
+// NodeInfo describes a node type installed on the server, as returned by
+// GET /object_info keyed by class type
+type NodeInfo struct {
+	Name        string     `json:"name"`
+	DisplayName string     `json:"display_name"`
+	Category    string     `json:"category"`
+	Input       NodeInputs `json:"input"`
+	Output      []string   `json:"output"`
+}
+
+// NodeInputs holds a node's input specs by input name. Each spec is a JSON
+// array whose first element is the input type, or the list of allowed
+// values for a combo input.
+type NodeInputs struct {
+	Required map[string]json.RawMessage `json:"required"`
+	Optional map[string]json.RawMessage `json:"optional"`
+}
+
+// QueueStatus counts the prompts in the ComfyUI queue
+type QueueStatus struct {
+	Pending int
+	Running int
+}
+
+// QueueResponse is returned from GET /queue
+type QueueResponse struct {
+	QueueRunning []json.RawMessage `json:"queue_running"`
+	QueuePending []json.RawMessage `json:"queue_pending"`
+}
+
+// Contains reports whether promptID is currently running or pending.
+// Queue items are arrays of [number, prompt_id, prompt, extra_data, outputs].
+func (q *QueueResponse) Contains(promptID string) (running, pending bool) {
+	return queueHas(q.QueueRunning, promptID), queueHas(q.QueuePending, promptID)
+}
+
+func queueHas(items []json.RawMessage, promptID string) bool {
+	for _, raw := range items {
+		var item []json.RawMessage
+		if err := json.Unmarshal(raw, &item); err != nil || len(item) < 2 {
+			continue
+		}
+		var id string
+		if err := json.Unmarshal(item[1], &id); err != nil {
+			continue
+		}
+		if id == promptID {
+			return true
+		}
+	}
+	return false
+}
+
 // SystemStats is returned from GET /system_stats
 type SystemStats struct {
 	System struct {
@@ -0,0 +1,131 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Unknown node policies for workflow validation
+const (
+	UnknownNodeWarn   = "warn"
+	UnknownNodeError  = "error"
+	UnknownNodeIgnore = "ignore"
+)
+
+// ObjectInfoCache holds the node class types installed on the ComfyUI server
+type ObjectInfoCache struct {
+	mu    sync.RWMutex
+	types map[string]struct{}
+}
+
+// Refresh fetches the installed node types from GET /object_info
+func (c *ObjectInfoCache) Refresh(ctx context.Context, client *Client) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", client.baseURL+"/object_info", nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %d", resp.StatusCode)
+	}
+
+	// Only the keys (class types) are needed
+	var info map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	types := make(map[string]struct{}, len(info))
+	for name := range info {
+		types[name] = struct{}{}
+	}
+
+	c.mu.Lock()
+	c.types = types
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Loaded reports whether the cache has been populated
+func (c *ObjectInfoCache) Loaded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.types != nil
+}
+
+// Has reports whether a node class type is installed
+func (c *ObjectInfoCache) Has(classType string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.types[classType]
+	return ok
+}
+
+// UnknownNodes returns the class types in workflow that are not installed,
+// sorted and de-duplicated
+func (c *ObjectInfoCache) UnknownNodes(workflow map[string]any) []string {
+	seen := make(map[string]struct{})
+	for _, node := range workflow {
+		nodeMap, ok := node.(map[string]any)
+		if !ok {
+			continue
+		}
+		classType, _ := nodeMap["class_type"].(string)
+		if classType == "" || c.Has(classType) {
+			continue
+		}
+		seen[classType] = struct{}{}
+	}
+
+	unknown := make([]string, 0, len(seen))
+	for name := range seen {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// ValidateWorkflow checks every workflow template's node types against the
+// nodes installed on the server, applying the configured unknown node policy.
+// If the server cannot be reached the check is skipped with a warning.
+func (c *Client) ValidateWorkflow(ctx context.Context) error {
+	if c.unknownNodePolicy == UnknownNodeIgnore {
+		return nil
+	}
+
+	if err := c.objectInfo.Refresh(ctx, c); err != nil {
+		c.logger.Warn("could not fetch object_info, skipping workflow validation", "error", err)
+		return nil
+	}
+
+	for _, name := range c.WorkflowNames() {
+		workflow, err := c.workflows[name].PrepareWorkflow("validation")
+		if err != nil {
+			return fmt.Errorf("prepare workflow %q: %w", name, err)
+		}
+
+		unknown := c.objectInfo.UnknownNodes(workflow)
+		if len(unknown) == 0 {
+			continue
+		}
+
+		if c.unknownNodePolicy == UnknownNodeError {
+			return fmt.Errorf("workflow %q uses node types not installed on comfyui: %v", name, unknown)
+		}
+
+		c.logger.Warn("workflow uses node types not reported by comfyui; they may be custom nodes that are not loaded",
+			"workflow", name, "node_types", unknown)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// workflowReloadDelay lets editors finish writing a workflow file before it
+// is reloaded; further changes within the delay restart it
+const workflowReloadDelay = 500 * time.Millisecond
+
+// WatchWorkflows reloads workflow templates when their files change on disk.
+// A changed file that fails validation is logged and the previously loaded
+// template stays in use. Blocks until ctx is cancelled.
+func (c *Client) WatchWorkflows(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create workflow watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Watch the directories rather than the files themselves so editors
+	// that save by replacing the file are still noticed
+	names := make(map[string]string, len(c.workflows))
+	dirs := make(map[string]bool)
+	for name, wm := range c.workflows {
+		path := filepath.Clean(wm.templatePath)
+		names[path] = name
+		dirs[filepath.Dir(path)] = true
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("watch workflow directory %s: %w", dir, err)
+		}
+	}
+
+	timer := time.NewTimer(workflowReloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+
+	changed := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			name, watched := names[filepath.Clean(event.Name)]
+			if !watched || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			changed[name] = true
+			timer.Reset(workflowReloadDelay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			c.logger.Warn("workflow watcher error", "error", err)
+		case <-timer.C:
+			for name := range changed {
+				c.reloadChangedWorkflow(name)
+			}
+			clear(changed)
+		}
+	}
+}
+
+// reloadChangedWorkflow reloads one workflow after its file changed
+func (c *Client) reloadChangedWorkflow(name string) {
+	backupPath, err := c.workflows[name].Reload()
+	if err != nil {
+		c.logger.Warn("changed workflow file is invalid, keeping previous template",
+			"workflow", name, "error", err)
+		return
+	}
+	if backupPath != "" {
+		c.logger.Info("backed up previous workflow", "workflow", name, "path", backupPath)
+	}
+	c.logger.Info("workflow reloaded after file change", "workflow", name)
+}
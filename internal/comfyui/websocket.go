@@ -2,6 +2,7 @@ package comfyui
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -19,6 +20,15 @@ type ExecutionMonitor struct {
 	wsURL    string
 	logger   *slog.Logger
 	clientID string
+
+	// When set, completion is detected by polling instead of WebSocket
+	pollClient   *Client
+	pollInterval time.Duration
+
+	// tlsConfig is used for wss:// URLs (nil = defaults)
+	tlsConfig *tls.Config
+	// apiKey authenticates the handshake as a bearer token (empty = none)
+	apiKey string
 }
 
 // NewExecutionMonitor creates a new execution monitor with a unique client ID
@@ -35,16 +45,37 @@ func (m *ExecutionMonitor) GetClientID() string {
 	return m.clientID
 }
 
+// EnablePolling switches the monitor to HTTP polling of /queue and /history
+func (m *ExecutionMonitor) EnablePolling(client *Client, interval time.Duration) {
+	m.pollClient = client
+	m.pollInterval = interval
+}
+
+// SetTLSConfig sets the TLS settings used to dial wss:// URLs
+func (m *ExecutionMonitor) SetTLSConfig(tlsConfig *tls.Config) {
+	m.tlsConfig = tlsConfig
+}
+
+// SetAPIKey sets the bearer token sent with the WebSocket handshake
+func (m *ExecutionMonitor) SetAPIKey(apiKey string) {
+	m.apiKey = apiKey
+}
+
 // WaitForCompletion waits for a specific prompt to complete
 // Returns nil on success, error on failure or context cancellation
 func (m *ExecutionMonitor) WaitForCompletion(ctx context.Context, promptID string, progressCb ProgressCallback) error {
+	if m.pollClient != nil {
+		return m.pollForCompletion(ctx, promptID)
+	}
+
 	url := fmt.Sprintf("%s?clientId=%s", m.wsURL, m.clientID)
 
 	dialer := websocket.Dialer{
 		HandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:  m.tlsConfig,
 	}
 
-	conn, _, err := dialer.DialContext(ctx, url, nil)
+	conn, _, err := dialer.DialContext(ctx, url, authHeader(m.apiKey))
 	if err != nil {
 		return fmt.Errorf("websocket dial: %w", err)
 	}
@@ -139,3 +170,52 @@ func (m *ExecutionMonitor) WaitForCompletion(ctx context.Context, promptID strin
 		}
 	}
 }
+
+// pollForCompletion polls the queue until promptID leaves it, then confirms
+// completion via the history endpoint. Progress callbacks are not available.
+func (m *ExecutionMonitor) pollForCompletion(ctx context.Context, promptID string) error {
+	m.logger.Info("polling for completion", "prompt_id", promptID, "interval", m.pollInterval)
+
+	ticker := time.NewTicker(m.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		queue, err := m.pollClient.GetQueue(ctx)
+		if err != nil {
+			m.logger.Debug("queue poll failed", "error", err, "prompt_id", promptID)
+			continue
+		}
+
+		running, pending := queue.Contains(promptID)
+		if running || pending {
+			m.logger.Debug("prompt still queued", "prompt_id", promptID, "running", running)
+			continue
+		}
+
+		// No longer queued, confirm with history
+		history, err := m.pollClient.GetHistory(ctx, promptID)
+		if err != nil {
+			m.logger.Debug("history poll failed", "error", err, "prompt_id", promptID)
+			continue
+		}
+
+		entry, ok := history[promptID]
+		if !ok {
+			// History may lag slightly behind the queue
+			continue
+		}
+
+		if entry.Status.StatusStr == "error" {
+			return fmt.Errorf("comfyui execution error: prompt %s failed", promptID)
+		}
+
+		m.logger.Debug("execution complete", "prompt_id", promptID)
+		return nil
+	}
+}
@@ -1,42 +1,103 @@
 package comfyui
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"math/rand/v2"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 const PromptPlaceholder = "{{PROMPT}}"
 
+// NegativePromptPlaceholder is replaced with the user's negative prompt.
+// Workflows without it ignore negative prompts.
+const NegativePromptPlaceholder = "{{NEGATIVE_PROMPT}}"
+
+// ReferenceImagePlaceholder is replaced with the name of an uploaded
+// reference image. Workflows without it do not support img2img.
+const ReferenceImagePlaceholder = "{{REFERENCE_IMAGE}}"
+
+// SeedPlaceholder is replaced with the user's fixed seed or a random one.
+// Written as a JSON string ("{{SEED}}") it becomes a number.
+const SeedPlaceholder = "{{SEED}}"
+
+// WidthPlaceholder and HeightPlaceholder are replaced with the output size
+// of the chosen aspect ratio. Written as JSON strings ("{{WIDTH}}") they
+// become numbers.
+const (
+	WidthPlaceholder  = "{{WIDTH}}"
+	HeightPlaceholder = "{{HEIGHT}}"
+)
+
+// maxWorkflowBackups is how many template backups are kept on disk
+const maxWorkflowBackups = 5
+
+// backupTimeFormat sorts lexicographically in chronological order
+const backupTimeFormat = "20060102-150405"
+
 // WorkflowManager handles loading and modifying workflow templates
 type WorkflowManager struct {
-	templatePath string
-	template     []byte
-	mu           sync.RWMutex
+	templatePath   string
+	template       []byte
+	backupOnReload bool
+	mu             sync.RWMutex
 }
 
-// NewWorkflowManager creates a new workflow manager and loads the template
-func NewWorkflowManager(templatePath string) (*WorkflowManager, error) {
+// NewWorkflowManager creates a new workflow manager and loads the template.
+// If backupOnReload is set, later loads save the previous template to
+// <templatePath>.bak.<timestamp> before replacing it.
+func NewWorkflowManager(templatePath string, backupOnReload bool) (*WorkflowManager, error) {
 	wm := &WorkflowManager{
-		templatePath: templatePath,
+		templatePath:   templatePath,
+		backupOnReload: backupOnReload,
 	}
 
-	if err := wm.Load(); err != nil {
+	if _, err := wm.Load(); err != nil {
 		return nil, err
 	}
 
 	return wm, nil
 }
 
-// Load reads and validates the workflow template
-func (wm *WorkflowManager) Load() error {
+// Load reads and validates the workflow template. If a different template
+// was already loaded and backups are enabled, it is backed up first and the
+// backup path is returned.
+func (wm *WorkflowManager) Load() (string, error) {
 	data, err := os.ReadFile(wm.templatePath)
 	if err != nil {
-		return fmt.Errorf("read workflow file: %w", err)
+		return "", fmt.Errorf("read workflow file: %w", err)
+	}
+
+	if err := validateTemplate(data); err != nil {
+		return "", err
 	}
 
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	var backupPath string
+	if wm.backupOnReload && wm.template != nil && !bytes.Equal(wm.template, data) {
+		backupPath, err = wm.backup(wm.template)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	wm.template = data
+
+	return backupPath, nil
+}
+
+// validateTemplate checks that data is JSON containing the prompt placeholder
+func validateTemplate(data []byte) error {
 	// Validate it's valid JSON
 	var parsed map[string]any
 	if err := json.Unmarshal(data, &parsed); err != nil {
@@ -48,25 +109,171 @@ func (wm *WorkflowManager) Load() error {
 		return fmt.Errorf("workflow must contain %s placeholder", PromptPlaceholder)
 	}
 
-	wm.mu.Lock()
-	wm.template = data
-	wm.mu.Unlock()
-
 	return nil
 }
 
+// backup writes data to a timestamped backup file and prunes old backups
+func (wm *WorkflowManager) backup(data []byte) (string, error) {
+	path := fmt.Sprintf("%s.bak.%s", wm.templatePath, time.Now().Format(backupTimeFormat))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write workflow backup: %w", err)
+	}
+
+	backups, err := wm.Backups()
+	if err != nil {
+		return path, err
+	}
+	for _, old := range backups[min(len(backups), maxWorkflowBackups):] {
+		if err := os.Remove(old); err != nil {
+			return path, fmt.Errorf("remove old workflow backup: %w", err)
+		}
+	}
+
+	return path, nil
+}
+
+// Backups returns the paths of existing template backups, newest first
+func (wm *WorkflowManager) Backups() ([]string, error) {
+	matches, err := filepath.Glob(wm.templatePath + ".bak.*")
+	if err != nil {
+		return nil, fmt.Errorf("list workflow backups: %w", err)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+	return matches, nil
+}
+
+// Restore copies the named backup over the template file and reloads it.
+// name must be the base name of one of the files returned by Backups.
+func (wm *WorkflowManager) Restore(name string) (string, error) {
+	backups, err := wm.Backups()
+	if err != nil {
+		return "", err
+	}
+
+	var path string
+	for _, b := range backups {
+		if filepath.Base(b) == name {
+			path = b
+			break
+		}
+	}
+	if path == "" {
+		return "", fmt.Errorf("workflow backup %q not found", name)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read workflow backup: %w", err)
+	}
+	if err := validateTemplate(data); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(wm.templatePath, data, 0o644); err != nil {
+		return "", fmt.Errorf("write workflow file: %w", err)
+	}
+
+	return wm.Load()
+}
+
 // PrepareWorkflow creates a workflow with the user's prompt
 func (wm *WorkflowManager) PrepareWorkflow(userPrompt string) (map[string]any, error) {
+	return wm.PrepareWorkflowFull(userPrompt, "", nil)
+}
+
+// SupportsDimensions reports whether the template has width or height
+// placeholders
+func (wm *WorkflowManager) SupportsDimensions() bool {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+	return bytes.Contains(wm.template, []byte(WidthPlaceholder)) ||
+		bytes.Contains(wm.template, []byte(HeightPlaceholder))
+}
+
+// SupportsReference reports whether the template accepts a reference image
+func (wm *WorkflowManager) SupportsReference() bool {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+	return bytes.Contains(wm.template, []byte(ReferenceImagePlaceholder))
+}
+
+// UsesNode reports whether the template contains a node of classType
+func (wm *WorkflowManager) UsesNode(classType string) bool {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+	return bytes.Contains(wm.template, []byte(`"class_type": "`+classType+`"`)) ||
+		bytes.Contains(wm.template, []byte(`"class_type":"`+classType+`"`))
+}
+
+// Checkpoint returns the ckpt_name of the template's first checkpoint
+// loader node, or "" if it has none or the name is a placeholder
+func (wm *WorkflowManager) Checkpoint() string {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+
+	var nodes map[string]struct {
+		ClassType string         `json:"class_type"`
+		Inputs    map[string]any `json:"inputs"`
+	}
+	if err := json.Unmarshal(wm.template, &nodes); err != nil {
+		return ""
+	}
+
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		node := nodes[id]
+		if node.ClassType != checkpointLoaderClass {
+			continue
+		}
+		if name, ok := node.Inputs["ckpt_name"].(string); ok && !strings.Contains(name, "{{") {
+			return name
+		}
+	}
+	return ""
+}
+
+// PrepareWorkflowFull creates a workflow with the user's positive and
+// negative prompts and extra substitutions keyed by placeholder, such as
+// ReferenceImagePlaceholder or WidthPlaceholder. A placeholder that is a
+// whole JSON string is replaced by a bare number if its value is numeric.
+// SeedPlaceholder gets a random seed unless extra sets it.
+func (wm *WorkflowManager) PrepareWorkflowFull(positive, negative string, extra map[string]string) (map[string]any, error) {
 	wm.mu.RLock()
 	templateCopy := make([]byte, len(wm.template))
 	copy(templateCopy, wm.template)
 	wm.mu.RUnlock()
 
-	// Sanitize the prompt for JSON embedding
-	sanitized := sanitizeForJSON(userPrompt)
+	if _, ok := extra[SeedPlaceholder]; !ok {
+		extra = maps.Clone(extra)
+		if extra == nil {
+			extra = make(map[string]string, 1)
+		}
+		extra[SeedPlaceholder] = strconv.FormatInt(rand.Int64N(maxSeed), 10)
+	}
 
-	// Replace placeholder
-	modified := strings.ReplaceAll(string(templateCopy), PromptPlaceholder, sanitized)
+	// Replace placeholders in one pass so prompt text is never re-expanded,
+	// sanitizing the values for JSON embedding
+	pairs := []string{
+		PromptPlaceholder, sanitizeForJSON(positive),
+		NegativePromptPlaceholder, sanitizeForJSON(negative),
+	}
+	placeholders := make([]string, 0, len(extra))
+	for placeholder := range extra {
+		placeholders = append(placeholders, placeholder)
+	}
+	sort.Strings(placeholders)
+	for _, placeholder := range placeholders {
+		value := extra[placeholder]
+		// The replacer tries pairs in order, so the quoted form wins
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			pairs = append(pairs, `"`+placeholder+`"`, value)
+		}
+		pairs = append(pairs, placeholder, sanitizeForJSON(value))
+	}
+	modified := strings.NewReplacer(pairs...).Replace(string(templateCopy))
 
 	// Parse and validate result
 	var workflow map[string]any
@@ -77,6 +284,85 @@ func (wm *WorkflowManager) PrepareWorkflow(userPrompt string) (map[string]any, e
 	return workflow, nil
 }
 
+// seedInputs are the node input names that hold sampler seeds
+var seedInputs = []string{"seed", "noise_seed"}
+
+// maxSeed keeps random seeds within the range JSON numbers represent exactly
+const maxSeed = 1 << 50
+
+// MaxSeed is the largest fixed seed accepted. Workflows are decoded as
+// JSON, which represents integers exactly only up to 2^53.
+const MaxSeed = 1 << 53
+
+// SetSeeds replaces every numeric seed input in the workflow with seed
+func SetSeeds(workflow map[string]any, seed int64) {
+	for _, node := range workflow {
+		nodeMap, ok := node.(map[string]any)
+		if !ok {
+			continue
+		}
+		inputs, ok := nodeMap["inputs"].(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, name := range seedInputs {
+			if _, isNumber := inputs[name].(float64); isNumber {
+				inputs[name] = seed
+			}
+		}
+	}
+}
+
+// RandomizeSeeds replaces every numeric seed input in the workflow with a
+// new random value
+func RandomizeSeeds(workflow map[string]any) {
+	for _, node := range workflow {
+		nodeMap, ok := node.(map[string]any)
+		if !ok {
+			continue
+		}
+		inputs, ok := nodeMap["inputs"].(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, name := range seedInputs {
+			if _, isNumber := inputs[name].(float64); isNumber {
+				inputs[name] = rand.Int64N(maxSeed)
+			}
+		}
+	}
+}
+
+// WorkflowSeed returns the first literal seed input in the workflow,
+// checking nodes in ID order, or -1 if there is none
+func WorkflowSeed(workflow map[string]any) int64 {
+	ids := make([]string, 0, len(workflow))
+	for id := range workflow {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		nodeMap, ok := workflow[id].(map[string]any)
+		if !ok {
+			continue
+		}
+		inputs, ok := nodeMap["inputs"].(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, name := range seedInputs {
+			switch seed := inputs[name].(type) {
+			case float64:
+				return int64(seed)
+			case int64:
+				return seed
+			}
+		}
+	}
+	return -1
+}
+
 // sanitizeForJSON escapes special characters for safe JSON string embedding
 func sanitizeForJSON(s string) string {
 	// Use json.Marshal to properly escape the string
@@ -96,6 +382,6 @@ func sanitizeForJSON(s string) string {
 }
 
 // Reload reloads the workflow template from disk
-func (wm *WorkflowManager) Reload() error {
+func (wm *WorkflowManager) Reload() (string, error) {
 	return wm.Load()
 }
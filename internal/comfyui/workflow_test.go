@@ -0,0 +1,120 @@
+package comfyui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWorkflowManagerCheckpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name: "checkpoint loader",
+			template: `{
+				"3": {"class_type": "KSampler", "inputs": {"seed": "{{SEED}}"}},
+				"4": {"class_type": "CheckpointLoaderSimple", "inputs": {"ckpt_name": "sdxl_base.safetensors"}},
+				"6": {"class_type": "CLIPTextEncode", "inputs": {"text": "{{PROMPT}}"}}
+			}`,
+			want: "sdxl_base.safetensors",
+		},
+		{
+			name: "no checkpoint loader",
+			template: `{
+				"1": {"class_type": "UNETLoader", "inputs": {"unet_name": "flux.safetensors"}},
+				"6": {"class_type": "CLIPTextEncode", "inputs": {"text": "{{PROMPT}}"}}
+			}`,
+			want: "",
+		},
+		{
+			name: "placeholder checkpoint",
+			template: `{
+				"4": {"class_type": "CheckpointLoaderSimple", "inputs": {"ckpt_name": "{{MODEL}}"}},
+				"6": {"class_type": "CLIPTextEncode", "inputs": {"text": "{{PROMPT}}"}}
+			}`,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "workflow.json")
+			if err := os.WriteFile(path, []byte(tt.template), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			wm, err := NewWorkflowManager(path, false)
+			if err != nil {
+				t.Fatalf("NewWorkflowManager: %v", err)
+			}
+			if got := wm.Checkpoint(); got != tt.want {
+				t.Errorf("Checkpoint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzSanitizeForJSON(f *testing.F) {
+	for _, seed := range []string{
+		"a cat in a hat",
+		`quote " backslash \ end`,
+		`", "class_type": "Injected", "x": "`,
+		"line\nbreak\ttab\rreturn",
+		"\x00\x1f\x7f",
+		"\u2028\u2029 separators",
+		"emoji 🐈‍⬛ and 日本語",
+		"{{PROMPT}} {{NEGATIVE_PROMPT}}",
+		"\xff\xfe invalid utf-8 \xc3",
+		`" escaped quote`,
+	} {
+		f.Add(seed)
+	}
+
+	path := filepath.Join(f.TempDir(), "workflow.json")
+	template := `{
+		"6": {"class_type": "CLIPTextEncode", "inputs": {"text": "{{PROMPT}}"}},
+		"7": {"class_type": "CLIPTextEncode", "inputs": {"text": "{{NEGATIVE_PROMPT}}"}}
+	}`
+	if err := os.WriteFile(path, []byte(template), 0o644); err != nil {
+		f.Fatal(err)
+	}
+	wm, err := NewWorkflowManager(path, false)
+	if err != nil {
+		f.Fatalf("NewWorkflowManager: %v", err)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(`{"text": "`+sanitizeForJSON(s)+`"}`), &doc); err != nil {
+			t.Fatalf("embedded %q is not valid JSON: %v", s, err)
+		}
+		if len(doc) != 1 {
+			t.Fatalf("embedded %q changed the object's keys: %v", s, doc)
+		}
+		got, ok := doc["text"].(string)
+		if !ok {
+			t.Fatalf("embedded %q is not a string: %#v", s, doc["text"])
+		}
+		if utf8.ValidString(s) && got != s {
+			t.Fatalf("round trip of %q = %q", s, got)
+		}
+
+		workflow, err := wm.PrepareWorkflowFull(s, s, nil)
+		if err != nil {
+			t.Fatalf("PrepareWorkflowFull(%q): %v", s, err)
+		}
+		if len(workflow) != 2 {
+			t.Fatalf("prompt %q changed the workflow's nodes: %v", s, workflow)
+		}
+		for _, id := range []string{"6", "7"} {
+			node, _ := workflow[id].(map[string]any)
+			inputs, _ := node["inputs"].(map[string]any)
+			if node["class_type"] != "CLIPTextEncode" || len(node) != 2 || len(inputs) != 1 || inputs["text"] != got {
+				t.Fatalf("prompt %q changed node %s: %v", s, id, node)
+			}
+		}
+	})
+}
@@ -2,8 +2,13 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/spf13/viper"
 )
@@ -14,27 +19,203 @@ type Config struct {
 	Image    ImageConfig    `mapstructure:"image"`
 	Logging  LoggingConfig  `mapstructure:"logging"`
 	Settings SettingsConfig `mapstructure:"settings"`
+	Prompt   PromptConfig   `mapstructure:"prompt"`
+	Server   ServerConfig   `mapstructure:"server"`
+	Health   HealthConfig   `mapstructure:"health"`
+	Metrics  MetricsConfig  `mapstructure:"metrics"`
+	Limiter  LimiterConfig  `mapstructure:"limiter"`
+	Quota    QuotaConfig    `mapstructure:"quota"`
+	Shutdown ShutdownConfig `mapstructure:"shutdown"`
+	Admin    AdminConfig    `mapstructure:"admin"`
+	Whisper  WhisperConfig  `mapstructure:"whisper"`
 }
 
 type TelegramConfig struct {
-	BotToken       string        `mapstructure:"bot_token"`
-	AllowedUsers   []int64       `mapstructure:"allowed_users"`
+	BotToken     string  `mapstructure:"bot_token"`
+	AllowedUsers []int64 `mapstructure:"allowed_users"`
+	// AdminUsers can approve users and run admin commands; all of them
+	// receive admin notifications
+	AdminUsers []int64 `mapstructure:"admin_users"`
+	// AdminUser is the deprecated single-admin key, merged into AdminUsers
 	AdminUser      int64         `mapstructure:"admin_user"`
 	PollingTimeout int           `mapstructure:"polling_timeout"`
 	RequestTimeout time.Duration `mapstructure:"request_timeout"`
+	// PollingHealthCheckInterval is how long without updates before the
+	// API is checked with getMe
+	PollingHealthCheckInterval time.Duration `mapstructure:"polling_health_check_interval"`
+	// PollingRestartDelay is the initial delay before restarting polling
+	// after a failed check; it doubles per failure up to 5 minutes
+	PollingRestartDelay time.Duration `mapstructure:"polling_restart_delay"`
+	// StaleRequestHours is how long a notified pending request may sit
+	// before the admin is reminded about it (0 disables reminders)
+	StaleRequestHours int `mapstructure:"stale_request_hours"`
+	// AllowGroupOriginals lets group members receive the original PNG
+	// according to their own settings (can be overridden per group)
+	AllowGroupOriginals bool `mapstructure:"allow_group_originals"`
+	// StatusUpdateIntervalMs is the minimum time between progress message
+	// edits; values below 1500 are raised to 1500
+	StatusUpdateIntervalMs int `mapstructure:"status_update_interval_ms"`
+	// StatusProgressFormat supports {bar}, {percent}, {current} and {total} placeholders
+	StatusProgressFormat string `mapstructure:"status_progress_format"`
+	// DisableLinkPreviews is the default for suppressing web page previews
+	// in bot messages; users can override it in /settings
+	DisableLinkPreviews bool `mapstructure:"disable_link_previews"`
+	// MaxApprovedUsers caps how many users the admin can approve (0 = unlimited)
+	MaxApprovedUsers int `mapstructure:"max_approved_users"`
+	// NotifyAccessExpiry tells users when their temporary access ends
+	NotifyAccessExpiry bool `mapstructure:"notify_access_expiry"`
+	// MaxHeapMB rejects new generations while the Go heap exceeds this
+	// size (0 = disabled)
+	MaxHeapMB int `mapstructure:"max_heap_mb"`
+	// MaxQueueWaitSeconds drops generations still waiting in the ComfyUI
+	// queue after this many seconds (0 = wait indefinitely)
+	MaxQueueWaitSeconds int `mapstructure:"max_queue_wait_seconds"`
+	// MaxBatchSize is the largest N accepted in a "[N] prompt" batch
+	MaxBatchSize int `mapstructure:"max_batch_size"`
+	// PromptSoftLimit asks for confirmation before generating prompts
+	// longer than this many characters (0 = never ask)
+	PromptSoftLimit int `mapstructure:"prompt_soft_limit"`
+	// MaxPromptLength rejects prompts longer than this many characters
+	// (0 = no limit)
+	MaxPromptLength int `mapstructure:"max_prompt_length"`
+	// InlineCacheTimeSeconds is how long Telegram may cache a finished
+	// inline query result
+	InlineCacheTimeSeconds int `mapstructure:"inline_cache_time_seconds"`
+	// ParseModes selects the Telegram parse mode per message type
+	ParseModes ParseModesConfig `mapstructure:"parse_modes"`
+	// WebhookURL switches from long polling to webhook mode when set
+	WebhookURL string `mapstructure:"webhook_url"`
+	// WebhookListenAddr is where the webhook HTTP server listens
+	WebhookListenAddr string `mapstructure:"webhook_listen_addr"`
+	// WebhookSecret is sent by Telegram in X-Telegram-Bot-Api-Secret-Token
+	// with every webhook request and required in webhook mode
+	WebhookSecret string `mapstructure:"webhook_secret"`
+	// CommandAliases maps alternative command names to canonical ones,
+	// e.g. "cfg": "settings"
+	CommandAliases map[string]string `mapstructure:"command_aliases"`
+	// WorkflowAccess restricts named workflows to the listed user IDs;
+	// workflows not listed are open to everyone
+	WorkflowAccess map[string][]int64 `mapstructure:"workflow_access"`
+}
+
+// ParseModesConfig holds a Telegram parse mode ("", "HTML" or "MarkdownV2")
+// for each kind of bot message
+type ParseModesConfig struct {
+	Success string `mapstructure:"success"`
+	Error   string `mapstructure:"error"`
+	Info    string `mapstructure:"info"`
+	Help    string `mapstructure:"help"`
 }
 
 type ComfyUIConfig struct {
-	BaseURL      string        `mapstructure:"base_url"`
+	BaseURL string `mapstructure:"base_url"`
+	// WebSocketURL defaults to BaseURL with ws:// or wss:// and /ws
 	WebSocketURL string        `mapstructure:"websocket_url"`
 	WorkflowPath string        `mapstructure:"workflow_path"`
 	Timeout      time.Duration `mapstructure:"timeout"`
+	// ForceHTTPPolling skips the WebSocket and polls /queue and /history instead
+	ForceHTTPPolling  bool               `mapstructure:"force_http_polling"`
+	PollingIntervalMs int                `mapstructure:"polling_interval_ms"`
+	AutoRecovery      AutoRecoveryConfig `mapstructure:"auto_recovery"`
+	Tokenizer         TokenizerConfig    `mapstructure:"tokenizer"`
+	// UnknownNodePolicy is "warn", "error" or "ignore" for workflow node
+	// types the server does not report in /object_info
+	UnknownNodePolicy string `mapstructure:"unknown_node_policy"`
+	// BackupOnReload keeps timestamped copies of the previous workflow
+	// when it is reloaded
+	BackupOnReload bool `mapstructure:"backup_on_reload"`
+	// AutoReloadWorkflow watches the workflow files and reloads them when
+	// they change on disk
+	AutoReloadWorkflow bool `mapstructure:"auto_reload_workflow"`
+	// Workflows are extra named workflow templates users can pick with
+	// /workflow; workflow_path is always available as "default"
+	Workflows map[string]string `mapstructure:"workflows"`
+	// RetryAttempts is how many times downloading an output is attempted on
+	// connection errors, timeouts and 5xx responses. Queueing a prompt is
+	// only retried when the connection fails or ComfyUI returns a 5xx.
+	RetryAttempts int `mapstructure:"retry_attempts"`
+	// RetryInitialDelay is the wait before the first retry; it doubles
+	// after each further failure
+	RetryInitialDelay time.Duration `mapstructure:"retry_initial_delay"`
+	// CircuitBreakerThreshold is how many consecutive failures to queue a
+	// prompt stop further attempts (0 = disabled)
+	CircuitBreakerThreshold int `mapstructure:"circuit_breaker_threshold"`
+	// CircuitBreakerResetSeconds is how long the breaker stays open before
+	// a single probe request is allowed
+	CircuitBreakerResetSeconds int `mapstructure:"circuit_breaker_reset_seconds"`
+	// MaxRemoteQueueDepth rejects new requests while more prompts than this
+	// are pending in the ComfyUI queue (0 = no limit)
+	MaxRemoteQueueDepth int `mapstructure:"max_remote_queue_depth"`
+	// MaxUserTimeoutSeconds is the longest generation timeout users can
+	// set with /settimeout; admins are not limited (0 = admins only)
+	MaxUserTimeoutSeconds int `mapstructure:"max_user_timeout_seconds"`
+	// EnableInterrupt sends /interrupt when a generation is abandoned on
+	// shutdown; this stops whatever ComfyUI is running, including other
+	// users' jobs
+	EnableInterrupt bool `mapstructure:"enable_interrupt"`
+	// Backends are several ComfyUI servers to spread generations over;
+	// if set, base_url and websocket_url are ignored
+	Backends []BackendConfig `mapstructure:"backends"`
+	// TLSSkipVerify disables certificate checks for https:// and wss://
+	// URLs, e.g. for self-signed certificates
+	TLSSkipVerify bool `mapstructure:"tls_skip_verify"`
+	// TLSCACertPath is a PEM file of CA certificates to trust in addition
+	// to the system ones
+	TLSCACertPath string `mapstructure:"tls_ca_cert_path"`
+	// APIKey is sent as "Authorization: Bearer <api_key>" with every HTTP
+	// request and WebSocket handshake, for servers behind an
+	// authenticating proxy (empty = no authentication)
+	APIKey string `mapstructure:"api_key"`
+}
+
+// BackendConfig is one ComfyUI server in comfyui.backends
+type BackendConfig struct {
+	BaseURL string `mapstructure:"base_url"`
+	// WebSocketURL defaults to BaseURL with ws:// or wss:// and /ws
+	WebSocketURL string `mapstructure:"websocket_url"`
+	// Weight is the backend's share of generations relative to the others
+	// (0 = 1)
+	Weight int `mapstructure:"weight"`
+}
+
+// TokenizerConfig controls the prompt token estimate warning
+type TokenizerConfig struct {
+	Enabled   bool `mapstructure:"enabled"`
+	MaxTokens int  `mapstructure:"max_tokens"`
+}
+
+// AutoRecoveryConfig controls restarting ComfyUI over SSH when it stops responding
+type AutoRecoveryConfig struct {
+	Enabled             bool          `mapstructure:"enabled"`
+	SSHHost             string        `mapstructure:"ssh_host"`
+	SSHUser             string        `mapstructure:"ssh_user"`
+	SSHKeyPath          string        `mapstructure:"ssh_key_path"`
+	KnownHostsPath      string        `mapstructure:"known_hosts_path"`
+	RestartCommand      string        `mapstructure:"restart_command"`
+	FailureThreshold    int           `mapstructure:"failure_threshold"`
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
 }
 
 type ImageConfig struct {
 	JPEGQuality int `mapstructure:"jpeg_quality"`
+	// Preserve16bit sends 16-bit PNG outputs unchanged instead of as JPEG
+	Preserve16bit bool `mapstructure:"preserve_16bit"`
+	// WebPQuality is the encoder effort for lossless WebP output; higher
+	// values are slower and may give smaller files
+	WebPQuality int `mapstructure:"webp_quality"`
+	// MaxWidth and MaxHeight bound the output size users can set with
+	// /setsize
+	MaxWidth  int `mapstructure:"max_width"`
+	MaxHeight int `mapstructure:"max_height"`
+	// MaxPhotoBytes and MaxDocumentBytes shrink the compressed preview and
+	// the original until they fit Telegram's upload limits (0 = no limit)
+	MaxPhotoBytes    int `mapstructure:"max_photo_bytes"`
+	MaxDocumentBytes int `mapstructure:"max_document_bytes"`
 }
 
+// MinImageSide is the smallest width or height users can set with /setsize
+const MinImageSide = 64
+
 type LoggingConfig struct {
 	Level      string `mapstructure:"level"`
 	JSONFormat bool   `mapstructure:"json_format"`
@@ -44,30 +225,183 @@ type SettingsConfig struct {
 	DatabasePath   string `mapstructure:"database_path"`
 	SendOriginal   bool   `mapstructure:"send_original"`
 	SendCompressed bool   `mapstructure:"send_compressed"`
+	// DiskAlertThresholdMB alerts the admin when database files exceed this size
+	DiskAlertThresholdMB int `mapstructure:"disk_alert_threshold_mb"`
+}
+
+type PromptConfig struct {
+	// VocabularyPath points to a YAML word list for /suggest (empty = bundled list)
+	VocabularyPath string `mapstructure:"vocabulary_path"`
+}
+
+type ServerConfig struct {
+	// ListenAddr is where the health check HTTP server listens
+	// (empty = disabled)
+	ListenAddr string `mapstructure:"listen_addr"`
+}
+
+type HealthConfig struct {
+	// AllowedCIDRs restricts the health check endpoints to these client
+	// ranges (empty = allow all)
+	AllowedCIDRs []string `mapstructure:"allowed_cidrs"`
 }
 
-func Load() (*Config, error) {
+type MetricsConfig struct {
+	// Enabled exposes Prometheus metrics at /metrics
+	Enabled bool `mapstructure:"enabled"`
+	// ListenAddr serves /metrics on its own HTTP server (empty = on
+	// server.listen_addr)
+	ListenAddr string `mapstructure:"listen_addr"`
+	// AllowedCIDRs restricts the metrics endpoint to these client ranges
+	// (empty = allow all)
+	AllowedCIDRs []string `mapstructure:"allowed_cidrs"`
+}
+
+type LimiterConfig struct {
+	// Type selects the limiter: "binary" allows one generation per user at
+	// a time, "token_bucket" allows bursts of Burst generations refilled at
+	// Rate per second
+	Type string `mapstructure:"type"`
+	// Rate is how many generations per second a token bucket refills
+	Rate float64 `mapstructure:"rate"`
+	// Burst is how many generations a user may start at once with a token
+	// bucket
+	Burst int `mapstructure:"burst"`
+	// CooldownSeconds is how long a user must wait after a generation
+	// completes before starting another (0 = no cooldown)
+	CooldownSeconds int `mapstructure:"cooldown_seconds"`
+	// MaxConcurrent caps generations running at once across all users
+	// (0 = unlimited)
+	MaxConcurrent int `mapstructure:"max_concurrent"`
+	// MaxQueueDepth is how many requests may wait for a free slot once
+	// MaxConcurrent is reached (0 = reject instead of queueing)
+	MaxQueueDepth int `mapstructure:"max_queue_depth"`
+	// DurationWindowSize is how many recent generations are averaged to
+	// estimate queue wait times
+	DurationWindowSize int `mapstructure:"duration_window_size"`
+}
+
+type QuotaConfig struct {
+	// MaxDailyPerUser caps generations per user per UTC day; admins are
+	// exempt (0 = unlimited)
+	MaxDailyPerUser int `mapstructure:"max_daily_per_user"`
+}
+
+type ShutdownConfig struct {
+	// DrainTimeout is how long running generations may take to finish
+	// and send their results once a shutdown starts
+	DrainTimeout time.Duration `mapstructure:"drain_timeout"`
+}
+
+// minWebhookSecretLength is the shortest admin.webhook_secret accepted
+const minWebhookSecretLength = 16
+
+type AdminConfig struct {
+	// PendingExpiryHours deletes access requests left unanswered this long
+	// (0 = never)
+	PendingExpiryHours int `mapstructure:"pending_expiry_hours"`
+	// WebhookSecret enables POST /webhook/approve and /webhook/revoke on
+	// server.listen_addr for external user management (empty = disabled)
+	WebhookSecret string `mapstructure:"webhook_secret"`
+}
+
+type WhisperConfig struct {
+	// URL is an OpenAI-compatible speech-to-text endpoint, such as
+	// https://api.openai.com/v1/audio/transcriptions or a self-hosted
+	// Whisper server (empty = voice messages disabled)
+	URL string `mapstructure:"url"`
+	// APIKey is sent as "Authorization: Bearer <api_key>" (empty = none)
+	APIKey string `mapstructure:"api_key"`
+	// Model is sent as the model form field
+	Model string `mapstructure:"model"`
+	// Timeout bounds one transcription request
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Load reads configuration from file, environment and defaults.
+// If path is non-empty it is used as the config file; otherwise the
+// standard search locations are tried.
+func Load(path string) (*Config, error) {
 	v := viper.New()
 
 	// Set defaults
 	v.SetDefault("telegram.polling_timeout", 60)
 	v.SetDefault("telegram.request_timeout", "5m")
+	v.SetDefault("telegram.polling_health_check_interval", "120s")
+	v.SetDefault("telegram.polling_restart_delay", "10s")
+	v.SetDefault("telegram.stale_request_hours", 24)
+	v.SetDefault("telegram.allow_group_originals", false)
+	v.SetDefault("telegram.status_update_interval_ms", 2000)
+	v.SetDefault("telegram.status_progress_format", "Generating… {bar} {percent}% ({current}/{total} steps)")
+	v.SetDefault("telegram.disable_link_previews", true)
+	v.SetDefault("telegram.max_approved_users", 0)
+	v.SetDefault("telegram.notify_access_expiry", true)
+	v.SetDefault("telegram.max_heap_mb", 0)
+	v.SetDefault("telegram.max_queue_wait_seconds", 0)
+	v.SetDefault("telegram.max_batch_size", 4)
+	v.SetDefault("telegram.prompt_soft_limit", 500)
+	v.SetDefault("telegram.max_prompt_length", 1000)
+	v.SetDefault("telegram.inline_cache_time_seconds", 300)
+	v.SetDefault("telegram.webhook_listen_addr", ":8443")
 	v.SetDefault("comfyui.base_url", "http://localhost:8188")
-	v.SetDefault("comfyui.websocket_url", "ws://localhost:8188/ws")
+	v.SetDefault("comfyui.tls_skip_verify", false)
 	v.SetDefault("comfyui.timeout", "5m")
+	v.SetDefault("comfyui.force_http_polling", false)
+	v.SetDefault("comfyui.polling_interval_ms", 1000)
+	v.SetDefault("comfyui.auto_recovery.enabled", false)
+	v.SetDefault("comfyui.auto_recovery.failure_threshold", 3)
+	v.SetDefault("comfyui.auto_recovery.health_check_interval", "30s")
+	v.SetDefault("comfyui.tokenizer.enabled", true)
+	v.SetDefault("comfyui.tokenizer.max_tokens", 75)
+	v.SetDefault("comfyui.unknown_node_policy", "warn")
+	v.SetDefault("comfyui.backup_on_reload", true)
+	v.SetDefault("comfyui.auto_reload_workflow", false)
+	v.SetDefault("comfyui.retry_attempts", 3)
+	v.SetDefault("comfyui.retry_initial_delay", "1s")
+	v.SetDefault("comfyui.circuit_breaker_threshold", 5)
+	v.SetDefault("comfyui.circuit_breaker_reset_seconds", 30)
+	v.SetDefault("comfyui.max_remote_queue_depth", 0)
+	v.SetDefault("comfyui.max_user_timeout_seconds", 1800)
+	v.SetDefault("comfyui.enable_interrupt", false)
 	v.SetDefault("image.jpeg_quality", 80)
+	v.SetDefault("image.preserve_16bit", false)
+	v.SetDefault("image.webp_quality", 80)
+	v.SetDefault("image.max_width", 2048)
+	v.SetDefault("image.max_height", 2048)
+	// Telegram rejects bot photos over 10MB and documents over 50MB
+	v.SetDefault("image.max_photo_bytes", 10<<20)
+	v.SetDefault("image.max_document_bytes", 50<<20)
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.json_format", false)
 	v.SetDefault("settings.database_path", "data/settings.db")
 	v.SetDefault("settings.send_original", true)
 	v.SetDefault("settings.send_compressed", true)
+	v.SetDefault("settings.disk_alert_threshold_mb", 500)
+	v.SetDefault("server.listen_addr", ":8080")
+	v.SetDefault("metrics.enabled", false)
+	v.SetDefault("limiter.type", "binary")
+	v.SetDefault("limiter.rate", 0.05)
+	v.SetDefault("limiter.burst", 3)
+	v.SetDefault("limiter.cooldown_seconds", 0)
+	v.SetDefault("limiter.max_concurrent", 0)
+	v.SetDefault("limiter.max_queue_depth", 0)
+	v.SetDefault("limiter.duration_window_size", 20)
+	v.SetDefault("quota.max_daily_per_user", 0)
+	v.SetDefault("shutdown.drain_timeout", "25s")
+	v.SetDefault("admin.pending_expiry_hours", 72)
+	v.SetDefault("whisper.model", "whisper-1")
+	v.SetDefault("whisper.timeout", "60s")
 
 	// Config file locations
-	v.SetConfigName("config")
-	v.SetConfigType("yaml")
-	v.AddConfigPath(".")
-	v.AddConfigPath("./configs")
-	v.AddConfigPath("/etc/comfy-tg-bot")
+	if path != "" {
+		v.SetConfigFile(path)
+	} else {
+		v.SetConfigName("config")
+		v.SetConfigType("yaml")
+		v.AddConfigPath(".")
+		v.AddConfigPath("./configs")
+		v.AddConfigPath("/etc/comfy-tg-bot")
+	}
 
 	// Environment variables
 	v.SetEnvPrefix("COMFY_BOT")
@@ -77,23 +411,100 @@ func Load() (*Config, error) {
 	// Explicitly bind nested keys to env vars (required for Unmarshal)
 	v.BindEnv("telegram.bot_token")
 	v.BindEnv("telegram.allowed_users")
+	v.BindEnv("telegram.admin_users")
 	v.BindEnv("telegram.admin_user")
 	v.BindEnv("telegram.polling_timeout")
 	v.BindEnv("telegram.request_timeout")
+	v.BindEnv("telegram.polling_health_check_interval")
+	v.BindEnv("telegram.polling_restart_delay")
+	v.BindEnv("telegram.stale_request_hours")
+	v.BindEnv("telegram.allow_group_originals")
+	v.BindEnv("telegram.status_update_interval_ms")
+	v.BindEnv("telegram.status_progress_format")
+	v.BindEnv("telegram.disable_link_previews")
+	v.BindEnv("telegram.max_approved_users")
+	v.BindEnv("telegram.notify_access_expiry")
+	v.BindEnv("telegram.max_heap_mb")
+	v.BindEnv("telegram.max_queue_wait_seconds")
+	v.BindEnv("telegram.max_batch_size")
+	v.BindEnv("telegram.prompt_soft_limit")
+	v.BindEnv("telegram.max_prompt_length")
+	v.BindEnv("telegram.inline_cache_time_seconds")
+	v.BindEnv("telegram.webhook_url")
+	v.BindEnv("telegram.webhook_listen_addr")
+	v.BindEnv("telegram.webhook_secret")
+	v.BindEnv("telegram.parse_modes.success")
+	v.BindEnv("telegram.parse_modes.error")
+	v.BindEnv("telegram.parse_modes.info")
+	v.BindEnv("telegram.parse_modes.help")
 	v.BindEnv("comfyui.base_url")
 	v.BindEnv("comfyui.websocket_url")
+	v.BindEnv("comfyui.tls_skip_verify")
+	v.BindEnv("comfyui.tls_ca_cert_path")
+	v.BindEnv("comfyui.api_key")
 	v.BindEnv("comfyui.workflow_path")
 	v.BindEnv("comfyui.timeout")
+	v.BindEnv("comfyui.force_http_polling")
+	v.BindEnv("comfyui.polling_interval_ms")
+	v.BindEnv("comfyui.auto_recovery.enabled")
+	v.BindEnv("comfyui.auto_recovery.ssh_host")
+	v.BindEnv("comfyui.auto_recovery.ssh_user")
+	v.BindEnv("comfyui.auto_recovery.ssh_key_path")
+	v.BindEnv("comfyui.auto_recovery.known_hosts_path")
+	v.BindEnv("comfyui.auto_recovery.restart_command")
+	v.BindEnv("comfyui.auto_recovery.failure_threshold")
+	v.BindEnv("comfyui.auto_recovery.health_check_interval")
+	v.BindEnv("comfyui.tokenizer.enabled")
+	v.BindEnv("comfyui.tokenizer.max_tokens")
+	v.BindEnv("comfyui.unknown_node_policy")
+	v.BindEnv("comfyui.backup_on_reload")
+	v.BindEnv("comfyui.auto_reload_workflow")
+	v.BindEnv("comfyui.retry_attempts")
+	v.BindEnv("comfyui.retry_initial_delay")
+	v.BindEnv("comfyui.circuit_breaker_threshold")
+	v.BindEnv("comfyui.circuit_breaker_reset_seconds")
+	v.BindEnv("comfyui.max_remote_queue_depth")
+	v.BindEnv("comfyui.max_user_timeout_seconds")
+	v.BindEnv("comfyui.enable_interrupt")
 	v.BindEnv("image.jpeg_quality")
+	v.BindEnv("image.preserve_16bit")
+	v.BindEnv("image.webp_quality")
+	v.BindEnv("image.max_width")
+	v.BindEnv("image.max_height")
+	v.BindEnv("image.max_photo_bytes")
+	v.BindEnv("image.max_document_bytes")
 	v.BindEnv("logging.level")
 	v.BindEnv("logging.json_format")
 	v.BindEnv("settings.database_path")
 	v.BindEnv("settings.send_original")
 	v.BindEnv("settings.send_compressed")
+	v.BindEnv("settings.disk_alert_threshold_mb")
+	v.BindEnv("prompt.vocabulary_path")
+	v.BindEnv("server.listen_addr")
+	v.BindEnv("health.allowed_cidrs")
+	v.BindEnv("metrics.enabled")
+	v.BindEnv("metrics.listen_addr")
+	v.BindEnv("metrics.allowed_cidrs")
+	v.BindEnv("limiter.type")
+	v.BindEnv("limiter.rate")
+	v.BindEnv("limiter.burst")
+	v.BindEnv("limiter.cooldown_seconds")
+	v.BindEnv("limiter.max_concurrent")
+	v.BindEnv("limiter.max_queue_depth")
+	v.BindEnv("limiter.duration_window_size")
+	v.BindEnv("quota.max_daily_per_user")
+	v.BindEnv("shutdown.drain_timeout")
+	v.BindEnv("admin.pending_expiry_hours")
+	v.BindEnv("admin.webhook_secret")
+	v.BindEnv("whisper.url")
+	v.BindEnv("whisper.api_key")
+	v.BindEnv("whisper.model")
+	v.BindEnv("whisper.timeout")
 
 	// Read config file (optional)
 	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+		// An explicitly requested file must exist
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok || path != "" {
 			return nil, fmt.Errorf("read config: %w", err)
 		}
 		// Config file not found is OK, use env vars and defaults
@@ -104,6 +515,21 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("unmarshal config: %w", err)
 	}
 
+	// Keep configs using the single admin_user key working
+	if id := cfg.Telegram.AdminUser; id != 0 && !slices.Contains(cfg.Telegram.AdminUsers, id) {
+		cfg.Telegram.AdminUsers = append(cfg.Telegram.AdminUsers, id)
+	}
+
+	// Derive WebSocket URLs that were not set from the HTTP ones
+	if cfg.ComfyUI.WebSocketURL == "" {
+		cfg.ComfyUI.WebSocketURL = webSocketURLFor(cfg.ComfyUI.BaseURL)
+	}
+	for i, b := range cfg.ComfyUI.Backends {
+		if b.WebSocketURL == "" {
+			cfg.ComfyUI.Backends[i].WebSocketURL = webSocketURLFor(b.BaseURL)
+		}
+	}
+
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("validate config: %w", err)
 	}
@@ -111,21 +537,291 @@ func Load() (*Config, error) {
 	return &cfg, nil
 }
 
+// webSocketURLFor returns ComfyUI's WebSocket endpoint for an HTTP base
+// URL: http becomes ws, https becomes wss, and /ws is appended. It returns
+// an empty string if baseURL is not an http:// or https:// URL.
+func webSocketURLFor(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	default:
+		return ""
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/ws"
+	return u.String()
+}
+
+// validateComfyUIURLs checks the schemes of a ComfyUI server's URLs
+func validateComfyUIURLs(key, baseURL, wsURL string) error {
+	if u, err := url.Parse(baseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%sbase_url must be an http:// or https:// URL", key)
+	}
+	if u, err := url.Parse(wsURL); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+		return fmt.Errorf("%swebsocket_url must be a ws:// or wss:// URL", key)
+	}
+	return nil
+}
+
+// webhookSecretPattern is the character set Telegram accepts for secret_token
+var webhookSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// workflowNamePattern keeps workflow names short enough for callback data
+var workflowNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
 func (c *Config) Validate() error {
 	if c.Telegram.BotToken == "" {
 		return fmt.Errorf("telegram.bot_token is required")
 	}
-	if len(c.Telegram.AllowedUsers) == 0 && c.Telegram.AdminUser == 0 {
-		return fmt.Errorf("telegram.allowed_users or telegram.admin_user must be set")
+	if len(c.Telegram.AllowedUsers) == 0 && len(c.Telegram.AdminUsers) == 0 {
+		return fmt.Errorf("telegram.allowed_users or telegram.admin_users must be set")
+	}
+	for _, id := range c.Telegram.AdminUsers {
+		if id == 0 {
+			return fmt.Errorf("telegram.admin_users must not contain 0")
+		}
+	}
+	if c.Telegram.PollingHealthCheckInterval <= 0 {
+		return fmt.Errorf("telegram.polling_health_check_interval must be positive")
+	}
+	if c.Telegram.PollingRestartDelay <= 0 {
+		return fmt.Errorf("telegram.polling_restart_delay must be positive")
+	}
+	if c.Telegram.StaleRequestHours < 0 {
+		return fmt.Errorf("telegram.stale_request_hours must not be negative")
+	}
+	if c.Telegram.MaxHeapMB < 0 {
+		return fmt.Errorf("telegram.max_heap_mb must not be negative")
+	}
+	if c.Telegram.MaxQueueWaitSeconds < 0 {
+		return fmt.Errorf("telegram.max_queue_wait_seconds must not be negative")
+	}
+	if c.Telegram.MaxBatchSize < 1 || c.Telegram.MaxBatchSize > 10 {
+		return fmt.Errorf("telegram.max_batch_size must be between 1 and 10")
+	}
+	if c.Telegram.PromptSoftLimit < 0 {
+		return fmt.Errorf("telegram.prompt_soft_limit must not be negative")
+	}
+	if c.Telegram.MaxPromptLength < 0 {
+		return fmt.Errorf("telegram.max_prompt_length must not be negative")
+	}
+	if c.Telegram.InlineCacheTimeSeconds < 0 {
+		return fmt.Errorf("telegram.inline_cache_time_seconds must not be negative")
+	}
+	if c.Telegram.MaxApprovedUsers < 0 {
+		return fmt.Errorf("telegram.max_approved_users must not be negative")
+	}
+	for key, mode := range map[string]string{
+		"success": c.Telegram.ParseModes.Success,
+		"error":   c.Telegram.ParseModes.Error,
+		"info":    c.Telegram.ParseModes.Info,
+		"help":    c.Telegram.ParseModes.Help,
+	} {
+		switch mode {
+		case "", "HTML", "MarkdownV2":
+		default:
+			return fmt.Errorf("telegram.parse_modes.%s must be empty, HTML or MarkdownV2", key)
+		}
+	}
+	if c.Telegram.WebhookURL != "" {
+		if !webhookSecretPattern.MatchString(c.Telegram.WebhookSecret) {
+			return fmt.Errorf("telegram.webhook_secret is required in webhook mode (1-256 characters of A-Z, a-z, 0-9, _ and -)")
+		}
+		if c.Telegram.WebhookListenAddr == "" {
+			return fmt.Errorf("telegram.webhook_listen_addr is required in webhook mode")
+		}
+	}
+	for alias, command := range c.Telegram.CommandAliases {
+		if command == "" || strings.EqualFold(alias, command) {
+			return fmt.Errorf("telegram.command_aliases.%s must name a different command", alias)
+		}
+		if _, chained := c.Telegram.CommandAliases[strings.ToLower(command)]; chained {
+			return fmt.Errorf("telegram.command_aliases.%s must not point to another alias", alias)
+		}
+	}
+	if c.Telegram.StatusUpdateIntervalMs < 0 {
+		return fmt.Errorf("telegram.status_update_interval_ms must not be negative")
 	}
 	if c.ComfyUI.WorkflowPath == "" {
 		return fmt.Errorf("comfyui.workflow_path is required")
 	}
+	for name, path := range c.ComfyUI.Workflows {
+		if !workflowNamePattern.MatchString(name) || name == "default" {
+			return fmt.Errorf("comfyui.workflows.%s: name must be lowercase letters, digits, _ or - and not \"default\"", name)
+		}
+		if path == "" {
+			return fmt.Errorf("comfyui.workflows.%s must be a file path", name)
+		}
+	}
+	for name := range c.Telegram.WorkflowAccess {
+		if _, ok := c.ComfyUI.Workflows[name]; !ok {
+			return fmt.Errorf("telegram.workflow_access.%s does not match a workflow in comfyui.workflows", name)
+		}
+	}
+	if len(c.ComfyUI.Backends) == 0 {
+		if err := validateComfyUIURLs("comfyui.", c.ComfyUI.BaseURL, c.ComfyUI.WebSocketURL); err != nil {
+			return err
+		}
+	}
+	for i, b := range c.ComfyUI.Backends {
+		if err := validateComfyUIURLs(fmt.Sprintf("comfyui.backends[%d].", i), b.BaseURL, b.WebSocketURL); err != nil {
+			return err
+		}
+		if b.Weight < 0 {
+			return fmt.Errorf("comfyui.backends[%d].weight must not be negative", i)
+		}
+	}
+	if strings.ContainsFunc(c.ComfyUI.APIKey, unicode.IsSpace) {
+		return fmt.Errorf("comfyui.api_key must not contain whitespace; give the token without the \"Bearer \" prefix")
+	}
+	if c.ComfyUI.ForceHTTPPolling && c.ComfyUI.PollingIntervalMs <= 0 {
+		return fmt.Errorf("comfyui.polling_interval_ms must be positive when force_http_polling is enabled")
+	}
+	if ar := c.ComfyUI.AutoRecovery; ar.Enabled {
+		if ar.SSHHost == "" || ar.SSHUser == "" || ar.SSHKeyPath == "" || ar.KnownHostsPath == "" || ar.RestartCommand == "" {
+			return fmt.Errorf("comfyui.auto_recovery requires ssh_host, ssh_user, ssh_key_path, known_hosts_path and restart_command")
+		}
+		if ar.FailureThreshold < 1 {
+			return fmt.Errorf("comfyui.auto_recovery.failure_threshold must be at least 1")
+		}
+		if ar.HealthCheckInterval <= 0 {
+			return fmt.Errorf("comfyui.auto_recovery.health_check_interval must be positive")
+		}
+	}
+	switch c.ComfyUI.UnknownNodePolicy {
+	case "warn", "error", "ignore":
+	default:
+		return fmt.Errorf("comfyui.unknown_node_policy must be one of warn, error, ignore")
+	}
+	if c.ComfyUI.Tokenizer.Enabled && c.ComfyUI.Tokenizer.MaxTokens < 1 {
+		return fmt.Errorf("comfyui.tokenizer.max_tokens must be at least 1")
+	}
+	if c.ComfyUI.RetryAttempts < 1 {
+		return fmt.Errorf("comfyui.retry_attempts must be at least 1")
+	}
+	if c.ComfyUI.RetryInitialDelay < 0 {
+		return fmt.Errorf("comfyui.retry_initial_delay must not be negative")
+	}
+	if c.ComfyUI.CircuitBreakerThreshold < 0 {
+		return fmt.Errorf("comfyui.circuit_breaker_threshold must not be negative")
+	}
+	if c.ComfyUI.CircuitBreakerThreshold > 0 && c.ComfyUI.CircuitBreakerResetSeconds <= 0 {
+		return fmt.Errorf("comfyui.circuit_breaker_reset_seconds must be positive when the circuit breaker is enabled")
+	}
+	if c.ComfyUI.MaxRemoteQueueDepth < 0 {
+		return fmt.Errorf("comfyui.max_remote_queue_depth must not be negative")
+	}
+	if c.ComfyUI.MaxUserTimeoutSeconds < 0 {
+		return fmt.Errorf("comfyui.max_user_timeout_seconds must not be negative")
+	}
 	if c.Image.JPEGQuality < 1 || c.Image.JPEGQuality > 100 {
 		return fmt.Errorf("image.jpeg_quality must be between 1 and 100")
 	}
+	if c.Image.WebPQuality < 0 || c.Image.WebPQuality > 100 {
+		return fmt.Errorf("image.webp_quality must be between 0 and 100")
+	}
+	if c.Image.MaxWidth < MinImageSide || c.Image.MaxHeight < MinImageSide {
+		return fmt.Errorf("image.max_width and image.max_height must be at least %d", MinImageSide)
+	}
+	if c.Image.MaxPhotoBytes < 0 || c.Image.MaxDocumentBytes < 0 {
+		return fmt.Errorf("image.max_photo_bytes and image.max_document_bytes must not be negative")
+	}
 	if !c.Settings.SendOriginal && !c.Settings.SendCompressed {
 		return fmt.Errorf("at least one of settings.send_original or settings.send_compressed must be true")
 	}
+	if c.Server.ListenAddr != "" && c.Telegram.WebhookURL != "" && c.Server.ListenAddr == c.Telegram.WebhookListenAddr {
+		return fmt.Errorf("server.listen_addr must differ from telegram.webhook_listen_addr")
+	}
+	if err := validateCIDRs("health.allowed_cidrs", c.Health.AllowedCIDRs); err != nil {
+		return err
+	}
+	if c.Metrics.Enabled && c.Metrics.ListenAddr == "" && c.Server.ListenAddr == "" {
+		return fmt.Errorf("metrics.listen_addr is required when server.listen_addr is empty")
+	}
+	if c.Metrics.ListenAddr != "" && c.Telegram.WebhookURL != "" && c.Metrics.ListenAddr == c.Telegram.WebhookListenAddr {
+		return fmt.Errorf("metrics.listen_addr must differ from telegram.webhook_listen_addr")
+	}
+	if err := validateCIDRs("metrics.allowed_cidrs", c.Metrics.AllowedCIDRs); err != nil {
+		return err
+	}
+	switch c.Limiter.Type {
+	case "binary":
+	case "token_bucket":
+		if c.Limiter.Rate <= 0 {
+			return fmt.Errorf("limiter.rate must be positive for the token_bucket limiter")
+		}
+		if c.Limiter.Burst < 1 {
+			return fmt.Errorf("limiter.burst must be at least 1 for the token_bucket limiter")
+		}
+		// The bucket's rate is its cooldown, and it never queues
+		if c.Limiter.CooldownSeconds != 0 {
+			return fmt.Errorf("limiter.cooldown_seconds is not supported by the token_bucket limiter; use limiter.rate")
+		}
+		if c.Limiter.MaxQueueDepth != 0 {
+			return fmt.Errorf("limiter.max_queue_depth is not supported by the token_bucket limiter")
+		}
+	default:
+		return fmt.Errorf("limiter.type must be one of binary, token_bucket")
+	}
+	if c.Limiter.CooldownSeconds < 0 {
+		return fmt.Errorf("limiter.cooldown_seconds must not be negative")
+	}
+	if c.Limiter.MaxConcurrent < 0 {
+		return fmt.Errorf("limiter.max_concurrent must not be negative")
+	}
+	if c.Limiter.MaxQueueDepth < 0 {
+		return fmt.Errorf("limiter.max_queue_depth must not be negative")
+	}
+	if c.Limiter.DurationWindowSize < 1 {
+		return fmt.Errorf("limiter.duration_window_size must be at least 1")
+	}
+	if c.Quota.MaxDailyPerUser < 0 {
+		return fmt.Errorf("quota.max_daily_per_user must not be negative")
+	}
+	if c.Shutdown.DrainTimeout < 0 {
+		return fmt.Errorf("shutdown.drain_timeout must not be negative")
+	}
+	if c.Admin.PendingExpiryHours < 0 {
+		return fmt.Errorf("admin.pending_expiry_hours must not be negative")
+	}
+	if c.Admin.WebhookSecret != "" {
+		if len(c.Admin.WebhookSecret) < minWebhookSecretLength {
+			return fmt.Errorf("admin.webhook_secret must be at least %d characters", minWebhookSecretLength)
+		}
+		if c.Server.ListenAddr == "" {
+			return fmt.Errorf("admin.webhook_secret requires server.listen_addr")
+		}
+	}
+	if c.Whisper.URL != "" {
+		if u, err := url.Parse(c.Whisper.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("whisper.url must be an http or https URL")
+		}
+		if c.Whisper.Timeout <= 0 {
+			return fmt.Errorf("whisper.timeout must be positive")
+		}
+	}
+	return nil
+}
+
+// validateCIDRs checks that every entry is a CIDR range or a bare IP address
+func validateCIDRs(key string, entries []string) error {
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return fmt.Errorf("%s: invalid CIDR %q", key, entry)
+			}
+		} else if net.ParseIP(entry) == nil {
+			return fmt.Errorf("%s: invalid IP address %q", key, entry)
+		}
+	}
 	return nil
 }
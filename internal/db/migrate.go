@@ -0,0 +1,109 @@
+// Package db provides versioned schema migrations for the SQLite stores
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int
+	SQL     string
+}
+
+// ErrSchemaTooNew is returned when the database was migrated by a newer
+// version of the bot than the one running, e.g. after a rollback
+var ErrSchemaTooNew = errors.New("database schema is newer than this version supports")
+
+// RunMigrations applies every migration in migrations newer than the
+// recorded schema version for scope. All stores share one database file,
+// so each store tracks its own versions under a distinct scope. Each
+// migration runs in its own transaction together with its version record.
+// Running it again is a no-op. If the database records a version newer
+// than any in migrations, ErrSchemaTooNew is returned rather than running
+// against a schema this binary does not know.
+func RunMigrations(db *sql.DB, scope string, migrations []Migration) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			scope TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			applied_at DATETIME NOT NULL,
+			PRIMARY KEY (scope, version)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("create schema_version table: %w", err)
+	}
+
+	var current int
+	err = db.QueryRow(
+		"SELECT COALESCE(MAX(version), 0) FROM schema_version WHERE scope = ?",
+		scope,
+	).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("query %s schema version: %w", scope, err)
+	}
+
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	latest := 0
+	if len(sorted) > 0 {
+		latest = sorted[len(sorted)-1].Version
+	}
+	if current > latest {
+		return fmt.Errorf("%s schema version %d, latest known %d: %w", scope, current, latest, ErrSchemaTooNew)
+	}
+
+	for i, m := range sorted {
+		if i > 0 && m.Version == sorted[i-1].Version {
+			return fmt.Errorf("duplicate %s migration version %d", scope, m.Version)
+		}
+		if m.Version <= current {
+			continue
+		}
+		if err := applyMigration(db, scope, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(db *sql.DB, scope string, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin %s migration %d: %w", scope, m.Version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil && !isDuplicateColumn(err) {
+		return fmt.Errorf("apply %s migration %d: %w", scope, m.Version, err)
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO schema_version (scope, version, applied_at) VALUES (?, ?, ?)",
+		scope, m.Version, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("record %s migration %d: %w", scope, m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit %s migration %d: %w", scope, m.Version, err)
+	}
+	return nil
+}
+
+// isDuplicateColumn reports an ADD COLUMN for a column that already exists.
+// Databases created before versioned migrations had their columns added
+// on the fly, so those migrations are recorded without being re-applied.
+func isDuplicateColumn(err error) bool {
+	return strings.Contains(err.Error(), "duplicate column name")
+}
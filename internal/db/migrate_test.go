@@ -0,0 +1,132 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func schemaVersions(t *testing.T, db *sql.DB, scope string) []int {
+	t.Helper()
+	rows, err := db.Query("SELECT version FROM schema_version WHERE scope = ? ORDER BY version", scope)
+	if err != nil {
+		t.Fatalf("query versions: %v", err)
+	}
+	defer rows.Close()
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+var testMigrations = []Migration{
+	{Version: 2, SQL: "ALTER TABLE items ADD COLUMN note TEXT"},
+	{Version: 1, SQL: "CREATE TABLE items (id INTEGER PRIMARY KEY)"},
+}
+
+func TestRunMigrationsIdempotent(t *testing.T) {
+	db := openTestDB(t)
+
+	for run := range 3 {
+		if err := RunMigrations(db, "items", testMigrations); err != nil {
+			t.Fatalf("run %d: %v", run+1, err)
+		}
+	}
+
+	if got := schemaVersions(t, db, "items"); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("versions = %v, want [1 2]", got)
+	}
+	if _, err := db.Exec("INSERT INTO items (id, note) VALUES (1, 'x')"); err != nil {
+		t.Errorf("migrated schema unusable: %v", err)
+	}
+}
+
+func TestRunMigrationsAppliesOnlyNew(t *testing.T) {
+	db := openTestDB(t)
+	if err := RunMigrations(db, "items", testMigrations[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunMigrations(db, "items", testMigrations); err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+	if got := schemaVersions(t, db, "items"); len(got) != 2 {
+		t.Errorf("versions = %v, want [1 2]", got)
+	}
+}
+
+func TestRunMigrationsRecordsExistingColumn(t *testing.T) {
+	// Databases from before versioned migrations already have the column
+	db := openTestDB(t)
+	if _, err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, note TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	migrations := []Migration{{Version: 1, SQL: "ALTER TABLE items ADD COLUMN note TEXT"}}
+	if err := RunMigrations(db, "items", migrations); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+	if got := schemaVersions(t, db, "items"); len(got) != 1 {
+		t.Errorf("versions = %v, want [1]", got)
+	}
+}
+
+func TestRunMigrationsSchemaTooNew(t *testing.T) {
+	db := openTestDB(t)
+	if err := RunMigrations(db, "items", testMigrations); err != nil {
+		t.Fatal(err)
+	}
+
+	// An older binary knows only the first migration
+	err := RunMigrations(db, "items", testMigrations[1:])
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("err = %v, want ErrSchemaTooNew", err)
+	}
+
+	// Other scopes in the same file are unaffected
+	other := []Migration{{Version: 1, SQL: "CREATE TABLE others (id INTEGER)"}}
+	if err := RunMigrations(db, "other", other); err != nil {
+		t.Errorf("other scope: %v", err)
+	}
+}
+
+func TestRunMigrationsFailureRollsBack(t *testing.T) {
+	db := openTestDB(t)
+	migrations := []Migration{
+		{Version: 1, SQL: "CREATE TABLE items (id INTEGER PRIMARY KEY)"},
+		{Version: 2, SQL: "NOT VALID SQL"},
+	}
+	if err := RunMigrations(db, "items", migrations); err == nil {
+		t.Fatal("expected an error for the invalid migration")
+	}
+	if got := schemaVersions(t, db, "items"); len(got) != 1 || got[0] != 1 {
+		t.Errorf("versions = %v, want only [1]", got)
+	}
+}
+
+func TestRunMigrationsDuplicateVersion(t *testing.T) {
+	db := openTestDB(t)
+	migrations := []Migration{
+		{Version: 1, SQL: "CREATE TABLE a (id INTEGER)"},
+		{Version: 1, SQL: "CREATE TABLE b (id INTEGER)"},
+	}
+	if err := RunMigrations(db, "dup", migrations); err == nil {
+		t.Error("expected an error for duplicate versions")
+	}
+}
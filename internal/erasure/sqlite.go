@@ -0,0 +1,80 @@
+package erasure
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteEraser implements Eraser for the shared SQLite database
+type SQLiteEraser struct {
+	db *sql.DB
+}
+
+// NewSQLiteEraser opens the shared database used by all stores
+func NewSQLiteEraser(dbPath string) (*SQLiteEraser, error) {
+	// Ensure directory exists
+	dir := filepath.Dir(dbPath)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite works best with a single writer
+	db.SetMaxOpenConns(1)
+
+	return &SQLiteEraser{db: db}, nil
+}
+
+// EraseUser deletes the user's rows from all tables in one transaction
+func (e *SQLiteEraser) EraseUser(userID int64) (int64, error) {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var total int64
+	for _, t := range UserTables {
+		var exists int
+		err := tx.QueryRow(
+			"SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?",
+			t.Table,
+		).Scan(&exists)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("check %s table: %w", t.Table, err)
+		}
+
+		res, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", t.Table, t.Column), userID)
+		if err != nil {
+			return 0, fmt.Errorf("delete from %s: %w", t.Table, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("count deleted %s rows: %w", t.Table, err)
+		}
+		total += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return total, nil
+}
+
+// Close closes the database connection
+func (e *SQLiteEraser) Close() error {
+	return e.db.Close()
+}
@@ -0,0 +1,35 @@
+package erasure
+
+// Eraser deletes every record belonging to a user (right to erasure)
+type Eraser interface {
+	// EraseUser deletes the user's rows from all tables in one transaction
+	// and returns the number of rows removed
+	EraseUser(userID int64) (int64, error)
+	// Close releases resources
+	Close() error
+}
+
+// UserTables lists every table holding per-user data, keyed by its user ID
+// column. Tables that do not exist in the database are skipped, so entries
+// can be added before the feature that creates them is deployed.
+var UserTables = []struct{ Table, Column string }{
+	{"user_settings", "user_id"},
+	{"generation_history", "user_id"},
+	{"favorite_prompts", "user_id"},
+	{"generation_stats", "user_id"},
+	{"stats_daily", "user_id"},
+	{"usage", "user_id"},
+	{"generation_reactions", "user_id"},
+	{"scheduled_generations", "user_id"},
+	{"feedback", "user_id"},
+	{"bug_reports", "user_id"},
+	{"public_gallery", "user_id"},
+	{"suspensions", "user_id"},
+	{"star_spending", "user_id"},
+	{"sent_messages", "user_id"},
+	{"user_chat_ids", "user_id"},
+	{"approved_users", "user_id"},
+	{"pending_requests", "user_id"},
+	// banned_users and user_workflow_permissions are deliberately absent
+	// so that erasing data does not lift a ban or workflow restriction
+}
@@ -56,6 +56,54 @@ var (
 		UserMsg:   "You already have a generation in progress. Please wait for it to complete.",
 		Retryable: false,
 	}
+
+	ErrMemoryPressure = &UserError{
+		Err:       errors.New("server under memory pressure"),
+		UserMsg:   "Server is under high memory load, please try again in a moment.",
+		Retryable: true,
+	}
+
+	ErrCooldown = &UserError{
+		Err:       errors.New("user generation cooldown active"),
+		UserMsg:   "Please wait a moment before starting another generation.",
+		Retryable: true,
+	}
+
+	ErrServerBusy = &UserError{
+		Err:       errors.New("global generation limit reached"),
+		UserMsg:   "The server is busy with other generations. Please try again in a moment.",
+		Retryable: true,
+	}
+
+	ErrQueueFull = &UserError{
+		Err:       errors.New("generation queue full"),
+		UserMsg:   "The generation queue is full. Please try again later.",
+		Retryable: true,
+	}
+
+	ErrQueueExpired = &UserError{
+		Err:       errors.New("generation request expired while queued"),
+		UserMsg:   "Your generation request expired while queued. Please try again.",
+		Retryable: true,
+	}
+
+	ErrShuttingDown = &UserError{
+		Err:       errors.New("bot is shutting down"),
+		UserMsg:   "The bot is restarting. Please send your request again in a minute.",
+		Retryable: true,
+	}
+
+	ErrReferenceUnsupported = &UserError{
+		Err:       errors.New("workflow has no reference image placeholder"),
+		UserMsg:   "Your current workflow doesn't support reference images. Send a text prompt instead, or choose another workflow with /workflow.",
+		Retryable: false,
+	}
+
+	ErrQuotaExceeded = &UserError{
+		Err:       errors.New("daily generation quota exceeded"),
+		UserMsg:   "You've reached your daily generation limit. It resets at midnight UTC.",
+		Retryable: false,
+	}
 )
 
 // Wrap wraps a technical error with a user message
@@ -0,0 +1,159 @@
+package favorites
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+
+	appdb "comfy-tg-bot/internal/db"
+)
+
+// migrations defines the favorites schema; append new versions, never edit old ones
+var migrations = []appdb.Migration{
+	{Version: 1, SQL: `
+		CREATE TABLE IF NOT EXISTS favorite_prompts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			prompt TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			UNIQUE (user_id, name)
+		)
+	`},
+}
+
+// SQLiteStore implements FavoriteStore using SQLite for persistence
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore creates a new SQLite-backed favorites store
+func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	// Ensure directory exists
+	dir := filepath.Dir(dbPath)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite works best with a single writer
+	db.SetMaxOpenConns(1)
+
+	if err := appdb.RunMigrations(db, "favorites", migrations); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Save stores a favorite, replacing the prompt of an existing one with the
+// same name. Returns ErrLimitReached if the user already has MaxPerUser
+// other favorites.
+func (s *SQLiteStore) Save(fav Favorite) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var others int
+	err = tx.QueryRow(
+		"SELECT COUNT(*) FROM favorite_prompts WHERE user_id = ? AND name != ?",
+		fav.UserID, fav.Name,
+	).Scan(&others)
+	if err != nil {
+		return fmt.Errorf("count favorites: %w", err)
+	}
+	if others >= MaxPerUser {
+		return ErrLimitReached
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO favorite_prompts (user_id, name, prompt, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, name) DO UPDATE SET
+			prompt = excluded.prompt,
+			created_at = excluded.created_at
+	`, fav.UserID, fav.Name, fav.Prompt, fav.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("save favorite: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// List returns the user's favorites ordered by name
+func (s *SQLiteStore) List(userID int64) ([]Favorite, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, name, prompt, created_at
+		FROM favorite_prompts
+		WHERE user_id = ?
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query favorites: %w", err)
+	}
+	defer rows.Close()
+
+	var favs []Favorite
+	for rows.Next() {
+		var f Favorite
+		if err := rows.Scan(&f.ID, &f.UserID, &f.Name, &f.Prompt, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan favorite: %w", err)
+		}
+		favs = append(favs, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate favorites: %w", err)
+	}
+
+	return favs, nil
+}
+
+// Get retrieves a favorite by name, returning nil if it does not exist
+func (s *SQLiteStore) Get(userID int64, name string) (*Favorite, error) {
+	var f Favorite
+	err := s.db.QueryRow(`
+		SELECT id, user_id, name, prompt, created_at
+		FROM favorite_prompts WHERE user_id = ? AND name = ?
+	`, userID, name).Scan(&f.ID, &f.UserID, &f.Name, &f.Prompt, &f.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get favorite: %w", err)
+	}
+	return &f, nil
+}
+
+// Delete removes a favorite, reporting whether it existed
+func (s *SQLiteStore) Delete(userID int64, name string) (bool, error) {
+	res, err := s.db.Exec("DELETE FROM favorite_prompts WHERE user_id = ? AND name = ?", userID, name)
+	if err != nil {
+		return false, fmt.Errorf("delete favorite: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("count deleted favorites: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Close releases database resources
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
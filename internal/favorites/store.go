@@ -0,0 +1,43 @@
+package favorites
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// MaxPerUser is how many prompts each user may save
+	MaxPerUser = 10
+	// MaxNameLength is the longest allowed favorite name in bytes, keeping
+	// button data within Telegram's 64-byte limit
+	MaxNameLength = 32
+)
+
+// ErrLimitReached is returned when saving a new favorite would exceed
+// MaxPerUser
+var ErrLimitReached = errors.New("favorite prompt limit reached")
+
+// Favorite is a prompt a user saved under a name
+type Favorite struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Prompt    string
+	CreatedAt time.Time
+}
+
+// FavoriteStore defines the interface for saved prompt persistence
+type FavoriteStore interface {
+	// Save stores a favorite, replacing the prompt of an existing one with
+	// the same name. Returns ErrLimitReached if the user already has
+	// MaxPerUser other favorites.
+	Save(fav Favorite) error
+	// List returns the user's favorites ordered by name
+	List(userID int64) ([]Favorite, error)
+	// Get retrieves a favorite by name, returning nil if it does not exist
+	Get(userID int64, name string) (*Favorite, error)
+	// Delete removes a favorite, reporting whether it existed
+	Delete(userID int64, name string) (bool, error)
+	// Close releases resources
+	Close() error
+}
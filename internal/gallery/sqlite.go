@@ -0,0 +1,116 @@
+package gallery
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+
+	appdb "comfy-tg-bot/internal/db"
+)
+
+// migrations defines the gallery schema; append new versions, never edit old ones
+var migrations = []appdb.Migration{
+	{Version: 1, SQL: `
+		CREATE TABLE IF NOT EXISTS public_gallery (
+			share_token TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			prompt TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			gallery_clones INTEGER DEFAULT 0
+		)
+	`},
+}
+
+// SQLiteStore implements Store using SQLite for persistence
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore creates a new SQLite-backed gallery store
+func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	// Ensure directory exists
+	dir := filepath.Dir(dbPath)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite works best with a single writer
+	db.SetMaxOpenConns(1)
+
+	if err := appdb.RunMigrations(db, "gallery", migrations); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// NewShareToken generates a random URL-safe share token
+func NewShareToken() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate share token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Publish stores a shared prompt
+func (s *SQLiteStore) Publish(entry Entry) error {
+	_, err := s.db.Exec(`
+		INSERT INTO public_gallery (share_token, user_id, prompt, created_at)
+		VALUES (?, ?, ?, ?)
+	`, entry.ShareToken, entry.UserID, entry.Prompt, entry.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("publish gallery entry: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves an entry by share token, returning nil if it does not exist
+func (s *SQLiteStore) Get(token string) (*Entry, error) {
+	var e Entry
+	err := s.db.QueryRow(`
+		SELECT share_token, user_id, prompt, created_at, gallery_clones
+		FROM public_gallery WHERE share_token = ?
+	`, token).Scan(&e.ShareToken, &e.UserID, &e.Prompt, &e.CreatedAt, &e.Clones)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get gallery entry: %w", err)
+	}
+	return &e, nil
+}
+
+// IncrementClones records that an entry was cloned
+func (s *SQLiteStore) IncrementClones(token string) error {
+	_, err := s.db.Exec(`
+		UPDATE public_gallery
+		SET gallery_clones = gallery_clones + 1
+		WHERE share_token = ?
+	`, token)
+
+	if err != nil {
+		return fmt.Errorf("increment gallery clones: %w", err)
+	}
+	return nil
+}
+
+// Close releases database resources
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
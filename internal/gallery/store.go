@@ -0,0 +1,24 @@
+package gallery
+
+import "time"
+
+// Entry is a prompt a user has shared publicly
+type Entry struct {
+	ShareToken string
+	UserID     int64
+	Prompt     string
+	CreatedAt  time.Time
+	Clones     int
+}
+
+// Store defines the interface for public gallery persistence
+type Store interface {
+	// Publish stores a shared prompt
+	Publish(entry Entry) error
+	// Get retrieves an entry by share token, returning nil if it does not exist
+	Get(token string) (*Entry, error)
+	// IncrementClones records that an entry was cloned
+	IncrementClones(token string) error
+	// Close releases resources
+	Close() error
+}
@@ -0,0 +1,141 @@
+package history
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+
+	appdb "comfy-tg-bot/internal/db"
+)
+
+// migrations defines the history schema; append new versions, never edit old ones
+var migrations = []appdb.Migration{
+	{Version: 1, SQL: `
+		CREATE TABLE IF NOT EXISTS generation_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			chat_id INTEGER NOT NULL,
+			prompt TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		)
+	`},
+	{Version: 2, SQL: "ALTER TABLE generation_history ADD COLUMN derived_from TEXT NOT NULL DEFAULT ''"},
+	{Version: 3, SQL: "ALTER TABLE generation_history ADD COLUMN image_filename TEXT NOT NULL DEFAULT ''"},
+	{Version: 4, SQL: `
+		CREATE INDEX IF NOT EXISTS idx_generation_history_user
+		ON generation_history (user_id, created_at)
+	`},
+	{Version: 5, SQL: "ALTER TABLE generation_history ADD COLUMN seed INTEGER NOT NULL DEFAULT -1"},
+}
+
+// SQLiteStore implements Store using SQLite for persistence
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore creates a new SQLite-backed history store
+func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	// Ensure directory exists
+	dir := filepath.Dir(dbPath)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite works best with a single writer
+	db.SetMaxOpenConns(1)
+
+	if err := appdb.RunMigrations(db, "history", migrations); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Add records a completed generation, deleting the user's entries beyond
+// the newest MaxEntriesPerUser
+func (s *SQLiteStore) Add(entry Entry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO generation_history (user_id, chat_id, prompt, derived_from, image_filename, seed, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, entry.UserID, entry.ChatID, entry.Prompt, entry.DerivedFrom, entry.ImageFilename, entry.Seed, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("add history entry: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM generation_history
+		WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM generation_history
+			WHERE user_id = ?
+			ORDER BY created_at DESC, id DESC
+			LIMIT ?
+		)
+	`, entry.UserID, entry.UserID, MaxEntriesPerUser)
+	if err != nil {
+		return fmt.Errorf("prune history: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Recent returns the user's most recent entries, newest first
+func (s *SQLiteStore) Recent(userID int64, limit int) ([]Entry, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, chat_id, prompt, derived_from, image_filename, seed, created_at
+		FROM generation_history
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.ChatID, &e.Prompt, &e.DerivedFrom, &e.ImageFilename, &e.Seed, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan history entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate history: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Clear deletes all of the user's entries
+func (s *SQLiteStore) Clear(userID int64) error {
+	if _, err := s.db.Exec("DELETE FROM generation_history WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("clear history: %w", err)
+	}
+	return nil
+}
+
+// Close releases database resources
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
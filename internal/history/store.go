@@ -0,0 +1,35 @@
+package history
+
+import "time"
+
+// MaxEntriesPerUser is how many of each user's generations are kept; older
+// entries are deleted as new ones are added
+const MaxEntriesPerUser = 20
+
+// Entry records a single completed generation
+type Entry struct {
+	ID     int64
+	UserID int64
+	ChatID int64
+	Prompt string
+	// DerivedFrom is the gallery share token this generation was cloned from
+	DerivedFrom string
+	// ImageFilename is the output file name reported by ComfyUI
+	ImageFilename string
+	// Seed is the sampler seed the generation ran with (-1 if unknown)
+	Seed      int64
+	CreatedAt time.Time
+}
+
+// Store defines the interface for generation history persistence
+type Store interface {
+	// Add records a completed generation, deleting the user's entries
+	// beyond the newest MaxEntriesPerUser
+	Add(entry Entry) error
+	// Recent returns the user's most recent entries, newest first
+	Recent(userID int64, limit int) ([]Entry, error)
+	// Clear deletes all of the user's entries
+	Clear(userID int64) error
+	// Close releases resources
+	Close() error
+}
@@ -0,0 +1,55 @@
+package image
+
+import (
+	"image"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// MaxPhotoDimension is the largest side Telegram accepts for photos
+const MaxPhotoDimension = 5000
+
+// DownscaleIfNeeded proportionally shrinks img so its longest side is at
+// most maxDim pixels. Images already within the limit are returned as-is.
+func DownscaleIfNeeded(img image.Image, maxDim int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if maxDim <= 0 || max(w, h) <= maxDim {
+		return img
+	}
+
+	var nw, nh int
+	if w >= h {
+		nw = maxDim
+		nh = max(1, int(math.Round(float64(h)*float64(maxDim)/float64(w))))
+	} else {
+		nh = maxDim
+		nw = max(1, int(math.Round(float64(w)*float64(maxDim)/float64(h))))
+	}
+
+	return scaleWith(xdraw.CatmullRom, img, nw, nh)
+}
+
+// scaleWith scales img to width x height with the given interpolator
+func scaleWith(s xdraw.Scaler, img image.Image, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	s.Scale(dst, dst.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+	return dst
+}
+
+// lanczos3Kernel is sharper than Catmull-Rom at a higher cost
+var lanczos3Kernel = &xdraw.Kernel{Support: 3, At: lanczos3}
+
+// lanczos3 is the Lanczos kernel with a = 3
+func lanczos3(x float64) float64 {
+	x = math.Abs(x)
+	switch {
+	case x == 0:
+		return 1
+	case x < 3:
+		px := math.Pi * x
+		return 3 * math.Sin(px) * math.Sin(px/3) / (px * px)
+	}
+	return 0
+}
@@ -0,0 +1,52 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestDownscaleIfNeeded(t *testing.T) {
+	tests := []struct {
+		name         string
+		w, h, maxDim int
+		wantW, wantH int
+	}{
+		{"within limit", 800, 600, 1000, 800, 600},
+		{"landscape", 2000, 1000, 1000, 1000, 500},
+		{"portrait", 1000, 3000, 1500, 500, 1500},
+		{"no limit", 2000, 1000, 0, 2000, 1000},
+		{"thin keeps one pixel", 4000, 1, 1000, 1000, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+			got := DownscaleIfNeeded(img, tt.maxDim).Bounds()
+			if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+				t.Errorf("got %dx%d, want %dx%d", got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestDownscaleIfNeededReturnsSmallImagesUnchanged(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	if got := DownscaleIfNeeded(img, 10); got != image.Image(img) {
+		t.Error("image within the limit was copied")
+	}
+}
+
+func TestDownscaleIfNeededKeepsSolidColor(t *testing.T) {
+	want := color.RGBA{R: 200, G: 40, B: 90, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	draw.Draw(img, img.Bounds(), image.NewUniform(want), image.Point{}, draw.Src)
+
+	got := DownscaleIfNeeded(img, 100)
+	b := got.Bounds()
+	for _, p := range []image.Point{b.Min, {b.Max.X - 1, b.Max.Y - 1}, {b.Dx() / 2, b.Dy() / 2}} {
+		if c := color.RGBAModel.Convert(got.At(p.X, p.Y)); c != want {
+			t.Errorf("pixel %v = %v, want %v", p, c, want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// maxFitAttempts bounds how often ResizeIfNeeded shrinks an image before
+// giving up
+const maxFitAttempts = 8
+
+// SetUploadLimits sets the sizes Process shrinks the compressed version and
+// the original to (0 = no limit). Must be called before images are processed.
+func (p *Processor) SetUploadLimits(maxPhotoBytes, maxDocumentBytes int) {
+	p.maxPhotoBytes = maxPhotoBytes
+	p.maxDocumentBytes = maxDocumentBytes
+}
+
+// ResizeDimensions proportionally shrinks img with a Lanczos filter so it
+// fits within maxW x maxH. Images already within the box are returned as-is.
+func (p *Processor) ResizeDimensions(img image.Image, maxW, maxH int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxW && h <= maxH {
+		return img
+	}
+
+	scale := math.Min(float64(maxW)/float64(w), float64(maxH)/float64(h))
+	nw := max(1, int(math.Round(float64(w)*scale)))
+	nh := max(1, int(math.Round(float64(h)*scale)))
+	return scaleWith(lanczos3Kernel, img, nw, nh)
+}
+
+// ResizeIfNeeded returns data unchanged if it is at most maxBytes long.
+// Otherwise the PNG or JPEG is decoded and re-encoded in the same format at
+// smaller dimensions until it fits.
+func (p *Processor) ResizeIfNeeded(data []byte, maxBytes int) ([]byte, error) {
+	if maxBytes <= 0 || len(data) <= maxBytes {
+		return data, nil
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	var encode func(image.Image) ([]byte, error)
+	switch format {
+	case "png":
+		encode = encodePNG
+	case "jpeg":
+		encode = p.EncodeJPEG
+	default:
+		return nil, fmt.Errorf("cannot resize %s images", format)
+	}
+
+	out, _, err := p.shrinkToFit(img, len(data), maxBytes, encode)
+	return out, err
+}
+
+// shrinkToFit re-encodes img at decreasing sizes until the encoding is at
+// most maxBytes long, returning it and the image it encodes. size is the
+// length of the current encoding. Encoded size is roughly proportional to
+// the pixel count, so each attempt scales both sides by the square root of
+// the remaining ratio, with some margin.
+func (p *Processor) shrinkToFit(img image.Image, size, maxBytes int, encode func(image.Image) ([]byte, error)) ([]byte, image.Image, error) {
+	b := img.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+
+	for range maxFitAttempts {
+		scale := math.Sqrt(float64(maxBytes)/float64(size)) * 0.95
+		w, h = math.Floor(w*scale), math.Floor(h*scale)
+		if w < 1 || h < 1 {
+			break
+		}
+
+		scaled := p.ResizeDimensions(img, int(w), int(h))
+		out, err := encode(scaled)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(out) <= maxBytes {
+			return out, scaled, nil
+		}
+		size = len(out)
+	}
+
+	return nil, nil, fmt.Errorf("image does not fit in %d bytes", maxBytes)
+}
+
+// encodePNG encodes img as PNG with the default compression
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Thumbnail decodes a PNG or JPEG image and returns it as a JPEG whose
+// longest side is at most maxDim. Previews favour speed, so it uses
+// bilinear interpolation rather than Lanczos.
+func (p *Processor) Thumbnail(data []byte, maxDim int) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	b := img.Bounds()
+	if b.Dx() > maxDim || b.Dy() > maxDim {
+		scale := math.Min(float64(maxDim)/float64(b.Dx()), float64(maxDim)/float64(b.Dy()))
+		w := max(1, int(math.Round(float64(b.Dx())*scale)))
+		h := max(1, int(math.Round(float64(b.Dy())*scale)))
+		img = scaleWith(xdraw.ApproxBiLinear, img, w, h)
+	}
+	return p.EncodeJPEG(img)
+}
@@ -0,0 +1,96 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+func TestResizeDimensions(t *testing.T) {
+	p := NewProcessor(90, 80, false)
+	tests := []struct {
+		name         string
+		w, h         int
+		maxW, maxH   int
+		wantW, wantH int
+	}{
+		{"fits", 500, 400, 1000, 1000, 500, 400},
+		{"width bound", 2000, 1000, 1000, 1000, 1000, 500},
+		{"height bound", 1000, 2000, 1000, 500, 250, 500},
+		{"both bound", 3000, 3000, 1200, 900, 900, 900},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+			got := p.ResizeDimensions(img, tt.maxW, tt.maxH).Bounds()
+			if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+				t.Errorf("got %dx%d, want %dx%d", got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestResizeDimensionsKeepsSolidColor(t *testing.T) {
+	want := color.RGBA{R: 10, G: 120, B: 250, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	draw.Draw(img, img.Bounds(), image.NewUniform(want), image.Point{}, draw.Src)
+
+	got := NewProcessor(90, 80, false).ResizeDimensions(img, 64, 64)
+	if c := color.RGBAModel.Convert(got.At(32, 24)); c != want {
+		t.Errorf("center pixel = %v, want %v", c, want)
+	}
+}
+
+func TestLanczos3(t *testing.T) {
+	if got := lanczos3(0); got != 1 {
+		t.Errorf("lanczos3(0) = %v, want 1", got)
+	}
+	for _, x := range []float64{1, 2, 3, 4} {
+		if got := lanczos3(x); math.Abs(got) > 1e-12 {
+			t.Errorf("lanczos3(%v) = %v, want 0", x, got)
+		}
+	}
+	if lanczos3(0.5) != lanczos3(-0.5) {
+		t.Error("lanczos3 is not symmetric")
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	p := NewProcessor(90, 80, false)
+	tests := []struct {
+		name         string
+		w, h         int
+		wantW, wantH int
+	}{
+		{"landscape", 1024, 768, 256, 192},
+		{"portrait", 600, 1200, 128, 256},
+		{"already small", 100, 50, 100, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encodePNG(image.NewRGBA(image.Rect(0, 0, tt.w, tt.h)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			thumb, err := p.Thumbnail(data, 256)
+			if err != nil {
+				t.Fatalf("Thumbnail: %v", err)
+			}
+			img, err := Decode(thumb)
+			if err != nil {
+				t.Fatalf("decode thumbnail: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Errorf("got %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestThumbnailRejectsGarbage(t *testing.T) {
+	if _, err := NewProcessor(90, 80, false).Thumbnail([]byte("not an image"), 256); err == nil {
+		t.Error("expected an error for undecodable data")
+	}
+}
@@ -0,0 +1,144 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image/png"
+	"strconv"
+)
+
+// pngSignature starts every PNG file
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// parametersKeyword is the text chunk keyword AUTOMATIC1111 uses for
+// generation parameters, which most image viewers and UIs understand
+const parametersKeyword = "parameters"
+
+// ihdrChunkSize is the length of the IHDR chunk including its length,
+// type and CRC fields
+const ihdrChunkSize = 4 + 4 + 13 + 4
+
+// EmbedMetadata returns a copy of pngData with the prompt and seed stored
+// in a "parameters" text chunk right after IHDR. A negative seed is left
+// out. The image data itself is not re-encoded.
+func (p *Processor) EmbedMetadata(pngData []byte, prompt string, seed int64) ([]byte, error) {
+	if !bytes.HasPrefix(pngData, pngSignature) {
+		return nil, fmt.Errorf("not a png image")
+	}
+	header := pngData[len(pngSignature):]
+	if len(header) < ihdrChunkSize || string(header[4:8]) != "IHDR" ||
+		binary.BigEndian.Uint32(header[:4]) != 13 {
+		return nil, fmt.Errorf("png is missing IHDR chunk")
+	}
+
+	text := prompt
+	if seed >= 0 {
+		text += "\nSeed: " + strconv.FormatInt(seed, 10)
+	}
+
+	insertAt := len(pngSignature) + ihdrChunkSize
+	var buf bytes.Buffer
+	buf.Grow(len(pngData) + len(text) + 64)
+	buf.Write(pngData[:insertAt])
+	writeTextChunk(&buf, parametersKeyword, text)
+	buf.Write(pngData[insertAt:])
+	return buf.Bytes(), nil
+}
+
+// StripMetadata returns pngData without its tEXt, iTXt and zTXt chunks,
+// which can carry the workflow and prompt ComfyUI embeds. Images with text
+// chunks are decoded and re-encoded, which drops every ancillary chunk;
+// images without any are returned unchanged.
+func (p *Processor) StripMetadata(pngData []byte) ([]byte, error) {
+	hasText, err := hasTextChunks(pngData)
+	if err != nil {
+		return nil, err
+	}
+	if !hasText {
+		return pngData, nil
+	}
+
+	img, err := png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		return nil, fmt.Errorf("decode png: %w", err)
+	}
+	return encodePNG(img)
+}
+
+// hasTextChunks reports whether pngData contains a tEXt, iTXt or zTXt chunk
+func hasTextChunks(pngData []byte) (bool, error) {
+	if !bytes.HasPrefix(pngData, pngSignature) {
+		return false, fmt.Errorf("not a png image")
+	}
+	rest := pngData[len(pngSignature):]
+	for len(rest) >= 12 {
+		length := binary.BigEndian.Uint32(rest[:4])
+		switch string(rest[4:8]) {
+		case "tEXt", "iTXt", "zTXt":
+			return true, nil
+		case "IEND":
+			return false, nil
+		}
+		// Length, type, data and CRC
+		if uint64(length)+12 > uint64(len(rest)) {
+			return false, fmt.Errorf("png chunk overruns image")
+		}
+		rest = rest[length+12:]
+	}
+	return false, fmt.Errorf("png is missing IEND chunk")
+}
+
+// AddMetadata embeds the prompt and seed into a copy of the original and
+// stores it in the result
+func (p *Processor) AddMetadata(r *Result, prompt string, seed int64) error {
+	data, err := p.EmbedMetadata(r.Original, prompt, seed)
+	if err != nil {
+		return err
+	}
+	r.OriginalWithMetadata = data
+	return nil
+}
+
+// writeTextChunk writes keyword and text as a tEXt chunk, or as an
+// uncompressed iTXt chunk if text cannot be represented in Latin-1
+func writeTextChunk(buf *bytes.Buffer, keyword, text string) {
+	var data []byte
+	chunkType := "tEXt"
+	if latin1, ok := toLatin1(text); ok {
+		data = append([]byte(keyword+"\x00"), latin1...)
+	} else {
+		// Compression flag and method, then empty language and
+		// translated keyword
+		chunkType = "iTXt"
+		data = append([]byte(keyword+"\x00\x00\x00\x00\x00"), text...)
+	}
+
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	buf.Write(length[:])
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(data)
+	buf.WriteString(chunkType)
+	buf.Write(data)
+
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	buf.Write(sum[:])
+}
+
+// toLatin1 encodes s as Latin-1, reporting false if it has characters
+// outside that range
+func toLatin1(s string) ([]byte, bool) {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, false
+		}
+		out = append(out, byte(r))
+	}
+	return out, true
+}
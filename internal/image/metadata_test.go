@@ -0,0 +1,119 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// testPNG encodes a small opaque gradient without any text chunks
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := range 8 {
+		for x := range 8 {
+			img.Set(x, y, color.RGBA{R: uint8(x * 32), G: uint8(y * 32), B: 100, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withChunk inserts a chunk of the given type right after IHDR
+func withChunk(pngData []byte, chunkType string, data []byte) []byte {
+	insertAt := len(pngSignature) + ihdrChunkSize
+	var buf bytes.Buffer
+	buf.Write(pngData[:insertAt])
+	binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+	buf.WriteString(chunkType)
+	buf.Write(data)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(chunkType), data...)))
+	buf.Write(pngData[insertAt:])
+	return buf.Bytes()
+}
+
+func TestStripMetadataRemovesText(t *testing.T) {
+	p := NewProcessor(90, 80, false)
+	plain := testPNG(t)
+
+	latin1, err := p.EmbedMetadata(plain, "a cat in a hat", 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unicode, err := p.EmbedMetadata(plain, "кот в шляпе", 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"tEXt", latin1},
+		{"iTXt", unicode},
+		{"zTXt", withChunk(plain, "zTXt", []byte("prompt\x00\x00x\x9c\x03\x00\x00\x00\x00\x01"))},
+		{"ComfyUI workflow", withChunk(withChunk(plain, "tEXt", []byte("prompt\x00{}")), "tEXt", []byte("workflow\x00{}"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if has, err := hasTextChunks(tt.data); err != nil || !has {
+				t.Fatalf("input hasTextChunks = %v, %v, want true", has, err)
+			}
+
+			out, err := p.StripMetadata(tt.data)
+			if err != nil {
+				t.Fatalf("StripMetadata: %v", err)
+			}
+			if has, err := hasTextChunks(out); err != nil || has {
+				t.Errorf("output hasTextChunks = %v, %v, want false", has, err)
+			}
+
+			want, _ := png.Decode(bytes.NewReader(plain))
+			got, err := png.Decode(bytes.NewReader(out))
+			if err != nil {
+				t.Fatalf("decode stripped png: %v", err)
+			}
+			for y := range 8 {
+				for x := range 8 {
+					if want.At(x, y) != got.At(x, y) {
+						t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got.At(x, y), want.At(x, y))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestStripMetadataWithoutTextUnchanged(t *testing.T) {
+	plain := testPNG(t)
+	out, err := NewProcessor(90, 80, false).StripMetadata(plain)
+	if err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	if !bytes.Equal(out, plain) {
+		t.Error("png without text chunks was re-encoded")
+	}
+}
+
+func TestStripMetadataRejectsInvalid(t *testing.T) {
+	p := NewProcessor(90, 80, false)
+	plain := testPNG(t)
+
+	for name, data := range map[string][]byte{
+		"not a png":     []byte("GIF89a"),
+		"truncated":     plain[:len(plain)/2],
+		"missing IEND":  plain[:len(plain)-12],
+		"chunk overrun": append(bytes.Clone(pngSignature), "\x00\x00\xff\xffIHDR\x00\x00\x00\x00"...),
+	} {
+		if _, err := p.StripMetadata(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -4,20 +4,38 @@ import (
 	"bytes"
 	"fmt"
 	"image"
+	"image/draw"
 	"image/jpeg"
 	"image/png"
+	"sync/atomic"
 )
 
 // Processor handles image format conversions
 type Processor struct {
-	jpegQuality int
+	// jpegQuality can be changed with SetQuality while images are processed
+	jpegQuality   atomic.Int64
+	webpQuality   int
+	preserve16bit bool
+
+	// Upload limits set by SetUploadLimits (0 = no limit)
+	maxPhotoBytes    int
+	maxDocumentBytes int
 }
 
-// NewProcessor creates a new image processor
-func NewProcessor(jpegQuality int) *Processor {
-	return &Processor{
-		jpegQuality: jpegQuality,
+// NewProcessor creates a new image processor. With preserve16bit set,
+// 16-bit images skip JPEG compression and are passed through unchanged.
+func NewProcessor(jpegQuality, webpQuality int, preserve16bit bool) *Processor {
+	p := &Processor{
+		webpQuality:   webpQuality,
+		preserve16bit: preserve16bit,
 	}
+	p.jpegQuality.Store(int64(jpegQuality))
+	return p
+}
+
+// SetQuality changes the JPEG quality used for images processed from now on
+func (p *Processor) SetQuality(q int) {
+	p.jpegQuality.Store(int64(q))
 }
 
 // Result contains both image versions
@@ -26,41 +44,247 @@ type Result struct {
 	Compressed     []byte
 	OriginalSize   int
 	CompressedSize int
+
+	// Dimensions of the original and of the compressed version, which is
+	// downscaled to fit Telegram's photo limit
+	Width            int
+	Height           int
+	CompressedWidth  int
+	CompressedHeight int
+
+	// Resized is set when the original or the compressed version was
+	// shrunk to fit the upload limits; the dimensions above are the final
+	// ones
+	Resized bool
+
+	// Passthrough16Bit is set when a 16-bit PNG was kept as-is, so
+	// Compressed holds the same PNG bytes as Original
+	Passthrough16Bit bool
+
+	// CompressedWebP is a lossless WebP of the compressed version, set by
+	// AddWebP only if it is smaller than Compressed
+	CompressedWebP     []byte
+	CompressedWebPSize int
+
+	// preview is the decoded image Compressed was encoded from
+	preview image.Image
+
+	// OriginalWithMetadata is the original with the prompt embedded, set
+	// by AddMetadata
+	OriginalWithMetadata []byte
 }
 
-// Process takes PNG data and returns both original and compressed versions
+// OriginalDocument returns the original to send as a document, preferring
+// the copy with embedded metadata
+func (r *Result) OriginalDocument() []byte {
+	if len(r.OriginalWithMetadata) > 0 {
+		return r.OriginalWithMetadata
+	}
+	return r.Original
+}
+
+// CompressedFilename returns a file name matching the compressed format
+func (r *Result) CompressedFilename() string {
+	if r.Passthrough16Bit {
+		return "image.png"
+	}
+	return "image.jpg"
+}
+
+// Downscaled reports whether the compressed version is smaller than the original
+func (r *Result) Downscaled() bool {
+	return r.CompressedWidth != r.Width || r.CompressedHeight != r.Height
+}
+
+// Process takes PNG data and returns both original and compressed versions.
+// The original is kept untouched unless it exceeds the document upload
+// limit; the compressed JPEG is downscaled if it exceeds MaxPhotoDimension
+// or the photo upload limit.
 func (p *Processor) Process(pngData []byte) (*Result, error) {
-	compressed, err := p.CompressToJPEG(pngData)
+	img, err := decodePNG(pngData)
 	if err != nil {
 		return nil, err
 	}
 
+	original, resized := pngData, false
+	if p.maxDocumentBytes > 0 && len(original) > p.maxDocumentBytes {
+		original, img, err = p.shrinkToFit(img, len(original), p.maxDocumentBytes, encodePNG)
+		if err != nil {
+			return nil, fmt.Errorf("fit original to upload limit: %w", err)
+		}
+		resized = true
+	}
+
+	// 16-bit images too large to send as a photo fall through to JPEG
+	if p.preserve16bit && is16Bit(img) && (p.maxPhotoBytes <= 0 || len(original) <= p.maxPhotoBytes) {
+		return &Result{
+			Original:         original,
+			Compressed:       original,
+			OriginalSize:     len(original),
+			CompressedSize:   len(original),
+			Width:            img.Bounds().Dx(),
+			Height:           img.Bounds().Dy(),
+			CompressedWidth:  img.Bounds().Dx(),
+			CompressedHeight: img.Bounds().Dy(),
+			Passthrough16Bit: true,
+			Resized:          resized,
+			preview:          img,
+		}, nil
+	}
+
+	scaled := DownscaleIfNeeded(img, MaxPhotoDimension)
+
+	compressed, err := p.EncodeJPEG(scaled)
+	if err != nil {
+		return nil, err
+	}
+	if p.maxPhotoBytes > 0 && len(compressed) > p.maxPhotoBytes {
+		compressed, scaled, err = p.shrinkToFit(scaled, len(compressed), p.maxPhotoBytes, p.EncodeJPEG)
+		if err != nil {
+			return nil, fmt.Errorf("fit photo to upload limit: %w", err)
+		}
+		resized = true
+	}
+
 	return &Result{
-		Original:       pngData,
-		Compressed:     compressed,
-		OriginalSize:   len(pngData),
-		CompressedSize: len(compressed),
+		Original:         original,
+		Compressed:       compressed,
+		OriginalSize:     len(original),
+		CompressedSize:   len(compressed),
+		Width:            img.Bounds().Dx(),
+		Height:           img.Bounds().Dy(),
+		CompressedWidth:  scaled.Bounds().Dx(),
+		CompressedHeight: scaled.Bounds().Dy(),
+		Resized:          resized,
+		preview:          scaled,
 	}, nil
 }
 
+// AddWebP encodes the same downscaled image as the compressed version as a
+// lossless WebP, and stores it in the result only if it is smaller than
+// Compressed. That is typical for flat artwork; photographic renders
+// usually stay smaller as JPEG.
+func (p *Processor) AddWebP(r *Result) error {
+	if r.preview == nil {
+		return fmt.Errorf("encode webp: result has no preview image")
+	}
+	webp, err := encodeWebP(r.preview, p.webpQuality)
+	if err != nil {
+		return err
+	}
+	if len(webp) >= r.CompressedSize {
+		return nil
+	}
+	r.CompressedWebP = webp
+	r.CompressedWebPSize = len(webp)
+	return nil
+}
+
 // CompressToJPEG converts PNG bytes to JPEG with configured quality
 func (p *Processor) CompressToJPEG(pngData []byte) ([]byte, error) {
-	// Decode PNG
-	img, err := png.Decode(bytes.NewReader(pngData))
+	img, err := decodePNG(pngData)
+	if err != nil {
+		return nil, err
+	}
+	return p.EncodeJPEG(img)
+}
+
+// is16Bit reports whether img uses 16 bits per channel
+func is16Bit(img image.Image) bool {
+	switch img.(type) {
+	case *image.RGBA64, *image.NRGBA64, *image.Gray16:
+		return true
+	}
+	return false
+}
+
+// decodePNG decodes PNG data, falling back to any registered format
+func decodePNG(data []byte) (image.Image, error) {
+	img, err := png.Decode(bytes.NewReader(data))
 	if err != nil {
 		// Try generic decode in case it's not strictly PNG
-		img, _, err = image.Decode(bytes.NewReader(pngData))
+		img, _, err = image.Decode(bytes.NewReader(data))
 		if err != nil {
 			return nil, fmt.Errorf("decode image: %w", err)
 		}
 	}
+	return img, nil
+}
+
+// CreateSideBySide concatenates two images horizontally for before/after
+// comparisons. The taller image is scaled down to the smaller height.
+func (p *Processor) CreateSideBySide(left, right image.Image) (image.Image, error) {
+	if left == nil || right == nil {
+		return nil, fmt.Errorf("both images are required")
+	}
+
+	lb, rb := left.Bounds(), right.Bounds()
+	if lb.Empty() || rb.Empty() {
+		return nil, fmt.Errorf("cannot compare empty images")
+	}
+
+	height := min(lb.Dy(), rb.Dy())
+	leftWidth := lb.Dx() * height / lb.Dy()
+	rightWidth := rb.Dx() * height / rb.Dy()
+
+	if lb.Dy() != height {
+		left = resize(left, leftWidth, height)
+	}
+	if rb.Dy() != height {
+		right = resize(right, rightWidth, height)
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, leftWidth+rightWidth, height))
+	draw.Draw(out, image.Rect(0, 0, leftWidth, height), left, left.Bounds().Min, draw.Src)
+	draw.Draw(out, image.Rect(leftWidth, 0, leftWidth+rightWidth, height), right, right.Bounds().Min, draw.Src)
+
+	return out, nil
+}
 
-	// Encode as JPEG
+// EncodeJPEG encodes an image as JPEG with the configured quality.
+// Transparent images are flattened onto white first.
+func (p *Processor) EncodeJPEG(img image.Image) ([]byte, error) {
+	img = flattenAlpha(img)
 	var buf bytes.Buffer
-	opts := &jpeg.Options{Quality: p.jpegQuality}
-	if err := jpeg.Encode(&buf, img, opts); err != nil {
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: int(p.jpegQuality.Load())}); err != nil {
 		return nil, fmt.Errorf("encode jpeg: %w", err)
 	}
-
 	return buf.Bytes(), nil
 }
+
+// flattenAlpha composites transparent RGBA and palette images onto a white
+// background. JPEG has no alpha channel, so encoding them directly turns
+// transparent areas black or garbles them.
+func flattenAlpha(img image.Image) image.Image {
+	switch src := img.(type) {
+	case *image.RGBA:
+		if src.Opaque() {
+			return img
+		}
+	case *image.NRGBA:
+		if src.Opaque() {
+			return img
+		}
+	case *image.Paletted:
+		if src.Opaque() {
+			return img
+		}
+	default:
+		return img
+	}
+
+	b := img.Bounds()
+	out := image.NewRGBA(b)
+	draw.Draw(out, b, image.White, image.Point{}, draw.Src)
+	draw.Draw(out, b, img, b.Min, draw.Over)
+	return out
+}
+
+// Decode decodes PNG, JPEG or any other registered image format
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	return img, nil
+}
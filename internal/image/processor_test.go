@@ -0,0 +1,102 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// pngDecoded round-trips img through PNG so the test sees the image type
+// the decoder produces for it
+func pngDecoded(t *testing.T, img image.Image) image.Image {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	decoded, err := Decode(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+// rgbAt returns the 8-bit RGB values and alpha at (x, y)
+func rgbAt(img image.Image, x, y int) (r, g, b, a uint8) {
+	c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+	return c.R, c.G, c.B, c.A
+}
+
+func TestFlattenAlpha(t *testing.T) {
+	// Left pixel fully transparent, right pixel half-transparent red
+	rgba := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	rgba.SetNRGBA(1, 0, color.NRGBA{R: 255, A: 128})
+
+	palette := image.NewPaletted(image.Rect(0, 0, 2, 1), color.Palette{
+		color.NRGBA{},
+		color.NRGBA{R: 255, A: 128},
+	})
+	palette.SetColorIndex(1, 0, 1)
+
+	opaque := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	opaque.Set(0, 0, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+	opaque.Set(1, 0, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+
+	tests := []struct {
+		name      string
+		img       image.Image
+		wantType  string
+		unchanged bool
+		want      [2][3]uint8
+	}{
+		{"RGBA PNG", pngDecoded(t, rgba), "*image.NRGBA", false, [2][3]uint8{{255, 255, 255}, {255, 127, 127}}},
+		{"palette PNG", pngDecoded(t, palette), "*image.Paletted", false, [2][3]uint8{{255, 255, 255}, {255, 127, 127}}},
+		{"RGB PNG", pngDecoded(t, opaque), "*image.RGBA", true, [2][3]uint8{{10, 20, 30}, {200, 100, 50}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprintf("%T", tt.img); got != tt.wantType {
+				t.Fatalf("PNG decoded as %s, want %s", got, tt.wantType)
+			}
+
+			out := flattenAlpha(tt.img)
+			if tt.unchanged && out != tt.img {
+				t.Error("opaque image was copied")
+			}
+			for x, want := range tt.want {
+				r, g, b, a := rgbAt(out, x, 0)
+				if a != 255 || absDiff(r, want[0]) > 1 || absDiff(g, want[1]) > 1 || absDiff(b, want[2]) > 1 {
+					t.Errorf("pixel %d = (%d, %d, %d, %d), want %v opaque", x, r, g, b, a, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeJPEGFlattensTransparency(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16)) // fully transparent
+
+	data, err := NewProcessor(90, 80, false).EncodeJPEG(img)
+	if err != nil {
+		t.Fatalf("EncodeJPEG: %v", err)
+	}
+	decoded, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := rgbAt(decoded, 8, 8); r < 250 || g < 250 || b < 250 {
+		t.Errorf("transparent area encoded as (%d, %d, %d), want white", r, g, b)
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,83 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// resize scales img to width x height using bilinear interpolation
+func resize(img image.Image, width, height int) *image.RGBA {
+	src := toRGBA(img)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	if sw == 0 || sh == 0 || width == 0 || height == 0 {
+		return dst
+	}
+
+	xRatio := float64(sw) / float64(width)
+	yRatio := float64(sh) / float64(height)
+
+	for y := 0; y < height; y++ {
+		fy := (float64(y)+0.5)*yRatio - 0.5
+		y0 := clamp(int(fy), 0, sh-1)
+		y1 := clamp(y0+1, 0, sh-1)
+		wy := fy - float64(y0)
+		if wy < 0 {
+			wy = 0
+		}
+
+		for x := 0; x < width; x++ {
+			fx := (float64(x)+0.5)*xRatio - 0.5
+			x0 := clamp(int(fx), 0, sw-1)
+			x1 := clamp(x0+1, 0, sw-1)
+			wx := fx - float64(x0)
+			if wx < 0 {
+				wx = 0
+			}
+
+			c00 := src.RGBAAt(sb.Min.X+x0, sb.Min.Y+y0)
+			c10 := src.RGBAAt(sb.Min.X+x1, sb.Min.Y+y0)
+			c01 := src.RGBAAt(sb.Min.X+x0, sb.Min.Y+y1)
+			c11 := src.RGBAAt(sb.Min.X+x1, sb.Min.Y+y1)
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: lerp2(c00.R, c10.R, c01.R, c11.R, wx, wy),
+				G: lerp2(c00.G, c10.G, c01.G, c11.G, wx, wy),
+				B: lerp2(c00.B, c10.B, c01.B, c11.B, wx, wy),
+				A: lerp2(c00.A, c10.A, c01.A, c11.A, wx, wy),
+			})
+		}
+	}
+
+	return dst
+}
+
+// toRGBA returns img as *image.RGBA, converting if necessary
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(b)
+	draw.Draw(rgba, b, img, b.Min, draw.Src)
+	return rgba
+}
+
+func lerp2(c00, c10, c01, c11 uint8, wx, wy float64) uint8 {
+	top := float64(c00)*(1-wx) + float64(c10)*wx
+	bottom := float64(c01)*(1-wx) + float64(c11)*wx
+	return uint8(top*(1-wy) + bottom*wy + 0.5)
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
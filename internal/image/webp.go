@@ -0,0 +1,51 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+
+	"github.com/HugoSmits86/nativewebp"
+)
+
+// MaxWebPDimension is the largest width or height WebP can store
+const MaxWebPDimension = 1 << 14
+
+// EncodeWebP converts PNG bytes to a lossless WebP. effort (0-100) trades
+// encoding speed for file size.
+func (p *Processor) EncodeWebP(pngData []byte, effort int) ([]byte, error) {
+	img, err := decodePNG(pngData)
+	if err != nil {
+		return nil, err
+	}
+	return encodeWebP(img, effort)
+}
+
+// encodeWebP encodes img as a lossless (VP8L) WebP
+func encodeWebP(img image.Image, effort int) ([]byte, error) {
+	b := img.Bounds()
+	if b.Dx() < 1 || b.Dy() < 1 {
+		return nil, fmt.Errorf("encode webp: empty image")
+	}
+	if b.Dx() > MaxWebPDimension || b.Dy() > MaxWebPDimension {
+		return nil, fmt.Errorf("encode webp: %dx%d exceeds %d pixels", b.Dx(), b.Dy(), MaxWebPDimension)
+	}
+
+	var buf bytes.Buffer
+	if err := nativewebp.Encode(&buf, img, &nativewebp.Options{CompressionLevel: webpCompressionLevel(effort)}); err != nil {
+		return nil, fmt.Errorf("encode webp: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// webpCompressionLevel maps an effort of 0-100 onto the encoder's levels
+func webpCompressionLevel(effort int) nativewebp.CompressionLevel {
+	switch {
+	case effort < 34:
+		return nativewebp.BestSpeed
+	case effort < 67:
+		return nativewebp.DefaultCompression
+	default:
+		return nativewebp.BestCompression
+	}
+}
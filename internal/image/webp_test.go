@@ -0,0 +1,201 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func gradientImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 255 / w), G: uint8(y * 255 / h), B: uint8((x + y) % 256), A: 255})
+		}
+	}
+	return img
+}
+
+func noiseImage(w, h int, alpha bool) *image.NRGBA {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	rng.Read(img.Pix)
+	if !alpha {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 255
+		}
+	}
+	return img
+}
+
+// repeatedImage has long runs and repeated rows so backward references are
+// exercised
+func repeatedImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{R: 200, G: 30, B: 90, A: 255}
+			if (x/7+y/5)%2 == 0 {
+				c = color.NRGBA{R: 10, G: 220, B: 40, A: 128}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		img  *image.NRGBA
+	}{
+		{"single pixel", gradientImage(1, 1)},
+		{"gradient", gradientImage(97, 61)},
+		{"noise", noiseImage(64, 48, false)},
+		{"noise with alpha", noiseImage(33, 17, true)},
+		{"repeated", repeatedImage(128, 96)},
+		{"solid", repeatedImage(1, 200)},
+	}
+	for _, tt := range tests {
+		for _, effort := range []int{0, 50, 100} {
+			t.Run(fmt.Sprintf("%s/effort%d", tt.name, effort), func(t *testing.T) {
+				data, err := encodeWebP(tt.img, effort)
+				if err != nil {
+					t.Fatalf("encodeWebP(effort=%d): %v", effort, err)
+				}
+				got, err := webp.Decode(bytes.NewReader(data))
+				if err != nil {
+					t.Fatalf("decode (effort=%d): %v", effort, err)
+				}
+				assertSamePixels(t, tt.img, got)
+			})
+		}
+	}
+}
+
+func TestProcessorEncodeWebP(t *testing.T) {
+	src := gradientImage(40, 30)
+	pngData, err := encodePNG(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := NewProcessor(90, 80, false).EncodeWebP(pngData, 80)
+	if err != nil {
+		t.Fatalf("EncodeWebP: %v", err)
+	}
+	cfg, err := webp.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeConfig: %v", err)
+	}
+	if cfg.Width != 40 || cfg.Height != 30 {
+		t.Errorf("got %dx%d, want 40x30", cfg.Width, cfg.Height)
+	}
+	got, err := webp.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	assertSamePixels(t, src, got)
+}
+
+func TestEncodeWebPRejectsOversizedImages(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, MaxWebPDimension+1, 1))
+	if _, err := encodeWebP(img, 80); err == nil {
+		t.Error("expected an error for an image wider than MaxWebPDimension")
+	}
+}
+
+func TestAddWebPOnlyWhenSmaller(t *testing.T) {
+	p := NewProcessor(90, 80, false)
+	tests := []struct {
+		name     string
+		img      *image.NRGBA
+		wantWebP bool
+	}{
+		// Flat artwork compresses far better losslessly than as JPEG
+		{"flat", repeatedImage(512, 512), true},
+		// Noise is incompressible without loss, so the JPEG wins
+		{"noise", noiseImage(256, 256, false), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pngData, err := encodePNG(tt.img)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := p.Process(pngData)
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			if err := p.AddWebP(r); err != nil {
+				t.Fatalf("AddWebP: %v", err)
+			}
+
+			if got := len(r.CompressedWebP) > 0; got != tt.wantWebP {
+				t.Fatalf("webp set = %v (webp %d bytes, jpeg %d bytes), want %v",
+					got, r.CompressedWebPSize, r.CompressedSize, tt.wantWebP)
+			}
+			if !tt.wantWebP {
+				return
+			}
+			if r.CompressedWebPSize >= r.CompressedSize {
+				t.Errorf("webp %d bytes not smaller than jpeg %d bytes", r.CompressedWebPSize, r.CompressedSize)
+			}
+			cfg, err := webp.DecodeConfig(bytes.NewReader(r.CompressedWebP))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != r.CompressedWidth || cfg.Height != r.CompressedHeight {
+				t.Errorf("webp is %dx%d, want the preview's %dx%d", cfg.Width, cfg.Height, r.CompressedWidth, r.CompressedHeight)
+			}
+		})
+	}
+}
+
+func TestAddWebPUsesPreview(t *testing.T) {
+	// A preview smaller than the original, as after downscaling for
+	// Telegram's photo limit
+	p := NewProcessor(90, 80, false)
+	r := &Result{
+		Original:         []byte("not decoded"),
+		CompressedSize:   1 << 30,
+		CompressedWidth:  64,
+		CompressedHeight: 32,
+		preview:          repeatedImage(64, 32),
+	}
+	if err := p.AddWebP(r); err != nil {
+		t.Fatalf("AddWebP: %v", err)
+	}
+	cfg, err := webp.DecodeConfig(bytes.NewReader(r.CompressedWebP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 64 || cfg.Height != 32 {
+		t.Errorf("webp is %dx%d, want the 64x32 preview", cfg.Width, cfg.Height)
+	}
+}
+
+func assertSamePixels(t *testing.T, want *image.NRGBA, got image.Image) {
+	t.Helper()
+	if got.Bounds().Size() != want.Bounds().Size() {
+		t.Fatalf("got size %v, want %v", got.Bounds().Size(), want.Bounds().Size())
+	}
+	wb, gb := want.Bounds(), got.Bounds()
+	for y := 0; y < wb.Dy(); y++ {
+		for x := 0; x < wb.Dx(); x++ {
+			w := want.NRGBAAt(wb.Min.X+x, wb.Min.Y+y)
+			g := color.NRGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y)).(color.NRGBA)
+			// Fully transparent pixels may carry any colour
+			if w.A == 0 && g.A == 0 {
+				continue
+			}
+			if w != g {
+				t.Fatalf("pixel (%d,%d): got %v, want %v", x, y, g, w)
+			}
+		}
+	}
+}
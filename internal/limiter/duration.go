@@ -0,0 +1,54 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// DurationTracker keeps a rolling average of recent generation durations,
+// used to estimate how long queued requests will wait
+type DurationTracker struct {
+	mu        sync.Mutex
+	durations []time.Duration
+	// next is the index the next duration overwrites once the window is full
+	next int
+	size int
+}
+
+// NewDurationTracker creates a tracker averaging the last size durations
+func NewDurationTracker(size int) *DurationTracker {
+	return &DurationTracker{
+		durations: make([]time.Duration, 0, size),
+		size:      size,
+	}
+}
+
+// Record adds a completed generation's duration, dropping the oldest one
+// once the window is full
+func (t *DurationTracker) Record(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.durations) < t.size {
+		t.durations = append(t.durations, d)
+		return
+	}
+	t.durations[t.next] = d
+	t.next = (t.next + 1) % t.size
+}
+
+// Average returns the mean of the recorded durations, or 0 if none have
+// been recorded yet
+func (t *DurationTracker) Average() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.durations) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range t.durations {
+		total += d
+	}
+	return total / time.Duration(len(t.durations))
+}
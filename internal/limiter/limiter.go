@@ -1,63 +1,372 @@
 package limiter
 
 import (
+	"context"
+	"fmt"
 	"sync"
+	"time"
+
+	apperrors "comfy-tg-bot/internal/errors"
 )
 
+// grantRetryInterval is how long queued requests wait before slots are
+// offered again after the memory guard turned them away
+const grantRetryInterval = time.Second
+
+// Limiter decides whether a user may start a generation. Every successful
+// TryAcquire or WaitOrAcquire must be paired with a Release.
+type Limiter interface {
+	TryAcquire(userID int64) error
+	// CheckCooldown returns ErrCooldown if the user may not start a
+	// generation yet because of their rate limit, without acquiring
+	CheckCooldown(userID int64) error
+	WaitOrAcquire(ctx context.Context, userID, chatID int64) error
+	Release(userID int64)
+	ActiveCount() int
+	QueuedCount() int
+}
+
 // UserLimiter limits concurrent requests per user
 type UserLimiter struct {
 	mu          sync.Mutex
 	activeUsers map[int64]struct{}
 	maxGlobal   int
 	globalCount int
+	memory      *MemoryGuard
+
+	// cooldownDuration is the minimum time between a user's generations
+	cooldownDuration time.Duration
+	lastCompleted    map[int64]time.Time
+
+	// queue holds requests waiting for a global slot (nil = no queueing)
+	queue  *Queue
+	notify QueueNotifier
+	// grantTimer retries granting queued requests that were held back by
+	// the memory guard or a cooldown (nil = no retry pending)
+	grantTimer *time.Timer
+
+	// exempt users bypass every limit; exemptActive counts their running
+	// generations, which take no global slot
+	exempt       map[int64]struct{}
+	exemptActive map[int64]int
+	exemptCount  int
 }
 
 // NewUserLimiter creates a new user limiter
 // maxGlobalConcurrent of 0 means unlimited global concurrent requests
 func NewUserLimiter(maxGlobalConcurrent int) *UserLimiter {
 	return &UserLimiter{
-		activeUsers: make(map[int64]struct{}),
-		maxGlobal:   maxGlobalConcurrent,
+		activeUsers:   make(map[int64]struct{}),
+		maxGlobal:     maxGlobalConcurrent,
+		lastCompleted: make(map[int64]time.Time),
+		exempt:        make(map[int64]struct{}),
+		exemptActive:  make(map[int64]int),
+	}
+}
+
+// SetCooldown makes users wait d after a generation completes before they
+// can start another (0 disables the cooldown)
+func (l *UserLimiter) SetCooldown(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cooldownDuration = d
+}
+
+// SetQueue lets requests wait for a global slot instead of failing, with at
+// most maxDepth waiting (0 disables queueing)
+func (l *UserLimiter) SetQueue(maxDepth int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if maxDepth > 0 {
+		l.queue = newQueue(maxDepth)
+	} else {
+		l.queue = nil
+	}
+}
+
+// SetQueueNotifier sets the function used to tell users their queue position
+func (l *UserLimiter) SetQueueNotifier(n QueueNotifier) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.notify = n
+}
+
+// SetExempt replaces the users exempt from all limits, e.g. admins. They
+// always acquire immediately, even alongside their own running generations,
+// and take no global slot, but still count in ActiveCount.
+func (l *UserLimiter) SetExempt(userIDs []int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.exempt = make(map[int64]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		l.exempt[id] = struct{}{}
 	}
 }
 
+// IsExempt reports whether a user is exempt from all limits
+func (l *UserLimiter) IsExempt(userID int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.exempt[userID]
+	return ok
+}
+
+// SetMemoryGuard enables load shedding under memory pressure
+func (l *UserLimiter) SetMemoryGuard(g *MemoryGuard) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.memory = g
+}
+
 // TryAcquire attempts to acquire a slot for a user
-// Returns false if user already has an active request or global limit reached
-func (l *UserLimiter) TryAcquire(userID int64) bool {
+// Returns ErrGenerationInProgress if user already has an active or queued
+// request, ErrServerBusy if the global limit is reached, ErrCooldown while
+// the user's cooldown is running, and ErrMemoryPressure while the heap is
+// too large
+func (l *UserLimiter) TryAcquire(userID int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.tryAcquireLocked(userID)
+}
+
+// CheckCooldown returns ErrCooldown while the user's cooldown is running.
+// Exempt users never have one.
+func (l *UserLimiter) CheckCooldown(userID int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.exempt[userID]; ok {
+		return nil
+	}
+	return l.checkCooldown(userID)
+}
+
+// WaitOrAcquire acquires a slot for a user, waiting in the queue when the
+// global limit is reached. The user is told their position via the queue
+// notifier. Returns ErrQueueFull if the queue is at capacity and
+// ErrQueueExpired if ctx ends before a slot opens, or ErrShuttingDown if
+// the queue is closed first; other errors are as for TryAcquire.
+func (l *UserLimiter) WaitOrAcquire(ctx context.Context, userID, chatID int64) error {
+	l.mu.Lock()
+	err := l.tryAcquireLocked(userID)
+	if err != apperrors.ErrServerBusy || l.queue == nil {
+		l.mu.Unlock()
+		return err
+	}
+	if l.queue.full() {
+		l.mu.Unlock()
+		return apperrors.ErrQueueFull
+	}
+
+	w := &waiter{userID: userID, chatID: chatID, ready: make(chan struct{})}
+	position := l.queue.push(w)
+	notify := l.notify
+	l.mu.Unlock()
+
+	if notify != nil {
+		notify(chatID, position)
+	}
+
+	select {
+	case <-w.ready:
+		return w.err
+	case <-ctx.Done():
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if l.queue == nil || !l.queue.remove(w) {
+		// A slot was granted just as ctx ended; hand it on
+		l.releaseLocked(userID, false)
+	}
+	return fmt.Errorf("wait for slot: %w", apperrors.ErrQueueExpired)
+}
 
-	// Check if user already has an active request
+// CloseQueue stops queueing during shutdown: waiting requests fail with
+// ErrShuttingDown and new ones fail with ErrServerBusy when no slot is
+// free. Requests that already hold a slot are not affected.
+func (l *UserLimiter) CloseQueue() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.queue == nil {
+		return
+	}
+	for l.queue.Len() > 0 {
+		w := l.queue.pop()
+		w.err = apperrors.ErrShuttingDown
+		close(w.ready)
+	}
+	l.queue = nil
+}
+
+// tryAcquireLocked implements TryAcquire. Caller must hold l.mu.
+func (l *UserLimiter) tryAcquireLocked(userID int64) error {
+	if _, ok := l.exempt[userID]; ok {
+		l.exemptActive[userID]++
+		l.exemptCount++
+		return nil
+	}
+
+	// Check if user already has an active or queued request
 	if _, exists := l.activeUsers[userID]; exists {
-		return false
+		return apperrors.ErrGenerationInProgress
+	}
+	if l.queue != nil && l.queue.contains(userID) {
+		return apperrors.ErrGenerationInProgress
 	}
 
-	// Check global limit (0 means unlimited)
-	if l.maxGlobal > 0 && l.globalCount >= l.maxGlobal {
-		return false
+	if err := l.checkCooldown(userID); err != nil {
+		return err
+	}
+
+	// Check global limit (0 means unlimited); queued requests go first
+	if !l.hasFreeSlot() || (l.queue != nil && l.queue.Len() > 0) {
+		return apperrors.ErrServerBusy
+	}
+
+	if l.memory != nil {
+		if err := l.memory.Check(); err != nil {
+			return err
+		}
 	}
 
 	l.activeUsers[userID] = struct{}{}
 	l.globalCount++
-	return true
+	return nil
 }
 
 // Release releases a user's slot
 func (l *UserLimiter) Release(userID int64) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.releaseLocked(userID, true)
+}
 
-	if _, exists := l.activeUsers[userID]; exists {
-		delete(l.activeUsers, userID)
-		l.globalCount--
+// releaseLocked frees a user's slot and grants free slots to queued
+// requests in order. Caller must hold l.mu.
+func (l *UserLimiter) releaseLocked(userID int64, completed bool) {
+	// Checked before the exempt set, which may have changed since the
+	// slot was acquired
+	if l.exemptActive[userID] > 0 {
+		l.exemptActive[userID]--
+		if l.exemptActive[userID] == 0 {
+			delete(l.exemptActive, userID)
+		}
+		l.exemptCount--
+		return
+	}
+
+	if _, exists := l.activeUsers[userID]; !exists {
+		return
+	}
+
+	delete(l.activeUsers, userID)
+	l.globalCount--
+	if completed && l.cooldownDuration > 0 {
+		l.lastCompleted[userID] = time.Now()
+	}
+
+	l.grantLocked()
+}
+
+// grantLocked hands free slots to queued requests in order. While the
+// memory guard is tripped nothing is granted, and a request whose user is
+// still cooling down is skipped; both stay queued and a retry is scheduled
+// so they are not stranded when no running generation is left to release.
+// Caller must hold l.mu.
+func (l *UserLimiter) grantLocked() {
+	if l.queue == nil {
+		return
 	}
+
+	var retry time.Duration
+	for i := 0; i < l.queue.Len() && l.hasFreeSlot(); {
+		if l.memory != nil && l.memory.Check() != nil {
+			retry = grantRetryInterval
+			break
+		}
+
+		w := l.queue.waiters[i]
+		if wait := l.cooldownRemaining(w.userID); wait > 0 {
+			if retry == 0 || wait < retry {
+				retry = wait
+			}
+			i++
+			continue
+		}
+
+		l.queue.removeAt(i)
+		l.activeUsers[w.userID] = struct{}{}
+		l.globalCount++
+		close(w.ready)
+	}
+
+	if retry > 0 && l.grantTimer == nil {
+		l.grantTimer = time.AfterFunc(retry, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.grantTimer = nil
+			l.grantLocked()
+		})
+	}
+}
+
+// hasFreeSlot reports whether the global limit allows another generation.
+// Caller must hold l.mu.
+func (l *UserLimiter) hasFreeSlot() bool {
+	return l.maxGlobal <= 0 || l.globalCount < l.maxGlobal
 }
 
-// ActiveCount returns current active generation count
+// checkCooldown returns ErrCooldown with the remaining wait if the user
+// completed a generation too recently. Caller must hold l.mu.
+func (l *UserLimiter) checkCooldown(userID int64) error {
+	remaining := l.cooldownRemaining(userID)
+	if remaining <= 0 {
+		return nil
+	}
+
+	seconds := int(remaining.Round(time.Second).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	return apperrors.Wrap(apperrors.ErrCooldown,
+		fmt.Sprintf("Please wait %ds before starting another generation.", seconds), true)
+}
+
+// cooldownRemaining returns how long the user must still wait before
+// starting another generation, or 0. Caller must hold l.mu.
+func (l *UserLimiter) cooldownRemaining(userID int64) time.Duration {
+	last, ok := l.lastCompleted[userID]
+	if !ok {
+		return 0
+	}
+
+	remaining := l.cooldownDuration - time.Since(last)
+	if remaining <= 0 {
+		delete(l.lastCompleted, userID)
+		return 0
+	}
+	return remaining
+}
+
+// ActiveCount returns current active generation count, including exempt
+// users' generations
 func (l *UserLimiter) ActiveCount() int {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	return l.globalCount
+	return l.globalCount + l.exemptCount
+}
+
+// QueuedCount returns the number of requests waiting for a slot
+func (l *UserLimiter) QueuedCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.queue == nil {
+		return 0
+	}
+	return l.queue.Len()
 }
 
 // IsUserActive checks if a user has an active request
@@ -65,5 +374,5 @@ func (l *UserLimiter) IsUserActive(userID int64) bool {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	_, exists := l.activeUsers[userID]
-	return exists
+	return exists || l.exemptActive[userID] > 0
 }
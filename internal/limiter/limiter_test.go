@@ -0,0 +1,178 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apperrors "comfy-tg-bot/internal/errors"
+)
+
+// waitAsync starts WaitOrAcquire in the background and returns its result
+// channel once the request is queued
+func waitAsync(t *testing.T, l *UserLimiter, userID int64) <-chan error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		done <- l.WaitOrAcquire(context.Background(), userID, userID)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for l.QueuedCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("user %d was never queued", userID)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return done
+}
+
+func assertPending(t *testing.T, done <-chan error, within time.Duration) {
+	t.Helper()
+	select {
+	case err := <-done:
+		t.Fatalf("waiter granted early: %v", err)
+	case <-time.After(within):
+	}
+}
+
+func assertGranted(t *testing.T, done <-chan error, within time.Duration) {
+	t.Helper()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("WaitOrAcquire: %v", err)
+		}
+	case <-time.After(within):
+		t.Fatal("waiter was never granted a slot")
+	}
+}
+
+func TestReleaseHoldsQueueUnderMemoryPressure(t *testing.T) {
+	var heap atomic.Uint64
+	guard := NewMemoryGuard(100, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	guard.readHeap = heap.Load
+
+	l := NewUserLimiter(1)
+	l.SetQueue(5)
+	l.SetMemoryGuard(guard)
+
+	if err := l.TryAcquire(1); err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	done := waitAsync(t, l, 2)
+
+	heap.Store(200 * 1024 * 1024)
+	l.Release(1)
+	assertPending(t, done, 50*time.Millisecond)
+	if got := l.QueuedCount(); got != 1 {
+		t.Fatalf("QueuedCount = %d, want 1", got)
+	}
+
+	heap.Store(0)
+	assertGranted(t, done, 2*grantRetryInterval)
+	if !l.IsUserActive(2) {
+		t.Error("user 2 should hold the slot")
+	}
+}
+
+func TestReleaseSkipsQueuedUserInCooldown(t *testing.T) {
+	l := NewUserLimiter(1)
+	l.SetQueue(5)
+	l.SetCooldown(100 * time.Millisecond)
+
+	if err := l.TryAcquire(1); err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	cooling := waitAsync(t, l, 2)
+	ready := make(chan error, 1)
+	go func() {
+		ready <- l.WaitOrAcquire(context.Background(), 3, 3)
+	}()
+	for l.QueuedCount() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// User 2 finished a generation elsewhere while waiting
+	l.mu.Lock()
+	l.lastCompleted[2] = time.Now()
+	l.mu.Unlock()
+
+	l.Release(1)
+	assertGranted(t, ready, time.Second)
+	assertPending(t, cooling, 20*time.Millisecond)
+
+	l.Release(3)
+	// User 3's own release starts its cooldown but frees the slot; user 2
+	// gets it once their cooldown ends
+	assertGranted(t, cooling, time.Second)
+}
+
+func TestWaitOrAcquireQueueFull(t *testing.T) {
+	l := NewUserLimiter(1)
+	l.SetQueue(1)
+
+	if err := l.TryAcquire(1); err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	waitAsync(t, l, 2)
+
+	err := l.WaitOrAcquire(context.Background(), 3, 3)
+	if !errors.Is(err, apperrors.ErrQueueFull) {
+		t.Fatalf("err = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestCooldownAfterRelease(t *testing.T) {
+	l := NewUserLimiter(0)
+	l.SetCooldown(50 * time.Millisecond)
+
+	if err := l.TryAcquire(1); err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	if err := l.TryAcquire(1); !errors.Is(err, apperrors.ErrGenerationInProgress) {
+		t.Fatalf("while active: err = %v, want ErrGenerationInProgress", err)
+	}
+
+	l.Release(1)
+	if err := l.TryAcquire(1); !errors.Is(err, apperrors.ErrCooldown) {
+		t.Fatalf("during cooldown: err = %v, want ErrCooldown", err)
+	}
+	if err := l.TryAcquire(2); err != nil {
+		t.Fatalf("other user during cooldown: %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := l.TryAcquire(1); err != nil {
+		t.Fatalf("after cooldown: %v", err)
+	}
+}
+
+func TestCheckCooldown(t *testing.T) {
+	l := NewUserLimiter(0)
+	l.SetCooldown(time.Minute)
+	l.SetExempt([]int64{9})
+
+	for _, id := range []int64{1, 9} {
+		if err := l.TryAcquire(id); err != nil {
+			t.Fatalf("TryAcquire(%d): %v", id, err)
+		}
+		if err := l.CheckCooldown(id); err != nil {
+			t.Errorf("CheckCooldown(%d) while active = %v, want nil", id, err)
+		}
+		l.Release(id)
+	}
+
+	if err := l.CheckCooldown(1); !errors.Is(err, apperrors.ErrCooldown) {
+		t.Errorf("after release: err = %v, want ErrCooldown", err)
+	}
+	if err := l.CheckCooldown(9); err != nil {
+		t.Errorf("exempt user after release: err = %v, want nil", err)
+	}
+	if got := l.ActiveCount(); got != 0 {
+		t.Errorf("ActiveCount = %d, want 0; CheckCooldown must not acquire", got)
+	}
+}
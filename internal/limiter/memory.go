@@ -0,0 +1,66 @@
+package limiter
+
+import (
+	"log/slog"
+	"runtime"
+	"sync"
+
+	apperrors "comfy-tg-bot/internal/errors"
+)
+
+// memoryResetRatio is the fraction of the threshold heap usage must fall
+// below before a tripped guard accepts work again
+const memoryResetRatio = 0.8
+
+// MemoryGuard sheds load while the Go heap is above a threshold. It trips
+// when HeapInuse exceeds the limit and resets once usage drops below 80%
+// of it, so it does not flap around the boundary.
+type MemoryGuard struct {
+	mu       sync.Mutex
+	maxHeap  uint64
+	tripped  bool
+	logger   *slog.Logger
+	readHeap func() uint64
+}
+
+// NewMemoryGuard creates a guard for the given heap limit in megabytes
+func NewMemoryGuard(maxHeapMB int, logger *slog.Logger) *MemoryGuard {
+	return &MemoryGuard{
+		maxHeap:  uint64(maxHeapMB) * 1024 * 1024,
+		logger:   logger,
+		readHeap: heapInuse,
+	}
+}
+
+// Check returns ErrMemoryPressure while the guard is tripped
+func (g *MemoryGuard) Check() error {
+	heap := g.readHeap()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch {
+	case !g.tripped && heap > g.maxHeap:
+		g.tripped = true
+		g.logger.Warn("memory pressure, rejecting new generations",
+			"heap_inuse_mb", heap/(1024*1024),
+			"max_heap_mb", g.maxHeap/(1024*1024),
+		)
+	case g.tripped && float64(heap) < float64(g.maxHeap)*memoryResetRatio:
+		g.tripped = false
+		g.logger.Info("memory pressure relieved, accepting generations",
+			"heap_inuse_mb", heap/(1024*1024),
+		)
+	}
+
+	if g.tripped {
+		return apperrors.ErrMemoryPressure
+	}
+	return nil
+}
+
+func heapInuse() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse
+}
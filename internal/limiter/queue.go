@@ -0,0 +1,76 @@
+package limiter
+
+// QueueNotifier tells a waiting user their position in the generation queue
+type QueueNotifier func(chatID int64, position int)
+
+// waiter is a request waiting for a global generation slot
+type waiter struct {
+	userID int64
+	chatID int64
+	// ready is closed once the waiter has been granted a slot, or with err
+	// set if the queue was closed first
+	ready chan struct{}
+	err   error
+}
+
+// Queue is a FIFO of requests waiting for a global generation slot.
+// It is not safe for concurrent use; UserLimiter guards it with its mutex.
+type Queue struct {
+	waiters  []*waiter
+	maxDepth int
+}
+
+// newQueue creates a queue holding at most maxDepth waiters
+func newQueue(maxDepth int) *Queue {
+	return &Queue{maxDepth: maxDepth}
+}
+
+// Len returns the number of waiting requests
+func (q *Queue) Len() int {
+	return len(q.waiters)
+}
+
+// full reports whether another waiter would exceed the depth cap
+func (q *Queue) full() bool {
+	return len(q.waiters) >= q.maxDepth
+}
+
+// push appends w and returns its 1-based position
+func (q *Queue) push(w *waiter) int {
+	q.waiters = append(q.waiters, w)
+	return len(q.waiters)
+}
+
+// pop removes and returns the oldest waiter
+func (q *Queue) pop() *waiter {
+	w := q.waiters[0]
+	q.waiters[0] = nil
+	q.waiters = q.waiters[1:]
+	return w
+}
+
+// remove drops w from the queue, reporting whether it was still waiting
+func (q *Queue) remove(w *waiter) bool {
+	for i, queued := range q.waiters {
+		if queued == w {
+			q.removeAt(i)
+			return true
+		}
+	}
+	return false
+}
+
+// removeAt drops the waiter at index i
+func (q *Queue) removeAt(i int) {
+	q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+}
+
+// contains reports whether userID is already waiting
+func (q *Queue) contains(userID int64) bool {
+	for _, w := range q.waiters {
+		if w.userID == userID {
+			return true
+		}
+	}
+	return false
+}
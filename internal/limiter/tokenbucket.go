@@ -0,0 +1,190 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	apperrors "comfy-tg-bot/internal/errors"
+)
+
+// TokenBucketLimiter limits how often each user may start generations.
+// Every user has a bucket of burst tokens that refills at rate tokens per
+// second; each generation takes one. Unlike UserLimiter, a user may run
+// several generations at once while tokens last. Requests are never queued.
+type TokenBucketLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[int64]*bucket
+	// active counts each user's running generations
+	active      map[int64]int
+	maxGlobal   int
+	globalCount int
+	memory      *MemoryGuard
+	now         func() time.Time
+
+	// exempt users bypass every limit; exemptActive counts their running
+	// generations, which take no global slot
+	exempt       map[int64]struct{}
+	exemptActive map[int64]int
+	exemptCount  int
+}
+
+// bucket is one user's token balance as of updated
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewTokenBucketLimiter creates a limiter letting each user start burst
+// generations at once, refilled at rate generations per second.
+// maxGlobalConcurrent of 0 means unlimited global concurrent requests
+func NewTokenBucketLimiter(rate float64, burst, maxGlobalConcurrent int) *TokenBucketLimiter {
+	return &TokenBucketLimiter{
+		rate:         rate,
+		burst:        float64(burst),
+		buckets:      make(map[int64]*bucket),
+		active:       make(map[int64]int),
+		maxGlobal:    maxGlobalConcurrent,
+		now:          time.Now,
+		exempt:       make(map[int64]struct{}),
+		exemptActive: make(map[int64]int),
+	}
+}
+
+// SetExempt replaces the users exempt from all limits, e.g. admins. They
+// take no token and no global slot, but still count in ActiveCount.
+func (l *TokenBucketLimiter) SetExempt(userIDs []int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.exempt = make(map[int64]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		l.exempt[id] = struct{}{}
+	}
+}
+
+// SetMemoryGuard enables load shedding under memory pressure
+func (l *TokenBucketLimiter) SetMemoryGuard(g *MemoryGuard) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.memory = g
+}
+
+// TryAcquire takes a token from the user's bucket. Returns ErrCooldown
+// with the time until the next token while the bucket is empty,
+// ErrServerBusy if the global limit is reached, and ErrMemoryPressure
+// while the heap is too large.
+func (l *TokenBucketLimiter) TryAcquire(userID int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.exempt[userID]; ok {
+		l.exemptActive[userID]++
+		l.exemptCount++
+		return nil
+	}
+
+	b := l.refillLocked(userID)
+	if err := l.checkTokens(b); err != nil {
+		return err
+	}
+
+	if l.maxGlobal > 0 && l.globalCount >= l.maxGlobal {
+		return apperrors.ErrServerBusy
+	}
+
+	if l.memory != nil {
+		if err := l.memory.Check(); err != nil {
+			return err
+		}
+	}
+
+	b.tokens--
+	l.active[userID]++
+	l.globalCount++
+	return nil
+}
+
+// CheckCooldown returns ErrCooldown while the user's bucket is empty,
+// without taking a token. Exempt users never have to wait.
+func (l *TokenBucketLimiter) CheckCooldown(userID int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.exempt[userID]; ok {
+		return nil
+	}
+	return l.checkTokens(l.refillLocked(userID))
+}
+
+// refillLocked returns the user's bucket with the tokens earned since it
+// was last updated. Caller must hold l.mu.
+func (l *TokenBucketLimiter) refillLocked(userID int64) *bucket {
+	now := l.now()
+	b, ok := l.buckets[userID]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[userID] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+	return b
+}
+
+// checkTokens returns ErrCooldown with the time until the next token if b
+// is empty
+func (l *TokenBucketLimiter) checkTokens(b *bucket) error {
+	if b.tokens >= 1 {
+		return nil
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	seconds := int(math.Ceil(wait.Seconds()))
+	return apperrors.Wrap(apperrors.ErrCooldown,
+		fmt.Sprintf("Please wait %ds before starting another generation.", seconds), true)
+}
+
+// WaitOrAcquire is TryAcquire; requests are never queued
+func (l *TokenBucketLimiter) WaitOrAcquire(ctx context.Context, userID, chatID int64) error {
+	return l.TryAcquire(userID)
+}
+
+// Release ends one of a user's generations. The token stays spent.
+func (l *TokenBucketLimiter) Release(userID int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Checked before the exempt set, which may have changed since the
+	// slot was acquired
+	if l.exemptActive[userID] > 0 {
+		l.exemptActive[userID]--
+		if l.exemptActive[userID] == 0 {
+			delete(l.exemptActive, userID)
+		}
+		l.exemptCount--
+		return
+	}
+
+	if l.active[userID] == 0 {
+		return
+	}
+	l.active[userID]--
+	if l.active[userID] == 0 {
+		delete(l.active, userID)
+	}
+	l.globalCount--
+}
+
+// ActiveCount returns current active generation count, including exempt
+// users' generations
+func (l *TokenBucketLimiter) ActiveCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.globalCount + l.exemptCount
+}
+
+// QueuedCount always returns 0 since requests are never queued
+func (l *TokenBucketLimiter) QueuedCount() int {
+	return 0
+}
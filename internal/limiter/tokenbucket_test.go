@@ -0,0 +1,87 @@
+package limiter
+
+import (
+	"errors"
+	"testing"
+
+	apperrors "comfy-tg-bot/internal/errors"
+)
+
+func TestTokenBucketGlobalLimit(t *testing.T) {
+	l := NewTokenBucketLimiter(1, 3, 2)
+
+	for _, id := range []int64{1, 2} {
+		if err := l.TryAcquire(id); err != nil {
+			t.Fatalf("TryAcquire(%d): %v", id, err)
+		}
+	}
+	if err := l.TryAcquire(3); !errors.Is(err, apperrors.ErrServerBusy) {
+		t.Fatalf("third user: err = %v, want ErrServerBusy", err)
+	}
+	if err := l.TryAcquire(1); !errors.Is(err, apperrors.ErrServerBusy) {
+		t.Fatalf("second generation at the cap: err = %v, want ErrServerBusy", err)
+	}
+
+	l.Release(1)
+	if err := l.TryAcquire(3); err != nil {
+		t.Fatalf("after release: %v", err)
+	}
+	if got := l.ActiveCount(); got != 2 {
+		t.Errorf("ActiveCount = %d, want 2", got)
+	}
+}
+
+func TestTokenBucketBurst(t *testing.T) {
+	l := NewTokenBucketLimiter(0.001, 2, 0)
+
+	for range 2 {
+		if err := l.TryAcquire(1); err != nil {
+			t.Fatalf("TryAcquire within burst: %v", err)
+		}
+	}
+	if err := l.TryAcquire(1); !errors.Is(err, apperrors.ErrCooldown) {
+		t.Fatalf("past burst: err = %v, want ErrCooldown", err)
+	}
+}
+
+func TestTokenBucketExempt(t *testing.T) {
+	l := NewTokenBucketLimiter(0.001, 1, 1)
+	l.SetExempt([]int64{99})
+
+	if err := l.TryAcquire(1); err != nil {
+		t.Fatal(err)
+	}
+	// Neither the full global limit nor an empty bucket stops an admin
+	for range 3 {
+		if err := l.TryAcquire(99); err != nil {
+			t.Fatalf("exempt user: %v", err)
+		}
+	}
+	if got := l.ActiveCount(); got != 4 {
+		t.Errorf("ActiveCount = %d, want 4", got)
+	}
+
+	for range 3 {
+		l.Release(99)
+	}
+	if err := l.TryAcquire(2); !errors.Is(err, apperrors.ErrServerBusy) {
+		t.Errorf("admin releases freed a user slot: err = %v", err)
+	}
+}
+
+func TestTokenBucketCheckCooldown(t *testing.T) {
+	l := NewTokenBucketLimiter(0.001, 1, 0)
+
+	// Checking takes no token
+	for range 2 {
+		if err := l.CheckCooldown(1); err != nil {
+			t.Fatalf("CheckCooldown with a full bucket: %v", err)
+		}
+	}
+	if err := l.TryAcquire(1); err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	if err := l.CheckCooldown(1); !errors.Is(err, apperrors.ErrCooldown) {
+		t.Errorf("empty bucket: err = %v, want ErrCooldown", err)
+	}
+}
@@ -0,0 +1,95 @@
+// Package metrics exports bot metrics for Prometheus
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	apperrors "comfy-tg-bot/internal/errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// generationBuckets are the duration histogram bounds in seconds
+var generationBuckets = []float64{1, 2.5, 5, 10, 20, 30, 60, 120, 300, 600}
+
+// LimiterStats reports generation slot usage
+type LimiterStats interface {
+	ActiveCount() int
+	QueuedCount() int
+}
+
+// Metrics holds the bot's exported metrics
+type Metrics struct {
+	registry *prometheus.Registry
+
+	generationDuration prometheus.Histogram
+	generationErrors   *prometheus.CounterVec
+}
+
+// New creates the bot's metrics. The active generation and queue depth
+// gauges read from limiter when scraped.
+func New(limiter LimiterStats) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		generationDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "comfybot_generation_duration_seconds",
+			Help:    "Duration of successful generations in seconds.",
+			Buckets: generationBuckets,
+		}),
+		generationErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "comfybot_generation_errors_total",
+			Help: "Failed generations by error type.",
+		}, []string{"type"}),
+	}
+
+	m.registry.MustRegister(
+		m.generationDuration,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "comfybot_active_generations",
+			Help: "Generations currently running.",
+		}, func() float64 { return float64(limiter.ActiveCount()) }),
+		m.generationErrors,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "comfybot_queue_depth",
+			Help: "Requests waiting for a free generation slot.",
+		}, func() float64 { return float64(limiter.QueuedCount()) }),
+	)
+	return m
+}
+
+// Handler serves the metrics for scraping
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveGeneration records a finished generation: its duration if it
+// succeeded, or its error type if it failed
+func (m *Metrics) ObserveGeneration(duration time.Duration, err error) {
+	if err != nil {
+		m.generationErrors.WithLabelValues(ErrorType(err)).Inc()
+		return
+	}
+	m.generationDuration.Observe(duration.Seconds())
+}
+
+// ErrorType classifies a generation error for the errors counter
+func ErrorType(err error) string {
+	switch {
+	case errors.Is(err, apperrors.ErrComfyUIUnavailable):
+		return "unavailable"
+	case errors.Is(err, apperrors.ErrGenerationTimeout), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, apperrors.ErrQueueExpired):
+		return "queue_expired"
+	case errors.Is(err, apperrors.ErrPromptRejected):
+		return "prompt_rejected"
+	case errors.Is(err, apperrors.ErrInvalidWorkflow), errors.Is(err, apperrors.ErrReferenceUnsupported):
+		return "workflow"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "comfy-tg-bot/internal/errors"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fakeLimiter struct{ active, queued int }
+
+func (f fakeLimiter) ActiveCount() int { return f.active }
+func (f fakeLimiter) QueuedCount() int { return f.queued }
+
+func TestObserveGeneration(t *testing.T) {
+	m := New(fakeLimiter{})
+	m.ObserveGeneration(3*time.Second, nil)
+	m.ObserveGeneration(time.Second, fmt.Errorf("queue: %w", apperrors.ErrComfyUIUnavailable))
+	m.ObserveGeneration(time.Second, context.DeadlineExceeded)
+	m.ObserveGeneration(time.Second, context.DeadlineExceeded)
+
+	if got := testutil.ToFloat64(m.generationErrors.WithLabelValues("unavailable")); got != 1 {
+		t.Errorf("unavailable errors = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.generationErrors.WithLabelValues("timeout")); got != 2 {
+		t.Errorf("timeout errors = %v, want 2", got)
+	}
+	if got := testutil.CollectAndCount(m.generationDuration); got != 1 {
+		t.Errorf("duration series = %d, want 1", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := New(fakeLimiter{active: 2, queued: 5})
+	m.ObserveGeneration(4*time.Second, nil)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		"comfybot_active_generations 2",
+		"comfybot_queue_depth 5",
+		`comfybot_generation_duration_seconds_bucket{le="5"} 1`,
+		"comfybot_generation_duration_seconds_count 1",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{apperrors.ErrComfyUIUnavailable, "unavailable"},
+		{apperrors.ErrGenerationTimeout, "timeout"},
+		{apperrors.ErrQueueExpired, "queue_expired"},
+		{apperrors.ErrPromptRejected, "prompt_rejected"},
+		{apperrors.ErrInvalidWorkflow, "workflow"},
+		{errors.New("boom"), "other"},
+	}
+	for _, tt := range tests {
+		if got := ErrorType(fmt.Errorf("wrapped: %w", tt.err)); got != tt.want {
+			t.Errorf("ErrorType(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
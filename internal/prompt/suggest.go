@@ -0,0 +1,142 @@
+package prompt
+
+import (
+	"math/rand/v2"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MaxSuggestions is the number of variations returned by SuggestPrompts
+const MaxSuggestions = 3
+
+// SuggestPrompts builds up to MaxSuggestions variations of the user's most
+// common recent prompt by swapping known adjectives, styles and subjects
+// with alternatives from the vocabulary
+func SuggestPrompts(recentPrompts []string, vocab *Vocabulary) []string {
+	if len(recentPrompts) == 0 || vocab == nil {
+		return nil
+	}
+
+	bases := rankPrompts(recentPrompts)
+	seen := make(map[string]struct{}, len(recentPrompts))
+	for _, p := range recentPrompts {
+		seen[normalize(p)] = struct{}{}
+	}
+
+	var suggestions []string
+	// Try a bounded number of expansions so small vocabularies terminate
+	for attempt := 0; attempt < MaxSuggestions*10 && len(suggestions) < MaxSuggestions; attempt++ {
+		base := bases[attempt%len(bases)]
+		candidate := vary(base, vocab)
+		key := normalize(candidate)
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		suggestions = append(suggestions, candidate)
+	}
+
+	return suggestions
+}
+
+// rankPrompts orders distinct prompts by frequency, most frequent first,
+// keeping recency order for ties
+func rankPrompts(prompts []string) []string {
+	counts := make(map[string]int)
+	var order []string
+	for _, p := range prompts {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if counts[p] == 0 {
+			order = append(order, p)
+		}
+		counts[p]++
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]] > counts[order[j]]
+	})
+	return order
+}
+
+// vary applies one template expansion to a prompt
+func vary(base string, vocab *Vocabulary) string {
+	lists := [][]string{vocab.Adjectives, vocab.Styles, vocab.Subjects}
+
+	// Prefer swapping a word the prompt already contains
+	for _, i := range rand.Perm(len(lists)) {
+		list := lists[i]
+		for _, word := range list {
+			if start, end := indexFold(base, word); start >= 0 {
+				replacement := pickOther(list, word)
+				if replacement == "" {
+					continue
+				}
+				return base[:start] + replacement + base[end:]
+			}
+		}
+	}
+
+	// Otherwise prepend an adjective or append a style
+	if len(vocab.Styles) > 0 && (len(vocab.Adjectives) == 0 || rand.IntN(2) == 0) {
+		return base + ", " + vocab.Styles[rand.IntN(len(vocab.Styles))]
+	}
+	if len(vocab.Adjectives) > 0 {
+		return vocab.Adjectives[rand.IntN(len(vocab.Adjectives))] + " " + base
+	}
+	return base
+}
+
+// pickOther returns a random entry from list that differs from word
+func pickOther(list []string, word string) string {
+	var others []string
+	for _, candidate := range list {
+		if !strings.EqualFold(candidate, word) {
+			others = append(others, candidate)
+		}
+	}
+	if len(others) == 0 {
+		return ""
+	}
+	return others[rand.IntN(len(others))]
+}
+
+// indexFold finds word in s as a whole word, case-insensitively, and
+// returns the byte offsets of the match in s (-1, -1 if not found). The
+// offsets index s itself; case folding can change a string's byte length,
+// so they cannot come from a lowercased copy.
+func indexFold(s, word string) (start, end int) {
+	re := regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}_])(` + regexp.QuoteMeta(word) + `)(?:$|[^\p{L}\p{N}_])`)
+	loc := re.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return -1, -1
+	}
+	return loc[2], loc[3]
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// RandomPrompt builds a prompt from a random adjective, subject and style
+func RandomPrompt(vocab *Vocabulary) string {
+	var parts []string
+	if len(vocab.Adjectives) > 0 {
+		parts = append(parts, vocab.Adjectives[rand.IntN(len(vocab.Adjectives))])
+	}
+	if len(vocab.Subjects) > 0 {
+		parts = append(parts, vocab.Subjects[rand.IntN(len(vocab.Subjects))])
+	}
+	text := strings.Join(parts, " ")
+	if len(vocab.Styles) > 0 {
+		style := vocab.Styles[rand.IntN(len(vocab.Styles))]
+		if text == "" {
+			return style
+		}
+		text += ", " + style
+	}
+	return text
+}
@@ -0,0 +1,44 @@
+package prompt
+
+import "testing"
+
+func TestIndexFold(t *testing.T) {
+	tests := []struct {
+		s, word    string
+		start, end int
+	}{
+		{"a red cat", "cat", 6, 9},
+		{"A Red CAT", "cat", 6, 9},
+		{"concatenate cat", "cat", 12, 15},
+		{"cats", "cat", -1, -1},
+		{"cat_food", "cat", -1, -1},
+		// İ and the Kelvin sign change byte length when lowercased
+		{"İİİ red cat", "cat", 11, 14},
+		{"K red cat", "red", 4, 7},
+		{"ÜBER dog", "über", 0, 5},
+		{"oil painting, cat", "oil painting", 0, 12},
+		{"c.t cat", "c.t", 0, 3},
+	}
+	for _, tt := range tests {
+		start, end := indexFold(tt.s, tt.word)
+		if start != tt.start || end != tt.end {
+			t.Errorf("indexFold(%q, %q) = %d, %d, want %d, %d", tt.s, tt.word, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestVarySwapsWordInOriginal(t *testing.T) {
+	vocab := &Vocabulary{Adjectives: []string{"red", "blue"}}
+	tests := []struct {
+		base, want string
+	}{
+		{"A RED car", "A blue car"},
+		{"İİİ red car", "İİİ blue car"},
+		{"K red car", "K blue car"},
+	}
+	for _, tt := range tests {
+		if got := vary(tt.base, vocab); got != tt.want {
+			t.Errorf("vary(%q) = %q, want %q", tt.base, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package prompt
+
+import (
+	"strings"
+	"unicode"
+)
+
+// charsPerToken approximates how many characters of a long word map to one
+// CLIP BPE token; common short words are a single token
+const charsPerToken = 6
+
+// Tokenizer estimates CLIP token counts for prompts. The estimate is
+// intentionally naive and may differ from the model's real tokenizer.
+type Tokenizer struct {
+	maxTokens int
+}
+
+// NewTokenizer creates a tokenizer that warns against the given limit
+func NewTokenizer(maxTokens int) *Tokenizer {
+	return &Tokenizer{maxTokens: maxTokens}
+}
+
+// MaxTokens returns the configured token limit
+func (t *Tokenizer) MaxTokens() int {
+	return t.maxTokens
+}
+
+// Estimate returns an approximate token count by splitting on whitespace
+// and punctuation. Each punctuation mark counts as one token and long
+// words count as several.
+func (t *Tokenizer) Estimate(text string) int {
+	tokens := 0
+	wordLen := 0
+
+	flush := func() {
+		if wordLen > 0 {
+			tokens += (wordLen + charsPerToken - 1) / charsPerToken
+			wordLen = 0
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			wordLen++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// NearLimit reports the estimated token count and whether it exceeds 90%
+// of the configured limit
+func (t *Tokenizer) NearLimit(text string) (int, bool) {
+	estimate := t.Estimate(strings.TrimSpace(text))
+	return estimate, float64(estimate) > float64(t.maxTokens)*0.9
+}
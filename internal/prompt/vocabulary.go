@@ -0,0 +1,58 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Vocabulary holds word lists used to build prompt variations
+type Vocabulary struct {
+	Adjectives []string `yaml:"adjectives"`
+	Styles     []string `yaml:"styles"`
+	Subjects   []string `yaml:"subjects"`
+}
+
+// DefaultVocabulary returns the bundled vocabulary
+func DefaultVocabulary() *Vocabulary {
+	return &Vocabulary{
+		Adjectives: []string{
+			"beautiful", "dramatic", "serene", "vibrant", "moody", "ethereal",
+			"mysterious", "cozy", "majestic", "whimsical", "gloomy", "colorful",
+		},
+		Styles: []string{
+			"oil painting", "watercolor", "digital art", "photorealistic",
+			"pixel art", "anime style", "pencil sketch", "cinematic lighting",
+			"studio ghibli style", "cyberpunk", "art nouveau", "low poly",
+		},
+		Subjects: []string{
+			"castle", "forest", "city", "mountain", "ocean", "cat", "dragon",
+			"robot", "lighthouse", "garden", "spaceship", "village",
+		},
+	}
+}
+
+// LoadVocabulary reads a vocabulary from a YAML file.
+// An empty path returns the bundled vocabulary.
+func LoadVocabulary(path string) (*Vocabulary, error) {
+	if path == "" {
+		return DefaultVocabulary(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read vocabulary file: %w", err)
+	}
+
+	var vocab Vocabulary
+	if err := yaml.Unmarshal(data, &vocab); err != nil {
+		return nil, fmt.Errorf("parse vocabulary file: %w", err)
+	}
+
+	if len(vocab.Adjectives) == 0 && len(vocab.Styles) == 0 && len(vocab.Subjects) == 0 {
+		return nil, fmt.Errorf("vocabulary file %s contains no words", path)
+	}
+
+	return &vocab, nil
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"comfy-tg-bot/internal/admin"
+)
+
+// maxWebhookBodyBytes bounds the size of an admin webhook request body
+const maxWebhookBodyBytes = 4 << 10
+
+// UserApprover grants and revokes access on behalf of the admin webhook
+type UserApprover interface {
+	// ApproveUser approves a user and notifies them, failing with
+	// admin.ErrUserBanned or admin.ErrApprovedLimitReached if not allowed
+	ApproveUser(userID int64) error
+
+	// RevokeUser removes a user's approval
+	RevokeUser(userID int64) error
+}
+
+// webhookRequest is the body of an admin webhook call
+type webhookRequest struct {
+	UserID int64  `json:"user_id"`
+	Secret string `json:"secret"`
+}
+
+// webhookResponse is the body of an admin webhook reply
+type webhookResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// AdminWebhookHandler lets external user management systems approve and
+// revoke users:
+//
+//	POST /webhook/approve  {"user_id": 123456, "secret": "..."}
+//	POST /webhook/revoke   {"user_id": 123456, "secret": "..."}
+//
+// Both reply with {"ok": true} or {"ok": false, "error": "..."}.
+func AdminWebhookHandler(secret string, approver UserApprover, logger *slog.Logger) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("POST /webhook/approve", adminWebhookAction("approve", secret, approver.ApproveUser, logger))
+	mux.Handle("POST /webhook/revoke", adminWebhookAction("revoke", secret, approver.RevokeUser, logger))
+	return mux
+}
+
+// adminWebhookAction authenticates a webhook call and applies action to
+// the requested user
+func adminWebhookAction(name, secret string, action func(userID int64) error, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req webhookRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes)).Decode(&req); err != nil {
+			logger.Warn("admin webhook rejected", "action", name, "remote_addr", r.RemoteAddr, "reason", "invalid body", "error", err)
+			writeWebhookResponse(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(req.Secret), []byte(secret)) != 1 {
+			logger.Warn("admin webhook rejected", "action", name, "remote_addr", r.RemoteAddr, "user_id", req.UserID, "reason", "invalid secret")
+			writeWebhookResponse(w, http.StatusUnauthorized, "invalid secret")
+			return
+		}
+
+		if req.UserID <= 0 {
+			logger.Warn("admin webhook rejected", "action", name, "remote_addr", r.RemoteAddr, "user_id", req.UserID, "reason", "invalid user_id")
+			writeWebhookResponse(w, http.StatusBadRequest, "user_id must be a positive integer")
+			return
+		}
+
+		if err := action(req.UserID); err != nil {
+			if errors.Is(err, admin.ErrUserBanned) || errors.Is(err, admin.ErrApprovedLimitReached) {
+				logger.Warn("admin webhook refused", "action", name, "remote_addr", r.RemoteAddr, "user_id", req.UserID, "error", err)
+				writeWebhookResponse(w, http.StatusConflict, err.Error())
+				return
+			}
+			logger.Error("admin webhook failed", "action", name, "remote_addr", r.RemoteAddr, "user_id", req.UserID, "error", err)
+			writeWebhookResponse(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		logger.Info("admin webhook succeeded", "action", name, "remote_addr", r.RemoteAddr, "user_id", req.UserID)
+		writeWebhookResponse(w, http.StatusOK, "")
+	}
+}
+
+// writeWebhookResponse replies with {"ok": true} if errMsg is empty and
+// {"ok": false, "error": errMsg} otherwise
+func writeWebhookResponse(w http.ResponseWriter, status int, errMsg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(webhookResponse{OK: errMsg == "", Error: errMsg})
+}
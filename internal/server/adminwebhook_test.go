@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"comfy-tg-bot/internal/admin"
+)
+
+const testSecret = "0123456789abcdef"
+
+// stubApprover records calls and fails with err
+type stubApprover struct {
+	approved []int64
+	revoked  []int64
+	err      error
+}
+
+func (s *stubApprover) ApproveUser(userID int64) error {
+	s.approved = append(s.approved, userID)
+	return s.err
+}
+
+func (s *stubApprover) RevokeUser(userID int64) error {
+	s.revoked = append(s.revoked, userID)
+	return s.err
+}
+
+func TestAdminWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		err        error
+		wantStatus int
+		wantError  string
+		wantCalls  int
+	}{
+		{"approve", http.MethodPost, "/webhook/approve", `{"user_id": 42, "secret": "` + testSecret + `"}`, nil, http.StatusOK, "", 1},
+		{"revoke", http.MethodPost, "/webhook/revoke", `{"user_id": 42, "secret": "` + testSecret + `"}`, nil, http.StatusOK, "", 1},
+		{"wrong secret", http.MethodPost, "/webhook/approve", `{"user_id": 42, "secret": "nope"}`, nil, http.StatusUnauthorized, "invalid secret", 0},
+		{"missing secret", http.MethodPost, "/webhook/approve", `{"user_id": 42}`, nil, http.StatusUnauthorized, "invalid secret", 0},
+		{"invalid JSON", http.MethodPost, "/webhook/approve", `{"user_id":`, nil, http.StatusBadRequest, "invalid JSON body", 0},
+		{"invalid user", http.MethodPost, "/webhook/approve", `{"user_id": 0, "secret": "` + testSecret + `"}`, nil, http.StatusBadRequest, "user_id must be a positive integer", 0},
+		{"banned", http.MethodPost, "/webhook/approve", `{"user_id": 42, "secret": "` + testSecret + `"}`, admin.ErrUserBanned, http.StatusConflict, admin.ErrUserBanned.Error(), 1},
+		{"limit reached", http.MethodPost, "/webhook/approve", `{"user_id": 42, "secret": "` + testSecret + `"}`, admin.ErrApprovedLimitReached, http.StatusConflict, admin.ErrApprovedLimitReached.Error(), 1},
+		{"store failure", http.MethodPost, "/webhook/revoke", `{"user_id": 42, "secret": "` + testSecret + `"}`, errors.New("disk I/O error"), http.StatusInternalServerError, "internal error", 1},
+		{"GET not allowed", http.MethodGet, "/webhook/approve", "", nil, http.StatusMethodNotAllowed, "", 0},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approver := &stubApprover{err: tt.err}
+			srv := httptest.NewServer(AdminWebhookHandler(testSecret, approver, logger))
+			defer srv.Close()
+
+			req, err := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if calls := len(approver.approved) + len(approver.revoked); calls != tt.wantCalls {
+				t.Errorf("approver called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantStatus == http.StatusMethodNotAllowed {
+				return
+			}
+
+			var body webhookResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body.OK != (tt.wantError == "") || body.Error != tt.wantError {
+				t.Errorf("response = %+v, want error %q", body, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestAdminWebhookRoutesAction(t *testing.T) {
+	approver := &stubApprover{}
+	srv := httptest.NewServer(AdminWebhookHandler(testSecret, approver, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer srv.Close()
+
+	for path, id := range map[string]string{"/webhook/approve": "1", "/webhook/revoke": "2"} {
+		resp, err := http.Post(srv.URL+path, "application/json",
+			strings.NewReader(`{"user_id": `+id+`, "secret": "`+testSecret+`"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(approver.approved) != 1 || approver.approved[0] != 1 || len(approver.revoked) != 1 || approver.revoked[0] != 2 {
+		t.Errorf("approved %v revoked %v, want [1] and [2]", approver.approved, approver.revoked)
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// shutdownTimeout bounds how long in-flight probes may take on shutdown
+const shutdownTimeout = 5 * time.Second
+
+// HealthHandler serves liveness and readiness probes:
+//
+//	GET /healthz  200 if live reports true, 503 otherwise
+//	GET /readyz   200 if live reports true and ready returns nil, 503 otherwise
+func HealthHandler(live func() bool, ready func(ctx context.Context) error) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		if !live() {
+			http.Error(w, "not receiving updates", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !live() {
+			http.Error(w, "not receiving updates", http.StatusServiceUnavailable)
+			return
+		}
+		if err := ready(r.Context()); err != nil {
+			http.Error(w, "comfyui unreachable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// Run serves handler on addr until ctx is cancelled, then shuts the
+// server down gracefully
+func Run(ctx context.Context, addr string, handler http.Handler, logger *slog.Logger) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	logger.Info("http server listening", "addr", addr)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("http server: %w", err)
+		}
+		return nil
+
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("shutdown http server: %w", err)
+		}
+		return nil
+	}
+}
@@ -2,13 +2,53 @@ package settings
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	_ "modernc.org/sqlite"
+
+	appdb "comfy-tg-bot/internal/db"
 )
 
+// migrations defines the settings schema; append new versions, never edit old ones
+var migrations = []appdb.Migration{
+	{Version: 1, SQL: `
+		CREATE TABLE IF NOT EXISTS user_settings (
+			user_id INTEGER PRIMARY KEY,
+			send_original INTEGER NOT NULL DEFAULT 1,
+			send_compressed INTEGER NOT NULL DEFAULT 1
+		)
+	`},
+	{Version: 2, SQL: `
+		CREATE TABLE IF NOT EXISTS group_settings (
+			group_id INTEGER PRIMARY KEY,
+			allow_originals INTEGER
+		)
+	`},
+	{Version: 3, SQL: "ALTER TABLE user_settings ADD COLUMN send_comparison INTEGER NOT NULL DEFAULT 1"},
+	{Version: 4, SQL: "ALTER TABLE user_settings ADD COLUMN side_by_side INTEGER NOT NULL DEFAULT 0"},
+	{Version: 5, SQL: "ALTER TABLE user_settings ADD COLUMN show_quick_keys INTEGER NOT NULL DEFAULT 0"},
+	// NULL falls back to the configured default
+	{Version: 6, SQL: "ALTER TABLE user_settings ADD COLUMN disable_link_previews INTEGER"},
+	{Version: 7, SQL: "ALTER TABLE user_settings ADD COLUMN send_video INTEGER NOT NULL DEFAULT 1"},
+	// JSON object of parameter name to raw value
+	{Version: 8, SQL: "ALTER TABLE user_settings ADD COLUMN workflow_params TEXT NOT NULL DEFAULT '{}'"},
+	{Version: 9, SQL: "ALTER TABLE user_settings ADD COLUMN workflow_name TEXT NOT NULL DEFAULT ''"},
+	{Version: 10, SQL: "ALTER TABLE user_settings ADD COLUMN send_webp INTEGER NOT NULL DEFAULT 0"},
+	{Version: 11, SQL: "ALTER TABLE user_settings ADD COLUMN negative_prompt TEXT NOT NULL DEFAULT ''"},
+	{Version: 12, SQL: "ALTER TABLE user_settings ADD COLUMN prompt_prefix TEXT NOT NULL DEFAULT ''"},
+	{Version: 13, SQL: "ALTER TABLE user_settings ADD COLUMN prompt_suffix TEXT NOT NULL DEFAULT ''"},
+	{Version: 14, SQL: "ALTER TABLE user_settings ADD COLUMN preferred_width INTEGER NOT NULL DEFAULT 0"},
+	{Version: 15, SQL: "ALTER TABLE user_settings ADD COLUMN preferred_height INTEGER NOT NULL DEFAULT 0"},
+	{Version: 16, SQL: "ALTER TABLE user_settings ADD COLUMN seed INTEGER"},
+	{Version: 17, SQL: "ALTER TABLE user_settings ADD COLUMN generation_timeout_seconds INTEGER"},
+	{Version: 18, SQL: "ALTER TABLE user_settings ADD COLUMN send_thumbnail_preview INTEGER NOT NULL DEFAULT 1"},
+	{Version: 19, SQL: "ALTER TABLE user_settings ADD COLUMN accept_forwards INTEGER NOT NULL DEFAULT 1"},
+	{Version: 20, SQL: "ALTER TABLE user_settings ADD COLUMN strip_metadata INTEGER NOT NULL DEFAULT 0"},
+}
+
 // SQLiteStore implements Store using SQLite for persistence
 type SQLiteStore struct {
 	db       *sql.DB
@@ -33,17 +73,9 @@ func NewSQLiteStore(dbPath string, defaults DefaultSettings) (*SQLiteStore, erro
 	// SQLite works best with a single writer
 	db.SetMaxOpenConns(1)
 
-	// Create table
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS user_settings (
-			user_id INTEGER PRIMARY KEY,
-			send_original INTEGER NOT NULL DEFAULT 1,
-			send_compressed INTEGER NOT NULL DEFAULT 1
-		)
-	`)
-	if err != nil {
+	if err := appdb.RunMigrations(db, "settings", migrations); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("create table: %w", err)
+		return nil, err
 	}
 
 	return &SQLiteStore{db: db, defaults: defaults}, nil
@@ -52,22 +84,53 @@ func NewSQLiteStore(dbPath string, defaults DefaultSettings) (*SQLiteStore, erro
 // Get retrieves user settings, returning defaults if none exist
 func (s *SQLiteStore) Get(userID int64) (*UserSettings, error) {
 	var us UserSettings
+	var disableLinkPreviews sql.NullBool
+	var workflowParams string
+	var seed sql.NullInt64
+	var timeoutSeconds sql.NullInt64
 	err := s.db.QueryRow(
-		"SELECT user_id, send_original, send_compressed FROM user_settings WHERE user_id = ?",
+		`SELECT user_id, send_original, send_compressed, send_comparison, side_by_side,
+			show_quick_keys, disable_link_previews, send_video, workflow_params, workflow_name, send_webp, negative_prompt,
+			prompt_prefix, prompt_suffix, preferred_width, preferred_height, seed,
+			generation_timeout_seconds, send_thumbnail_preview, accept_forwards, strip_metadata
+		FROM user_settings WHERE user_id = ?`,
 		userID,
-	).Scan(&us.UserID, &us.SendOriginal, &us.SendCompressed)
+	).Scan(&us.UserID, &us.SendOriginal, &us.SendCompressed, &us.SendComparison, &us.SideBySide,
+		&us.ShowQuickKeys, &disableLinkPreviews, &us.SendVideo, &workflowParams, &us.WorkflowName, &us.SendWebP, &us.NegativePrompt,
+		&us.PromptPrefix, &us.PromptSuffix, &us.PreferredWidth, &us.PreferredHeight, &seed,
+		&timeoutSeconds, &us.SendThumbnailPreview, &us.AcceptForwards, &us.StripMetadata)
 
 	if err == sql.ErrNoRows {
 		// Return defaults for new users
 		return &UserSettings{
-			UserID:         userID,
-			SendOriginal:   s.defaults.SendOriginal,
-			SendCompressed: s.defaults.SendCompressed,
+			UserID:               userID,
+			SendOriginal:         s.defaults.SendOriginal,
+			SendCompressed:       s.defaults.SendCompressed,
+			SendComparison:       true,
+			DisableLinkPreviews:  s.defaults.DisableLinkPreviews,
+			SendVideo:            true,
+			SendThumbnailPreview: true,
+			AcceptForwards:       true,
 		}, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("query user settings: %w", err)
 	}
+
+	us.DisableLinkPreviews = s.defaults.DisableLinkPreviews
+	if disableLinkPreviews.Valid {
+		us.DisableLinkPreviews = disableLinkPreviews.Bool
+	}
+	if seed.Valid {
+		us.Seed = &seed.Int64
+	}
+	if timeoutSeconds.Valid {
+		seconds := int(timeoutSeconds.Int64)
+		us.GenerationTimeoutSeconds = &seconds
+	}
+	if err := json.Unmarshal([]byte(workflowParams), &us.WorkflowParams); err != nil {
+		return nil, fmt.Errorf("decode workflow params: %w", err)
+	}
 	return &us, nil
 }
 
@@ -77,13 +140,45 @@ func (s *SQLiteStore) Save(us *UserSettings) error {
 		return err
 	}
 
-	_, err := s.db.Exec(`
-		INSERT INTO user_settings (user_id, send_original, send_compressed)
-		VALUES (?, ?, ?)
+	workflowParams, err := json.Marshal(us.WorkflowParams)
+	if err != nil {
+		return fmt.Errorf("encode workflow params: %w", err)
+	}
+	if us.WorkflowParams == nil {
+		workflowParams = []byte("{}")
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO user_settings (user_id, send_original, send_compressed, send_comparison, side_by_side,
+			show_quick_keys, disable_link_previews, send_video, workflow_params, workflow_name, send_webp, negative_prompt,
+			prompt_prefix, prompt_suffix, preferred_width, preferred_height, seed,
+			generation_timeout_seconds, send_thumbnail_preview, accept_forwards, strip_metadata)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(user_id) DO UPDATE SET
 			send_original = excluded.send_original,
-			send_compressed = excluded.send_compressed
-	`, us.UserID, us.SendOriginal, us.SendCompressed)
+			send_compressed = excluded.send_compressed,
+			send_comparison = excluded.send_comparison,
+			side_by_side = excluded.side_by_side,
+			show_quick_keys = excluded.show_quick_keys,
+			disable_link_previews = excluded.disable_link_previews,
+			send_video = excluded.send_video,
+			workflow_params = excluded.workflow_params,
+			workflow_name = excluded.workflow_name,
+			send_webp = excluded.send_webp,
+			negative_prompt = excluded.negative_prompt,
+			prompt_prefix = excluded.prompt_prefix,
+			prompt_suffix = excluded.prompt_suffix,
+			preferred_width = excluded.preferred_width,
+			preferred_height = excluded.preferred_height,
+			seed = excluded.seed,
+			generation_timeout_seconds = excluded.generation_timeout_seconds,
+			send_thumbnail_preview = excluded.send_thumbnail_preview,
+			accept_forwards = excluded.accept_forwards,
+			strip_metadata = excluded.strip_metadata
+	`, us.UserID, us.SendOriginal, us.SendCompressed, us.SendComparison, us.SideBySide,
+		us.ShowQuickKeys, us.DisableLinkPreviews, us.SendVideo, string(workflowParams), us.WorkflowName, us.SendWebP, us.NegativePrompt,
+		us.PromptPrefix, us.PromptSuffix, us.PreferredWidth, us.PreferredHeight, us.Seed,
+		us.GenerationTimeoutSeconds, us.SendThumbnailPreview, us.AcceptForwards, us.StripMetadata)
 
 	if err != nil {
 		return fmt.Errorf("save user settings: %w", err)
@@ -91,6 +186,54 @@ func (s *SQLiteStore) Save(us *UserSettings) error {
 	return nil
 }
 
+// GetGroup retrieves group settings, returning an empty override set if none exist
+func (s *SQLiteStore) GetGroup(groupID int64) (*GroupSettings, error) {
+	gs := GroupSettings{GroupID: groupID}
+	var allowOriginals sql.NullBool
+
+	err := s.db.QueryRow(
+		"SELECT allow_originals FROM group_settings WHERE group_id = ?",
+		groupID,
+	).Scan(&allowOriginals)
+
+	if err == sql.ErrNoRows {
+		return &gs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query group settings: %w", err)
+	}
+
+	if allowOriginals.Valid {
+		gs.AllowOriginals = &allowOriginals.Bool
+	}
+	return &gs, nil
+}
+
+// SaveGroup persists group settings using upsert
+func (s *SQLiteStore) SaveGroup(gs *GroupSettings) error {
+	var allowOriginals sql.NullBool
+	if gs.AllowOriginals != nil {
+		allowOriginals = sql.NullBool{Bool: *gs.AllowOriginals, Valid: true}
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO group_settings (group_id, allow_originals)
+		VALUES (?, ?)
+		ON CONFLICT(group_id) DO UPDATE SET
+			allow_originals = excluded.allow_originals
+	`, gs.GroupID, allowOriginals)
+
+	if err != nil {
+		return fmt.Errorf("save group settings: %w", err)
+	}
+	return nil
+}
+
+// Ping verifies the database connection is alive
+func (s *SQLiteStore) Ping() error {
+	return s.db.Ping()
+}
+
 // Close releases database resources
 func (s *SQLiteStore) Close() error {
 	return s.db.Close()
@@ -10,6 +10,51 @@ type UserSettings struct {
 	UserID         int64
 	SendOriginal   bool
 	SendCompressed bool
+	// SendComparison sends input and output together for img2img/upscale results
+	SendComparison bool
+	// SideBySide sends the comparison as one combined image instead of an album
+	SideBySide bool
+	// ShowQuickKeys shows the persistent quick-action reply keyboard
+	ShowQuickKeys bool
+	// DisableLinkPreviews suppresses web page previews in bot messages
+	DisableLinkPreviews bool
+	// SendVideo sends video outputs as playable videos instead of files
+	SendVideo bool
+	// WorkflowParams overrides whitelisted workflow inputs such as steps and cfg
+	WorkflowParams map[string]string
+	// WorkflowName is the named workflow the user generates with (empty = default)
+	WorkflowName string
+	// SendWebP sends the compressed version as a lossless WebP file instead
+	// of a JPEG photo when the WebP is smaller
+	SendWebP bool
+	// NegativePrompt is applied to workflows with a {{NEGATIVE_PROMPT}} placeholder
+	NegativePrompt string
+	// PromptPrefix and PromptSuffix are added before and after every prompt
+	PromptPrefix string
+	PromptSuffix string
+	// PreferredWidth and PreferredHeight fill the {{WIDTH}} and {{HEIGHT}}
+	// placeholders instead of an aspect ratio choice (0 = not set)
+	PreferredWidth  int
+	PreferredHeight int
+	// Seed fills the {{SEED}} placeholder and every sampler seed input
+	// (nil = random for {{SEED}}, workflow default otherwise)
+	Seed *int64
+	// GenerationTimeoutSeconds replaces telegram.request_timeout for the
+	// user's generations (nil = global default)
+	GenerationTimeoutSeconds *int
+	// SendThumbnailPreview sends a small preview as soon as an image is
+	// generated, removed once the full-resolution versions arrive
+	SendThumbnailPreview bool
+	// AcceptForwards treats messages forwarded to the bot as prompts
+	AcceptForwards bool
+	// StripMetadata removes text chunks from original PNGs instead of
+	// embedding the prompt and seed
+	StripMetadata bool
+}
+
+// HasPreferredSize reports whether the user has set output dimensions
+func (s *UserSettings) HasPreferredSize() bool {
+	return s.PreferredWidth > 0 && s.PreferredHeight > 0
 }
 
 // Validate ensures settings are valid
@@ -20,18 +65,32 @@ func (s *UserSettings) Validate() error {
 	return nil
 }
 
+// GroupSettings represents per-group configuration managed by admins
+type GroupSettings struct {
+	GroupID int64
+	// AllowOriginals overrides the global allow_group_originals config when non-nil
+	AllowOriginals *bool
+}
+
 // Store defines the interface for settings persistence
 type Store interface {
 	// Get retrieves user settings, returning defaults if none exist
 	Get(userID int64) (*UserSettings, error)
 	// Save persists user settings
 	Save(settings *UserSettings) error
+	// GetGroup retrieves group settings, returning an empty override set if none exist
+	GetGroup(groupID int64) (*GroupSettings, error)
+	// SaveGroup persists group settings
+	SaveGroup(settings *GroupSettings) error
+	// Ping verifies the underlying database is reachable
+	Ping() error
 	// Close releases resources
 	Close() error
 }
 
 // DefaultSettings holds the global defaults from config
 type DefaultSettings struct {
-	SendOriginal   bool
-	SendCompressed bool
+	SendOriginal        bool
+	SendCompressed      bool
+	DisableLinkPreviews bool
 }
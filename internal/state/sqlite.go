@@ -0,0 +1,83 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+
+	appdb "comfy-tg-bot/internal/db"
+)
+
+// migrations defines the state schema; append new versions, never edit old ones
+var migrations = []appdb.Migration{
+	{Version: 1, SQL: `
+		CREATE TABLE IF NOT EXISTS bot_state (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		)
+	`},
+}
+
+// SQLiteStore implements StateStore using SQLite for persistence
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore creates a new SQLite-backed state store
+func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	// Ensure directory exists
+	dir := filepath.Dir(dbPath)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite works best with a single writer
+	db.SetMaxOpenConns(1)
+
+	if err := appdb.RunMigrations(db, "state", migrations); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Get returns the value stored under key, or "" if it is not set
+func (s *SQLiteStore) Get(key string) (string, error) {
+	var value string
+	err := s.db.QueryRow(`SELECT value FROM bot_state WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("query state %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// Set stores value under key, replacing any previous value
+func (s *SQLiteStore) Set(key, value string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO bot_state (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, key, value)
+	if err != nil {
+		return fmt.Errorf("save state %s: %w", key, err)
+	}
+	return nil
+}
+
+// Close releases database resources
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
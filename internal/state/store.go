@@ -0,0 +1,13 @@
+// Package state persists small pieces of bot state, such as the Telegram
+// update offset, across restarts
+package state
+
+// StateStore defines the interface for key/value bot state
+type StateStore interface {
+	// Get returns the value stored under key, or "" if it is not set
+	Get(key string) (string, error)
+	// Set stores value under key, replacing any previous value
+	Set(key, value string) error
+	// Close releases resources
+	Close() error
+}
@@ -0,0 +1,207 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	appdb "comfy-tg-bot/internal/db"
+)
+
+// migrations defines the stats schema; append new versions, never edit old ones
+var migrations = []appdb.Migration{
+	{Version: 1, SQL: `
+		CREATE TABLE IF NOT EXISTS generation_stats (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			model TEXT NOT NULL,
+			duration_ms INTEGER NOT NULL,
+			success INTEGER NOT NULL,
+			created_at DATETIME NOT NULL
+		)
+	`},
+	{Version: 2, SQL: `
+		CREATE INDEX IF NOT EXISTS idx_generation_stats_model
+		ON generation_stats (model)
+	`},
+	// Per-user daily totals for /stats; date is the UTC day
+	{Version: 3, SQL: `
+		CREATE TABLE IF NOT EXISTS stats_daily (
+			date TEXT NOT NULL,
+			user_id INTEGER NOT NULL,
+			generations INTEGER NOT NULL DEFAULT 0,
+			errors INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (date, user_id)
+		)
+	`},
+}
+
+// SQLiteStore implements Store using SQLite for persistence
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore creates a new SQLite-backed stats store
+func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	// Ensure directory exists
+	dir := filepath.Dir(dbPath)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite works best with a single writer
+	db.SetMaxOpenConns(1)
+
+	if err := appdb.RunMigrations(db, "stats", migrations); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Record stores the outcome of a generation and adds it to the user's daily
+// totals inside an immediate transaction so concurrent writers queue
+// instead of failing
+func (s *SQLiteStore) Record(gen Generation) error {
+	ctx := context.Background()
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		INSERT INTO generation_stats (user_id, model, duration_ms, success, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, gen.UserID, gen.Model, gen.DurationMs, gen.Success, gen.CreatedAt)
+	if err != nil {
+		conn.ExecContext(ctx, "ROLLBACK")
+		return fmt.Errorf("record generation: %w", err)
+	}
+
+	failed := 0
+	if !gen.Success {
+		failed = 1
+	}
+	_, err = conn.ExecContext(ctx, `
+		INSERT INTO stats_daily (date, user_id, generations, errors)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT(date, user_id) DO UPDATE SET
+			generations = generations + 1,
+			errors = errors + excluded.errors
+	`, gen.CreatedAt.UTC().Format(dateFormat), gen.UserID, failed)
+	if err != nil {
+		conn.ExecContext(ctx, "ROLLBACK")
+		return fmt.Errorf("record daily stats: %w", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		conn.ExecContext(ctx, "ROLLBACK")
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ModelStats summarizes generations for a model, returning nil if none exist
+func (s *SQLiteStore) ModelStats(model string) (*ModelStatsEntry, error) {
+	entry := ModelStatsEntry{Model: model}
+	err := s.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(AVG(duration_ms), 0), COALESCE(AVG(success), 0)
+		FROM generation_stats WHERE model = ?
+	`, model).Scan(&entry.Count, &entry.AvgMs, &entry.SuccessRate)
+	if err != nil {
+		return nil, fmt.Errorf("query model stats: %w", err)
+	}
+
+	if entry.Count == 0 {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// UserStats summarizes generations for a user
+func (s *SQLiteStore) UserStats(userID int64) (*UserStatsEntry, error) {
+	entry := UserStatsEntry{UserID: userID}
+	err := s.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(AVG(duration_ms), 0), COALESCE(AVG(success), 0)
+		FROM generation_stats WHERE user_id = ?
+	`, userID).Scan(&entry.Count, &entry.AvgMs, &entry.SuccessRate)
+	if err != nil {
+		return nil, fmt.Errorf("query user stats: %w", err)
+	}
+	return &entry, nil
+}
+
+// AllModelStats summarizes generations for every model, most used first
+func (s *SQLiteStore) AllModelStats() ([]ModelStatsEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT model, COUNT(*), AVG(duration_ms), AVG(success)
+		FROM generation_stats
+		GROUP BY model
+		ORDER BY COUNT(*) DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query all model stats: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []ModelStatsEntry
+	for rows.Next() {
+		var e ModelStatsEntry
+		if err := rows.Scan(&e.Model, &e.Count, &e.AvgMs, &e.SuccessRate); err != nil {
+			return nil, fmt.Errorf("scan model stats: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate model stats: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetGlobalStats summarizes every user's generations over the last days
+// UTC days, including today
+func (s *SQLiteStore) GetGlobalStats(days int) (*GlobalStats, error) {
+	since := time.Now().UTC().AddDate(0, 0, -(days - 1)).Format(dateFormat)
+
+	gs := GlobalStats{Days: days}
+	err := s.db.QueryRow(`
+		SELECT COUNT(DISTINCT user_id), COALESCE(SUM(generations), 0), COALESCE(SUM(errors), 0)
+		FROM stats_daily WHERE date >= ?
+	`, since).Scan(&gs.Users, &gs.Generations, &gs.Errors)
+	if err != nil {
+		return nil, fmt.Errorf("query global stats: %w", err)
+	}
+
+	if gs.Generations > 0 {
+		gs.SuccessRate = float64(gs.Generations-gs.Errors) / float64(gs.Generations)
+	}
+	if days > 0 {
+		gs.AvgPerDay = float64(gs.Generations) / float64(days)
+	}
+	return &gs, nil
+}
+
+// Close releases database resources
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,63 @@
+package stats
+
+import "time"
+
+// DefaultModel is recorded when the default workflow loads no fixed
+// checkpoint
+const DefaultModel = "default"
+
+// dateFormat is the layout of stats_daily dates
+const dateFormat = "2006-01-02"
+
+// Generation records the outcome of a single generation
+type Generation struct {
+	UserID     int64
+	Model      string
+	DurationMs int64
+	Success    bool
+	CreatedAt  time.Time
+}
+
+// ModelStatsEntry summarizes generations for one model
+type ModelStatsEntry struct {
+	Model       string
+	Count       int
+	AvgMs       float64
+	SuccessRate float64
+}
+
+// UserStatsEntry summarizes generations for one user
+type UserStatsEntry struct {
+	UserID      int64
+	Count       int
+	AvgMs       float64
+	SuccessRate float64
+}
+
+// GlobalStats summarizes every user's generations over a number of days
+type GlobalStats struct {
+	Days int
+	// Users is the number of users who generated at least once
+	Users       int
+	Generations int
+	Errors      int
+	SuccessRate float64
+	AvgPerDay   float64
+}
+
+// Store defines the interface for generation statistics persistence
+type Store interface {
+	// Record stores the outcome of a generation
+	Record(gen Generation) error
+	// ModelStats summarizes generations for a model, returning nil if none exist
+	ModelStats(model string) (*ModelStatsEntry, error)
+	// UserStats summarizes generations for a user
+	UserStats(userID int64) (*UserStatsEntry, error)
+	// AllModelStats summarizes generations for every model, most used first
+	AllModelStats() ([]ModelStatsEntry, error)
+	// GetGlobalStats summarizes every user's generations over the last
+	// days UTC days, including today
+	GetGlobalStats(days int) (*GlobalStats, error)
+	// Close releases resources
+	Close() error
+}
@@ -0,0 +1,126 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/settings"
+)
+
+// maxPromptAffixLength bounds stored prompt prefixes and suffixes
+const maxPromptAffixLength = 200
+
+// handleSetAffix handles /setprefix and /setsuffix, which store text added
+// before or after every prompt. Without arguments it shows the current one.
+func (h *Handler) handleSetAffix(ctx context.Context, msg *tgbotapi.Message, suffix bool) {
+	userID := msg.From.ID
+	text := strings.TrimSpace(msg.CommandArguments())
+	label, command := affixNames(suffix)
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load settings. Please try again.")
+		return
+	}
+
+	if text == "" {
+		current := userSettings.PromptPrefix
+		if suffix {
+			current = userSettings.PromptSuffix
+		}
+		h.sendText(msg.Chat.ID, fmt.Sprintf(
+			"%s: %s\n\nUsage: /set%s <text>, or /clear%s to remove it.",
+			label, affixLabel(current), command, command))
+		return
+	}
+
+	if len(text) > maxPromptAffixLength {
+		h.sendError(msg.Chat.ID, fmt.Sprintf(
+			"%s is too long (max %d characters).", label, maxPromptAffixLength))
+		return
+	}
+
+	if suffix {
+		userSettings.PromptSuffix = text
+	} else {
+		userSettings.PromptPrefix = text
+	}
+	if err := h.settings.Save(userSettings); err != nil {
+		h.logger.Error("failed to save user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to save settings. Please try again.")
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, label+" saved.")
+}
+
+// handleClearAffix handles /clearprefix and /clearsuffix
+func (h *Handler) handleClearAffix(ctx context.Context, msg *tgbotapi.Message, suffix bool) {
+	userID := msg.From.ID
+	label, _ := affixNames(suffix)
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load settings. Please try again.")
+		return
+	}
+
+	if suffix {
+		userSettings.PromptSuffix = ""
+	} else {
+		userSettings.PromptPrefix = ""
+	}
+	if err := h.settings.Save(userSettings); err != nil {
+		h.logger.Error("failed to save user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to save settings. Please try again.")
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, label+" removed.")
+}
+
+// affixNames returns the display label and command stem for a prefix or
+// suffix
+func affixNames(suffix bool) (label, command string) {
+	if suffix {
+		return "Prompt suffix", "suffix"
+	}
+	return "Prompt prefix", "prefix"
+}
+
+// affixLabel formats a stored prefix or suffix for display
+func affixLabel(affix string) string {
+	if affix == "" {
+		return "(none)"
+	}
+	return affix
+}
+
+// affixPrompt adds the user's prompt prefix and suffix to prompt. History
+// and captions keep the prompt as typed, so the affixes are not repeated
+// when it is generated again.
+func (h *Handler) affixPrompt(userID int64, prompt string) string {
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Warn("failed to load settings, using prompt as typed", "error", err, "user_id", userID)
+		return prompt
+	}
+	return joinPromptAffixes(userSettings, prompt)
+}
+
+// joinPromptAffixes joins the non-empty prefix, prompt and suffix with
+// spaces
+func joinPromptAffixes(s *settings.UserSettings, prompt string) string {
+	parts := make([]string, 0, 3)
+	for _, p := range []string{s.PromptPrefix, prompt, s.PromptSuffix} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,82 @@
+package telegram
+
+import (
+	"fmt"
+	"time"
+
+	"comfy-tg-bot/internal/admin"
+)
+
+// ApproveUser permanently approves a user on behalf of an external system,
+// dropping any pending request and telling the user as the approve button
+// does. Fails with admin.ErrUserBanned or admin.ErrApprovedLimitReached if
+// the user may not be approved.
+func (b *Bot) ApproveUser(userID int64) error {
+	h := b.handler
+
+	banned, err := h.adminStore.IsBanned(userID)
+	if err != nil {
+		return fmt.Errorf("check banned status: %w", err)
+	}
+	if banned {
+		return admin.ErrUserBanned
+	}
+
+	existing, err := h.adminStore.GetApproved(userID)
+	if err != nil {
+		return fmt.Errorf("get approved user: %w", err)
+	}
+	if existing == nil && h.cfg.MaxApprovedUsers > 0 {
+		count, err := h.adminStore.CountApproved()
+		if err != nil {
+			return fmt.Errorf("count approved users: %w", err)
+		}
+		if count >= h.cfg.MaxApprovedUsers {
+			return admin.ErrApprovedLimitReached
+		}
+	}
+
+	// A user's private chat ID is their user ID unless they asked from
+	// elsewhere
+	chatID := userID
+	approved := admin.ApprovedUser{
+		UserID:     userID,
+		ApprovedAt: time.Now(),
+		ApprovedBy: admin.SystemActor,
+	}
+	pending, err := h.adminStore.GetPending(userID)
+	if err != nil {
+		return fmt.Errorf("get pending request: %w", err)
+	}
+	if pending != nil {
+		chatID = pending.ChatID
+		approved.Username = pending.Username
+		if _, err := h.adminStore.ClaimPending(userID); err != nil {
+			return fmt.Errorf("claim pending request: %w", err)
+		}
+	} else if existing != nil {
+		approved.Username = existing.Username
+	}
+
+	if err := h.adminStore.AddApproved(approved); err != nil {
+		// Put the request back so an admin can still answer it
+		if pending != nil {
+			if err := h.adminStore.AddPending(*pending); err != nil {
+				h.logger.Error("failed to restore pending request", "error", err, "user_id", userID)
+			}
+		}
+		return fmt.Errorf("approve user: %w", err)
+	}
+
+	h.sendSuccess(chatID, "Your access has been approved. You can now use the bot.")
+	return nil
+}
+
+// RevokeUser removes a user's approval on behalf of an external system.
+// Like /revoke, the user is not told.
+func (b *Bot) RevokeUser(userID int64) error {
+	if err := b.handler.adminStore.RemoveApproved(userID, admin.SystemActor); err != nil {
+		return fmt.Errorf("revoke user: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/admin"
+	"comfy-tg-bot/internal/server"
+)
+
+func TestTwoAdminsApproveSamePendingUser(t *testing.T) {
+	const userID = 500
+	admins := []int64{10, 20}
+	h, api, store := newTestHandler(t, admins...)
+
+	if err := store.AddPending(admin.PendingRequest{UserID: userID, Username: "alice", ChatID: userID, RequestedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdatePendingNotified(userID, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i, adminID := range admins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.handleAdminCallback(context.Background(), &tgbotapi.CallbackQuery{
+				ID:      fmt.Sprint(adminID),
+				From:    &tgbotapi.User{ID: adminID},
+				Message: &tgbotapi.Message{MessageID: i + 1, Chat: &tgbotapi.Chat{ID: adminID}},
+				Data:    "admin:approve:500",
+			})
+		}()
+	}
+	wg.Wait()
+
+	answers := api.callbackAnswers()
+	approved, lost := 0, 0
+	for _, a := range answers {
+		switch a {
+		case "User approved":
+			approved++
+		// Depending on timing the slower admin finds the request gone or
+		// loses the claim
+		case "Request not found or already processed", "Already processed by another admin":
+			lost++
+		}
+	}
+	if approved != 1 || lost != 1 {
+		t.Errorf("callback answers %q, want one approval and one already processed", answers)
+	}
+
+	if ok, err := store.IsApproved(userID); err != nil || !ok {
+		t.Errorf("IsApproved = %v, %v, want approved", ok, err)
+	}
+	if pending, err := store.ListAllPending(); err != nil || len(pending) != 0 {
+		t.Errorf("pending = %v, %v, want none", pending, err)
+	}
+	entries, err := store.GetAuditLog(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != admin.AuditApprove {
+		t.Errorf("audit log %+v, want a single approval", entries)
+	}
+	if sent := api.sentTo(userID); len(sent) != 1 {
+		t.Errorf("user got %q, want one approval message", sent)
+	}
+}
+
+func TestAdminWebhookApproveAndRevoke(t *testing.T) {
+	const secret = "0123456789abcdef"
+	h, api, store := newTestHandler(t, 10)
+	bot := &Bot{handler: h}
+	srv := httptest.NewServer(server.AdminWebhookHandler(secret, bot, h.logger))
+	defer srv.Close()
+
+	if err := store.AddPending(admin.PendingRequest{UserID: 500, Username: "alice", ChatID: 77, RequestedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(path string, userID int64) (int, string) {
+		t.Helper()
+		body := fmt.Sprintf(`{"user_id": %d, "secret": %q}`, userID, secret)
+		resp, err := http.Post(srv.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var reply struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, reply.Error
+	}
+
+	if status, errMsg := call("/webhook/approve", 500); status != http.StatusOK {
+		t.Fatalf("approve: %d %q", status, errMsg)
+	}
+	if ok, err := store.IsApproved(500); err != nil || !ok {
+		t.Errorf("IsApproved after approve = %v, %v", ok, err)
+	}
+	if pending, err := store.ListAllPending(); err != nil || len(pending) != 0 {
+		t.Errorf("pending after approve = %v, %v, want none", pending, err)
+	}
+	// The pending request's chat is notified, as with the approve button
+	if sent := api.sentTo(77); len(sent) != 1 || !strings.Contains(sent[0], "approved") {
+		t.Errorf("messages to user chat = %q, want one approval", sent)
+	}
+
+	if status, errMsg := call("/webhook/revoke", 500); status != http.StatusOK {
+		t.Fatalf("revoke: %d %q", status, errMsg)
+	}
+	if ok, err := store.IsApproved(500); err != nil || ok {
+		t.Errorf("IsApproved after revoke = %v, %v", ok, err)
+	}
+
+	if err := store.Ban(600, 10, "spam"); err != nil {
+		t.Fatal(err)
+	}
+	if status, errMsg := call("/webhook/approve", 600); status != http.StatusConflict || errMsg != admin.ErrUserBanned.Error() {
+		t.Errorf("approve banned user: %d %q, want 409 %q", status, errMsg, admin.ErrUserBanned)
+	}
+
+	got := make(map[string]int)
+	entries, err := store.GetAuditLog(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.TargetID == 500 {
+			if e.PerformedBy != admin.SystemActor {
+				t.Errorf("audit entry %+v not by the system actor", e)
+			}
+			got[e.Action]++
+		}
+	}
+	if got[admin.AuditApprove] != 1 || got[admin.AuditRevoke] != 1 {
+		t.Errorf("audit actions for user 500 = %v, want one approve and one revoke", got)
+	}
+}
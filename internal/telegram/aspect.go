@@ -0,0 +1,82 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/comfyui"
+)
+
+// aspectPromptTTL is how long a prompt waits for an aspect ratio choice
+const aspectPromptTTL = 5 * time.Minute
+
+// aspectCallbackPrefix prefixes aspect ratio callback data, e.g. "aspect:16:9"
+const aspectCallbackPrefix = "aspect:"
+
+// aspectPrompt is a prompt waiting for the user to pick an aspect ratio
+type aspectPrompt struct {
+	prompt  string
+	expires time.Time
+}
+
+// askAspectRatio stores the prompt and asks the user to choose an aspect
+// ratio. Returns false if the user's workflow has no dimension
+// placeholders or they have set a size with /setsize, in which case the
+// caller should generate directly.
+func (h *Handler) askAspectRatio(chatID, userID int64, prompt string) bool {
+	if !h.comfy.SupportsDimensions(h.workflowName(userID)) {
+		return false
+	}
+	if us, err := h.settings.Get(userID); err == nil && us.HasPreferredSize() {
+		return false
+	}
+
+	h.aspectMu.Lock()
+	h.aspectPrompts[userID] = aspectPrompt{prompt: prompt, expires: time.Now().Add(aspectPromptTTL)}
+	h.aspectMu.Unlock()
+
+	msg := h.newMessage(chatID, "Choose an aspect ratio for: "+truncate(prompt, 500))
+	msg.ReplyMarkup = aspectKeyboard()
+	if _, err := h.bot.Send(msg); err != nil {
+		h.logger.Error("failed to send aspect ratio keyboard", "error", err)
+	}
+	return true
+}
+
+// aspectKeyboard builds one button per aspect ratio
+func aspectKeyboard() tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, ar := range comfyui.AspectRatios {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(ar.Name, aspectCallbackPrefix+ar.Name))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// handleAspectCallback starts the pending generation with the chosen ratio
+func (h *Handler) handleAspectCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	userID := query.From.ID
+
+	aspect, ok := comfyui.FindAspectRatio(strings.TrimPrefix(query.Data, aspectCallbackPrefix))
+	if !ok {
+		h.answerCallback(query.ID, "Unknown aspect ratio")
+		return
+	}
+
+	h.aspectMu.Lock()
+	pending, ok := h.aspectPrompts[userID]
+	delete(h.aspectPrompts, userID)
+	h.aspectMu.Unlock()
+
+	if !ok || time.Now().After(pending.expires) || query.Message == nil {
+		h.answerCallback(query.ID, "This prompt has expired. Please send it again.")
+		return
+	}
+
+	h.updateAdminMessage(query.Message.Chat.ID, query.Message.MessageID,
+		"Generating ("+aspect.Name+"): "+truncate(pending.prompt, 500))
+	h.answerCallback(query.ID, "Generating...")
+	h.generateForUser(ctx, query.Message.Chat.ID, userID, pending.prompt, genOptions{aspect: &aspect})
+}
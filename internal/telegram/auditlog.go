@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/admin"
+)
+
+// auditLogLimit is the number of entries shown by /auditlog
+const auditLogLimit = 20
+
+// handleAuditLog handles the /auditlog command for admins
+func (h *Handler) handleAuditLog(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.adminStore == nil {
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
+		return
+	}
+
+	entries, err := h.adminStore.GetAuditLog(0, auditLogLimit)
+	if err != nil {
+		h.logger.Error("failed to load audit log", "error", err)
+		h.sendError(msg.Chat.ID, "Failed to load the audit log.")
+		return
+	}
+
+	if len(entries) == 0 {
+		h.sendText(msg.Chat.ID, "The audit log is empty.")
+		return
+	}
+
+	h.sendText(msg.Chat.ID, formatAuditLog(entries))
+}
+
+// formatAuditLog renders audit entries one per line, e.g.
+// "2026-01-02 15:04 UTC: 111 revoke user 222 (@name)". Actions the bot
+// took on its own, such as expiring access, are shown as by "system".
+func formatAuditLog(entries []admin.AuditEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Last %d admin actions:\n\n", len(entries))
+	for _, e := range entries {
+		actor := "system"
+		if e.PerformedBy != admin.SystemActor {
+			actor = strconv.FormatInt(e.PerformedBy, 10)
+		}
+		fmt.Fprintf(&b, "%s: %s %s %s %d",
+			e.Timestamp.UTC().Format("2006-01-02 15:04 UTC"),
+			actor, e.Action, e.TargetType, e.TargetID)
+		if e.Notes != "" {
+			if e.TargetType == admin.TargetUser {
+				fmt.Fprintf(&b, " (%s)", formatUsername(e.Notes))
+			} else {
+				fmt.Fprintf(&b, " (%s)", e.Notes)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,114 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// rejectBanReason is stored for users banned from the approval keyboard
+const rejectBanReason = "rejected access request"
+
+// rejectKeyboard asks whether a rejected user should also be banned
+func rejectKeyboard(userID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Reject", fmt.Sprintf("admin:rejectonly:%d", userID)),
+			tgbotapi.NewInlineKeyboardButtonData("Reject & Ban", fmt.Sprintf("admin:rejectban:%d", userID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Back", fmt.Sprintf("admin:back:%d", userID)),
+		),
+	)
+}
+
+// showKeyboard replaces the buttons under an admin message
+func (h *Handler) showKeyboard(query *tgbotapi.CallbackQuery, keyboard tgbotapi.InlineKeyboardMarkup) {
+	edit := tgbotapi.NewEditMessageReplyMarkup(query.Message.Chat.ID, query.Message.MessageID, keyboard)
+	if _, err := h.bot.Send(edit); err != nil {
+		h.logger.Error("failed to update admin keyboard", "error", err)
+	}
+	h.answerCallback(query.ID, "")
+}
+
+// handleBan handles the /ban command for admins
+func (h *Handler) handleBan(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.adminStore == nil {
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
+		return
+	}
+
+	const usage = "Usage: /ban <user_id> [reason]"
+	idArg, reason, _ := strings.Cut(strings.TrimSpace(msg.CommandArguments()), " ")
+	if idArg == "" {
+		h.sendError(msg.Chat.ID, usage)
+		return
+	}
+
+	userID, err := strconv.ParseInt(idArg, 10, 64)
+	if err != nil {
+		h.sendError(msg.Chat.ID, "Invalid user ID. "+usage)
+		return
+	}
+
+	if h.whitelist.IsAdmin(userID) {
+		h.sendError(msg.Chat.ID, "Admins cannot be banned.")
+		return
+	}
+
+	if err := h.adminStore.Ban(userID, msg.From.ID, strings.TrimSpace(reason)); err != nil {
+		h.logger.Error("failed to ban user", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to ban user.")
+		return
+	}
+	h.logger.Info("user banned", "user_id", userID, "admin_id", msg.From.ID)
+
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf("User %d has been banned. Their messages will be ignored.", userID))
+}
+
+// handleUnban handles the /unban command for admins
+func (h *Handler) handleUnban(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.adminStore == nil {
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
+		return
+	}
+
+	args := msg.CommandArguments()
+	if args == "" {
+		h.sendError(msg.Chat.ID, "Usage: /unban <user_id>")
+		return
+	}
+
+	userID, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64)
+	if err != nil {
+		h.sendError(msg.Chat.ID, "Invalid user ID. Usage: /unban <user_id>")
+		return
+	}
+
+	unbanned, err := h.adminStore.Unban(userID, msg.From.ID)
+	if err != nil {
+		h.logger.Error("failed to unban user", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to unban user.")
+		return
+	}
+	if !unbanned {
+		h.sendError(msg.Chat.ID, fmt.Sprintf("User %d is not banned.", userID))
+		return
+	}
+	h.logger.Info("user unbanned", "user_id", userID, "admin_id", msg.From.ID)
+
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf("User %d has been unbanned and may request access again.", userID))
+}
@@ -0,0 +1,267 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/comfyui"
+	apperrors "comfy-tg-bot/internal/errors"
+)
+
+// batchConcurrency is how many prompts of a batch are queued on ComfyUI at
+// once
+const batchConcurrency = 2
+
+// batchPrefix matches the "[N] " batch size prefix of a prompt
+var batchPrefix = regexp.MustCompile(`^\s*\[(\d+)\]\s*`)
+
+// parseBatchPrefix splits a "[N] prompt" message into N and the prompt.
+// ok is false if the text has no batch prefix.
+func parseBatchPrefix(text string) (count int, prompt string, ok bool) {
+	m := batchPrefix.FindStringSubmatch(text)
+	if m == nil {
+		return 0, text, false
+	}
+	count, err := strconv.Atoi(m[1])
+	if err != nil {
+		// Too many digits to be a sensible batch size
+		count = -1
+	}
+	return count, text[len(m[0]):], true
+}
+
+// batchResult is the outcome of one generation in a batch
+type batchResult struct {
+	output *comfyui.Output
+	err    error
+	// promptIDs are the ComfyUI prompts queued for the image
+	promptIDs []string
+}
+
+// generateBatch generates count variations of prompt, each with its own
+// seed, and sends the images as an album. The user's limiter slot is held
+// until every generation has finished.
+func (h *Handler) generateBatch(ctx context.Context, chatID, userID int64, prompt string, count int) {
+	if h.rejectIfMaintenance(chatID, userID) {
+		return
+	}
+	if count < 1 || count > h.cfg.MaxBatchSize {
+		h.sendError(chatID, fmt.Sprintf("Batch size must be between 1 and %d.", h.cfg.MaxBatchSize))
+		return
+	}
+	if count == 1 {
+		h.generateForUser(ctx, chatID, userID, prompt, genOptions{})
+		return
+	}
+
+	if len(prompt) < 3 {
+		h.sendError(chatID, "Please provide a more detailed prompt (at least 3 characters).")
+		return
+	}
+
+	finalPrompt := h.affixPrompt(userID, prompt)
+	h.warnIfNearTokenLimit(chatID, finalPrompt)
+
+	if err := h.checkQuotaN(userID, count); err != nil {
+		h.sendError(chatID, apperrors.GetUserMessage(err))
+		return
+	}
+
+	if h.remoteQueueBusy(ctx, chatID) {
+		return
+	}
+
+	if err := h.limiter.WaitOrAcquire(ctx, userID, chatID); err != nil {
+		h.sendError(chatID, apperrors.GetUserMessage(err))
+		return
+	}
+	genCtx, pending := h.trackGeneration(ctx, userID)
+	defer h.finishGeneration(userID, pending)
+
+	statusMsg, err := h.bot.Send(h.newMessage(chatID, fmt.Sprintf("Generating %d images...", count)))
+	if err != nil {
+		h.logger.Error("failed to send status message", "error", err)
+	}
+	deleteStatus := func() {
+		if statusMsg.MessageID != 0 {
+			h.bot.Request(tgbotapi.NewDeleteMessage(chatID, statusMsg.MessageID))
+		}
+	}
+
+	stopAction := h.startChatAction(genCtx, chatID, tgbotapi.ChatTyping)
+	defer func() { stopAction() }()
+
+	h.logger.Info("starting batch generation", "user_id", userID, "count", count, "prompt_length", len(prompt))
+
+	results := make([]batchResult, count)
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-genCtx.Done():
+				results[i].err = genCtx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			started := time.Now()
+			genOpts := h.generateOptions(userID)
+			genOpts.RandomSeed = true
+			model := h.generationModel(genOpts)
+			// Every queued prompt of the batch is tracked so /cancel can
+			// remove all of them; finished ones are dropped again
+			var queued []string
+			genOpts.OnQueued = func(promptID string) {
+				queued = append(queued, promptID)
+				h.addPendingPromptID(pending, promptID)
+			}
+			output, err := h.comfy.GenerateImage(genCtx, finalPrompt, genOpts)
+			h.removePendingPromptIDs(pending, queued)
+			if !generationCancelled(genCtx) {
+				h.recordGeneration(userID, model, started, err == nil)
+				h.observeGeneration(started, err)
+			}
+			results[i] = batchResult{output: output, err: err, promptIDs: queued}
+		}()
+	}
+	wg.Wait()
+	stopAction()
+
+	if generationCancelled(genCtx) {
+		deleteStatus()
+		return
+	}
+
+	var outputs []*comfyui.Output
+	var firstErr error
+	var failedPromptIDs []string
+	for _, r := range results {
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			failedPromptIDs = append(failedPromptIDs, r.promptIDs...)
+			continue
+		}
+		outputs = append(outputs, r.output)
+		h.recordUsage(userID)
+	}
+	if firstErr != nil {
+		h.interruptIfCancelled(genCtx, userID, failedPromptIDs)
+		h.logger.Error("batch generation failed", "error", firstErr, "user_id", userID,
+			"failed", count-len(outputs), "count", count)
+		h.rememberError(userID, firstErr)
+	}
+	deleteStatus()
+
+	if len(outputs) == 0 {
+		h.sendError(chatID, apperrors.GetUserMessage(firstErr))
+		return
+	}
+
+	h.recordHistory(userID, chatID, prompt, "", outputs[0])
+	stopAction = h.startChatAction(genCtx, chatID, tgbotapi.ChatUploadPhoto)
+	h.sendBatch(chatID, userID, prompt, finalPrompt, outputs)
+
+	if failed := count - len(outputs); failed > 0 {
+		h.sendError(chatID, fmt.Sprintf("%d of %d images failed: %s",
+			failed, count, apperrors.GetUserMessage(firstErr)))
+	}
+}
+
+// sendBatch sends the images of a batch as albums, each captioned with its
+// index and seed: previews as photos and originals as files according to
+// the user's settings. Videos are sent individually. finalPrompt is the
+// prompt as generated, with the user's prefix and suffix, for the PNG
+// metadata.
+func (h *Handler) sendBatch(chatID, userID int64, prompt, finalPrompt string, outputs []*comfyui.Output) {
+	sendOriginal, sendCompressed, stripMetadata := true, true, false
+	if us, err := h.settings.Get(userID); err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+	} else {
+		sendOriginal, sendCompressed, stripMetadata = us.SendOriginal, us.SendCompressed, us.StripMetadata
+	}
+
+	var photos, documents []any
+	var seeds []int64
+	for i, output := range outputs {
+		if output.IsVideo() {
+			h.sendVideoOutput(chatID, userID, prompt, output, 0)
+			continue
+		}
+
+		result, err := h.processOutput(stripMetadata, output, finalPrompt)
+		if err != nil {
+			h.logger.Error("image processing failed", "error", err)
+			continue
+		}
+
+		seeds = append(seeds, output.Seed)
+		caption := fmt.Sprintf("%d/%d", i+1, len(outputs))
+		if output.Seed >= 0 {
+			caption += fmt.Sprintf("%s%d", captionSeedPrefix, output.Seed)
+		}
+		if sendCompressed {
+			photo := tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{
+				Name:  result.CompressedFilename(),
+				Bytes: result.Compressed,
+			})
+			photo.Caption = caption
+			photos = append(photos, photo)
+		}
+		if sendOriginal {
+			doc := tgbotapi.NewInputMediaDocument(tgbotapi.FileBytes{
+				Name:  fmt.Sprintf("image_%d.png", i+1),
+				Bytes: result.OriginalDocument(),
+			})
+			doc.Caption = caption
+			documents = append(documents, doc)
+		}
+	}
+
+	h.sendAlbum(chatID, prompt, photos, seeds)
+	h.sendAlbum(chatID, prompt, documents, seeds)
+}
+
+// sendAlbum sends media as one album. Telegram albums need at least two
+// items, so a single item is sent on its own captioned with the prompt and
+// its seed from seeds.
+func (h *Handler) sendAlbum(chatID int64, prompt string, media []any, seeds []int64) {
+	if len(media) == 0 {
+		return
+	}
+
+	if len(media) == 1 {
+		var single tgbotapi.Chattable
+		switch item := media[0].(type) {
+		case tgbotapi.InputMediaPhoto:
+			photo := tgbotapi.NewPhoto(chatID, item.Media)
+			photo.Caption = promptCaption(prompt, seeds[0])
+			single = photo
+		case tgbotapi.InputMediaDocument:
+			doc := tgbotapi.NewDocument(chatID, item.Media)
+			doc.Caption = promptCaption(prompt, seeds[0])
+			single = doc
+		default:
+			return
+		}
+		if _, err := h.bot.Send(single); err != nil {
+			h.logger.Error("failed to send batch image", "error", err)
+		}
+		return
+	}
+
+	if _, err := h.bot.SendMediaGroup(tgbotapi.NewMediaGroup(chatID, media)); err != nil {
+		h.logger.Error("failed to send album", "error", err)
+	}
+}
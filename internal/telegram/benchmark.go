@@ -0,0 +1,154 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/comfyui"
+	apperrors "comfy-tg-bot/internal/errors"
+)
+
+// Bounds for the number of /benchmark runs
+const (
+	minBenchmarkRuns = 2
+	maxBenchmarkRuns = 5
+)
+
+// handleBenchmark handles the /benchmark <n> <prompt> command for admins
+func (h *Handler) handleBenchmark(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	countArg, prompt, _ := strings.Cut(strings.TrimSpace(msg.CommandArguments()), " ")
+	runs, err := strconv.Atoi(countArg)
+	prompt = strings.TrimSpace(prompt)
+	if err != nil || prompt == "" {
+		h.sendError(msg.Chat.ID, "Usage: /benchmark <n> <prompt>")
+		return
+	}
+	if runs < minBenchmarkRuns || runs > maxBenchmarkRuns {
+		h.sendError(msg.Chat.ID, fmt.Sprintf("Run count must be between %d and %d.", minBenchmarkRuns, maxBenchmarkRuns))
+		return
+	}
+
+	userID := msg.From.ID
+	if err := h.limiter.TryAcquire(userID); err != nil {
+		h.sendError(msg.Chat.ID, apperrors.GetUserMessage(err))
+		return
+	}
+	defer h.limiter.Release(userID)
+
+	queueBefore := h.queueDepth(ctx)
+
+	statusMsg, err := h.bot.Send(h.newMessage(msg.Chat.ID, fmt.Sprintf("Benchmarking %d runs...", runs)))
+	if err != nil {
+		h.logger.Error("failed to send status message", "error", err)
+	}
+
+	h.logger.Info("starting benchmark", "user_id", userID, "runs", runs, "prompt_length", len(prompt))
+
+	var durations []time.Duration
+	failures := 0
+	for i := 1; i <= runs; i++ {
+		if statusMsg.MessageID != 0 {
+			edit := tgbotapi.NewEditMessageText(msg.Chat.ID, statusMsg.MessageID,
+				fmt.Sprintf("Benchmarking run %d/%d...", i, runs))
+			h.bot.Send(edit)
+		}
+
+		// Random seeds keep ComfyUI from serving repeated runs from its cache
+		started := time.Now()
+		var queued []string
+		_, err := h.comfy.GenerateImage(ctx, prompt, comfyui.GenerateOptions{
+			RandomSeed: true,
+			OnQueued:   func(promptID string) { queued = append(queued, promptID) },
+		})
+		elapsed := time.Since(started)
+		if err != nil {
+			if ctx.Err() != nil {
+				h.interruptIfCancelled(ctx, userID, queued)
+				return
+			}
+			h.logger.Warn("benchmark run failed", "error", err, "run", i)
+			failures++
+			continue
+		}
+		durations = append(durations, elapsed)
+	}
+
+	if statusMsg.MessageID != 0 {
+		h.bot.Request(tgbotapi.NewDeleteMessage(msg.Chat.ID, statusMsg.MessageID))
+	}
+
+	queueAfter := h.queueDepth(ctx)
+
+	if len(durations) == 0 {
+		h.sendError(msg.Chat.ID, fmt.Sprintf("All %d benchmark runs failed. Check the logs for details.", runs))
+		return
+	}
+
+	fastest, slowest, mean, stddev := durationStats(durations)
+	h.logger.Info("benchmark completed",
+		"runs", runs, "failures", failures,
+		"min", fastest, "max", slowest, "mean", mean, "stddev", stddev)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Benchmark results (%d runs):\n\n", runs)
+	b.WriteString("Run | Time\n")
+	for i, d := range durations {
+		fmt.Fprintf(&b, "%d | %.1fs\n", i+1, d.Seconds())
+	}
+	b.WriteString("\nMetric | Value\n")
+	fmt.Fprintf(&b, "Min | %.1fs\n", fastest.Seconds())
+	fmt.Fprintf(&b, "Max | %.1fs\n", slowest.Seconds())
+	fmt.Fprintf(&b, "Mean | %.1fs\n", mean.Seconds())
+	fmt.Fprintf(&b, "Std dev | %.1fs\n", stddev.Seconds())
+	fmt.Fprintf(&b, "Failures | %d\n", failures)
+	fmt.Fprintf(&b, "Queue before | %s\n", queueBefore)
+	fmt.Fprintf(&b, "Queue after | %s", queueAfter)
+
+	h.sendText(msg.Chat.ID, b.String())
+}
+
+// queueDepth describes the current ComfyUI queue for reports
+func (h *Handler) queueDepth(ctx context.Context) string {
+	queue, err := h.comfy.GetQueue(ctx)
+	if err != nil {
+		return "unavailable"
+	}
+	return fmt.Sprintf("%d running, %d pending", len(queue.QueueRunning), len(queue.QueuePending))
+}
+
+// durationStats returns the min, max, mean and population standard
+// deviation of a non-empty slice of durations
+func durationStats(durations []time.Duration) (fastest, slowest, mean, stddev time.Duration) {
+	fastest, slowest = durations[0], durations[0]
+	var sum float64
+	for _, d := range durations {
+		if d < fastest {
+			fastest = d
+		}
+		if d > slowest {
+			slowest = d
+		}
+		sum += float64(d)
+	}
+	avg := sum / float64(len(durations))
+
+	var variance float64
+	for _, d := range durations {
+		diff := float64(d) - avg
+		variance += diff * diff
+	}
+	variance /= float64(len(durations))
+
+	return fastest, slowest, time.Duration(avg), time.Duration(math.Sqrt(variance))
+}
@@ -5,16 +5,26 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"comfy-tg-bot/internal/admin"
-	"comfy-tg-bot/internal/comfyui"
+	"comfy-tg-bot/internal/bugreport"
 	"comfy-tg-bot/internal/config"
+	"comfy-tg-bot/internal/erasure"
+	"comfy-tg-bot/internal/favorites"
+	"comfy-tg-bot/internal/gallery"
+	"comfy-tg-bot/internal/history"
 	"comfy-tg-bot/internal/image"
 	"comfy-tg-bot/internal/limiter"
+	"comfy-tg-bot/internal/metrics"
+	"comfy-tg-bot/internal/prompt"
 	"comfy-tg-bot/internal/settings"
+	"comfy-tg-bot/internal/state"
+	"comfy-tg-bot/internal/stats"
+	"comfy-tg-bot/internal/usage"
 )
 
 // Bot represents the Telegram bot
@@ -26,16 +36,44 @@ type Bot struct {
 
 	// Track active message processing
 	activeRequests sync.WaitGroup
+
+	// healthy is set while updates are being received
+	healthy atomic.Bool
+
+	// drainTimeout is how long in-flight updates may run after shutdown
+	// starts before they are cancelled
+	drainTimeout time.Duration
+
+	// stateStore keeps the update offset across restarts (nil = start
+	// from whatever Telegram still holds)
+	stateStore state.StateStore
 }
 
+// defaultDrainTimeout is used unless SetDrainTimeout is called
+const defaultDrainTimeout = 25 * time.Second
+
+// drainCancelGrace is how long cancelled updates get to clean up once the
+// drain timeout has passed
+const drainCancelGrace = 5 * time.Second
+
 // NewBot creates a new Telegram bot
 func NewBot(
 	cfg config.TelegramConfig,
-	comfyClient *comfyui.Client,
+	comfyClient ComfyUI,
 	imageProcessor *image.Processor,
-	userLimiter *limiter.UserLimiter,
+	userLimiter limiter.Limiter,
 	settingsStore settings.Store,
 	adminStore admin.Store,
+	diskMonitor *admin.DiskMonitor,
+	historyStore history.Store,
+	favoriteStore favorites.FavoriteStore,
+	galleryStore gallery.Store,
+	bugReports bugreport.BugReportStore,
+	statsStore stats.Store,
+	quota *usage.Quota,
+	eraser erasure.Eraser,
+	vocab *prompt.Vocabulary,
+	tokenizer *prompt.Tokenizer,
 	logger *slog.Logger,
 ) (*Bot, error) {
 	api, err := tgbotapi.NewBotAPI(cfg.BotToken)
@@ -43,71 +81,269 @@ func NewBot(
 		return nil, fmt.Errorf("create bot api: %w", err)
 	}
 
-	whitelist := NewWhitelist(cfg.AllowedUsers, adminStore, cfg.AdminUser, logger)
-	handler := NewHandler(api, comfyClient, imageProcessor, whitelist, userLimiter, settingsStore, adminStore, logger)
+	whitelist := NewWhitelist(cfg.AllowedUsers, adminStore, cfg.AdminUsers, logger)
+	handler := NewHandler(api, cfg, comfyClient, imageProcessor, whitelist, userLimiter, settingsStore, adminStore, diskMonitor, historyStore, favoriteStore, galleryStore, bugReports, statsStore, quota, eraser, vocab, tokenizer, logger)
 
 	return &Bot{
-		api:     api,
-		handler: handler,
-		cfg:     cfg,
-		logger:  logger,
+		api:          api,
+		handler:      handler,
+		cfg:          cfg,
+		logger:       logger,
+		drainTimeout: defaultDrainTimeout,
 	}, nil
 }
 
-// Run starts the bot and blocks until context is cancelled
+// Run starts the bot and blocks until context is cancelled. Shutdown has
+// two phases: once ctx is cancelled no new updates are accepted, and
+// updates already being handled get up to the drain timeout to finish
+// their generations and send the results before they are cancelled.
 func (b *Bot) Run(ctx context.Context) error {
-	u := tgbotapi.NewUpdate(0)
+	b.logger.Info("bot started", "username", b.api.Self.UserName)
+
+	b.registerCommands()
+
+	b.handler.runCtx = ctx
+	go b.handler.runStaleReminders(ctx, time.Duration(b.cfg.StaleRequestHours)*time.Hour)
+	go b.handler.runLongPromptCleanup(ctx)
+
+	// Updates are handled on drainCtx, which is only cancelled once the
+	// drain timeout has passed
+	drainCtx, cancelDrain := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelDrain()
+
+	if b.cfg.WebhookURL != "" {
+		return b.runWebhook(ctx, drainCtx, cancelDrain)
+	}
+	return b.runPolling(ctx, drainCtx, cancelDrain)
+}
+
+// runPolling receives updates via long polling until ctx is cancelled,
+// handling them on drainCtx
+func (b *Bot) runPolling(ctx, drainCtx context.Context, cancelDrain context.CancelFunc) error {
+	u := tgbotapi.NewUpdate(b.savedUpdateOffset())
 	u.Timeout = b.cfg.PollingTimeout
 
-	updates := b.api.GetUpdatesChan(u)
+	pollCtx, stopPolling := context.WithCancel(ctx)
+	updates := b.pollUpdates(pollCtx, u)
+	b.healthy.Store(true)
+	defer b.healthy.Store(false)
 
-	b.logger.Info("bot started", "username", b.api.Self.UserName)
+	// If no update arrives within the health check interval, verify the
+	// API is reachable and restart polling if it is not
+	healthCheck := time.NewTimer(b.cfg.PollingHealthCheckInterval)
+	defer healthCheck.Stop()
+	restartFailures := 0
 
 	for {
 		select {
 		case <-ctx.Done():
-			b.logger.Info("stopping bot, waiting for active requests")
-
 			// Stop receiving updates
-			b.api.StopReceivingUpdates()
+			stopPolling()
+			b.waitForActiveRequests(cancelDrain)
+			return ctx.Err()
+
+		case <-healthCheck.C:
+			_, err := b.api.GetMe()
+			if err == nil {
+				b.healthy.Store(true)
+				restartFailures = 0
+				healthCheck.Reset(b.cfg.PollingHealthCheckInterval)
+				continue
+			}
 
-			// Wait for active requests with timeout
-			done := make(chan struct{})
-			go func() {
-				b.activeRequests.Wait()
-				close(done)
-			}()
+			b.healthy.Store(false)
+			restartFailures++
+			delay := b.pollingRestartDelay(restartFailures)
+			b.logger.Warn("telegram api unreachable, restarting update polling",
+				"error", err,
+				"attempt", restartFailures,
+				"delay", delay,
+			)
 
 			select {
-			case <-done:
-				b.logger.Info("all active requests completed")
-			case <-time.After(25 * time.Second):
-				b.logger.Warn("some requests may not have completed")
+			case <-ctx.Done():
+				continue
+			case <-time.After(delay):
 			}
 
-			return ctx.Err()
+			stopPolling()
+			pollCtx, stopPolling = context.WithCancel(ctx)
+			updates = b.pollUpdates(pollCtx, u)
+			healthCheck.Reset(b.cfg.PollingHealthCheckInterval)
 
 		case update, ok := <-updates:
 			if !ok {
+				stopPolling()
 				return nil
 			}
 
-			// Process update in goroutine
-			b.activeRequests.Add(1)
-			go func(upd tgbotapi.Update) {
-				defer b.activeRequests.Done()
-
-				// Create request context with timeout
-				reqCtx, cancel := context.WithTimeout(ctx, b.cfg.RequestTimeout)
-				defer cancel()
+			// Resume after this update if polling or the bot has to be
+			// restarted
+			u.Offset = update.UpdateID + 1
+			b.saveUpdateOffset(update.UpdateID)
+			b.healthy.Store(true)
+			restartFailures = 0
+			if !healthCheck.Stop() {
+				select {
+				case <-healthCheck.C:
+				default:
+				}
+			}
+			healthCheck.Reset(b.cfg.PollingHealthCheckInterval)
 
-				b.handler.HandleUpdate(reqCtx, upd)
-			}(update)
+			b.dispatch(drainCtx, update)
 		}
 	}
 }
 
+// dispatch processes an update in its own goroutine
+func (b *Bot) dispatch(ctx context.Context, update tgbotapi.Update) {
+	b.activeRequests.Add(1)
+	go func() {
+		defer b.activeRequests.Done()
+
+		// Create request context with timeout
+		reqCtx, cancel := context.WithTimeout(ctx, b.cfg.RequestTimeout)
+		defer cancel()
+
+		b.handler.HandleUpdate(reqCtx, update)
+	}()
+}
+
+// waitForActiveRequests waits up to the drain timeout for in-flight
+// updates during shutdown, then cancels the ones still running
+func (b *Bot) waitForActiveRequests(cancelDrain context.CancelFunc) {
+	b.logger.Info("stopping bot, waiting for active requests", "timeout", b.drainTimeout)
+
+	done := make(chan struct{})
+	go func() {
+		b.activeRequests.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		b.logger.Info("all active requests completed")
+		return
+	case <-time.After(b.drainTimeout):
+	}
+
+	b.logger.Warn("drain timeout exceeded, cancelling active requests")
+	cancelDrain()
+
+	select {
+	case <-done:
+		b.logger.Info("all active requests stopped")
+	case <-time.After(drainCancelGrace):
+		b.logger.Warn("some requests may not have completed")
+	}
+}
+
+// Healthy reports whether the bot is currently receiving updates: polling
+// is running and Telegram was reachable at the last check, or the webhook
+// server is listening
+func (b *Bot) Healthy() bool {
+	return b.healthy.Load()
+}
+
+// SetMetrics enables generation metrics; must be called before Run
+func (b *Bot) SetMetrics(m *metrics.Metrics) {
+	b.handler.metrics = m
+}
+
+// SetMaxRemoteQueueDepth rejects new prompts while ComfyUI has more than n
+// jobs queued (0 = no limit); must be called before Run
+func (b *Bot) SetMaxRemoteQueueDepth(n int) {
+	b.handler.maxRemoteQueue = n
+}
+
+// UpdateAllowedUsers replaces the statically allowed users, e.g. after the
+// configuration is reloaded
+func (b *Bot) UpdateAllowedUsers(userIDs []int64) {
+	b.handler.whitelist.UpdateStaticList(userIDs)
+}
+
+// SetMaxImageSize bounds the output size users can set with /setsize;
+// must be called before Run
+func (b *Bot) SetMaxImageSize(width, height int) {
+	b.handler.maxImageWidth = width
+	b.handler.maxImageHeight = height
+}
+
+// SetMaxUserTimeout bounds the timeout non-admins can set with /settimeout,
+// in seconds (0 = admins only); must be called before Run
+func (b *Bot) SetMaxUserTimeout(seconds int) {
+	b.handler.maxUserTimeout = seconds
+}
+
+// SetDurationTracker records how long generations take so queued users
+// can be given a wait estimate; must be called before Run
+func (b *Bot) SetDurationTracker(t *limiter.DurationTracker) {
+	b.handler.durations = t
+}
+
+// SetInterruptEnabled allows sending /interrupt to ComfyUI when a
+// generation is abandoned; must be called before Run
+func (b *Bot) SetInterruptEnabled(enabled bool) {
+	b.handler.enableInterrupt = enabled
+}
+
+// SetStateStore persists the last received update so a restart does not
+// handle it again; must be called before Run
+func (b *Bot) SetStateStore(s state.StateStore) {
+	b.stateStore = s
+}
+
+// SetTranscriber enables voice message prompts; must be called before Run
+func (b *Bot) SetTranscriber(t Transcriber) {
+	b.handler.transcriber = t
+}
+
+// SetDrainTimeout sets how long in-flight updates may run once shutdown
+// starts; must be called before Run
+func (b *Bot) SetDrainTimeout(d time.Duration) {
+	b.drainTimeout = d
+}
+
+// RunPendingExpiry deletes access requests left unanswered for longer than
+// expireAfter, checking hourly until ctx is cancelled (0 = never expire)
+func (b *Bot) RunPendingExpiry(ctx context.Context, expireAfter time.Duration) {
+	b.handler.runPendingExpiry(ctx, expireAfter)
+}
+
+// NotifyAccessExpired tells a user their temporary access has ended
+func (b *Bot) NotifyAccessExpired(user admin.ApprovedUser) {
+	// A user's private chat ID is their user ID
+	b.handler.sendText(user.UserID, "Your temporary access to this bot has expired. Send a message to request access again.")
+}
+
 // GetBotInfo returns information about the bot
 func (b *Bot) GetBotInfo() tgbotapi.User {
 	return b.api.Self
 }
+
+// NotifyAdmin sends a plain text message to every configured admin
+func (b *Bot) NotifyAdmin(text string) {
+	b.handler.notifyAdmins(text)
+}
+
+// NotifyQueuePosition tells a user where their request sits in the queue
+// and, once generations have been timed, roughly how long they will wait
+func (b *Bot) NotifyQueuePosition(chatID int64, position int) {
+	text := fmt.Sprintf("You are #%d in the queue. We'll start your image soon.", position)
+	if b.handler.durations != nil {
+		if avg := b.handler.durations.Average(); avg > 0 {
+			text += "\nEstimated wait: ~" + formatWait(time.Duration(position)*avg)
+		}
+	}
+	b.handler.sendText(chatID, text)
+}
+
+// formatWait formats a wait estimate as e.g. "2m 30s" or "45s"
+func formatWait(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	return fmt.Sprintf("%dm %ds", int(d.Minutes()), int(d.Seconds())%60)
+}
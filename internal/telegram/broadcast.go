@@ -0,0 +1,149 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// broadcastInterval spaces out broadcast messages to stay well below
+// Telegram's limit of 30 messages per second
+const broadcastInterval = 50 * time.Millisecond
+
+// broadcastProgressEvery is how many recipients pass between progress
+// updates of the admin's status message
+const broadcastProgressEvery = 100
+
+// recordChatID stores the private chat a user is talking to the bot in, so
+// /broadcast can reach them. Each user is written once per chat ID.
+func (h *Handler) recordChatID(userID, chatID int64) {
+	if h.adminStore == nil {
+		return
+	}
+
+	h.knownChatsMu.Lock()
+	known := h.knownChats[userID] == chatID
+	h.knownChats[userID] = chatID
+	h.knownChatsMu.Unlock()
+	if known {
+		return
+	}
+
+	if err := h.adminStore.RecordChatID(userID, chatID); err != nil {
+		h.logger.Warn("failed to record chat id", "error", err, "user_id", userID)
+		h.knownChatsMu.Lock()
+		delete(h.knownChats, userID)
+		h.knownChatsMu.Unlock()
+	}
+}
+
+// handleBroadcast handles the /broadcast command for admins: the message
+// is sent to every approved user whose access has not expired. Sending
+// runs in the background so it isn't cut off by the request timeout.
+func (h *Handler) handleBroadcast(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.adminStore == nil {
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
+		return
+	}
+
+	text := strings.TrimSpace(msg.CommandArguments())
+	if text == "" {
+		h.sendError(msg.Chat.ID, "Usage: /broadcast <message>")
+		return
+	}
+
+	users, err := h.adminStore.ListAllApproved()
+	if err != nil {
+		h.logger.Error("failed to list approved users", "error", err)
+		h.sendError(msg.Chat.ID, "Failed to load approved users.")
+		return
+	}
+	chatIDs, err := h.adminStore.ListChatIDs()
+	if err != nil {
+		h.logger.Error("failed to list chat ids", "error", err)
+		h.sendError(msg.Chat.ID, "Failed to load user chats.")
+		return
+	}
+
+	now := time.Now()
+	var recipients []int64
+	for _, u := range users {
+		if u.Expired(now) {
+			continue
+		}
+		chatID, ok := chatIDs[u.UserID]
+		if !ok {
+			// A user's private chat ID is their user ID
+			chatID = u.UserID
+		}
+		recipients = append(recipients, chatID)
+	}
+	if len(recipients) == 0 {
+		h.sendText(msg.Chat.ID, "There are no approved users to broadcast to.")
+		return
+	}
+
+	if !h.broadcasting.CompareAndSwap(false, true) {
+		h.sendError(msg.Chat.ID, "A broadcast is already being sent.")
+		return
+	}
+
+	statusMsg, err := h.bot.Send(h.newMessage(msg.Chat.ID, fmt.Sprintf("Broadcasting to %d users...", len(recipients))))
+	if err != nil {
+		h.logger.Warn("failed to send broadcast status", "error", err)
+	}
+	h.logger.Info("broadcast started", "admin_id", msg.From.ID, "recipients", len(recipients))
+
+	go func() {
+		defer h.broadcasting.Store(false)
+		h.sendBroadcast(h.runCtx, msg.Chat.ID, statusMsg.MessageID, text, recipients)
+	}()
+}
+
+// sendBroadcast sends text to each recipient, updating the admin's status
+// message as it goes and reporting the totals when done or interrupted
+func (h *Handler) sendBroadcast(ctx context.Context, adminChatID int64, statusMsgID int, text string, recipients []int64) {
+	ticker := time.NewTicker(broadcastInterval)
+	defer ticker.Stop()
+
+	sent, failed := 0, 0
+	for i, chatID := range recipients {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				h.logger.Warn("broadcast interrupted", "sent", sent, "failed", failed, "error", ctx.Err())
+				h.sendError(adminChatID, fmt.Sprintf(
+					"Broadcast interrupted: %d sent, %d failed, %d not attempted.",
+					sent, failed, len(recipients)-i))
+				return
+			case <-ticker.C:
+			}
+		}
+
+		if _, err := h.bot.Send(h.newMessage(chatID, text)); err != nil {
+			h.logger.Warn("failed to send broadcast", "error", err, "chat_id", chatID)
+			failed++
+		} else {
+			sent++
+		}
+
+		if done := i + 1; statusMsgID != 0 && done%broadcastProgressEvery == 0 && done < len(recipients) {
+			h.bot.Request(tgbotapi.NewEditMessageText(adminChatID, statusMsgID,
+				fmt.Sprintf("Broadcasting to %d users... %d/%d done.", len(recipients), done, len(recipients))))
+		}
+	}
+
+	h.logger.Info("broadcast finished", "sent", sent, "failed", failed)
+	if statusMsgID != 0 {
+		h.bot.Request(tgbotapi.NewDeleteMessage(adminChatID, statusMsgID))
+	}
+	h.sendSuccess(adminChatID, fmt.Sprintf("Broadcast finished: %d sent, %d failed.", sent, failed))
+}
@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/bugreport"
+)
+
+// maxBugDescriptionLength caps the stored description
+const maxBugDescriptionLength = 2000
+
+// rememberError records the most recent error seen by a user for bug reports
+func (h *Handler) rememberError(userID int64, err error) {
+	h.lastErrorsMu.Lock()
+	defer h.lastErrorsMu.Unlock()
+	h.lastErrors[userID] = err.Error()
+}
+
+// lastError returns the most recent error seen by a user, if any
+func (h *Handler) lastError(userID int64) string {
+	h.lastErrorsMu.Lock()
+	defer h.lastErrorsMu.Unlock()
+	return h.lastErrors[userID]
+}
+
+// handleReportBug handles the /reportbug command
+func (h *Handler) handleReportBug(ctx context.Context, msg *tgbotapi.Message) {
+	if h.bugReports == nil {
+		h.sendError(msg.Chat.ID, "Bug reporting is not available.")
+		return
+	}
+
+	description := strings.TrimSpace(msg.CommandArguments())
+	if description == "" {
+		h.sendError(msg.Chat.ID, "Usage: /reportbug <description of the problem>")
+		return
+	}
+	description = truncate(description, maxBugDescriptionLength)
+
+	userID := msg.From.ID
+
+	snapshot := "(unavailable)"
+	if userSettings, err := h.settings.Get(userID); err == nil {
+		snapshot = fmt.Sprintf("send_original=%t send_compressed=%t",
+			userSettings.SendOriginal, userSettings.SendCompressed)
+	} else {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+	}
+
+	report := bugreport.Report{
+		UserID:           userID,
+		Username:         msg.From.UserName,
+		Description:      description,
+		LastErrorMessage: h.lastError(userID),
+		SettingsSnapshot: snapshot,
+		SubmittedAt:      time.Now(),
+	}
+
+	id, err := h.bugReports.Submit(report)
+	if err != nil {
+		h.logger.Error("failed to submit bug report", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to submit bug report. Please try again later.")
+		return
+	}
+	report.ID = id
+
+	h.logger.Info("bug report submitted", "report_id", id, "user_id", userID)
+
+	h.notifyAdmins(formatBugReport(&report))
+
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf("Bug report #%d submitted, thank you!", id))
+}
+
+// handleGetBug handles the /getbug command for admins
+func (h *Handler) handleGetBug(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.bugReports == nil {
+		h.sendError(msg.Chat.ID, "Bug reporting is not available.")
+		return
+	}
+
+	args := strings.TrimSpace(msg.CommandArguments())
+	if args == "" {
+		h.sendError(msg.Chat.ID, "Usage: /getbug <report_id>")
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(args, "#"), 10, 64)
+	if err != nil {
+		h.sendError(msg.Chat.ID, "Invalid report ID. Usage: /getbug <report_id>")
+		return
+	}
+
+	report, err := h.bugReports.Get(id)
+	if err != nil {
+		h.logger.Error("failed to get bug report", "error", err, "report_id", id)
+		h.sendError(msg.Chat.ID, "Failed to load bug report.")
+		return
+	}
+	if report == nil {
+		h.sendError(msg.Chat.ID, fmt.Sprintf("Bug report #%d not found.", id))
+		return
+	}
+
+	h.sendText(msg.Chat.ID, formatBugReport(report))
+}
+
+func formatBugReport(r *bugreport.Report) string {
+	lastErr := r.LastErrorMessage
+	if lastErr == "" {
+		lastErr = "(none)"
+	}
+
+	return fmt.Sprintf(
+		"Bug report #%d\n\n"+
+			"User ID: %d\n"+
+			"Username: %s\n"+
+			"Submitted: %s\n"+
+			"Last error: %s\n"+
+			"Settings: %s\n\n"+
+			"%s",
+		r.ID, r.UserID, formatUsername(r.Username),
+		r.SubmittedAt.Format("2006-01-02 15:04:05"),
+		lastErr, r.SettingsSnapshot, r.Description,
+	)
+}
@@ -0,0 +1,135 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// errGenerationCancelled is the context cause of a generation stopped by /cancel
+var errGenerationCancelled = errors.New("generation cancelled by user")
+
+// pendingPrompt is an in-flight generation that /cancel can stop
+type pendingPrompt struct {
+	// promptIDs are the ComfyUI prompt IDs queued for the generation, one
+	// per image of a batch; empty until a prompt is queued
+	promptIDs []string
+	cancel    context.CancelCauseFunc
+	// released is set once the limiter slot has been released, by /cancel
+	// or finishGeneration
+	released bool
+}
+
+// trackGeneration registers a generation for a user who holds a limiter
+// slot. The returned context is cancelled by /cancel and, if the user set
+// one with /settimeout, ends after their timeout instead of the request
+// timeout. finishGeneration must be called when the generation ends; it
+// releases the slot unless /cancel already did.
+func (h *Handler) trackGeneration(ctx context.Context, userID int64) (context.Context, *pendingPrompt) {
+	var genCtx context.Context
+	var cancel context.CancelCauseFunc
+	if timeout := h.userTimeout(userID); timeout > 0 {
+		genCtx, cancel = withUserTimeout(ctx, timeout)
+	} else {
+		genCtx, cancel = context.WithCancelCause(ctx)
+	}
+	pending := &pendingPrompt{cancel: cancel}
+
+	h.pendingMu.Lock()
+	h.pendingPrompts[userID] = pending
+	h.pendingMu.Unlock()
+
+	return genCtx, pending
+}
+
+// addPendingPromptID records a ComfyUI prompt ID of a tracked generation
+func (h *Handler) addPendingPromptID(pending *pendingPrompt, promptID string) {
+	h.pendingMu.Lock()
+	defer h.pendingMu.Unlock()
+	pending.promptIDs = append(pending.promptIDs, promptID)
+}
+
+// pendingPromptIDs returns the ComfyUI prompt IDs of a tracked generation
+func (h *Handler) pendingPromptIDs(pending *pendingPrompt) []string {
+	h.pendingMu.Lock()
+	defer h.pendingMu.Unlock()
+	return slices.Clone(pending.promptIDs)
+}
+
+// removePendingPromptIDs forgets prompt IDs that have finished, so /cancel
+// does not try to remove them from the ComfyUI queue
+func (h *Handler) removePendingPromptIDs(pending *pendingPrompt, promptIDs []string) {
+	h.pendingMu.Lock()
+	defer h.pendingMu.Unlock()
+	pending.promptIDs = slices.DeleteFunc(pending.promptIDs, func(id string) bool {
+		return slices.Contains(promptIDs, id)
+	})
+}
+
+// finishGeneration unregisters a tracked generation and releases the
+// user's limiter slot if /cancel has not already done so
+func (h *Handler) finishGeneration(userID int64, pending *pendingPrompt) {
+	pending.cancel(nil)
+
+	h.pendingMu.Lock()
+	if h.pendingPrompts[userID] == pending {
+		delete(h.pendingPrompts, userID)
+	}
+	released := pending.released
+	pending.released = true
+	h.pendingMu.Unlock()
+
+	if !released {
+		h.limiter.Release(userID)
+	}
+}
+
+// generationCancelled reports whether the user stopped the generation
+// running under genCtx with /cancel
+func generationCancelled(genCtx context.Context) bool {
+	return errors.Is(context.Cause(genCtx), errGenerationCancelled)
+}
+
+// handleCancel handles the /cancel command
+func (h *Handler) handleCancel(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+
+	h.pendingMu.Lock()
+	pending, ok := h.pendingPrompts[userID]
+	var promptIDs []string
+	if ok {
+		delete(h.pendingPrompts, userID)
+		promptIDs = slices.Clone(pending.promptIDs)
+		pending.released = true
+	}
+	h.pendingMu.Unlock()
+
+	if !ok {
+		h.sendText(msg.Chat.ID, "No active generation to cancel.")
+		return
+	}
+
+	if len(promptIDs) > 0 {
+		cancelCtx, cancel := context.WithTimeout(context.Background(), interruptTimeout)
+		defer cancel()
+		var failed []string
+		for _, promptID := range promptIDs {
+			if err := h.comfy.CancelPrompt(cancelCtx, promptID); err != nil {
+				h.logger.Warn("failed to cancel prompt in comfyui", "error", err, "user_id", userID, "prompt_id", promptID)
+				failed = append(failed, promptID)
+			}
+		}
+		if len(failed) > 0 && h.enableInterrupt {
+			h.interrupt(userID, errGenerationCancelled, failed)
+		}
+	}
+
+	// The generation deletes its status message once it sees the cancellation
+	pending.cancel(errGenerationCancelled)
+	h.limiter.Release(userID)
+
+	h.logger.Info("generation cancelled", "user_id", userID, "prompt_ids", promptIDs)
+	h.sendSuccess(msg.Chat.ID, "Generation cancelled.")
+}
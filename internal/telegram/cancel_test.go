@@ -0,0 +1,24 @@
+package telegram
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPendingPromptIDs(t *testing.T) {
+	h := &Handler{}
+	pending := &pendingPrompt{}
+
+	for _, id := range []string{"a", "b", "c"} {
+		h.addPendingPromptID(pending, id)
+	}
+	h.removePendingPromptIDs(pending, []string{"b"})
+	if want := []string{"a", "c"}; !slices.Equal(pending.promptIDs, want) {
+		t.Errorf("promptIDs = %v, want %v", pending.promptIDs, want)
+	}
+
+	h.removePendingPromptIDs(pending, []string{"a", "c", "unknown"})
+	if len(pending.promptIDs) != 0 {
+		t.Errorf("promptIDs = %v, want none", pending.promptIDs)
+	}
+}
@@ -0,0 +1,48 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/comfyui"
+)
+
+// chatActionInterval is how often a chat action is repeated; Telegram shows
+// each one for about 5 seconds or until the bot sends a message
+const chatActionInterval = 4 * time.Second
+
+// sendRepeatingChatAction shows action (e.g. tgbotapi.ChatTyping) in chatID
+// until ctx is cancelled. Failures are ignored since the action is only a
+// visual hint.
+func sendRepeatingChatAction(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, action string) {
+	ticker := time.NewTicker(chatActionInterval)
+	defer ticker.Stop()
+
+	for {
+		bot.Request(tgbotapi.NewChatAction(chatID, action))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// startChatAction shows action in chatID until the returned function is
+// called or ctx ends
+func (h *Handler) startChatAction(ctx context.Context, chatID int64, action string) context.CancelFunc {
+	ctx, cancel := context.WithCancel(ctx)
+	go sendRepeatingChatAction(ctx, h.bot, chatID, action)
+	return cancel
+}
+
+// uploadChatAction returns the chat action shown while sending output
+func uploadChatAction(output *comfyui.Output) string {
+	if output.IsVideo() {
+		return tgbotapi.ChatUploadVideo
+	}
+	return tgbotapi.ChatUploadPhoto
+}
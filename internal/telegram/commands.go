@@ -0,0 +1,51 @@
+package telegram
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// userCommands is the command menu registered with Telegram. It lists
+// canonical commands only; configured aliases are resolved in
+// handleCommand but never shown in the menu.
+var userCommands = []tgbotapi.BotCommand{
+	{Command: "help", Description: "Usage instructions"},
+	{Command: "settings", Description: "Configure image delivery preferences"},
+	{Command: "workflow", Description: "Choose the workflow for your images"},
+	{Command: "models", Description: "List the checkpoints installed on ComfyUI"},
+	{Command: "setneg", Description: "Set a default negative prompt"},
+	{Command: "setprefix", Description: "Add text before every prompt"},
+	{Command: "setsuffix", Description: "Add text after every prompt"},
+	{Command: "setsize", Description: "Set the image size, e.g. 832x1216"},
+	{Command: "setseed", Description: "Use a fixed seed for reproducible images"},
+	{Command: "settimeout", Description: "Set how long your generations may run"},
+	{Command: "suggest", Description: "Get prompt ideas based on your recent prompts"},
+	{Command: "random", Description: "Generate an image from a random prompt"},
+	{Command: "requeue", Description: "Generate your last prompt again"},
+	{Command: "history", Description: "Show your recent prompts to generate again"},
+	{Command: "favorites", Description: "Generate one of your favorite prompts"},
+	{Command: "saveprompt", Description: "Save your last prompt as a favorite"},
+	{Command: "cancel", Description: "Stop your current generation"},
+	{Command: "mystats", Description: "Show your generation statistics"},
+	{Command: "share", Description: "Share your latest prompt in the public gallery"},
+	{Command: "clone", Description: "Generate a variant of a shared prompt"},
+	{Command: "reportbug", Description: "Report a problem to the admin"},
+	{Command: "status", Description: "Check ComfyUI server status"},
+}
+
+// registerCommands publishes the command menu to Telegram
+func (b *Bot) registerCommands() {
+	if _, err := b.api.Request(tgbotapi.NewSetMyCommands(userCommands...)); err != nil {
+		b.logger.Warn("failed to register bot commands", "error", err)
+	}
+}
+
+// resolveCommand maps a configured alias to its canonical command name
+func (h *Handler) resolveCommand(command string) string {
+	command = strings.ToLower(command)
+	if canonical, ok := h.cfg.CommandAliases[command]; ok {
+		return strings.ToLower(canonical)
+	}
+	return command
+}
@@ -0,0 +1,75 @@
+package telegram
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/image"
+	"comfy-tg-bot/internal/settings"
+)
+
+// comparisonStyle describes how before/after comparisons are delivered
+func comparisonStyle(s *settings.UserSettings) string {
+	if s.SideBySide {
+		return "Side by side"
+	}
+	return "Album"
+}
+
+// sendComparison sends the input and output of an img2img or upscale
+// generation together, as an album or a single side-by-side image
+// depending on the user's settings. Returns false if nothing was sent.
+func (h *Handler) sendComparison(chatID int64, input, output []byte, us *settings.UserSettings) bool {
+	if !us.SendComparison || len(input) == 0 {
+		return false
+	}
+
+	if us.SideBySide {
+		return h.sendSideBySide(chatID, input, output)
+	}
+
+	before := tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{Name: "original.jpg", Bytes: input})
+	before.Caption = "Original"
+	after := tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{Name: "generated.jpg", Bytes: output})
+	after.Caption = "Generated"
+
+	album := tgbotapi.NewMediaGroup(chatID, []any{before, after})
+	if _, err := h.bot.SendMediaGroup(album); err != nil {
+		h.logger.Error("failed to send comparison album", "error", err)
+		return false
+	}
+	return true
+}
+
+// sendSideBySide renders input and output next to each other in one photo
+func (h *Handler) sendSideBySide(chatID int64, input, output []byte) bool {
+	left, err := image.Decode(input)
+	if err != nil {
+		h.logger.Error("failed to decode comparison input", "error", err)
+		return false
+	}
+	right, err := image.Decode(output)
+	if err != nil {
+		h.logger.Error("failed to decode comparison output", "error", err)
+		return false
+	}
+
+	combined, err := h.processor.CreateSideBySide(left, right)
+	if err != nil {
+		h.logger.Error("failed to create side-by-side image", "error", err)
+		return false
+	}
+
+	data, err := h.processor.EncodeJPEG(combined)
+	if err != nil {
+		h.logger.Error("failed to encode side-by-side image", "error", err)
+		return false
+	}
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "comparison.jpg", Bytes: data})
+	photo.Caption = "Original (left) vs Generated (right)"
+	if _, err := h.bot.Send(photo); err != nil {
+		h.logger.Error("failed to send side-by-side image", "error", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package telegram
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Start parameter kinds returned by ParseStartParam
+const (
+	StartParamNone   = ""
+	StartParamPrompt = "prompt"
+	StartParamShare  = "share"
+)
+
+// shareTokenPattern matches public gallery share tokens
+var shareTokenPattern = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+// ParseStartParam classifies a /start deep link payload. Gallery share
+// tokens are returned as-is; anything else must be a base64url-encoded
+// prompt of printable UTF-8 text.
+func ParseStartParam(raw string) (kind string, value string, err error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return StartParamNone, "", nil
+	}
+
+	if shareTokenPattern.MatchString(raw) {
+		return StartParamShare, raw, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(raw, "="))
+	if err != nil {
+		return StartParamNone, "", fmt.Errorf("decode start parameter: %w", err)
+	}
+
+	text := strings.TrimSpace(string(decoded))
+	if !utf8.ValidString(text) || len(text) < 3 {
+		return StartParamNone, "", errors.New("start parameter is not a prompt")
+	}
+	for _, r := range text {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return StartParamNone, "", errors.New("start parameter is not a prompt")
+		}
+	}
+
+	return StartParamPrompt, text, nil
+}
+
+// EncodeStartParam encodes a prompt for use in a t.me/<bot>?start= link.
+// Telegram limits start parameters to 64 characters.
+func EncodeStartParam(prompt string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(prompt))
+}
+
+// handleStartParam acts on a /start deep link payload.
+// Returns true if the payload was handled.
+func (h *Handler) handleStartParam(ctx context.Context, msg *tgbotapi.Message) bool {
+	kind, value, err := ParseStartParam(msg.CommandArguments())
+	if err != nil {
+		h.logger.Debug("ignoring invalid start parameter", "error", err, "user_id", msg.From.ID)
+		return false
+	}
+
+	switch kind {
+	case StartParamShare:
+		h.cloneShared(ctx, msg.Chat.ID, msg.From.ID, value)
+		return true
+
+	case StartParamPrompt:
+		h.deepLinkMu.Lock()
+		h.deepLinkPrompts[msg.From.ID] = value
+		h.deepLinkMu.Unlock()
+
+		reply := h.newMessage(msg.Chat.ID,
+			fmt.Sprintf("Did you want to generate: %s?", truncate(value, 500)))
+		reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("Yes", "deeplink:yes"),
+				tgbotapi.NewInlineKeyboardButtonData("No", "deeplink:no"),
+			),
+		)
+		if _, err := h.bot.Send(reply); err != nil {
+			h.logger.Error("failed to send deep link confirmation", "error", err)
+		}
+		return true
+	}
+
+	return false
+}
+
+// handleDeepLinkCallback handles the Yes/No answer to a deep link prompt
+func (h *Handler) handleDeepLinkCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	userID := query.From.ID
+
+	h.deepLinkMu.Lock()
+	promptText, ok := h.deepLinkPrompts[userID]
+	delete(h.deepLinkPrompts, userID)
+	h.deepLinkMu.Unlock()
+
+	if !ok || query.Message == nil {
+		h.answerCallback(query.ID, "This request has expired")
+		return
+	}
+
+	if query.Data != "deeplink:yes" {
+		h.updateAdminMessage(query.Message.Chat.ID, query.Message.MessageID, "Okay, send me any prompt you like.")
+		h.answerCallback(query.ID, "Cancelled")
+		return
+	}
+
+	h.updateAdminMessage(query.Message.Chat.ID, query.Message.MessageID, "Generating: "+truncate(promptText, 500))
+	h.answerCallback(query.ID, "Generating...")
+	h.generateForUser(ctx, query.Message.Chat.ID, userID, promptText, genOptions{})
+}
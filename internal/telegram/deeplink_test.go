@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/gallery"
+)
+
+func TestStartParamShareToken(t *testing.T) {
+	const text = "/start 0123456789ab"
+	newStart := func() *tgbotapi.Message {
+		return &tgbotapi.Message{
+			Text:     text,
+			Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/start")}},
+			From:     &tgbotapi.User{ID: 5},
+			Chat:     &tgbotapi.Chat{ID: 5},
+		}
+	}
+
+	h, api, _ := newTestHandler(t)
+	msg := newStart()
+	if !h.handleStartParam(context.Background(), msg) {
+		t.Fatal("share token not handled")
+	}
+	if msg.Text != text {
+		t.Errorf("message text rewritten to %q", msg.Text)
+	}
+	if sent := api.sentTo(5); len(sent) != 1 || sent[0] != "Cloning is not available." {
+		t.Errorf("sent %q without a gallery", sent)
+	}
+
+	store, err := gallery.NewSQLiteStore(filepath.Join(t.TempDir(), "gallery.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	h, api, _ = newTestHandler(t)
+	h.gallery = store
+	if !h.handleStartParam(context.Background(), newStart()) {
+		t.Fatal("share token not handled")
+	}
+	if sent := api.sentTo(5); len(sent) != 1 || sent[0] != "No shared image found for that token." {
+		t.Errorf("sent %q for an unknown token", sent)
+	}
+}
+
+func TestParseStartParam(t *testing.T) {
+	tests := []struct {
+		raw       string
+		wantKind  string
+		wantValue string
+		wantErr   bool
+	}{
+		{"", StartParamNone, "", false},
+		{"0123456789ab", StartParamShare, "0123456789ab", false},
+		{EncodeStartParam("a red fox"), StartParamPrompt, "a red fox", false},
+		{EncodeStartParam("hi"), StartParamNone, "", true},
+		{EncodeStartParam("bad\x00bytes"), StartParamNone, "", true},
+		{"!!not base64!!", StartParamNone, "", true},
+	}
+	for _, tt := range tests {
+		kind, value, err := ParseStartParam(tt.raw)
+		if kind != tt.wantKind || value != tt.wantValue || (err != nil) != tt.wantErr {
+			t.Errorf("ParseStartParam(%q) = %q, %q, %v", tt.raw, kind, value, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/admin"
+)
+
+// importDataUsage explains the two ways to hand /importdata a backup
+const importDataUsage = "Send the backup file with /importdata as its caption, or reply to it with /importdata."
+
+// handleExportData handles the /exportdata command for admins, sending
+// every approved user and group as a JSON backup
+func (h *Handler) handleExportData(msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.adminStore == nil {
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
+		return
+	}
+
+	data, err := h.adminStore.Export()
+	if err != nil {
+		h.logger.Error("failed to export admin data", "error", err)
+		h.sendError(msg.Chat.ID, "Failed to export admin data.")
+		return
+	}
+
+	doc := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileBytes{
+		Name:  "admin-backup-" + time.Now().UTC().Format("2006-01-02") + ".json",
+		Bytes: data,
+	})
+	if _, err := h.bot.Send(doc); err != nil {
+		h.logger.Error("failed to send admin backup", "error", err, "chat_id", msg.Chat.ID)
+	}
+}
+
+// handleImportData handles the /importdata command sent as a reply to a
+// backup document
+func (h *Handler) handleImportData(ctx context.Context, msg *tgbotapi.Message) {
+	var doc *tgbotapi.Document
+	if msg.ReplyToMessage != nil {
+		doc = msg.ReplyToMessage.Document
+	}
+	h.importData(ctx, msg, doc)
+}
+
+// handleImportDocument imports a backup document captioned /importdata,
+// reporting whether the message was one
+func (h *Handler) handleImportDocument(ctx context.Context, msg *tgbotapi.Message) bool {
+	if msg.Document == nil {
+		return false
+	}
+
+	fields := strings.Fields(msg.Caption)
+	if len(fields) == 0 {
+		return false
+	}
+	command, target, addressed := strings.Cut(fields[0], "@")
+	if command != "/importdata" || (addressed && !strings.EqualFold(target, h.bot.Self.UserName)) {
+		return false
+	}
+
+	h.importData(ctx, msg, msg.Document)
+	return true
+}
+
+// importData upserts the approved users and groups from a backup document
+func (h *Handler) importData(ctx context.Context, msg *tgbotapi.Message, doc *tgbotapi.Document) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.adminStore == nil {
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
+		return
+	}
+
+	if doc == nil {
+		h.sendError(msg.Chat.ID, importDataUsage)
+		return
+	}
+
+	data, err := h.downloadFile(ctx, doc.FileID)
+	if err != nil {
+		h.logger.Error("failed to download admin backup", "error", err, "user_id", msg.From.ID)
+		h.sendError(msg.Chat.ID, "Failed to download the backup file.")
+		return
+	}
+
+	if err := h.adminStore.Import(data, msg.From.ID); err != nil {
+		h.logger.Error("failed to import admin data", "error", err, "user_id", msg.From.ID)
+		if errors.Is(err, admin.ErrUnsupportedExport) {
+			h.sendError(msg.Chat.ID, fmt.Sprintf("This backup was written by a newer or unknown version of the bot (%s).", err))
+			return
+		}
+		h.sendError(msg.Chat.ID, "Failed to import admin data. Is this a file from /exportdata?")
+		return
+	}
+
+	h.logger.Info("admin data imported", "admin_id", msg.From.ID, "file", doc.FileName)
+	h.sendSuccess(msg.Chat.ID, "Admin data imported. Existing users and groups not in the backup were kept.")
+}
@@ -0,0 +1,129 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/admin"
+	"comfy-tg-bot/internal/config"
+	"comfy-tg-bot/internal/settings"
+)
+
+// apiCall is a Bot API request received by fakeAPI
+type apiCall struct {
+	method string
+	params map[string]string
+}
+
+// fakeAPI is a stand-in for the Telegram Bot API that accepts every
+// request and records it
+type fakeAPI struct {
+	mu    sync.Mutex
+	calls []apiCall
+	msgID int
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	call := apiCall{method: path.Base(r.URL.Path), params: make(map[string]string)}
+	for k, v := range r.Form {
+		call.params[k] = v[0]
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	f.msgID++
+	id := f.msgID
+	f.mu.Unlock()
+
+	var result any = true
+	switch call.method {
+	case "getMe":
+		result = map[string]any{"id": 1, "is_bot": true, "username": "testbot"}
+	case "sendMessage", "editMessageText":
+		result = map[string]any{"message_id": id, "date": 0, "chat": map[string]any{"id": 1}}
+	}
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+// callbackAnswers returns the texts of all answered callback queries
+func (f *fakeAPI) callbackAnswers() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var answers []string
+	for _, c := range f.calls {
+		if c.method == "answerCallbackQuery" {
+			answers = append(answers, c.params["text"])
+		}
+	}
+	return answers
+}
+
+// methods returns the Bot API methods called so far, in order
+func (f *fakeAPI) methods() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	methods := make([]string, len(f.calls))
+	for i, c := range f.calls {
+		methods[i] = c.method
+	}
+	return methods
+}
+
+// sentTo returns the texts of messages sent to chatID
+func (f *fakeAPI) sentTo(chatID int64) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var texts []string
+	for _, c := range f.calls {
+		if c.method == "sendMessage" && c.params["chat_id"] == fmt.Sprint(chatID) {
+			texts = append(texts, c.params["text"])
+		}
+	}
+	return texts
+}
+
+// newTestHandler returns a Handler talking to a fakeAPI, with SQLite admin
+// and settings stores and the given admins
+func newTestHandler(t *testing.T, adminIDs ...int64) (*Handler, *fakeAPI, *admin.SQLiteStore) {
+	t.Helper()
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", srv.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatalf("NewBotAPIWithAPIEndpoint: %v", err)
+	}
+
+	dir := t.TempDir()
+	adminStore, err := admin.NewSQLiteStore(filepath.Join(dir, "admin.db"))
+	if err != nil {
+		t.Fatalf("admin store: %v", err)
+	}
+	t.Cleanup(func() { adminStore.Close() })
+	settingsStore, err := settings.NewSQLiteStore(filepath.Join(dir, "settings.db"), settings.DefaultSettings{})
+	if err != nil {
+		t.Fatalf("settings store: %v", err)
+	}
+	t.Cleanup(func() { settingsStore.Close() })
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	whitelist := NewWhitelist(nil, adminStore, adminIDs, logger)
+	h := NewHandler(bot, config.TelegramConfig{}, nil, nil, whitelist, nil, settingsStore,
+		adminStore, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, logger)
+	return h, api, adminStore
+}
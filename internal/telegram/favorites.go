@@ -0,0 +1,151 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/favorites"
+)
+
+// favoriteCallbackPrefix prefixes /favorites button data; the name follows
+const favoriteCallbackPrefix = "fav:"
+
+// handleSavePrompt handles /saveprompt <name>: saves the user's most recent
+// prompt as a favorite
+func (h *Handler) handleSavePrompt(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+
+	if h.favorites == nil || h.history == nil {
+		h.sendError(msg.Chat.ID, "Favorites are not available.")
+		return
+	}
+
+	name := strings.TrimSpace(msg.CommandArguments())
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		h.sendError(msg.Chat.ID, "Usage: /saveprompt <name>\nThe name must be a single word.")
+		return
+	}
+	if len(name) > favorites.MaxNameLength {
+		h.sendError(msg.Chat.ID, fmt.Sprintf("Favorite names can be at most %d characters.", favorites.MaxNameLength))
+		return
+	}
+
+	entries, err := h.history.Recent(userID, 1)
+	if err != nil {
+		h.logger.Error("failed to load history", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load your last prompt. Please try again.")
+		return
+	}
+	if len(entries) == 0 {
+		h.sendText(msg.Chat.ID, "You haven't generated anything yet. Send me a prompt first!")
+		return
+	}
+
+	err = h.favorites.Save(favorites.Favorite{
+		UserID:    userID,
+		Name:      name,
+		Prompt:    entries[0].Prompt,
+		CreatedAt: time.Now(),
+	})
+	if errors.Is(err, favorites.ErrLimitReached) {
+		h.sendError(msg.Chat.ID, fmt.Sprintf("You can save at most %d favorites. Remove one with /delfav <name> first.", favorites.MaxPerUser))
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to save favorite", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to save your favorite. Please try again.")
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf("Saved as \"%s\": %s", name, truncate(entries[0].Prompt, 200)))
+}
+
+// handleFavorites handles /favorites: the user's saved prompts as buttons
+// that generate them
+func (h *Handler) handleFavorites(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+
+	if h.favorites == nil {
+		h.sendError(msg.Chat.ID, "Favorites are not available.")
+		return
+	}
+
+	favs, err := h.favorites.List(userID)
+	if err != nil {
+		h.logger.Error("failed to list favorites", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load your favorites. Please try again.")
+		return
+	}
+	if len(favs) == 0 {
+		h.sendText(msg.Chat.ID, "You have no favorites yet. Save your last prompt with /saveprompt <name>.")
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, f := range favs {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(f.Name, favoriteCallbackPrefix+f.Name),
+		))
+	}
+	h.sendWithKeyboard(msg.Chat.ID, "Your favorite prompts. Tap one to generate it:", tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// handleFavoriteCallback generates a tapped /favorites prompt
+func (h *Handler) handleFavoriteCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	userID := query.From.ID
+
+	if h.favorites == nil || query.Message == nil {
+		h.answerCallback(query.ID, "Invalid selection")
+		return
+	}
+
+	// Favorites are looked up among the user's own, so buttons forwarded
+	// from other users cannot be used
+	fav, err := h.favorites.Get(userID, strings.TrimPrefix(query.Data, favoriteCallbackPrefix))
+	if err != nil {
+		h.logger.Error("failed to get favorite", "error", err, "user_id", userID)
+		h.answerCallback(query.ID, "Failed to load favorite")
+		return
+	}
+	if fav == nil {
+		h.answerCallback(query.ID, "Favorite not found, use /favorites again")
+		return
+	}
+
+	h.answerCallback(query.ID, "Generating...")
+	h.generateForUser(ctx, query.Message.Chat.ID, userID, fav.Prompt, genOptions{randomSeed: true})
+}
+
+// handleDeleteFavorite handles /delfav <name>
+func (h *Handler) handleDeleteFavorite(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+
+	if h.favorites == nil {
+		h.sendError(msg.Chat.ID, "Favorites are not available.")
+		return
+	}
+
+	name := strings.TrimSpace(msg.CommandArguments())
+	if name == "" {
+		h.sendError(msg.Chat.ID, "Usage: /delfav <name>")
+		return
+	}
+
+	deleted, err := h.favorites.Delete(userID, name)
+	if err != nil {
+		h.logger.Error("failed to delete favorite", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to delete your favorite. Please try again.")
+		return
+	}
+	if !deleted {
+		h.sendError(msg.Chat.ID, fmt.Sprintf("You have no favorite named \"%s\".", name))
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf("Favorite \"%s\" deleted.", name))
+}
@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"context"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleForward treats a message forwarded into a private chat as a prompt:
+// text generates as usual, a captioned photo generates from the photo and a
+// voice message is transcribed.
+// Forwarded commands are never run, so a forward cannot act on the user's
+// behalf.
+func (h *Handler) handleForward(ctx context.Context, msg *tgbotapi.Message, userID int64) {
+	h.logger.Debug("received forwarded message", "user_id", userID, "origin", forwardOrigin(msg))
+
+	if !h.acceptsForwards(userID) {
+		h.sendText(msg.Chat.ID, "Forwarded messages are not used as prompts. Turn them back on in /settings.")
+		return
+	}
+
+	if msg.IsCommand() {
+		h.sendError(msg.Chat.ID, "Forwarded commands are not run. Send the command yourself.")
+		return
+	}
+
+	if h.handleReferenceImage(ctx, msg, userID) {
+		return
+	}
+
+	if msg.Voice != nil {
+		h.handleVoice(ctx, msg, userID)
+		return
+	}
+
+	if msg.Text != "" {
+		h.handlePrompt(ctx, msg, userID)
+	}
+}
+
+// acceptsForwards reports whether the user wants forwarded messages used as
+// prompts. Falls back to the default (on) if settings cannot be loaded.
+func (h *Handler) acceptsForwards(userID int64) bool {
+	s, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Warn("failed to get forward setting", "error", err, "user_id", userID)
+		return true
+	}
+	return s.AcceptForwards
+}
+
+// forwardOrigin describes where a forwarded message came from, for logging
+func forwardOrigin(msg *tgbotapi.Message) string {
+	switch {
+	case msg.ForwardFromChat != nil:
+		origin := "chat " + strconv.FormatInt(msg.ForwardFromChat.ID, 10)
+		if msg.ForwardFromChat.UserName != "" {
+			origin += " (@" + msg.ForwardFromChat.UserName + ")"
+		}
+		return origin
+	case msg.ForwardFrom != nil:
+		return "user " + strconv.FormatInt(msg.ForwardFrom.ID, 10)
+	case msg.ForwardSenderName != "":
+		// The sender hides their account when forwarded
+		return "hidden user " + strconv.Quote(msg.ForwardSenderName)
+	default:
+		return "unknown"
+	}
+}
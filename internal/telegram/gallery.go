@@ -0,0 +1,97 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/gallery"
+)
+
+// handleShare handles the /share command, publishing the user's most
+// recent prompt to the public gallery
+func (h *Handler) handleShare(ctx context.Context, msg *tgbotapi.Message) {
+	if h.gallery == nil || h.history == nil {
+		h.sendError(msg.Chat.ID, "Sharing is not available.")
+		return
+	}
+
+	userID := msg.From.ID
+
+	entries, err := h.history.Recent(userID, 1)
+	if err != nil {
+		h.logger.Error("failed to load history", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load your latest prompt. Please try again.")
+		return
+	}
+	if len(entries) == 0 {
+		h.sendText(msg.Chat.ID, "Generate an image first, then use /share to publish its prompt.")
+		return
+	}
+
+	token, err := gallery.NewShareToken()
+	if err != nil {
+		h.logger.Error("failed to create share token", "error", err)
+		h.sendError(msg.Chat.ID, "Failed to share your prompt. Please try again.")
+		return
+	}
+
+	entry := gallery.Entry{
+		ShareToken: token,
+		UserID:     userID,
+		Prompt:     entries[0].Prompt,
+		CreatedAt:  time.Now(),
+	}
+	if err := h.gallery.Publish(entry); err != nil {
+		h.logger.Error("failed to publish gallery entry", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to share your prompt. Please try again.")
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf(
+		"Shared! Others can remix it with:\n/clone %s", token))
+}
+
+// handleClone handles the /clone command, generating a new variant of a
+// shared prompt with a random seed
+func (h *Handler) handleClone(ctx context.Context, msg *tgbotapi.Message) {
+	token := strings.TrimSpace(msg.CommandArguments())
+	if token == "" {
+		h.sendError(msg.Chat.ID, "Usage: /clone <share_token>")
+		return
+	}
+
+	h.cloneShared(ctx, msg.Chat.ID, msg.From.ID, token)
+}
+
+// cloneShared regenerates the gallery image shared as token for the user,
+// with a new random seed. Used by /clone and share deep links.
+func (h *Handler) cloneShared(ctx context.Context, chatID, userID int64, token string) {
+	if h.gallery == nil {
+		h.sendError(chatID, "Cloning is not available.")
+		return
+	}
+
+	entry, err := h.gallery.Get(token)
+	if err != nil {
+		h.logger.Error("failed to get gallery entry", "error", err, "share_token", token)
+		h.sendError(chatID, "Failed to load the shared image. Please try again.")
+		return
+	}
+	if entry == nil {
+		h.sendError(chatID, "No shared image found for that token.")
+		return
+	}
+
+	if err := h.gallery.IncrementClones(token); err != nil {
+		h.logger.Error("failed to increment gallery clones", "error", err, "share_token", token)
+	}
+
+	h.generateForUser(ctx, chatID, userID, entry.Prompt, genOptions{
+		randomSeed:  true,
+		derivedFrom: token,
+	})
+}
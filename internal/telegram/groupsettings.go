@@ -0,0 +1,145 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// groupSettingsPrefix prefixes /groupsettings callback data, e.g.
+// "groupsettings:originals:on"
+const groupSettingsPrefix = "groupsettings:"
+
+// isGroupSettingsCommand reports whether a group message asks this bot for
+// /groupsettings, either as a command or after a bot mention
+func (h *Handler) isGroupSettingsCommand(msg *tgbotapi.Message, mentionText string) bool {
+	if msg.IsCommand() && msg.Command() == "groupsettings" {
+		// Commands addressed to another bot look like /groupsettings@otherbot
+		_, target, addressed := strings.Cut(msg.CommandWithAt(), "@")
+		return !addressed || strings.EqualFold(target, h.bot.Self.UserName)
+	}
+	return strings.EqualFold(strings.TrimSpace(mentionText), "/groupsettings")
+}
+
+// canManageGroup reports whether a user may change a group's settings: bot
+// admins and the group's own administrators
+func (h *Handler) canManageGroup(groupID, userID int64) bool {
+	if h.whitelist.IsAdmin(userID) {
+		return true
+	}
+
+	member, err := h.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: groupID, UserID: userID},
+	})
+	if err != nil {
+		h.logger.Error("failed to get chat member", "error", err, "group_id", groupID, "user_id", userID)
+		return false
+	}
+	return member.IsCreator() || member.IsAdministrator()
+}
+
+// handleGroupSettings shows the group's settings with buttons to change them
+func (h *Handler) handleGroupSettings(ctx context.Context, msg *tgbotapi.Message) {
+	groupID := msg.Chat.ID
+	if !h.canManageGroup(groupID, msg.From.ID) {
+		h.sendError(groupID, "Only group admins can change group settings.")
+		return
+	}
+
+	text, err := h.groupSettingsText(groupID)
+	if err != nil {
+		h.logger.Error("failed to get group settings", "error", err, "group_id", groupID)
+		h.sendError(groupID, "Failed to load group settings.")
+		return
+	}
+
+	reply := h.newMessage(groupID, text)
+	reply.ReplyToMessageID = msg.MessageID
+	reply.ReplyMarkup = groupSettingsKeyboard()
+	if _, err := h.bot.Send(reply); err != nil {
+		h.logger.Error("failed to send group settings", "error", err)
+	}
+}
+
+// groupSettingsText describes the group's current settings
+func (h *Handler) groupSettingsText(groupID int64) (string, error) {
+	groupSettings, err := h.settings.GetGroup(groupID)
+	if err != nil {
+		return "", err
+	}
+
+	override := "default"
+	if groupSettings.AllowOriginals != nil {
+		override = strings.ToLower(onOff(*groupSettings.AllowOriginals))
+	}
+	return fmt.Sprintf("Group settings\n\nOriginal PNGs: %s (effective: %s)\n\n"+
+		"Members who enabled originals in their own /settings also receive the PNG when this is on.",
+		override, onOff(h.groupOriginalsAllowed(groupID))), nil
+}
+
+// groupSettingsKeyboard builds the buttons for the original PNG override
+func groupSettingsKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Originals: On", groupSettingsPrefix+"originals:on"),
+			tgbotapi.NewInlineKeyboardButtonData("Originals: Off", groupSettingsPrefix+"originals:off"),
+			tgbotapi.NewInlineKeyboardButtonData("Default", groupSettingsPrefix+"originals:default"),
+		),
+	)
+}
+
+// handleGroupSettingsCallback applies a /groupsettings button press
+func (h *Handler) handleGroupSettingsCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	if query.Message == nil {
+		h.answerCallback(query.ID, "Invalid action")
+		return
+	}
+	groupID := query.Message.Chat.ID
+
+	if !h.canManageGroup(groupID, query.From.ID) {
+		h.answerCallback(query.ID, "Only group admins can change group settings")
+		return
+	}
+
+	groupSettings, err := h.settings.GetGroup(groupID)
+	if err != nil {
+		h.logger.Error("failed to get group settings", "error", err, "group_id", groupID)
+		h.answerCallback(query.ID, "Failed to load group settings")
+		return
+	}
+
+	switch strings.TrimPrefix(query.Data, groupSettingsPrefix) {
+	case "originals:on":
+		allow := true
+		groupSettings.AllowOriginals = &allow
+	case "originals:off":
+		allow := false
+		groupSettings.AllowOriginals = &allow
+	case "originals:default":
+		groupSettings.AllowOriginals = nil
+	default:
+		h.answerCallback(query.ID, "Unknown setting")
+		return
+	}
+
+	if err := h.settings.SaveGroup(groupSettings); err != nil {
+		h.logger.Error("failed to save group settings", "error", err, "group_id", groupID)
+		h.answerCallback(query.ID, "Failed to save group settings")
+		return
+	}
+	h.logger.Info("group settings changed", "group_id", groupID, "user_id", query.From.ID, "setting", query.Data)
+
+	text, err := h.groupSettingsText(groupID)
+	if err != nil {
+		h.logger.Error("failed to get group settings", "error", err, "group_id", groupID)
+		h.answerCallback(query.ID, "Saved")
+		return
+	}
+	edit := tgbotapi.NewEditMessageTextAndMarkup(groupID, query.Message.MessageID, text, groupSettingsKeyboard())
+	if _, err := h.bot.Send(edit); err != nil {
+		h.logger.Error("failed to update group settings message", "error", err)
+	}
+	h.answerCallback(query.ID, "Saved")
+}
@@ -2,54 +2,204 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"comfy-tg-bot/internal/admin"
+	"comfy-tg-bot/internal/bugreport"
 	"comfy-tg-bot/internal/comfyui"
+	"comfy-tg-bot/internal/config"
+	"comfy-tg-bot/internal/erasure"
 	apperrors "comfy-tg-bot/internal/errors"
+	"comfy-tg-bot/internal/favorites"
+	"comfy-tg-bot/internal/gallery"
+	"comfy-tg-bot/internal/history"
 	"comfy-tg-bot/internal/image"
 	"comfy-tg-bot/internal/limiter"
+	"comfy-tg-bot/internal/metrics"
+	"comfy-tg-bot/internal/prompt"
 	"comfy-tg-bot/internal/settings"
+	"comfy-tg-bot/internal/stats"
+	"comfy-tg-bot/internal/usage"
 )
 
+// ComfyUI is the ComfyUI API the bot uses, implemented by comfyui.Client
+// and comfyui.BackendPool
+type ComfyUI interface {
+	GenerateImage(ctx context.Context, prompt string, opts comfyui.GenerateOptions) (*comfyui.Output, error)
+	GenerateImageFromReference(ctx context.Context, prompt string, imageData []byte, opts comfyui.GenerateOptions) (*comfyui.Output, error)
+	CheckHealth(ctx context.Context) error
+	CircuitState() comfyui.CircuitState
+	GetQueue(ctx context.Context) (*comfyui.QueueResponse, error)
+	GetQueueStatus(ctx context.Context) (*comfyui.QueueStatus, error)
+	Interrupt(ctx context.Context, promptIDs ...string) error
+	CancelPrompt(ctx context.Context, promptID string) error
+	ReloadWorkflow() error
+	RollbackWorkflow(name string) error
+	WorkflowBackups() ([]string, error)
+	WorkflowNames() []string
+	HasWorkflow(name string) bool
+	SupportsDimensions(workflow string) bool
+	SupportsReference(workflow string) bool
+	ListCheckpoints(ctx context.Context) ([]string, error)
+	UsesCheckpointLoader(workflow string) bool
+	Checkpoint(workflow string) string
+}
+
 // Handler processes Telegram updates
 type Handler struct {
 	bot        *tgbotapi.BotAPI
-	comfy      *comfyui.Client
+	cfg        config.TelegramConfig
+	comfy      ComfyUI
 	processor  *image.Processor
 	whitelist  *Whitelist
-	limiter    *limiter.UserLimiter
+	limiter    limiter.Limiter
 	settings   settings.Store
 	adminStore admin.Store
+	disk       *admin.DiskMonitor
+	history    history.Store
+	favorites  favorites.FavoriteStore
+	gallery    gallery.Store
+	bugReports bugreport.BugReportStore
+	stats      stats.Store
+	quota      *usage.Quota
+	metrics    *metrics.Metrics
+	eraser     erasure.Eraser
+	vocab      *prompt.Vocabulary
+	tokenizer  *prompt.Tokenizer
 	logger     *slog.Logger
+	startedAt  time.Time
+
+	// maxRemoteQueue rejects prompts while ComfyUI has more jobs than this
+	// (0 = no limit)
+	maxRemoteQueue int
+
+	// maxImageWidth and maxImageHeight bound /setsize
+	maxImageWidth  int
+	maxImageHeight int
+
+	// durations averages recent generation times for queue wait estimates
+	// (nil = no estimates)
+	durations *limiter.DurationTracker
+
+	// maintenance holds the admin's note while maintenance mode turns away
+	// new prompts (nil = off)
+	maintenance atomic.Pointer[string]
+
+	// enableInterrupt allows /interrupt, which stops every user's running
+	// job, when a generation is abandoned
+	enableInterrupt bool
+
+	// transcriber turns voice messages into prompts (nil = unsupported)
+	transcriber Transcriber
+
+	// maxUserTimeout bounds /settimeout for non-admins, in seconds
+	// (0 = admins only)
+	maxUserTimeout int
+
+	// Last suggestions offered to each user, indexed by callback data
+	suggestionsMu sync.Mutex
+	suggestions   map[int64][]string
+
+	// Prompts from /start deep links awaiting confirmation
+	deepLinkMu      sync.Mutex
+	deepLinkPrompts map[int64]string
+
+	// Prompts waiting for an aspect ratio choice
+	aspectMu      sync.Mutex
+	aspectPrompts map[int64]aspectPrompt
+
+	// Prompts over telegram.prompt_soft_limit awaiting confirmation
+	longPromptMu sync.Mutex
+	longPrompts  map[int64]longPrompt
+
+	// Inline prompts being generated or uploaded, and each user's latest
+	// inline query ID for debouncing
+	inlineMu      sync.Mutex
+	inlineResults map[inlineKey]inlineResult
+	inlineLatest  map[int64]string
+
+	// Most recent error message per user, attached to bug reports
+	lastErrorsMu sync.Mutex
+	lastErrors   map[int64]string
+
+	// Private chat IDs already recorded for /broadcast, by user ID
+	knownChatsMu sync.Mutex
+	knownChats   map[int64]int64
+
+	// In-flight generations that /cancel can stop
+	pendingMu      sync.Mutex
+	pendingPrompts map[int64]*pendingPrompt
+
+	// runCtx is the bot's root context, for work such as /broadcast that
+	// outlives the update that started it. Set by Bot.Run.
+	runCtx context.Context
+	// broadcasting is set while a /broadcast is being sent
+	broadcasting atomic.Bool
 }
 
 // NewHandler creates a new update handler
 func NewHandler(
 	bot *tgbotapi.BotAPI,
-	comfy *comfyui.Client,
+	cfg config.TelegramConfig,
+	comfy ComfyUI,
 	processor *image.Processor,
 	whitelist *Whitelist,
-	limiter *limiter.UserLimiter,
+	limiter limiter.Limiter,
 	settingsStore settings.Store,
 	adminStore admin.Store,
+	diskMonitor *admin.DiskMonitor,
+	historyStore history.Store,
+	favoriteStore favorites.FavoriteStore,
+	galleryStore gallery.Store,
+	bugReports bugreport.BugReportStore,
+	statsStore stats.Store,
+	quota *usage.Quota,
+	eraser erasure.Eraser,
+	vocab *prompt.Vocabulary,
+	tokenizer *prompt.Tokenizer,
 	logger *slog.Logger,
 ) *Handler {
 	return &Handler{
-		bot:        bot,
-		comfy:      comfy,
-		processor:  processor,
-		whitelist:  whitelist,
-		limiter:    limiter,
-		settings:   settingsStore,
-		adminStore: adminStore,
-		logger:     logger,
+		bot:         bot,
+		cfg:         cfg,
+		comfy:       comfy,
+		processor:   processor,
+		whitelist:   whitelist,
+		limiter:     limiter,
+		settings:    settingsStore,
+		adminStore:  adminStore,
+		disk:        diskMonitor,
+		history:     historyStore,
+		favorites:   favoriteStore,
+		gallery:     galleryStore,
+		bugReports:  bugReports,
+		stats:       statsStore,
+		quota:       quota,
+		eraser:      eraser,
+		vocab:       vocab,
+		tokenizer:   tokenizer,
+		logger:      logger,
+		startedAt:   time.Now(),
+		suggestions: make(map[int64][]string),
+		lastErrors:  make(map[int64]string),
+
+		deepLinkPrompts: make(map[int64]string),
+		pendingPrompts:  make(map[int64]*pendingPrompt),
+		knownChats:      make(map[int64]int64),
+		aspectPrompts:   make(map[int64]aspectPrompt),
+		longPrompts:     make(map[int64]longPrompt),
+		inlineResults:   make(map[inlineKey]inlineResult),
+		inlineLatest:    make(map[int64]string),
+		runCtx:          context.Background(),
 	}
 }
 
@@ -72,6 +222,10 @@ func (h *Handler) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
 	userID, chatID, isGroup, allowed := h.whitelist.CheckAccess(update)
 
 	if !allowed {
+		if update.InlineQuery != nil {
+			h.answerInline(update.InlineQuery.ID, nil, 0)
+			return
+		}
 		if update.Message != nil {
 			if isGroup {
 				h.handleUnauthorizedGroup(ctx, update.Message)
@@ -82,8 +236,53 @@ func (h *Handler) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
 		return
 	}
 
+	if update.Message != nil && !isGroup {
+		h.recordChatID(userID, chatID)
+	}
+
+	if update.InlineQuery != nil {
+		h.handleInlineQuery(ctx, update.InlineQuery)
+		return
+	}
+
 	// Handle callback queries (inline button presses)
 	if update.CallbackQuery != nil {
+		if strings.HasPrefix(update.CallbackQuery.Data, "suggest:") {
+			h.handleSuggestCallback(ctx, update.CallbackQuery)
+			return
+		}
+		if strings.HasPrefix(update.CallbackQuery.Data, historyCallbackPrefix) {
+			h.handleHistoryCallback(ctx, update.CallbackQuery)
+			return
+		}
+		if strings.HasPrefix(update.CallbackQuery.Data, favoriteCallbackPrefix) {
+			h.handleFavoriteCallback(ctx, update.CallbackQuery)
+			return
+		}
+		if strings.HasPrefix(update.CallbackQuery.Data, "deeplink:") {
+			h.handleDeepLinkCallback(ctx, update.CallbackQuery)
+			return
+		}
+		if strings.HasPrefix(update.CallbackQuery.Data, "nuke:") {
+			h.handleNukeCallback(ctx, update.CallbackQuery)
+			return
+		}
+		if strings.HasPrefix(update.CallbackQuery.Data, "workflow:") {
+			h.handleWorkflowCallback(ctx, update.CallbackQuery)
+			return
+		}
+		if strings.HasPrefix(update.CallbackQuery.Data, longPromptCallbackPrefix) {
+			h.handleLongPromptCallback(ctx, update.CallbackQuery)
+			return
+		}
+		if strings.HasPrefix(update.CallbackQuery.Data, aspectCallbackPrefix) {
+			h.handleAspectCallback(ctx, update.CallbackQuery)
+			return
+		}
+		if strings.HasPrefix(update.CallbackQuery.Data, groupSettingsPrefix) {
+			h.handleGroupSettingsCallback(ctx, update.CallbackQuery)
+			return
+		}
 		h.handleSettingsCallback(ctx, update.CallbackQuery)
 		return
 	}
@@ -94,9 +293,13 @@ func (h *Handler) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
 
 	msg := update.Message
 
-	// For group chats, only respond to bot mentions
+	// For group chats, only respond to bot mentions and /groupsettings
 	if isGroup {
 		prompt, hasMention := h.parseBotMention(msg)
+		if h.isGroupSettingsCommand(msg, prompt) {
+			h.handleGroupSettings(ctx, msg)
+			return
+		}
 		if hasMention && prompt != "" {
 			h.handleGroupPrompt(ctx, msg, userID, chatID, prompt)
 		}
@@ -104,12 +307,44 @@ func (h *Handler) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
 		return
 	}
 
+	// Forwarded messages are prompts collected from other chats
+	if msg.ForwardDate != 0 {
+		h.handleForward(ctx, msg, userID)
+		return
+	}
+
 	// Handle commands (private chats only)
 	if msg.IsCommand() {
 		h.handleCommand(ctx, msg)
 		return
 	}
 
+	// Quick-action keyboard buttons arrive as plain text
+	if h.handleQuickAction(ctx, msg) {
+		return
+	}
+
+	// Admin backups uploaded with an /importdata caption
+	if h.handleImportDocument(ctx, msg) {
+		return
+	}
+
+	// Photos and image documents with a caption are img2img prompts
+	if h.handleReferenceImage(ctx, msg, userID) {
+		return
+	}
+
+	// Voice messages are transcribed into prompts
+	if msg.Voice != nil {
+		h.handleVoice(ctx, msg, userID)
+		return
+	}
+
+	// Replying to a generated image without a new prompt regenerates it
+	if h.handleReplyRegenerate(ctx, msg, userID) {
+		return
+	}
+
 	// Handle text messages as prompts (private chats)
 	if msg.Text != "" {
 		h.handlePrompt(ctx, msg, userID)
@@ -117,30 +352,12 @@ func (h *Handler) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
 }
 
 func (h *Handler) handleCommand(ctx context.Context, msg *tgbotapi.Message) {
-	switch msg.Command() {
+	switch h.resolveCommand(msg.Command()) {
 	case "start":
-		h.sendText(msg.Chat.ID,
-			"Welcome to the ComfyUI Bot!\n\n"+
-				"Send me a text prompt and I'll generate an image for you.\n\n"+
-				"Commands:\n"+
-				"/help - Show this help message\n"+
-				"/status - Check ComfyUI server status")
+		h.handleStart(ctx, msg)
 
 	case "help":
-		helpText := "Simply send me a text description of the image you want to generate.\n\n" +
-			"For example: \"A beautiful sunset over mountains with a lake reflection\"\n\n" +
-			"In groups, mention me with @" + h.bot.Self.UserName + " followed by your prompt.\n\n" +
-			"Commands:\n" +
-			"/settings - Configure image delivery preferences\n" +
-			"/status - Check ComfyUI server status"
-
-		if h.whitelist.IsAdmin(msg.From.ID) {
-			helpText += "\n\nAdmin commands:\n" +
-				"/revoke <user_id> - Revoke user access\n" +
-				"/revokegroup <group_id> - Revoke group access"
-		}
-
-		h.sendText(msg.Chat.ID, helpText)
+		h.sendMarkup(msg.Chat.ID, h.helpText(msg.From.ID), h.cfg.ParseModes.Help)
 
 	case "status":
 		h.handleStatus(ctx, msg)
@@ -148,104 +365,527 @@ func (h *Handler) handleCommand(ctx context.Context, msg *tgbotapi.Message) {
 	case "settings":
 		h.handleSettings(ctx, msg)
 
+	case "workflow":
+		h.handleWorkflow(ctx, msg)
+
+	case "models":
+		h.handleModels(ctx, msg)
+
+	case "setneg":
+		h.handleSetNegative(ctx, msg)
+
+	case "setprefix":
+		h.handleSetAffix(ctx, msg, false)
+
+	case "setsuffix":
+		h.handleSetAffix(ctx, msg, true)
+
+	case "clearprefix":
+		h.handleClearAffix(ctx, msg, false)
+
+	case "clearsuffix":
+		h.handleClearAffix(ctx, msg, true)
+
+	case "setsize":
+		h.handleSetSize(ctx, msg)
+
+	case "resetsize":
+		h.handleResetSize(ctx, msg)
+
+	case "setseed":
+		h.handleSetSeed(ctx, msg)
+
+	case "randomseed":
+		h.handleRandomSeed(ctx, msg)
+
+	case "settimeout":
+		h.handleSetTimeout(ctx, msg)
+
+	case "cleartimeout":
+		h.handleClearTimeout(ctx, msg)
+
+	case "random":
+		h.handleRandom(ctx, msg)
+
+	case "history":
+		h.handleHistory(ctx, msg)
+
+	case "saveprompt":
+		h.handleSavePrompt(ctx, msg)
+
+	case "favorites":
+		h.handleFavorites(ctx, msg)
+
+	case "delfav":
+		h.handleDeleteFavorite(ctx, msg)
+
+	case "requeue":
+		h.handleRequeue(ctx, msg)
+
+	case "mystats":
+		h.handleMyStats(ctx, msg)
+
+	case "showkeys":
+		h.handleQuickKeys(ctx, msg, true)
+
+	case "hidekeys":
+		h.handleQuickKeys(ctx, msg, false)
+
+	case "suggest":
+		h.handleSuggest(ctx, msg)
+
+	case "share":
+		h.handleShare(ctx, msg)
+
+	case "clone":
+		h.handleClone(ctx, msg)
+
+	case "reportbug":
+		h.handleReportBug(ctx, msg)
+
+	case "getbug":
+		h.handleGetBug(ctx, msg)
+
+	case "stats":
+		h.handleGlobalStats(ctx, msg)
+
+	case "modelstats":
+		h.handleModelStats(ctx, msg)
+
+	case "setuserworkflows":
+		h.handleSetUserWorkflows(ctx, msg)
+
+	case "exportdata":
+		h.handleExportData(msg)
+
+	case "importdata":
+		h.handleImportData(ctx, msg)
+
+	case "benchmark":
+		h.handleBenchmark(ctx, msg)
+
+	case "debug":
+		h.handleDebug(ctx, msg)
+
+	case "wfreload", "reloadworkflow":
+		h.handleWorkflowReload(ctx, msg)
+
+	case "wfrollback":
+		h.handleWorkflowRollback(ctx, msg)
+
 	case "revoke":
 		h.handleRevoke(ctx, msg)
 
+	case "nuke":
+		h.handleNuke(ctx, msg)
+
+	case "cancel":
+		h.handleCancel(ctx, msg)
+
+	case "nukeuser":
+		h.handleNukeUser(ctx, msg)
+
+	case "broadcast":
+		h.handleBroadcast(ctx, msg)
+
+	case "maintenance":
+		h.handleMaintenance(ctx, msg)
+
+	case "rejectall":
+		h.handleRejectAll(ctx, msg)
+
+	case "pending":
+		h.handlePending(ctx, msg)
+
+	case "pendinggroups":
+		h.handlePendingGroups(ctx, msg)
+
+	case "listusers":
+		h.handleListUsers(ctx, msg)
+
 	case "revokegroup":
 		h.handleRevokeGroup(ctx, msg)
 
+	case "auditlog":
+		h.handleAuditLog(ctx, msg)
+
+	case "ban":
+		h.handleBan(ctx, msg)
+
+	case "unban":
+		h.handleUnban(ctx, msg)
+
+	case "grouporiginals":
+		h.handleGroupOriginals(ctx, msg)
+
 	default:
-		h.sendText(msg.Chat.ID, "Unknown command. Use /help for available commands.")
+		h.sendError(msg.Chat.ID, "Unknown command. Use /help for available commands.")
+	}
+}
+
+func (h *Handler) handleStart(ctx context.Context, msg *tgbotapi.Message) {
+	// Deep links (t.me/<bot>?start=<payload>) carry a prompt or share token
+	if h.handleStartParam(ctx, msg) {
+		return
+	}
+
+	reply := h.newMessage(msg.Chat.ID,
+		"Welcome to the ComfyUI Bot!\n\n"+
+			"Send me a text prompt and I'll generate an image for you.\n\n"+
+			"Commands:\n"+
+			"/help - Show this help message\n"+
+			"/status - Check ComfyUI server status\n"+
+			"/showkeys - Show the quick-action keyboard")
+
+	if userSettings, err := h.settings.Get(msg.From.ID); err == nil && userSettings.ShowQuickKeys {
+		reply.ReplyMarkup = buildReplyKeyboard()
+	}
+
+	if _, err := h.bot.Send(reply); err != nil {
+		h.logger.Error("failed to send start message", "error", err)
 	}
 }
 
+// Quick-action reply keyboard button labels
+const (
+	quickRandom   = "🎲 Random"
+	quickRequeue  = "↩ Requeue"
+	quickSettings = "⚙ Settings"
+	quickStats    = "📊 My Stats"
+)
+
+// buildReplyKeyboard builds the persistent quick-action keyboard
+func buildReplyKeyboard() tgbotapi.ReplyKeyboardMarkup {
+	keyboard := tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(quickRandom),
+			tgbotapi.NewKeyboardButton(quickRequeue),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(quickSettings),
+			tgbotapi.NewKeyboardButton(quickStats),
+		),
+	)
+	keyboard.ResizeKeyboard = true
+	keyboard.InputFieldPlaceholder = "Describe an image..."
+	return keyboard
+}
+
 func (h *Handler) handleStatus(ctx context.Context, msg *tgbotapi.Message) {
+	if h.wantsJSON(msg) {
+		h.sendJSONDocument(msg.Chat.ID, "status.json", h.collectStatus(ctx))
+		return
+	}
+
+	circuit := h.comfy.CircuitState()
 	err := h.comfy.CheckHealth(ctx)
 	if err != nil {
-		h.sendText(msg.Chat.ID, fmt.Sprintf("ComfyUI Status: Offline\nError: %v", err))
+		h.sendError(msg.Chat.ID, fmt.Sprintf("ComfyUI Status: Offline\nError: %v\nCircuit breaker: %s\nMaintenance mode: %s",
+			err, circuit, h.maintenanceLabel()))
 		return
 	}
 
 	activeCount := h.limiter.ActiveCount()
 	h.sendText(msg.Chat.ID, fmt.Sprintf(
 		"ComfyUI Status: Online\n"+
-			"Active generations: %d", activeCount))
+			"Active generations: %d\n"+
+			"Circuit breaker: %s\n"+
+			"Maintenance mode: %s", activeCount, circuit, h.maintenanceLabel()))
 }
 
 func (h *Handler) handlePrompt(ctx context.Context, msg *tgbotapi.Message, userID int64) {
-	prompt := strings.TrimSpace(msg.Text)
+	if h.rejectIfMaintenance(msg.Chat.ID, userID) || h.rejectTooLong(msg.Chat.ID, msg.Text) {
+		return
+	}
+
+	// Very long prompts need confirmation before anything is generated
+	if h.confirmLongPrompt(msg.Chat.ID, userID, msg.Text) {
+		return
+	}
+	h.dispatchPrompt(ctx, msg.Chat.ID, userID, msg.Text)
+}
+
+// dispatchPrompt generates a private-chat prompt as a batch, after an
+// aspect ratio choice, or directly
+func (h *Handler) dispatchPrompt(ctx context.Context, chatID, userID int64, text string) {
+	if count, prompt, ok := parseBatchPrefix(text); ok {
+		h.generateBatch(ctx, chatID, userID, strings.TrimSpace(prompt), count)
+		return
+	}
+
+	// Workflows with dimension placeholders ask for an aspect ratio first
+	if len(strings.TrimSpace(text)) >= 3 && h.askAspectRatio(chatID, userID, text) {
+		return
+	}
+	h.generateForUser(ctx, chatID, userID, text, genOptions{})
+}
+
+// genOptions carries optional behaviour for a private-chat generation
+type genOptions struct {
+	// randomSeed requests a fresh seed instead of the workflow's
+	randomSeed bool
+	// derivedFrom is the gallery share token being cloned, if any
+	derivedFrom string
+	// reference is an uploaded image to generate from (img2img), if any
+	reference []byte
+	// aspect is the chosen output shape (nil = default)
+	aspect *comfyui.AspectRatio
+}
+
+// generateOptions builds a user's generation options from their settings
+func (h *Handler) generateOptions(userID int64) comfyui.GenerateOptions {
+	opts := comfyui.GenerateOptions{
+		MaxQueueWait: time.Duration(h.cfg.MaxQueueWaitSeconds) * time.Second,
+	}
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Warn("failed to load settings, using workflow defaults", "error", err, "user_id", userID)
+		return opts
+	}
+	opts.Workflow = h.resolveWorkflow(userID, userSettings.WorkflowName)
+	opts.Params = userSettings.WorkflowParams
+	opts.NegativePrompt = userSettings.NegativePrompt
+	opts.AspectRatio = preferredSize(userSettings)
+	opts.Seed = userSettings.Seed
+	return opts
+}
+
+// checkQuota returns ErrQuotaExceeded if the user has no generations left
+// today. Admins are exempt.
+func (h *Handler) checkQuota(userID int64) error {
+	return h.checkQuotaN(userID, 1)
+}
+
+// checkQuotaN returns ErrQuotaExceeded if the user has fewer than n
+// generations left today. Admins are exempt.
+func (h *Handler) checkQuotaN(userID int64, n int) error {
+	if h.quota == nil || h.whitelist.IsAdmin(userID) {
+		return nil
+	}
+	return h.quota.CheckN(userID, n)
+}
+
+// recordUsage counts a successful generation toward the daily quota
+func (h *Handler) recordUsage(userID int64) {
+	if h.quota != nil {
+		h.quota.Record(userID)
+	}
+}
+
+// observeGeneration updates the generation metrics, if enabled, and the
+// durations used for queue wait estimates
+func (h *Handler) observeGeneration(started time.Time, err error) {
+	elapsed := time.Since(started)
+	if h.metrics != nil {
+		h.metrics.ObserveGeneration(elapsed, err)
+	}
+	if h.durations != nil && err == nil {
+		h.durations.Record(elapsed)
+	}
+}
+
+// remoteQueueBusy tells the user to try later and returns true if ComfyUI
+// has more jobs queued than allowed. If the queue cannot be read the prompt
+// is accepted and the generation itself reports any failure.
+func (h *Handler) remoteQueueBusy(ctx context.Context, chatID int64) bool {
+	if h.maxRemoteQueue <= 0 {
+		return false
+	}
+
+	status, err := h.comfy.GetQueueStatus(ctx)
+	if err != nil {
+		h.logger.Warn("failed to check comfyui queue depth", "error", err)
+		return false
+	}
+
+	queued := status.Pending + status.Running
+	if queued <= h.maxRemoteQueue {
+		return false
+	}
+	h.sendError(chatID, fmt.Sprintf("The image generator is busy (%d jobs queued). Please try again later.", queued))
+	return true
+}
+
+// generateForUser runs a generation in a private chat and delivers the
+// result according to the user's settings
+func (h *Handler) generateForUser(ctx context.Context, chatID, userID int64, prompt string, opts genOptions) {
+	if h.rejectIfMaintenance(chatID, userID) {
+		return
+	}
+
+	prompt = strings.TrimSpace(prompt)
 
 	if len(prompt) < 3 {
-		h.sendText(msg.Chat.ID, "Please provide a more detailed prompt (at least 3 characters).")
+		h.sendError(chatID, "Please provide a more detailed prompt (at least 3 characters).")
+		return
+	}
+
+	finalPrompt := h.affixPrompt(userID, prompt)
+	h.warnIfNearTokenLimit(chatID, finalPrompt)
+
+	if err := h.checkQuota(userID); err != nil {
+		h.sendError(chatID, apperrors.GetUserMessage(err))
 		return
 	}
 
-	// Check if user already has an active request
-	if !h.limiter.TryAcquire(userID) {
-		h.sendText(msg.Chat.ID, apperrors.ErrGenerationInProgress.UserMsg)
+	if h.remoteQueueBusy(ctx, chatID) {
 		return
 	}
-	defer h.limiter.Release(userID)
+
+	// Check if user already has an active request; wait in the queue if
+	// the global limit is reached
+	if err := h.limiter.WaitOrAcquire(ctx, userID, chatID); err != nil {
+		h.sendError(chatID, apperrors.GetUserMessage(err))
+		return
+	}
+	genCtx, pending := h.trackGeneration(ctx, userID)
+	defer h.finishGeneration(userID, pending)
 
 	// Send "generating" message
-	statusMsg, err := h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Generating your image..."))
+	statusMsg, err := h.bot.Send(h.newMessage(chatID, "Generating your image..."))
 	if err != nil {
 		h.logger.Error("failed to send status message", "error", err)
 	}
 
+	// Show "typing" while generating, then an upload action while sending
+	stopAction := h.startChatAction(genCtx, chatID, tgbotapi.ChatTyping)
+	defer func() { stopAction() }()
+
 	// Generate image
 	h.logger.Info("starting generation", "user_id", userID, "prompt_length", len(prompt))
 
-	imageData, err := h.comfy.GenerateImage(ctx, prompt)
+	started := time.Now()
+	genOpts := h.generateOptions(userID)
+	model := h.generationModel(genOpts)
+	genOpts.Progress = h.progressCallback(chatID, statusMsg.MessageID)
+	genOpts.RandomSeed = opts.randomSeed
+	if opts.aspect != nil {
+		genOpts.AspectRatio = opts.aspect
+	}
+	genOpts.OnQueued = func(promptID string) { h.addPendingPromptID(pending, promptID) }
+	var output *comfyui.Output
+	if len(opts.reference) > 0 {
+		output, err = h.comfy.GenerateImageFromReference(genCtx, finalPrompt, opts.reference, genOpts)
+	} else {
+		output, err = h.comfy.GenerateImage(genCtx, finalPrompt, genOpts)
+	}
+	stopAction()
+	if generationCancelled(genCtx) {
+		if statusMsg.MessageID != 0 {
+			h.bot.Request(tgbotapi.NewDeleteMessage(chatID, statusMsg.MessageID))
+		}
+		return
+	}
+	h.recordGeneration(userID, model, started, err == nil)
+	h.observeGeneration(started, err)
 	if err != nil {
+		h.interruptIfCancelled(genCtx, userID, h.pendingPromptIDs(pending))
 		h.logger.Error("generation failed", "error", err, "user_id", userID)
-		h.sendText(msg.Chat.ID, apperrors.GetUserMessage(err))
+		h.rememberError(userID, err)
+		h.sendError(chatID, apperrors.GetUserMessage(err))
 
 		// Delete status message on error
 		if statusMsg.MessageID != 0 {
-			h.bot.Request(tgbotapi.NewDeleteMessage(msg.Chat.ID, statusMsg.MessageID))
+			h.bot.Request(tgbotapi.NewDeleteMessage(chatID, statusMsg.MessageID))
 		}
 		return
 	}
+	h.recordUsage(userID)
+	stopAction = h.startChatAction(genCtx, chatID, uploadChatAction(output))
+
+	if output.IsVideo() {
+		h.logger.Info("video generation complete",
+			"user_id", userID,
+			"format", output.Video.Format,
+			"size", len(output.Data),
+		)
+		h.recordHistory(userID, chatID, prompt, opts.derivedFrom, output)
+		if statusMsg.MessageID != 0 {
+			h.bot.Request(tgbotapi.NewDeleteMessage(chatID, statusMsg.MessageID))
+		}
+		h.sendVideoOutput(chatID, userID, prompt, output, 0)
+		return
+	}
+
+	// Get user settings
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		// Fall back to sending both
+		userSettings = &settings.UserSettings{
+			UserID:         userID,
+			SendOriginal:   true,
+			SendCompressed: true,
+		}
+	}
+
+	// Show a quick preview while the full image is processed and uploaded
+	if userSettings.SendThumbnailPreview {
+		if previewID := h.sendThumbnailPreview(chatID, output.Data); previewID != 0 {
+			defer h.bot.Request(tgbotapi.NewDeleteMessage(chatID, previewID))
+		}
+	}
 
 	// Process image
-	result, err := h.processor.Process(imageData)
+	result, err := h.processOutput(userSettings.StripMetadata, output, finalPrompt)
 	if err != nil {
 		h.logger.Error("image processing failed", "error", err)
-		h.sendText(msg.Chat.ID, "Failed to process the generated image.")
+		h.sendError(chatID, "Failed to process the generated image.")
 		return
 	}
 
+	if result.Passthrough16Bit {
+		h.logger.Info("16-bit image passthrough, skipping jpeg compression",
+			"width", result.Width,
+			"height", result.Height,
+		)
+	}
+
+	if result.Downscaled() || result.Resized {
+		h.logger.Info("downscaled image for telegram photo limit",
+			"original_width", result.Width,
+			"original_height", result.Height,
+			"width", result.CompressedWidth,
+			"height", result.CompressedHeight,
+			"fit_upload_limit", result.Resized,
+		)
+	}
+
 	h.logger.Info("generation complete",
 		"user_id", userID,
+		"seed", output.Seed,
 		"original_size", result.OriginalSize,
 		"compressed_size", result.CompressedSize,
 	)
 
+	h.recordHistory(userID, chatID, prompt, opts.derivedFrom, output)
+
 	// Delete "generating" message
 	if statusMsg.MessageID != 0 {
-		h.bot.Request(tgbotapi.NewDeleteMessage(msg.Chat.ID, statusMsg.MessageID))
+		h.bot.Request(tgbotapi.NewDeleteMessage(chatID, statusMsg.MessageID))
 	}
 
-	// Get user settings
-	userSettings, err := h.settings.Get(userID)
-	if err != nil {
-		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
-		// Fall back to sending both
-		userSettings = &settings.UserSettings{
-			UserID:         userID,
-			SendOriginal:   true,
-			SendCompressed: true,
-		}
+	// For img2img, a before/after comparison replaces the preview
+	sentComparison := false
+	if userSettings.SendCompressed && len(opts.reference) > 0 {
+		sentComparison = h.sendComparison(chatID, opts.reference, result.Compressed, userSettings)
+	}
+
+	// Send compressed version as a WebP document if the user prefers it
+	// and it beats the JPEG
+	sentWebP := false
+	if userSettings.SendCompressed && userSettings.SendWebP && !sentComparison {
+		sentWebP = h.sendWebP(chatID, prompt, output.Seed, result)
 	}
 
 	// Send compressed version as photo (for preview)
-	if userSettings.SendCompressed {
-		photoMsg := tgbotapi.NewPhoto(msg.Chat.ID, tgbotapi.FileBytes{
-			Name:  "image.jpg",
+	if userSettings.SendCompressed && !sentWebP && !sentComparison {
+		photoMsg := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{
+			Name:  result.CompressedFilename(),
 			Bytes: result.Compressed,
 		})
-		photoMsg.Caption = fmt.Sprintf("Prompt: %s", truncate(prompt, 200))
+		photoMsg.Caption = promptCaption(prompt, output.Seed)
 		if _, err := h.bot.Send(photoMsg); err != nil {
 			h.logger.Error("failed to send photo", "error", err)
 		}
@@ -253,14 +893,14 @@ func (h *Handler) handlePrompt(ctx context.Context, msg *tgbotapi.Message, userI
 
 	// Send original as document
 	if userSettings.SendOriginal {
-		docMsg := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileBytes{
+		docMsg := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
 			Name:  "image.png",
-			Bytes: result.Original,
+			Bytes: result.OriginalDocument(),
 		})
 		caption := "Original PNG"
 		if !userSettings.SendCompressed {
 			// If not sending compressed, include prompt in original caption
-			caption = fmt.Sprintf("Prompt: %s", truncate(prompt, 200))
+			caption = promptCaption(prompt, output.Seed)
 		}
 		docMsg.Caption = caption
 		if _, err := h.bot.Send(docMsg); err != nil {
@@ -269,20 +909,73 @@ func (h *Handler) handlePrompt(ctx context.Context, msg *tgbotapi.Message, userI
 	}
 }
 
+// interruptTimeout bounds the /interrupt request sent after cancellation
+const interruptTimeout = 5 * time.Second
+
+// interruptIfCancelled asks ComfyUI to stop executing when the request
+// context was cancelled, e.g. on shutdown, before the image was retrieved,
+// so the GPU does not keep working on a result nobody will receive. Only
+// done with comfyui.enable_interrupt, and not after a timeout, since
+// /interrupt stops whatever is running.
+func (h *Handler) interruptIfCancelled(ctx context.Context, userID int64, promptIDs []string) {
+	if !h.enableInterrupt || ctx.Err() == nil || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return
+	}
+	h.interrupt(userID, ctx.Err(), promptIDs)
+}
+
+// interrupt stops whatever the ComfyUI backend running promptIDs on behalf
+// of userID is currently executing
+func (h *Handler) interrupt(userID int64, reason error, promptIDs []string) {
+	h.logger.Warn("interrupting comfyui; this also stops other users' running jobs", "user_id", userID, "reason", reason)
+
+	interruptCtx, cancel := context.WithTimeout(context.Background(), interruptTimeout)
+	defer cancel()
+
+	if err := h.comfy.Interrupt(interruptCtx, promptIDs...); err != nil {
+		h.logger.Warn("failed to interrupt comfyui", "error", err, "user_id", userID)
+		return
+	}
+	h.logger.Info("interrupted comfyui", "user_id", userID, "reason", reason)
+}
+
+// warnIfNearTokenLimit tells the user when their prompt is likely to be
+// truncated by the model's token limit
+func (h *Handler) warnIfNearTokenLimit(chatID int64, prompt string) {
+	if h.tokenizer == nil {
+		return
+	}
+
+	tokens, near := h.tokenizer.NearLimit(prompt)
+	if !near {
+		return
+	}
+
+	h.sendText(chatID, fmt.Sprintf(
+		"Your prompt may be ~%d tokens, close to the %d-token limit—excess may be ignored. "+
+			"(This is a rough estimate.)",
+		tokens, h.tokenizer.MaxTokens()))
+}
+
 func (h *Handler) handleSettings(ctx context.Context, msg *tgbotapi.Message) {
 	userID := msg.From.ID
 
+	if args := strings.TrimSpace(msg.CommandArguments()); args != "" {
+		h.handleSettingsArgs(msg, args)
+		return
+	}
+
 	userSettings, err := h.settings.Get(userID)
 	if err != nil {
 		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
-		h.sendText(msg.Chat.ID, "Failed to load settings. Please try again.")
+		h.sendError(msg.Chat.ID, "Failed to load settings. Please try again.")
 		return
 	}
 
 	text := h.formatSettingsMessage(userSettings)
 	keyboard := h.buildSettingsKeyboard(userSettings)
 
-	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply := h.newMessage(msg.Chat.ID, text)
 	reply.ReplyMarkup = keyboard
 	if _, err := h.bot.Send(reply); err != nil {
 		h.logger.Error("failed to send settings message", "error", err)
@@ -313,6 +1006,25 @@ func (h *Handler) handleSettingsCallback(ctx context.Context, query *tgbotapi.Ca
 		userSettings.SendOriginal = !userSettings.SendOriginal
 	case "toggle_compressed":
 		userSettings.SendCompressed = !userSettings.SendCompressed
+	case "toggle_comparison":
+		userSettings.SendComparison = !userSettings.SendComparison
+	case "toggle_side_by_side":
+		userSettings.SideBySide = !userSettings.SideBySide
+	case "toggle_link_previews":
+		userSettings.DisableLinkPreviews = !userSettings.DisableLinkPreviews
+	case "toggle_video":
+		userSettings.SendVideo = !userSettings.SendVideo
+	case "toggle_webp":
+		userSettings.SendWebP = !userSettings.SendWebP
+	case "toggle_thumbnail":
+		userSettings.SendThumbnailPreview = !userSettings.SendThumbnailPreview
+	case "toggle_forwards":
+		userSettings.AcceptForwards = !userSettings.AcceptForwards
+	case "toggle_strip_metadata":
+		userSettings.StripMetadata = !userSettings.StripMetadata
+	case "reset_size":
+		userSettings.PreferredWidth = 0
+		userSettings.PreferredHeight = 0
 	default:
 		h.answerCallback(query.ID, "Unknown action")
 		return
@@ -361,8 +1073,34 @@ func (h *Handler) formatSettingsMessage(s *settings.UserSettings) string {
 	return fmt.Sprintf(
 		"Your Settings:\n\n"+
 			"Send Original PNG: %s\n"+
-			"Send Compressed JPEG: %s",
-		originalStatus, compressedStatus,
+			"Send Compressed JPEG: %s\n"+
+			"WebP When Smaller: %s\n"+
+			"Thumbnail Preview: %s\n"+
+			"Prompt in PNG: %s\n"+
+			"Before/After Comparison: %s\n"+
+			"Comparison Style: %s\n"+
+			"Link Previews: %s\n"+
+			"Videos: %s\n"+
+			"Forwarded Prompts: %s\n"+
+			"Workflow: %s\n"+
+			"Workflow Params: %s\n"+
+			"Prompt Prefix: %s\n"+
+			"Prompt Suffix: %s\n"+
+			"Image Size: %s\n"+
+			"Seed: %s\n"+
+			"Generation Timeout: %s\n\n"+
+			"Set params with /settings steps=30 cfg=7.5 (use =default to reset)",
+		originalStatus, compressedStatus, onOff(s.SendWebP), onOff(s.SendThumbnailPreview), metadataLabel(s),
+		onOff(s.SendComparison), comparisonStyle(s),
+		onOff(!s.DisableLinkPreviews),
+		videoStyle(s),
+		onOff(s.AcceptForwards),
+		workflowLabel(s.WorkflowName),
+		formatWorkflowParams(s.WorkflowParams),
+		affixLabel(s.PromptPrefix), affixLabel(s.PromptSuffix),
+		sizeLabel(s),
+		seedLabel(s),
+		h.timeoutLabel(s),
 	)
 }
 
@@ -384,6 +1122,33 @@ func (h *Handler) buildSettingsKeyboard(s *settings.UserSettings) tgbotapi.Inlin
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData(compressedText, "settings:toggle_compressed"),
 		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("WebP When Smaller: "+onOff(s.SendWebP), "settings:toggle_webp"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Thumbnail Preview: "+onOff(s.SendThumbnailPreview), "settings:toggle_thumbnail"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Prompt in PNG: "+metadataLabel(s), "settings:toggle_strip_metadata"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Comparison: "+onOff(s.SendComparison), "settings:toggle_comparison"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Comparison Style: "+comparisonStyle(s), "settings:toggle_side_by_side"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Link Previews: "+onOff(!s.DisableLinkPreviews), "settings:toggle_link_previews"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Videos: "+videoStyle(s), "settings:toggle_video"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Forwarded Prompts: "+onOff(s.AcceptForwards), "settings:toggle_forwards"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(sizeButtonText(s), "settings:reset_size"),
+		),
 	)
 }
 
@@ -394,11 +1159,59 @@ func (h *Handler) answerCallback(callbackID string, text string) {
 	}
 }
 
-func (h *Handler) sendText(chatID int64, text string) {
+// newMessage creates a text message honouring the recipient's link preview
+// preference. Private chat IDs equal the user ID; other chats use the
+// configured default.
+func (h *Handler) newMessage(chatID int64, text string) tgbotapi.MessageConfig {
 	msg := tgbotapi.NewMessage(chatID, text)
+	msg.DisableWebPagePreview = h.cfg.DisableLinkPreviews
+
+	if chatID > 0 {
+		if us, err := h.settings.Get(chatID); err == nil {
+			msg.DisableWebPagePreview = us.DisableLinkPreviews
+		}
+	}
+
+	return msg
+}
+
+// sendText sends an informational message
+func (h *Handler) sendText(chatID int64, text string) {
+	h.sendTextWithMode(chatID, text, h.cfg.ParseModes.Info)
+}
+
+// sendError sends an error message
+func (h *Handler) sendError(chatID int64, text string) {
+	h.sendTextWithMode(chatID, text, h.cfg.ParseModes.Error)
+}
+
+// sendSuccess sends a confirmation message
+func (h *Handler) sendSuccess(chatID int64, text string) {
+	h.sendTextWithMode(chatID, text, h.cfg.ParseModes.Success)
+}
+
+// sendTextWithMode sends plain text using the given parse mode. The text
+// has no formatting of its own, so it is escaped to be shown literally.
+func (h *Handler) sendTextWithMode(chatID int64, text, parseMode string) {
+	h.sendMarkup(chatID, markup(escapeForMode(text, parseMode)), parseMode)
+}
+
+// sendMarkup sends text already formatted for parseMode, e.g. built with a
+// formatter
+func (h *Handler) sendMarkup(chatID int64, text markup, parseMode string) {
+	msg := h.newMessage(chatID, string(text))
+	msg.ParseMode = parseMode
 	if _, err := h.bot.Send(msg); err != nil {
-		h.logger.Error("failed to send message", "error", err, "chat_id", chatID)
+		h.logger.Error("failed to send message", "error", err, "chat_id", chatID, "parse_mode", parseMode)
+	}
+}
+
+// formatUsername renders a Telegram username for display, or "(none)" if unset
+func formatUsername(username string) string {
+	if username == "" {
+		return "(none)"
 	}
+	return "@" + username
 }
 
 func truncate(s string, maxLen int) string {
@@ -411,18 +1224,30 @@ func truncate(s string, maxLen int) string {
 // handleUnauthorizedUser handles access attempts from non-whitelisted users
 func (h *Handler) handleUnauthorizedUser(ctx context.Context, msg *tgbotapi.Message) {
 	// If no admin is configured, just send the unauthorized message
-	if h.whitelist.AdminUserID() == 0 || h.adminStore == nil {
-		h.sendText(msg.Chat.ID, apperrors.ErrUnauthorized.UserMsg)
+	if !h.whitelist.HasAdmins() || h.adminStore == nil {
+		h.sendError(msg.Chat.ID, apperrors.ErrUnauthorized.UserMsg)
 		return
 	}
 
 	userID := msg.From.ID
 
+	// Banned users are ignored without a reply
+	banned, err := h.adminStore.IsBanned(userID)
+	if err != nil {
+		h.logger.Error("failed to check banned status", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, apperrors.ErrUnauthorized.UserMsg)
+		return
+	}
+	if banned {
+		h.logger.Debug("ignoring message from banned user", "user_id", userID)
+		return
+	}
+
 	// Check if already pending
 	pending, err := h.adminStore.GetPending(userID)
 	if err != nil {
 		h.logger.Error("failed to check pending status", "error", err, "user_id", userID)
-		h.sendText(msg.Chat.ID, apperrors.ErrUnauthorized.UserMsg)
+		h.sendError(msg.Chat.ID, apperrors.ErrUnauthorized.UserMsg)
 		return
 	}
 
@@ -443,7 +1268,7 @@ func (h *Handler) handleUnauthorizedUser(ctx context.Context, msg *tgbotapi.Mess
 		}
 		if err := h.adminStore.AddPending(req); err != nil {
 			h.logger.Error("failed to add pending request", "error", err, "user_id", userID)
-			h.sendText(msg.Chat.ID, apperrors.ErrUnauthorized.UserMsg)
+			h.sendError(msg.Chat.ID, apperrors.ErrUnauthorized.UserMsg)
 			return
 		}
 	}
@@ -456,13 +1281,11 @@ func (h *Handler) handleUnauthorizedUser(ctx context.Context, msg *tgbotapi.Mess
 		}
 	}
 
-	h.sendText(msg.Chat.ID, "Your access request has been sent to the admin for approval.")
+	h.sendSuccess(msg.Chat.ID, "Your access request has been sent to the admin for approval.")
 }
 
-// notifyAdmin sends an approval request to the admin
+// notifyAdmin sends an approval request to every admin
 func (h *Handler) notifyAdmin(userID int64, username, firstName string) int {
-	adminChatID := h.whitelist.AdminUserID()
-
 	usernameDisplay := username
 	if usernameDisplay == "" {
 		usernameDisplay = "(none)"
@@ -483,22 +1306,62 @@ func (h *Handler) notifyAdmin(userID int64, username, firstName string) int {
 		userID, usernameDisplay, nameDisplay,
 	)
 
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+	return h.sendToAdmins(text, approvalKeyboard(userID))
+}
+
+// sendToAdmins sends text with an inline keyboard to every admin and
+// returns the ID of the message sent to the first admin, or 0 if that send
+// failed. Only the first admin's message is tracked for later edits.
+func (h *Handler) sendToAdmins(text string, keyboard tgbotapi.InlineKeyboardMarkup) int {
+	primaryMsgID := 0
+	for i, adminID := range h.whitelist.AdminUserIDs() {
+		msg := h.newMessage(adminID, text)
+		msg.ReplyMarkup = keyboard
+
+		sent, err := h.bot.Send(msg)
+		if err != nil {
+			h.logger.Error("failed to notify admin", "error", err, "admin_id", adminID)
+			continue
+		}
+		if i == 0 {
+			primaryMsgID = sent.MessageID
+		}
+	}
+	return primaryMsgID
+}
+
+// notifyAdmins sends plain text to every admin
+func (h *Handler) notifyAdmins(text string) {
+	for _, adminID := range h.whitelist.AdminUserIDs() {
+		h.sendText(adminID, text)
+	}
+}
+
+// approvalDurations maps approve callback actions to how long access
+// lasts (0 = permanent)
+var approvalDurations = map[string]time.Duration{
+	"approve":    0,
+	"approve24h": 24 * time.Hour,
+	"approve7d":  7 * 24 * time.Hour,
+}
+
+// approvalKeyboard builds the approve/reject keyboard for a pending user
+func approvalKeyboard(userID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Approve", fmt.Sprintf("admin:approve:%d", userID)),
 			tgbotapi.NewInlineKeyboardButtonData("Reject", fmt.Sprintf("admin:reject:%d", userID)),
 		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Approve (24h)", fmt.Sprintf("admin:approve24h:%d", userID)),
+			tgbotapi.NewInlineKeyboardButtonData("Approve (7d)", fmt.Sprintf("admin:approve7d:%d", userID)),
+			tgbotapi.NewInlineKeyboardButtonData("Approve (permanent)", fmt.Sprintf("admin:approve:%d", userID)),
+		),
 	)
+}
 
-	msg := tgbotapi.NewMessage(adminChatID, text)
-	msg.ReplyMarkup = keyboard
-
-	sent, err := h.bot.Send(msg)
-	if err != nil {
-		h.logger.Error("failed to notify admin", "error", err)
-		return 0
-	}
-	return sent.MessageID
+// formatExpiry renders an access expiry time for display
+func formatExpiry(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 UTC")
 }
 
 // handleAdminCallback handles approve/reject callbacks from the admin
@@ -509,6 +1372,25 @@ func (h *Handler) handleAdminCallback(ctx context.Context, query *tgbotapi.Callb
 	}
 
 	data := query.Data
+
+	switch data {
+	case "admin:confirm_rejectall":
+		h.handleRejectAllCallback(ctx, query, true)
+		return
+	case "admin:cancel_rejectall":
+		h.handleRejectAllCallback(ctx, query, false)
+		return
+	}
+
+	if strings.HasPrefix(data, listUsersPagePrefix) {
+		h.handleListUsersCallback(ctx, query)
+		return
+	}
+	if strings.HasPrefix(data, pendingPagePrefix) || strings.HasPrefix(data, pendingGroupsPagePrefix) {
+		h.handlePendingPageCallback(ctx, query)
+		return
+	}
+
 	parts := strings.Split(strings.TrimPrefix(data, "admin:"), ":")
 	if len(parts) != 2 {
 		h.answerCallback(query.ID, "Invalid action")
@@ -535,24 +1417,55 @@ func (h *Handler) handleAdminCallback(ctx context.Context, query *tgbotapi.Callb
 	}
 
 	switch action {
-	case "approve":
+	case "approve", "approve24h", "approve7d":
+		if h.cfg.MaxApprovedUsers > 0 {
+			count, err := h.adminStore.CountApproved()
+			if err != nil {
+				h.logger.Error("failed to count approved users", "error", err)
+				h.answerCallback(query.ID, "Failed to approve")
+				return
+			}
+			if count >= h.cfg.MaxApprovedUsers {
+				h.logger.Warn("approved user limit reached", "count", count, "limit", h.cfg.MaxApprovedUsers, "user_id", userID)
+				h.answerCallback(query.ID, "User limit reached, cannot approve")
+				h.sendError(query.Message.Chat.ID, fmt.Sprintf(
+					"Approved user limit reached (%d/%d). Revoke a user with /revoke before approving user %d.",
+					count, h.cfg.MaxApprovedUsers, userID))
+				return
+			}
+		}
+
+		if !h.claimPending(query, userID, false) {
+			return
+		}
+
 		approved := admin.ApprovedUser{
 			UserID:     userID,
 			Username:   pending.Username,
 			ApprovedAt: time.Now(),
 			ApprovedBy: query.From.ID,
 		}
+		if d := approvalDurations[action]; d > 0 {
+			expiresAt := approved.ApprovedAt.Add(d)
+			approved.ExpiresAt = &expiresAt
+		}
 		if err := h.adminStore.AddApproved(approved); err != nil {
 			h.logger.Error("failed to approve user", "error", err, "user_id", userID)
+			// Put the request back so it can be approved again
+			if err := h.adminStore.AddPending(*pending); err != nil {
+				h.logger.Error("failed to restore pending request", "error", err, "user_id", userID)
+			}
 			h.answerCallback(query.ID, "Failed to approve")
 			return
 		}
-		if err := h.adminStore.RemovePending(userID); err != nil {
-			h.logger.Error("failed to remove pending", "error", err, "user_id", userID)
-		}
+		h.logger.Info("user approved", "user_id", userID, "admin_id", query.From.ID, "expires_at", approved.ExpiresAt)
 
 		// Notify user they were approved
-		h.sendText(pending.ChatID, "Your access has been approved! You can now use the bot.")
+		until := ""
+		if approved.ExpiresAt != nil {
+			until = " until " + formatExpiry(*approved.ExpiresAt)
+		}
+		h.sendSuccess(pending.ChatID, "Your access has been approved"+until+". You can now use the bot.")
 
 		// Update admin message
 		usernameDisplay := pending.Username
@@ -562,13 +1475,30 @@ func (h *Handler) handleAdminCallback(ctx context.Context, query *tgbotapi.Callb
 			usernameDisplay = "@" + usernameDisplay
 		}
 		h.updateAdminMessage(query.Message.Chat.ID, query.Message.MessageID,
-			fmt.Sprintf("User %d (%s) approved", userID, usernameDisplay))
+			fmt.Sprintf("User %d (%s) approved%s", userID, usernameDisplay, until))
 
 		h.answerCallback(query.ID, "User approved")
 
 	case "reject":
-		if err := h.adminStore.RemovePending(userID); err != nil {
-			h.logger.Error("failed to remove pending", "error", err, "user_id", userID)
+		h.showKeyboard(query, rejectKeyboard(userID))
+
+	case "back":
+		h.showKeyboard(query, approvalKeyboard(userID))
+
+	case "rejectonly", "rejectban":
+		if !h.claimPending(query, userID, true) {
+			return
+		}
+		h.logger.Info("user rejected", "user_id", userID, "admin_id", query.From.ID)
+
+		banned := false
+		if action == "rejectban" {
+			if err := h.adminStore.Ban(userID, query.From.ID, rejectBanReason); err != nil {
+				h.logger.Error("failed to ban user", "error", err, "user_id", userID)
+			} else {
+				banned = true
+				h.logger.Info("user banned", "user_id", userID, "admin_id", query.From.ID)
+			}
 		}
 
 		// Notify user they were rejected
@@ -581,16 +1511,46 @@ func (h *Handler) handleAdminCallback(ctx context.Context, query *tgbotapi.Callb
 		} else {
 			usernameDisplay = "@" + usernameDisplay
 		}
+		status := "rejected"
+		if banned {
+			status = "rejected and banned"
+		}
 		h.updateAdminMessage(query.Message.Chat.ID, query.Message.MessageID,
-			fmt.Sprintf("User %d (%s) rejected", userID, usernameDisplay))
+			fmt.Sprintf("User %d (%s) %s", userID, usernameDisplay, status))
 
-		h.answerCallback(query.ID, "User rejected")
+		h.answerCallback(query.ID, "User "+status)
 
 	default:
 		h.answerCallback(query.ID, "Unknown action")
 	}
 }
 
+// claimPending removes a user's pending request on behalf of the admin who
+// pressed a button, recording it as rejected if reject is set. If another
+// admin got there first, the message is marked as handled and false is
+// returned.
+func (h *Handler) claimPending(query *tgbotapi.CallbackQuery, userID int64, reject bool) bool {
+	var claimed bool
+	var err error
+	if reject {
+		claimed, err = h.adminStore.RemovePending(userID, query.From.ID)
+	} else {
+		claimed, err = h.adminStore.ClaimPending(userID)
+	}
+	if err != nil {
+		h.logger.Error("failed to claim pending request", "error", err, "user_id", userID)
+		h.answerCallback(query.ID, "Failed to process request")
+		return false
+	}
+	if !claimed {
+		h.updateAdminMessage(query.Message.Chat.ID, query.Message.MessageID,
+			fmt.Sprintf("Request from user %d was already handled by another admin.", userID))
+		h.answerCallback(query.ID, "Already processed by another admin")
+		return false
+	}
+	return true
+}
+
 // updateAdminMessage updates an admin notification message
 func (h *Handler) updateAdminMessage(chatID int64, msgID int, newText string) {
 	edit := tgbotapi.NewEditMessageText(chatID, msgID, newText)
@@ -602,34 +1562,45 @@ func (h *Handler) updateAdminMessage(chatID int64, msgID int, newText string) {
 // handleRevoke handles the /revoke command for admins
 func (h *Handler) handleRevoke(ctx context.Context, msg *tgbotapi.Message) {
 	if !h.whitelist.IsAdmin(msg.From.ID) {
-		h.sendText(msg.Chat.ID, "This command is only available to admins.")
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
 		return
 	}
 
 	if h.adminStore == nil {
-		h.sendText(msg.Chat.ID, "Admin features are not configured.")
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
 		return
 	}
 
 	args := msg.CommandArguments()
 	if args == "" {
-		h.sendText(msg.Chat.ID, "Usage: /revoke <user_id>")
+		h.sendError(msg.Chat.ID, "Usage: /revoke <user_id>")
 		return
 	}
 
 	userID, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64)
 	if err != nil {
-		h.sendText(msg.Chat.ID, "Invalid user ID. Usage: /revoke <user_id>")
+		h.sendError(msg.Chat.ID, "Invalid user ID. Usage: /revoke <user_id>")
+		return
+	}
+
+	approved, err := h.adminStore.GetApproved(userID)
+	if err != nil {
+		h.logger.Error("failed to get approved user", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to revoke user access.")
 		return
 	}
 
-	if err := h.adminStore.RemoveApproved(userID); err != nil {
+	if err := h.adminStore.RemoveApproved(userID, msg.From.ID); err != nil {
 		h.logger.Error("failed to revoke user", "error", err, "user_id", userID)
-		h.sendText(msg.Chat.ID, "Failed to revoke user access.")
+		h.sendError(msg.Chat.ID, "Failed to revoke user access.")
 		return
 	}
 
-	h.sendText(msg.Chat.ID, fmt.Sprintf("User %d access has been revoked.", userID))
+	text := fmt.Sprintf("User %d access has been revoked.", userID)
+	if approved != nil && approved.ExpiresAt != nil {
+		text += fmt.Sprintf(" Their temporary access was set to expire at %s.", formatExpiry(*approved.ExpiresAt))
+	}
+	h.sendSuccess(msg.Chat.ID, text)
 }
 
 // parseBotMention checks if the message contains a mention of the bot
@@ -684,57 +1655,134 @@ func (h *Handler) parseBotMention(msg *tgbotapi.Message) (string, bool) {
 
 // handleGroupPrompt handles image generation requests from groups
 func (h *Handler) handleGroupPrompt(ctx context.Context, msg *tgbotapi.Message, userID, groupID int64, prompt string) {
+	if h.rejectIfMaintenance(msg.Chat.ID, userID) {
+		return
+	}
+
 	prompt = strings.TrimSpace(prompt)
+	if h.rejectTooLong(msg.Chat.ID, prompt) {
+		return
+	}
 
 	if len(prompt) < 3 {
-		h.sendText(msg.Chat.ID, "Please provide a more detailed prompt (at least 3 characters).")
+		h.sendError(msg.Chat.ID, "Please provide a more detailed prompt (at least 3 characters).")
+		return
+	}
+
+	finalPrompt := h.affixPrompt(userID, prompt)
+	h.warnIfNearTokenLimit(msg.Chat.ID, finalPrompt)
+
+	if err := h.checkQuota(userID); err != nil {
+		h.sendError(msg.Chat.ID, apperrors.GetUserMessage(err))
+		return
+	}
+
+	if h.remoteQueueBusy(ctx, msg.Chat.ID) {
 		return
 	}
 
 	// Check if user already has an active request (rate limit per user, not per group)
-	if !h.limiter.TryAcquire(userID) {
-		h.sendText(msg.Chat.ID, apperrors.ErrGenerationInProgress.UserMsg)
+	if err := h.limiter.WaitOrAcquire(ctx, userID, msg.Chat.ID); err != nil {
+		h.sendError(msg.Chat.ID, apperrors.GetUserMessage(err))
 		return
 	}
-	defer h.limiter.Release(userID)
+	genCtx, pending := h.trackGeneration(ctx, userID)
+	defer h.finishGeneration(userID, pending)
 
 	// Send "generating" message
-	statusMsg, err := h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Generating your image..."))
+	statusMsg, err := h.bot.Send(h.newMessage(msg.Chat.ID, "Generating your image..."))
 	if err != nil {
 		h.logger.Error("failed to send status message", "error", err)
 	}
 
+	// Show "typing" while generating, then an upload action while sending
+	stopAction := h.startChatAction(genCtx, msg.Chat.ID, tgbotapi.ChatTyping)
+	defer func() { stopAction() }()
+
 	// Generate image
 	h.logger.Info("starting group generation",
 		"user_id", userID,
 		"group_id", groupID,
 		"prompt_length", len(prompt))
 
-	imageData, err := h.comfy.GenerateImage(ctx, prompt)
+	started := time.Now()
+	genOpts := h.generateOptions(userID)
+	model := h.generationModel(genOpts)
+	genOpts.Progress = h.progressCallback(msg.Chat.ID, statusMsg.MessageID)
+	genOpts.OnQueued = func(promptID string) { h.addPendingPromptID(pending, promptID) }
+	output, err := h.comfy.GenerateImage(genCtx, finalPrompt, genOpts)
+	stopAction()
+	if generationCancelled(genCtx) {
+		if statusMsg.MessageID != 0 {
+			h.bot.Request(tgbotapi.NewDeleteMessage(msg.Chat.ID, statusMsg.MessageID))
+		}
+		return
+	}
+	h.recordGeneration(userID, model, started, err == nil)
+	h.observeGeneration(started, err)
 	if err != nil {
+		h.interruptIfCancelled(genCtx, userID, h.pendingPromptIDs(pending))
 		h.logger.Error("generation failed", "error", err, "user_id", userID, "group_id", groupID)
-		h.sendText(msg.Chat.ID, apperrors.GetUserMessage(err))
+		h.rememberError(userID, err)
+		h.sendError(msg.Chat.ID, apperrors.GetUserMessage(err))
 
 		if statusMsg.MessageID != 0 {
 			h.bot.Request(tgbotapi.NewDeleteMessage(msg.Chat.ID, statusMsg.MessageID))
 		}
 		return
 	}
+	h.recordUsage(userID)
+	stopAction = h.startChatAction(genCtx, msg.Chat.ID, uploadChatAction(output))
+
+	if output.IsVideo() {
+		h.logger.Info("group video generation complete",
+			"user_id", userID,
+			"group_id", groupID,
+			"format", output.Video.Format,
+			"size", len(output.Data),
+		)
+		h.recordHistory(userID, groupID, prompt, "", output)
+		if statusMsg.MessageID != 0 {
+			h.bot.Request(tgbotapi.NewDeleteMessage(msg.Chat.ID, statusMsg.MessageID))
+		}
+		h.sendVideoOutput(msg.Chat.ID, userID, prompt, output, msg.MessageID)
+		return
+	}
 
 	// Process image
-	result, err := h.processor.Process(imageData)
+	result, err := h.processOutput(h.stripsMetadata(userID), output, finalPrompt)
 	if err != nil {
 		h.logger.Error("image processing failed", "error", err)
-		h.sendText(msg.Chat.ID, "Failed to process the generated image.")
+		h.sendError(msg.Chat.ID, "Failed to process the generated image.")
 		return
 	}
 
+	if result.Passthrough16Bit {
+		h.logger.Info("16-bit image passthrough, skipping jpeg compression",
+			"width", result.Width,
+			"height", result.Height,
+		)
+	}
+
+	if result.Downscaled() || result.Resized {
+		h.logger.Info("downscaled image for telegram photo limit",
+			"original_width", result.Width,
+			"original_height", result.Height,
+			"width", result.CompressedWidth,
+			"height", result.CompressedHeight,
+			"fit_upload_limit", result.Resized,
+		)
+	}
+
 	h.logger.Info("group generation complete",
 		"user_id", userID,
 		"group_id", groupID,
+		"seed", output.Seed,
 		"compressed_size", result.CompressedSize,
 	)
 
+	h.recordHistory(userID, groupID, prompt, "", output)
+
 	// Delete "generating" message
 	if statusMsg.MessageID != 0 {
 		h.bot.Request(tgbotapi.NewDeleteMessage(msg.Chat.ID, statusMsg.MessageID))
@@ -742,17 +1790,57 @@ func (h *Handler) handleGroupPrompt(ctx context.Context, msg *tgbotapi.Message,
 
 	// Send ONLY compressed version for groups
 	photoMsg := tgbotapi.NewPhoto(msg.Chat.ID, tgbotapi.FileBytes{
-		Name:  "image.jpg",
+		Name:  result.CompressedFilename(),
 		Bytes: result.Compressed,
 	})
-	photoMsg.Caption = fmt.Sprintf("Prompt: %s", truncate(prompt, 200))
+	photoMsg.Caption = promptCaption(prompt, output.Seed)
 	photoMsg.ReplyToMessageID = msg.MessageID // Reply to the original request
 
-	if _, err := h.bot.Send(photoMsg); err != nil {
+	sentPhoto, err := h.bot.Send(photoMsg)
+	if err != nil {
 		h.logger.Error("failed to send photo to group", "error", err)
+		return
+	}
+
+	// Optionally follow up with the original, threaded under the photo
+	if !h.groupOriginalsAllowed(groupID) {
+		return
+	}
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		return
+	}
+	if !userSettings.SendOriginal {
+		return
+	}
+
+	docMsg := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileBytes{
+		Name:  "image.png",
+		Bytes: result.OriginalDocument(),
+	})
+	docMsg.Caption = "Original PNG"
+	docMsg.ReplyToMessageID = sentPhoto.MessageID
+	if _, err := h.bot.Send(docMsg); err != nil {
+		h.logger.Error("failed to send document to group", "error", err)
 	}
 }
 
+// groupOriginalsAllowed reports whether original PNGs may be sent to a group,
+// preferring the group-level override over the global config
+func (h *Handler) groupOriginalsAllowed(groupID int64) bool {
+	groupSettings, err := h.settings.GetGroup(groupID)
+	if err != nil {
+		h.logger.Error("failed to get group settings", "error", err, "group_id", groupID)
+		return h.cfg.AllowGroupOriginals
+	}
+	if groupSettings.AllowOriginals != nil {
+		return *groupSettings.AllowOriginals
+	}
+	return h.cfg.AllowGroupOriginals
+}
+
 // handleUnauthorizedGroup handles access attempts from unapproved groups
 func (h *Handler) handleUnauthorizedGroup(ctx context.Context, msg *tgbotapi.Message) {
 	// Only process if this is a mention of the bot
@@ -762,7 +1850,7 @@ func (h *Handler) handleUnauthorizedGroup(ctx context.Context, msg *tgbotapi.Mes
 	}
 
 	// If no admin is configured, just ignore
-	if h.whitelist.AdminUserID() == 0 || h.adminStore == nil {
+	if !h.whitelist.HasAdmins() || h.adminStore == nil {
 		return
 	}
 
@@ -803,10 +1891,8 @@ func (h *Handler) handleUnauthorizedGroup(ctx context.Context, msg *tgbotapi.Mes
 	}
 }
 
-// notifyAdminAboutGroup sends an approval request to the admin for a group
+// notifyAdminAboutGroup sends an approval request to every admin for a group
 func (h *Handler) notifyAdminAboutGroup(groupID int64, title string) int {
-	adminChatID := h.whitelist.AdminUserID()
-
 	titleDisplay := title
 	if titleDisplay == "" {
 		titleDisplay = "(unnamed group)"
@@ -819,22 +1905,17 @@ func (h *Handler) notifyAdminAboutGroup(groupID int64, title string) int {
 		groupID, titleDisplay,
 	)
 
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+	return h.sendToAdmins(text, groupApprovalKeyboard(groupID))
+}
+
+// groupApprovalKeyboard builds the Approve/Reject buttons for a group request
+func groupApprovalKeyboard(groupID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("Approve", fmt.Sprintf("admin_group:approve:%d", groupID)),
 			tgbotapi.NewInlineKeyboardButtonData("Reject", fmt.Sprintf("admin_group:reject:%d", groupID)),
 		),
 	)
-
-	msg := tgbotapi.NewMessage(adminChatID, text)
-	msg.ReplyMarkup = keyboard
-
-	sent, err := h.bot.Send(msg)
-	if err != nil {
-		h.logger.Error("failed to notify admin about group", "error", err)
-		return 0
-	}
-	return sent.MessageID
 }
 
 // handleAdminGroupCallback handles approve/reject callbacks for groups
@@ -888,7 +1969,7 @@ func (h *Handler) handleAdminGroupCallback(ctx context.Context, query *tgbotapi.
 		}
 
 		// Notify group they were approved
-		h.sendText(groupID, "This group has been approved! You can now use the bot by mentioning @"+h.bot.Self.UserName+" followed by your prompt.")
+		h.sendSuccess(groupID, "This group has been approved! You can now use the bot by mentioning @"+h.bot.Self.UserName+" followed by your prompt.")
 
 		// Update admin message
 		titleDisplay := pending.Title
@@ -923,32 +2004,91 @@ func (h *Handler) handleAdminGroupCallback(ctx context.Context, query *tgbotapi.
 // handleRevokeGroup handles the /revokegroup command for admins
 func (h *Handler) handleRevokeGroup(ctx context.Context, msg *tgbotapi.Message) {
 	if !h.whitelist.IsAdmin(msg.From.ID) {
-		h.sendText(msg.Chat.ID, "This command is only available to admins.")
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
 		return
 	}
 
 	if h.adminStore == nil {
-		h.sendText(msg.Chat.ID, "Admin features are not configured.")
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
 		return
 	}
 
 	args := msg.CommandArguments()
 	if args == "" {
-		h.sendText(msg.Chat.ID, "Usage: /revokegroup <group_id>")
+		h.sendError(msg.Chat.ID, "Usage: /revokegroup <group_id>")
 		return
 	}
 
 	groupID, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64)
 	if err != nil {
-		h.sendText(msg.Chat.ID, "Invalid group ID. Usage: /revokegroup <group_id>")
+		h.sendError(msg.Chat.ID, "Invalid group ID. Usage: /revokegroup <group_id>")
 		return
 	}
 
-	if err := h.adminStore.RemoveApprovedGroup(groupID); err != nil {
+	if err := h.adminStore.RemoveApprovedGroup(groupID, msg.From.ID); err != nil {
 		h.logger.Error("failed to revoke group", "error", err, "group_id", groupID)
-		h.sendText(msg.Chat.ID, "Failed to revoke group access.")
+		h.sendError(msg.Chat.ID, "Failed to revoke group access.")
 		return
 	}
 
-	h.sendText(msg.Chat.ID, fmt.Sprintf("Group %d access has been revoked.", groupID))
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf("Group %d access has been revoked.", groupID))
+}
+
+// handleGroupOriginals handles the /grouporiginals command for admins
+func (h *Handler) handleGroupOriginals(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	const usage = "Usage: /grouporiginals <group_id> <on|off|default>"
+
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) != 2 {
+		h.sendError(msg.Chat.ID, usage)
+		return
+	}
+
+	groupID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		h.sendError(msg.Chat.ID, "Invalid group ID. "+usage)
+		return
+	}
+
+	groupSettings, err := h.settings.GetGroup(groupID)
+	if err != nil {
+		h.logger.Error("failed to get group settings", "error", err, "group_id", groupID)
+		h.sendError(msg.Chat.ID, "Failed to load group settings.")
+		return
+	}
+
+	switch strings.ToLower(args[1]) {
+	case "on":
+		allow := true
+		groupSettings.AllowOriginals = &allow
+	case "off":
+		allow := false
+		groupSettings.AllowOriginals = &allow
+	case "default":
+		groupSettings.AllowOriginals = nil
+	default:
+		h.sendError(msg.Chat.ID, usage)
+		return
+	}
+
+	if err := h.settings.SaveGroup(groupSettings); err != nil {
+		h.logger.Error("failed to save group settings", "error", err, "group_id", groupID)
+		h.sendError(msg.Chat.ID, "Failed to save group settings.")
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf("Original PNGs for group %d: %s (effective: %s)",
+		groupID, strings.ToLower(args[1]), onOff(h.groupOriginalsAllowed(groupID))))
+}
+
+func onOff(b bool) string {
+	if b {
+		return "ON"
+	}
+	return "OFF"
 }
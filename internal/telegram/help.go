@@ -0,0 +1,98 @@
+package telegram
+
+import "strings"
+
+// helpEntry is a command usage and what it does, as listed by /help
+type helpEntry struct {
+	usage       string
+	description string
+}
+
+// userHelp lists the commands every user can run
+var userHelp = []helpEntry{
+	{"/settings", "Configure image delivery preferences"},
+	{"/settings steps=30 cfg=7.5", "Override workflow parameters"},
+	{"/workflow [name]", "Choose which workflow generates your images"},
+	{"/models", "List the checkpoints installed on ComfyUI"},
+	{"/setneg <text>", "Set a default negative prompt"},
+	{"/setprefix, /setsuffix <text>", "Add text before or after every prompt"},
+	{"/clearprefix, /clearsuffix", "Remove your prompt prefix or suffix"},
+	{"/setsize <width>x<height>", "Set the image size, e.g. 832x1216"},
+	{"/resetsize", "Go back to the workflow's image size"},
+	{"/setseed <number>", "Use a fixed seed for reproducible images"},
+	{"/randomseed", "Go back to random seeds"},
+	{"/settimeout <seconds>", "Allow generations to run longer or shorter"},
+	{"/cleartimeout", "Go back to the default timeout"},
+	{"/suggest", "Get prompt ideas based on your recent prompts"},
+	{"/random", "Generate an image from a random prompt"},
+	{"/requeue", "Generate your last prompt again"},
+	{"/history [clear]", "Show your recent prompts to generate again, or clear them"},
+	{"/saveprompt <name>", "Save your last prompt as a favorite"},
+	{"/favorites", "Generate one of your favorite prompts"},
+	{"/delfav <name>", "Delete a favorite"},
+	{"/cancel", "Stop your current generation"},
+	{"/mystats", "Show your generation statistics"},
+	{"/showkeys, /hidekeys", "Show or hide the quick-action keyboard"},
+	{"/share", "Share your latest prompt in the public gallery"},
+	{"/clone <share_token>", "Generate a variant of a shared prompt"},
+	{"/reportbug <description>", "Report a problem to the admin"},
+	{"/nuke", "Permanently delete all your data"},
+	{"/status", "Check ComfyUI server status"},
+}
+
+// adminHelp lists the admin commands, shown to admins only
+var adminHelp = []helpEntry{
+	{"/status --json", "Server status as a JSON file"},
+	{"/debug [--json]", "Detailed bot diagnostics"},
+	{"/getbug <report_id>", "Show a bug report"},
+	{"/stats [days]", "Bot-wide usage over the last days (default 7)"},
+	{"/modelstats [model]", "Compare generation performance per model"},
+	{"/benchmark <n> <prompt>", "Time n sequential generations (2-5)"},
+	{"/maintenance <on [message]|off>", "Turn away new prompts during maintenance"},
+	{"/wfreload (/reloadworkflow)", "Reload the workflow template from disk"},
+	{"/wfrollback [backup]", "List or restore workflow backups"},
+	{"/listusers", "List approved users"},
+	{"/revoke <user_id>", "Revoke user access"},
+	{"/setuserworkflows <user_id> [workflows|all]", "Restrict a user to some workflows"},
+	{"/ban <user_id> [reason]", "Ban a user from requesting access"},
+	{"/unban <user_id>", "Lift a ban"},
+	{"/auditlog", "Show the last 20 access changes: approvals, rejections, revocations, expiries and imports"},
+	{"/pending", "List pending access requests"},
+	{"/pendinggroups", "List pending group requests"},
+	{"/rejectall", "Reject all pending access requests"},
+	{"/broadcast <message>", "Send a message to every approved user"},
+	{"/nukeuser <user_id>", "Delete all data for a user"},
+	{"/revokegroup <group_id>", "Revoke group access"},
+	{"/grouporiginals <group_id> <on|off|default>", "Allow original PNGs in a group"},
+	{"/exportdata", "Download approved users and groups as a JSON backup"},
+	{"/importdata", "Restore a backup (send it with this caption or reply to it)"},
+}
+
+// helpText builds the /help message for userID in the help parse mode
+func (h *Handler) helpText(userID int64) markup {
+	f := formatter{mode: h.cfg.ParseModes.Help}
+
+	var b strings.Builder
+	b.WriteString(string(f.Sprintf(
+		"Simply send me a text description of the image you want to generate.\n\n"+
+			"For example: \"A beautiful sunset over mountains with a lake reflection\"\n\n"+
+			"Start a prompt with %s to get 3 variations at once.\n\n"+
+			"In groups, mention me with %s followed by your prompt.\n\n",
+		f.Code("[3]"), f.Code("@"+h.bot.Self.UserName))))
+
+	writeHelp(&b, f, "Commands:", userHelp)
+	if h.whitelist.IsAdmin(userID) {
+		b.WriteString("\n\n")
+		writeHelp(&b, f, "Admin commands:", adminHelp)
+	}
+	return markup(b.String())
+}
+
+// writeHelp writes a bold title followed by one line per entry
+func writeHelp(b *strings.Builder, f formatter, title string, entries []helpEntry) {
+	b.WriteString(string(f.Bold(title)))
+	for _, e := range entries {
+		b.WriteString("\n")
+		b.WriteString(string(f.Sprintf("%s - %s", f.Code(e.usage), e.description)))
+	}
+}
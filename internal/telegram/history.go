@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/history"
+)
+
+const (
+	// historyShown is how many recent prompts /history lists
+	historyShown = 5
+	// historyCallbackPrefix prefixes /history button data; the entry ID follows
+	historyCallbackPrefix = "history:"
+)
+
+// handleHistory handles the /history command: the user's recent prompts
+// with a button to generate each again. "/history clear" deletes them.
+func (h *Handler) handleHistory(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+
+	if h.history == nil {
+		h.sendError(msg.Chat.ID, "History is not available.")
+		return
+	}
+
+	if strings.EqualFold(strings.TrimSpace(msg.CommandArguments()), "clear") {
+		if err := h.history.Clear(userID); err != nil {
+			h.logger.Error("failed to clear history", "error", err, "user_id", userID)
+			h.sendError(msg.Chat.ID, "Failed to clear your history. Please try again.")
+			return
+		}
+		h.sendSuccess(msg.Chat.ID, "Your prompt history has been cleared.")
+		return
+	}
+
+	entries, err := h.history.Recent(userID, historyShown)
+	if err != nil {
+		h.logger.Error("failed to load history", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load your prompt history. Please try again.")
+		return
+	}
+	if len(entries) == 0 {
+		h.sendText(msg.Chat.ID, "You haven't generated anything yet. Send me a prompt!")
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var text strings.Builder
+	text.WriteString("Your recent prompts. Tap one to generate it again:\n")
+	for i, e := range entries {
+		fmt.Fprintf(&text, "\n%d. %s (%s", i+1, truncate(e.Prompt, 200), e.CreatedAt.Format("2006-01-02 15:04"))
+		if e.Seed >= 0 {
+			fmt.Fprintf(&text, ", seed %d", e.Seed)
+		}
+		text.WriteString(")")
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("%d. %s", i+1, truncate(e.Prompt, 60)),
+				fmt.Sprintf("%s%d", historyCallbackPrefix, e.ID)),
+		))
+	}
+
+	reply := h.newMessage(msg.Chat.ID, text.String())
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := h.bot.Send(reply); err != nil {
+		h.logger.Error("failed to send history", "error", err)
+	}
+}
+
+// handleHistoryCallback generates a tapped /history prompt again with a new
+// seed
+func (h *Handler) handleHistoryCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	userID := query.From.ID
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(query.Data, historyCallbackPrefix), 10, 64)
+	if err != nil || h.history == nil || query.Message == nil {
+		h.answerCallback(query.ID, "Invalid selection")
+		return
+	}
+
+	// Look the entry up among the user's own so IDs from other users'
+	// buttons cannot be used
+	entries, err := h.history.Recent(userID, history.MaxEntriesPerUser)
+	if err != nil {
+		h.logger.Error("failed to load history", "error", err, "user_id", userID)
+		h.answerCallback(query.ID, "Failed to load history")
+		return
+	}
+
+	for _, e := range entries {
+		if e.ID == id {
+			h.answerCallback(query.ID, "Generating...")
+			h.generateForUser(ctx, query.Message.Chat.ID, userID, e.Prompt, genOptions{randomSeed: true})
+			return
+		}
+	}
+	h.answerCallback(query.ID, "Prompt no longer in your history, use /history again")
+}
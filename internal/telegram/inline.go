@@ -0,0 +1,249 @@
+package telegram
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	apperrors "comfy-tg-bot/internal/errors"
+)
+
+// inlineDebounce is how long an inline query must stay the user's latest
+// before it is generated, so typing "@bot a red car" does not start a
+// generation for "a r"
+const inlineDebounce = 1500 * time.Millisecond
+
+// inlineResultTTL is how long a generated inline image can be sent again
+// without regenerating it
+const inlineResultTTL = time.Hour
+
+// inlineKey identifies a user's inline prompt
+type inlineKey struct {
+	userID int64
+	// resultID is derived from the prompt by inlineResultID
+	resultID string
+}
+
+// inlineResult is an inline prompt being generated or already uploaded
+type inlineResult struct {
+	// fileID is the uploaded photo, empty while generating
+	fileID  string
+	seed    int64
+	expires time.Time
+}
+
+// handleInlineQuery answers "@bot <prompt>" from any chat. Generation takes
+// longer than Telegram waits for an answer, so the first query gets a
+// placeholder while the image is generated and sent to the user's private
+// chat; repeating the query then offers the uploaded photo.
+func (h *Handler) handleInlineQuery(ctx context.Context, query *tgbotapi.InlineQuery) {
+	prompt := strings.TrimSpace(query.Query)
+	if utf8.RuneCountInString(prompt) < 3 {
+		h.answerInline(query.ID, nil, 0)
+		return
+	}
+
+	userID := query.From.ID
+	key := inlineKey{userID: userID, resultID: inlineResultID(prompt)}
+
+	if !h.awaitLatestInline(ctx, userID, query.ID) {
+		// The user kept typing; Telegram drops unanswered stale queries
+		return
+	}
+
+	now := time.Now()
+	h.inlineMu.Lock()
+	cached, ok := h.inlineResults[key]
+	if ok && now.After(cached.expires) {
+		ok = false
+	}
+	if !ok {
+		for k, r := range h.inlineResults {
+			if now.After(r.expires) {
+				delete(h.inlineResults, k)
+			}
+		}
+		h.inlineResults[key] = inlineResult{expires: now.Add(inlineResultTTL)}
+	}
+	h.inlineMu.Unlock()
+
+	switch {
+	case ok && cached.fileID != "":
+		photo := tgbotapi.NewInlineQueryResultCachedPhoto(key.resultID, cached.fileID)
+		photo.Caption = promptCaption(prompt, cached.seed)
+		h.answerInline(query.ID, []any{photo}, h.cfg.InlineCacheTimeSeconds)
+		return
+
+	case ok:
+		h.answerInline(query.ID, []any{inlinePlaceholder(key.resultID, prompt,
+			"Still generating...", "Repeat this query in a moment to send the image.")}, 0)
+		return
+	}
+
+	if reason := h.inlineRejection(userID); reason != "" {
+		h.forgetInline(key)
+		h.answerInline(query.ID, []any{inlinePlaceholder(key.resultID, prompt, "Cannot generate now", reason)}, 0)
+		return
+	}
+
+	h.answerInline(query.ID, []any{inlinePlaceholder(key.resultID, prompt,
+		"Generating your image...",
+		"It will arrive in your private chat; repeat this query then to send it here.")}, 0)
+	h.generateInline(ctx, key, prompt)
+}
+
+// awaitLatestInline waits inlineDebounce and reports whether queryID is
+// still the user's most recent inline query
+func (h *Handler) awaitLatestInline(ctx context.Context, userID int64, queryID string) bool {
+	h.inlineMu.Lock()
+	h.inlineLatest[userID] = queryID
+	h.inlineMu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(inlineDebounce):
+	}
+
+	h.inlineMu.Lock()
+	defer h.inlineMu.Unlock()
+	if h.inlineLatest[userID] != queryID {
+		return false
+	}
+	delete(h.inlineLatest, userID)
+	return true
+}
+
+// inlineRejection checks maintenance mode, the daily quota and the limiter,
+// acquiring a limiter slot if the user may generate. Returns why the user
+// cannot generate, or "" if they hold a slot.
+func (h *Handler) inlineRejection(userID int64) string {
+	if note, on := h.maintenanceFor(userID); on {
+		return maintenanceMessage(note)
+	}
+	if err := h.checkQuota(userID); err != nil {
+		return apperrors.GetUserMessage(err)
+	}
+	// Inline queries cannot wait in the queue, so only take a free slot
+	if err := h.limiter.TryAcquire(userID); err != nil {
+		return apperrors.GetUserMessage(err)
+	}
+	return ""
+}
+
+// generateInline generates an inline prompt for a user holding a limiter
+// slot and sends it to their private chat, remembering the uploaded photo
+// for the next matching query
+func (h *Handler) generateInline(ctx context.Context, key inlineKey, prompt string) {
+	userID := key.userID
+	genCtx, pending := h.trackGeneration(ctx, userID)
+	defer h.finishGeneration(userID, pending)
+
+	h.logger.Info("starting inline generation", "user_id", userID, "prompt_length", len(prompt))
+
+	started := time.Now()
+	genOpts := h.generateOptions(userID)
+	model := h.generationModel(genOpts)
+	genOpts.OnQueued = func(promptID string) { h.addPendingPromptID(pending, promptID) }
+	output, err := h.comfy.GenerateImage(genCtx, h.affixPrompt(userID, prompt), genOpts)
+	if generationCancelled(genCtx) {
+		h.forgetInline(key)
+		return
+	}
+	h.recordGeneration(userID, model, started, err == nil)
+	h.observeGeneration(started, err)
+	if err != nil {
+		h.interruptIfCancelled(genCtx, userID, h.pendingPromptIDs(pending))
+		h.logger.Error("inline generation failed", "error", err, "user_id", userID)
+		h.rememberError(userID, err)
+		h.forgetInline(key)
+		h.sendError(userID, apperrors.GetUserMessage(err))
+		return
+	}
+	h.recordUsage(userID)
+
+	if output.IsVideo() {
+		h.forgetInline(key)
+		h.sendError(userID, "Inline queries only support image workflows. Send the prompt here instead.")
+		return
+	}
+
+	result, err := h.processor.Process(output.Data)
+	if err != nil {
+		h.logger.Error("image processing failed", "error", err)
+		h.forgetInline(key)
+		h.sendError(userID, "Failed to process the generated image.")
+		return
+	}
+	h.recordHistory(userID, userID, prompt, "", output)
+
+	photo := tgbotapi.NewPhoto(userID, tgbotapi.FileBytes{
+		Name:  result.CompressedFilename(),
+		Bytes: result.Compressed,
+	})
+	photo.Caption = promptCaption(prompt, output.Seed)
+	sent, err := h.bot.Send(photo)
+	if err == nil && len(sent.Photo) == 0 {
+		err = errors.New("no photo in sent message")
+	}
+	if err != nil {
+		// Fails if the user never started a private chat with the bot
+		h.logger.Warn("failed to upload inline result", "error", err, "user_id", userID)
+		h.forgetInline(key)
+		return
+	}
+
+	h.inlineMu.Lock()
+	h.inlineResults[key] = inlineResult{
+		fileID:  sent.Photo[len(sent.Photo)-1].FileID,
+		seed:    output.Seed,
+		expires: time.Now().Add(inlineResultTTL),
+	}
+	h.inlineMu.Unlock()
+
+	h.logger.Info("inline generation complete", "user_id", userID, "seed", output.Seed)
+}
+
+// forgetInline drops an inline prompt so the next query starts over
+func (h *Handler) forgetInline(key inlineKey) {
+	h.inlineMu.Lock()
+	delete(h.inlineResults, key)
+	h.inlineMu.Unlock()
+}
+
+// answerInline answers an inline query. Results are personal, since each
+// user's images depend on their own settings.
+func (h *Handler) answerInline(queryID string, results []any, cacheSeconds int) {
+	if results == nil {
+		results = []any{}
+	}
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: queryID,
+		Results:       results,
+		CacheTime:     cacheSeconds,
+		IsPersonal:    true,
+	}
+	if _, err := h.bot.Request(answer); err != nil {
+		h.logger.Error("failed to answer inline query", "error", err)
+	}
+}
+
+// inlinePlaceholder is the text result offered while an inline image is not
+// ready; choosing it posts the prompt
+func inlinePlaceholder(resultID, prompt, title, description string) tgbotapi.InlineQueryResultArticle {
+	article := tgbotapi.NewInlineQueryResultArticle("pending-"+resultID, title, "Prompt: "+prompt)
+	article.Description = description
+	return article
+}
+
+// inlineResultID derives a stable inline result ID from a prompt
+func inlineResultID(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,122 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// listUsersPageSize is the number of approved users shown per /listusers page
+const listUsersPageSize = 10
+
+// listUsersPagePrefix is the callback data prefix for /listusers page buttons
+const listUsersPagePrefix = "admin:list:page:"
+
+// handleListUsers handles the /listusers command for admins
+func (h *Handler) handleListUsers(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.adminStore == nil {
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
+		return
+	}
+
+	text, keyboard, err := h.renderApprovedPage(1)
+	if err != nil {
+		h.logger.Error("failed to list approved users", "error", err)
+		h.sendError(msg.Chat.ID, "Failed to load approved users.")
+		return
+	}
+
+	reply := h.newMessage(msg.Chat.ID, text)
+	if keyboard != nil {
+		reply.ReplyMarkup = *keyboard
+	}
+	if _, err := h.bot.Send(reply); err != nil {
+		h.logger.Error("failed to send approved users list", "error", err)
+	}
+}
+
+// handleListUsersCallback handles the Previous/Next buttons for /listusers.
+// The caller must have verified the sender is the admin.
+func (h *Handler) handleListUsersCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	page, err := strconv.Atoi(strings.TrimPrefix(query.Data, listUsersPagePrefix))
+	if err != nil || page < 1 {
+		h.answerCallback(query.ID, "Invalid page")
+		return
+	}
+
+	text, keyboard, err := h.renderApprovedPage(page)
+	if err != nil {
+		h.logger.Error("failed to list approved users", "error", err)
+		h.answerCallback(query.ID, "Failed to load approved users")
+		return
+	}
+
+	var edit tgbotapi.EditMessageTextConfig
+	if keyboard != nil {
+		edit = tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID, text, *keyboard)
+	} else {
+		edit = tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	}
+	if _, err := h.bot.Send(edit); err != nil {
+		h.logger.Error("failed to update approved users list", "error", err)
+	}
+	h.answerCallback(query.ID, "")
+}
+
+// renderApprovedPage builds the text and navigation keyboard for a 1-based
+// page of approved users. Pages past the end are clamped to the last page.
+// The keyboard is nil when everything fits on one page.
+func (h *Handler) renderApprovedPage(page int) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	users, total, err := h.adminStore.ListApproved((page-1)*listUsersPageSize, listUsersPageSize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	pages := (total + listUsersPageSize - 1) / listUsersPageSize
+	if pages == 0 {
+		return "No approved users.", nil, nil
+	}
+	if page > pages {
+		// Users were revoked since the page was rendered
+		page = pages
+		users, total, err = h.adminStore.ListApproved((page-1)*listUsersPageSize, listUsersPageSize)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Approved users: %d (page %d/%d)\n\n", total, page, pages)
+	b.WriteString("User ID | Username | Approved | Expires\n")
+	for _, u := range users {
+		expires := "never"
+		if u.ExpiresAt != nil {
+			expires = formatExpiry(*u.ExpiresAt)
+		}
+		fmt.Fprintf(&b, "%d | %s | %s | %s\n", u.UserID, formatUsername(u.Username), u.ApprovedAt.UTC().Format("2006-01-02"), expires)
+	}
+
+	if pages == 1 {
+		return strings.TrimRight(b.String(), "\n"), nil, nil
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Previous",
+			fmt.Sprintf("%s%d", listUsersPagePrefix, page-1)))
+	}
+	if page < pages {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Next",
+			fmt.Sprintf("%s%d", listUsersPagePrefix, page+1)))
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(row)
+	return strings.TrimRight(b.String(), "\n"), &keyboard, nil
+}
@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// longPromptTTL is how long a long prompt waits for confirmation
+const longPromptTTL = 5 * time.Minute
+
+// longPromptCleanupInterval is how often unanswered long prompts are dropped
+const longPromptCleanupInterval = time.Minute
+
+// longPromptCallbackPrefix prefixes the confirmation buttons, e.g. "longprompt:yes"
+const longPromptCallbackPrefix = "longprompt:"
+
+// longPrompt is a prompt over the soft limit waiting for confirmation
+type longPrompt struct {
+	prompt  string
+	expires time.Time
+}
+
+// rejectTooLong tells the user their prompt exceeds
+// telegram.max_prompt_length. Returns true if the prompt must not be
+// generated.
+func (h *Handler) rejectTooLong(chatID int64, prompt string) bool {
+	length := utf8.RuneCountInString(prompt)
+	if h.cfg.MaxPromptLength <= 0 || length <= h.cfg.MaxPromptLength {
+		return false
+	}
+	h.sendError(chatID, fmt.Sprintf("Prompt too long (%d characters). Maximum is %d characters.",
+		length, h.cfg.MaxPromptLength))
+	return true
+}
+
+// confirmLongPrompt asks the user whether to generate a prompt longer than
+// telegram.prompt_soft_limit. Returns false if the prompt is within the
+// limit, in which case the caller should continue as normal.
+func (h *Handler) confirmLongPrompt(chatID, userID int64, prompt string) bool {
+	length := utf8.RuneCountInString(prompt)
+	if h.cfg.PromptSoftLimit <= 0 || length <= h.cfg.PromptSoftLimit {
+		return false
+	}
+
+	h.longPromptMu.Lock()
+	h.longPrompts[userID] = longPrompt{prompt: prompt, expires: time.Now().Add(longPromptTTL)}
+	h.longPromptMu.Unlock()
+
+	msg := h.newMessage(chatID, fmt.Sprintf(
+		"Your prompt is %d characters long, which may produce poor results. Proceed anyway?", length))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Yes", longPromptCallbackPrefix+"yes"),
+			tgbotapi.NewInlineKeyboardButtonData("No", longPromptCallbackPrefix+"no"),
+		),
+	)
+	if _, err := h.bot.Send(msg); err != nil {
+		h.logger.Error("failed to send long prompt confirmation", "error", err)
+	}
+	return true
+}
+
+// handleLongPromptCallback generates or discards a confirmed long prompt
+func (h *Handler) handleLongPromptCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	userID := query.From.ID
+
+	h.longPromptMu.Lock()
+	pending, ok := h.longPrompts[userID]
+	delete(h.longPrompts, userID)
+	h.longPromptMu.Unlock()
+
+	if !ok || time.Now().After(pending.expires) || query.Message == nil {
+		h.answerCallback(query.ID, "This prompt has expired. Please send it again.")
+		return
+	}
+
+	chatID := query.Message.Chat.ID
+	if query.Data != longPromptCallbackPrefix+"yes" {
+		h.updateAdminMessage(chatID, query.Message.MessageID, "Cancelled.")
+		h.answerCallback(query.ID, "Cancelled")
+		return
+	}
+
+	h.updateAdminMessage(chatID, query.Message.MessageID, "Generating: "+truncate(pending.prompt, 500))
+	h.answerCallback(query.ID, "Generating...")
+	h.dispatchPrompt(ctx, chatID, userID, pending.prompt)
+}
+
+// runLongPromptCleanup drops long prompts whose confirmation has expired.
+// Blocks until ctx is cancelled.
+func (h *Handler) runLongPromptCleanup(ctx context.Context) {
+	if h.cfg.PromptSoftLimit <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(longPromptCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.longPromptMu.Lock()
+			for userID, pending := range h.longPrompts {
+				if now.After(pending.expires) {
+					delete(h.longPrompts, userID)
+				}
+			}
+			h.longPromptMu.Unlock()
+		}
+	}
+}
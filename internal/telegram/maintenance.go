@@ -0,0 +1,82 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maintenanceReply is sent instead of generating while maintenance mode is on
+const maintenanceReply = "The bot is currently undergoing maintenance. Please try again later."
+
+// handleMaintenance handles /maintenance on [message] and /maintenance off
+// for admins. While on, new prompts are turned away; running generations
+// finish as normal.
+func (h *Handler) handleMaintenance(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	mode, note, _ := strings.Cut(strings.TrimSpace(msg.CommandArguments()), " ")
+	switch strings.ToLower(mode) {
+	case "on":
+		note = strings.TrimSpace(note)
+		h.maintenance.Store(&note)
+		h.logger.Info("maintenance mode enabled", "admin_id", msg.From.ID, "message", note)
+		h.sendSuccess(msg.Chat.ID, "Maintenance mode is on. New prompts will be turned away with:\n\n"+maintenanceMessage(note))
+
+	case "off":
+		h.maintenance.Store(nil)
+		h.logger.Info("maintenance mode disabled", "admin_id", msg.From.ID)
+		h.sendSuccess(msg.Chat.ID, "Maintenance mode is off.")
+
+	default:
+		h.sendText(msg.Chat.ID, "Maintenance mode: "+h.maintenanceLabel()+
+			"\n\nUsage: /maintenance on [message] or /maintenance off")
+	}
+}
+
+// rejectIfMaintenance tells a non-admin user that the bot is under
+// maintenance. Returns true if the prompt should not be generated.
+func (h *Handler) rejectIfMaintenance(chatID, userID int64) bool {
+	note, on := h.maintenanceFor(userID)
+	if !on {
+		return false
+	}
+	h.sendText(chatID, maintenanceMessage(note))
+	return true
+}
+
+// maintenanceFor reports whether maintenance mode applies to userID, and
+// the admin's note if so. Admins are never turned away.
+func (h *Handler) maintenanceFor(userID int64) (string, bool) {
+	note := h.maintenance.Load()
+	if note == nil || h.whitelist.IsAdmin(userID) {
+		return "", false
+	}
+	return *note, true
+}
+
+// maintenanceLabel describes the maintenance state for /status
+func (h *Handler) maintenanceLabel() string {
+	note := h.maintenance.Load()
+	switch {
+	case note == nil:
+		return "off"
+	case *note == "":
+		return "on"
+	default:
+		return "on (" + *note + ")"
+	}
+}
+
+// maintenanceMessage is the reply to prompts during maintenance, with the
+// admin's note if one was given
+func maintenanceMessage(note string) string {
+	if note == "" {
+		return maintenanceReply
+	}
+	return maintenanceReply + "\n\n" + note
+}
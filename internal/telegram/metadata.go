@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"comfy-tg-bot/internal/comfyui"
+	"comfy-tg-bot/internal/image"
+	"comfy-tg-bot/internal/settings"
+)
+
+// processOutput processes a generated image. The prompt as sent to
+// ComfyUI, including the user's prefix and suffix, and the seed are
+// embedded in the original PNG so it can be reproduced, unless
+// stripMetadata is set: then every text chunk is removed and nothing is
+// embedded, so the two never apply together.
+func (h *Handler) processOutput(stripMetadata bool, output *comfyui.Output, prompt string) (*image.Result, error) {
+	if stripMetadata {
+		// Fail rather than send a PNG the user asked to be stripped
+		data, err := h.processor.StripMetadata(output.Data)
+		if err != nil {
+			return nil, err
+		}
+		return h.processor.Process(data)
+	}
+
+	result, err := h.processor.Process(output.Data)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.processor.AddMetadata(result, prompt, output.Seed); err != nil {
+		h.logger.Warn("failed to embed image metadata", "error", err)
+	}
+	return result, nil
+}
+
+// stripsMetadata reports whether the user wants metadata removed from their
+// original PNGs. Falls back to the default (off) if settings cannot be
+// loaded.
+func (h *Handler) stripsMetadata(userID int64) bool {
+	s, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Warn("failed to get metadata setting", "error", err, "user_id", userID)
+		return false
+	}
+	return s.StripMetadata
+}
+
+// metadataLabel describes what happens to metadata in original PNGs
+func metadataLabel(s *settings.UserSettings) string {
+	if s.StripMetadata {
+		return "Stripped"
+	}
+	return "Embedded"
+}
@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"log/slog"
+	"sync"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
@@ -10,22 +11,27 @@ import (
 
 // Whitelist manages allowed user IDs
 type Whitelist struct {
+	// mu guards staticAllowed, which is replaced on config reload
+	mu            sync.RWMutex
 	staticAllowed map[int64]struct{}
 	adminStore    admin.Store
-	adminUserID   int64
-	logger        *slog.Logger
+	// adminUserIDs lists the admins in configured order
+	adminUserIDs []int64
+	admins       map[int64]struct{}
+	logger       *slog.Logger
 }
 
 // NewWhitelist creates a new whitelist from a slice of user IDs
-func NewWhitelist(userIDs []int64, adminStore admin.Store, adminUserID int64, logger *slog.Logger) *Whitelist {
-	allowed := make(map[int64]struct{}, len(userIDs))
-	for _, id := range userIDs {
-		allowed[id] = struct{}{}
+func NewWhitelist(userIDs []int64, adminStore admin.Store, adminUserIDs []int64, logger *slog.Logger) *Whitelist {
+	admins := make(map[int64]struct{}, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = struct{}{}
 	}
 	return &Whitelist{
-		staticAllowed: allowed,
+		staticAllowed: userIDSet(userIDs),
 		adminStore:    adminStore,
-		adminUserID:   adminUserID,
+		adminUserIDs:  adminUserIDs,
+		admins:        admins,
 		logger:        logger,
 	}
 }
@@ -33,16 +39,20 @@ func NewWhitelist(userIDs []int64, adminStore admin.Store, adminUserID int64, lo
 // IsAllowed checks if a user is whitelisted (static or dynamically approved)
 func (w *Whitelist) IsAllowed(userID int64) bool {
 	// Check static list first (fastest)
-	if _, ok := w.staticAllowed[userID]; ok {
+	w.mu.RLock()
+	_, ok := w.staticAllowed[userID]
+	w.mu.RUnlock()
+	if ok {
 		return true
 	}
 
 	// Check if user is admin
-	if userID == w.adminUserID && w.adminUserID != 0 {
+	if w.IsAdmin(userID) {
 		return true
 	}
 
-	// Check dynamic approved users from database
+	// Check dynamic approved users from database; temporary access that
+	// has expired but not been swept yet is not approved
 	if w.adminStore != nil {
 		approved, err := w.adminStore.IsApproved(userID)
 		if err != nil {
@@ -55,14 +65,38 @@ func (w *Whitelist) IsAllowed(userID int64) bool {
 	return false
 }
 
-// IsAdmin checks if a user is the admin
+// UpdateStaticList replaces the user IDs allowed by configuration.
+// Dynamically approved users and admins are not affected.
+func (w *Whitelist) UpdateStaticList(userIDs []int64) {
+	allowed := userIDSet(userIDs)
+	w.mu.Lock()
+	w.staticAllowed = allowed
+	w.mu.Unlock()
+}
+
+// userIDSet builds a lookup set from a slice of user IDs
+func userIDSet(userIDs []int64) map[int64]struct{} {
+	set := make(map[int64]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		set[id] = struct{}{}
+	}
+	return set
+}
+
+// IsAdmin checks if a user is one of the admins
 func (w *Whitelist) IsAdmin(userID int64) bool {
-	return w.adminUserID != 0 && userID == w.adminUserID
+	_, ok := w.admins[userID]
+	return ok
+}
+
+// HasAdmins reports whether any admin is configured
+func (w *Whitelist) HasAdmins() bool {
+	return len(w.adminUserIDs) > 0
 }
 
-// AdminUserID returns the admin user ID
-func (w *Whitelist) AdminUserID() int64 {
-	return w.adminUserID
+// AdminUserIDs returns the admin user IDs in configured order
+func (w *Whitelist) AdminUserIDs() []int64 {
+	return w.adminUserIDs
 }
 
 // IsGroupAllowed checks if a group has been approved for bot usage
@@ -98,6 +132,11 @@ func (w *Whitelist) CheckAccess(update tgbotapi.Update) (userID int64, chatID in
 			isGroup = update.CallbackQuery.Message.Chat.IsGroup() ||
 				update.CallbackQuery.Message.Chat.IsSuperGroup()
 		}
+	} else if update.InlineQuery != nil && update.InlineQuery.From != nil {
+		// Inline queries have no chat; results go to the user's private chat
+		userID = update.InlineQuery.From.ID
+		username = update.InlineQuery.From.UserName
+		chatID = userID
 	} else {
 		return 0, 0, false, false
 	}
@@ -0,0 +1,45 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxModelsListed keeps the /models reply within Telegram's message limit
+const maxModelsListed = 50
+
+// handleModels handles the /models command: the checkpoints installed on
+// ComfyUI, if the user's workflow loads one
+func (h *Handler) handleModels(ctx context.Context, msg *tgbotapi.Message) {
+	workflow := h.generateOptions(msg.From.ID).Workflow
+	if !h.comfy.UsesCheckpointLoader(workflow) {
+		h.sendText(msg.Chat.ID, fmt.Sprintf(
+			"Your workflow (%s) doesn't load a checkpoint, so there are no models to list.", workflowLabel(workflow)))
+		return
+	}
+
+	names, err := h.comfy.ListCheckpoints(ctx)
+	if err != nil {
+		h.logger.Error("failed to list checkpoints", "error", err)
+		h.sendError(msg.Chat.ID, "Failed to load the model list from ComfyUI. Please try again later.")
+		return
+	}
+	if len(names) == 0 {
+		h.sendText(msg.Chat.ID, "ComfyUI has no checkpoints installed.")
+		return
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Checkpoints installed on ComfyUI (%d):\n", len(names))
+	for i, name := range names {
+		if i == maxModelsListed {
+			fmt.Fprintf(&text, "\n...and %d more", len(names)-maxModelsListed)
+			break
+		}
+		fmt.Fprintf(&text, "\n- %s", name)
+	}
+	h.sendText(msg.Chat.ID, text.String())
+}
@@ -0,0 +1,151 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/comfyui"
+	"comfy-tg-bot/internal/stats"
+)
+
+// generationModel returns the model recorded for a generation with opts:
+// the checkpoint its workflow loads, or the workflow name if the workflow
+// has no fixed checkpoint
+func (h *Handler) generationModel(opts comfyui.GenerateOptions) string {
+	if ckpt := h.comfy.Checkpoint(opts.Workflow); ckpt != "" {
+		return ckpt
+	}
+	if opts.Workflow != "" {
+		return opts.Workflow
+	}
+	return stats.DefaultModel
+}
+
+// recordGeneration stores timing and outcome of a generation
+func (h *Handler) recordGeneration(userID int64, model string, started time.Time, success bool) {
+	if h.stats == nil {
+		return
+	}
+
+	gen := stats.Generation{
+		UserID:     userID,
+		Model:      model,
+		DurationMs: time.Since(started).Milliseconds(),
+		Success:    success,
+		CreatedAt:  time.Now(),
+	}
+	if err := h.stats.Record(gen); err != nil {
+		h.logger.Error("failed to record generation stats", "error", err, "user_id", userID)
+	}
+}
+
+// handleModelStats handles the /modelstats command for admins
+func (h *Handler) handleModelStats(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.stats == nil {
+		h.sendError(msg.Chat.ID, "Statistics are not available.")
+		return
+	}
+
+	var entries []stats.ModelStatsEntry
+	if model := strings.TrimSpace(msg.CommandArguments()); model != "" {
+		entry, err := h.stats.ModelStats(model)
+		if err != nil {
+			h.logger.Error("failed to get model stats", "error", err, "model", model)
+			h.sendError(msg.Chat.ID, "Failed to load model statistics.")
+			return
+		}
+		if entry != nil {
+			entries = append(entries, *entry)
+		}
+	} else {
+		all, err := h.stats.AllModelStats()
+		if err != nil {
+			h.logger.Error("failed to get model stats", "error", err)
+			h.sendError(msg.Chat.ID, "Failed to load model statistics.")
+			return
+		}
+		entries = all
+	}
+
+	if len(entries) == 0 {
+		h.sendText(msg.Chat.ID, "No generations recorded yet.")
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("Model statistics:\n\n")
+	b.WriteString("Model | Count | Avg time | Success\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%s | %d | %.1fs | %.0f%%\n",
+			e.Model, e.Count, e.AvgMs/1000, e.SuccessRate*100)
+	}
+
+	h.sendText(msg.Chat.ID, b.String())
+}
+
+// defaultStatsDays and maxStatsDays bound the /stats period
+const (
+	defaultStatsDays = 7
+	maxStatsDays     = 365
+)
+
+// handleGlobalStats handles the /stats [days] command for admins: usage
+// over the period plus the current load
+func (h *Handler) handleGlobalStats(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.stats == nil {
+		h.sendError(msg.Chat.ID, "Statistics are not available.")
+		return
+	}
+
+	days := defaultStatsDays
+	if arg := strings.TrimSpace(msg.CommandArguments()); arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > maxStatsDays {
+			h.sendError(msg.Chat.ID, fmt.Sprintf("Usage: /stats [days] (1-%d)", maxStatsDays))
+			return
+		}
+		days = n
+	}
+
+	gs, err := h.stats.GetGlobalStats(days)
+	if err != nil {
+		h.logger.Error("failed to get global stats", "error", err)
+		h.sendError(msg.Chat.ID, "Failed to load statistics.")
+		return
+	}
+
+	queue := "unavailable"
+	if status, err := h.comfy.GetQueueStatus(ctx); err != nil {
+		h.logger.Warn("failed to get comfyui queue status", "error", err)
+	} else {
+		queue = fmt.Sprintf("%d pending, %d running", status.Pending, status.Running)
+	}
+
+	h.sendText(msg.Chat.ID, fmt.Sprintf(
+		"Bot statistics (last %d days):\n\n"+
+			"Active users: %d\n"+
+			"Generations: %d\n"+
+			"Errors: %d\n"+
+			"Success rate: %.0f%%\n"+
+			"Average per day: %.1f\n\n"+
+			"Active generations: %d\n"+
+			"ComfyUI queue: %s",
+		gs.Days, gs.Users, gs.Generations, gs.Errors, gs.SuccessRate*100, gs.AvgPerDay,
+		h.limiter.ActiveCount(), queue,
+	))
+}
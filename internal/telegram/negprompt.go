@@ -0,0 +1,63 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxNegativePromptLength bounds stored negative prompts
+const maxNegativePromptLength = 1000
+
+// clearNegativeArg removes the stored negative prompt
+const clearNegativeArg = "clear"
+
+// handleSetNegative handles the /setneg command, which stores a default
+// negative prompt. Without arguments it shows the current one.
+func (h *Handler) handleSetNegative(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+	text := strings.TrimSpace(msg.CommandArguments())
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load settings. Please try again.")
+		return
+	}
+
+	if text == "" {
+		current := userSettings.NegativePrompt
+		if current == "" {
+			current = "(none)"
+		}
+		h.sendText(msg.Chat.ID, fmt.Sprintf(
+			"Negative prompt: %s\n\nUsage: /setneg <text>, or /setneg %s to remove it.\n"+
+				"Workflows without a negative prompt input ignore it.",
+			current, clearNegativeArg))
+		return
+	}
+
+	if strings.EqualFold(text, clearNegativeArg) {
+		text = ""
+	}
+	if len(text) > maxNegativePromptLength {
+		h.sendError(msg.Chat.ID, fmt.Sprintf(
+			"Negative prompt is too long (max %d characters).", maxNegativePromptLength))
+		return
+	}
+
+	userSettings.NegativePrompt = text
+	if err := h.settings.Save(userSettings); err != nil {
+		h.logger.Error("failed to save user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to save settings. Please try again.")
+		return
+	}
+
+	if text == "" {
+		h.sendSuccess(msg.Chat.ID, "Negative prompt removed.")
+		return
+	}
+	h.sendSuccess(msg.Chat.ID, "Negative prompt saved.")
+}
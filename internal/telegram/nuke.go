@@ -0,0 +1,142 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleNuke handles the /nuke command: a user asks for all of their data
+// to be erased. Nothing is deleted until the user confirms.
+func (h *Handler) handleNuke(ctx context.Context, msg *tgbotapi.Message) {
+	if h.eraser == nil {
+		h.sendError(msg.Chat.ID, "Data erasure is not available.")
+		return
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Delete everything", "nuke:confirm"),
+			tgbotapi.NewInlineKeyboardButtonData("Cancel", "nuke:cancel"),
+		),
+	)
+
+	reply := h.newMessage(msg.Chat.ID,
+		"This will permanently delete your settings, history, statistics, shared prompts and bug reports, "+
+			"and revoke your access to the bot. Are you sure?")
+	reply.ReplyMarkup = keyboard
+	if _, err := h.bot.Send(reply); err != nil {
+		h.logger.Error("failed to send nuke confirmation", "error", err)
+	}
+}
+
+// handleNukeCallback handles the confirm/cancel buttons for /nuke
+func (h *Handler) handleNukeCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	if query.Message == nil {
+		h.answerCallback(query.ID, "This request has expired")
+		return
+	}
+
+	if query.Data != "nuke:confirm" {
+		h.updateAdminMessage(query.Message.Chat.ID, query.Message.MessageID, "Data deletion cancelled.")
+		h.answerCallback(query.ID, "Cancelled")
+		return
+	}
+
+	userID := query.From.ID
+	count, err := h.eraseUser(userID)
+	if err != nil {
+		h.answerCallback(query.ID, "Failed to delete data")
+		return
+	}
+
+	h.updateAdminMessage(query.Message.Chat.ID, query.Message.MessageID,
+		fmt.Sprintf("All data for user %d has been deleted (%d records).", userID, count))
+	h.answerCallback(query.ID, "Data deleted")
+
+	for _, adminID := range h.whitelist.AdminUserIDs() {
+		if adminID == userID {
+			continue
+		}
+		h.sendText(adminID, fmt.Sprintf(
+			"User %d (%s) deleted their data with /nuke (%d records).",
+			userID, formatUsername(query.From.UserName), count))
+	}
+}
+
+// handleNukeUser handles the /nukeuser command for admins
+func (h *Handler) handleNukeUser(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.eraser == nil {
+		h.sendError(msg.Chat.ID, "Data erasure is not available.")
+		return
+	}
+
+	args := strings.TrimSpace(msg.CommandArguments())
+	if args == "" {
+		h.sendError(msg.Chat.ID, "Usage: /nukeuser <user_id>")
+		return
+	}
+
+	userID, err := strconv.ParseInt(args, 10, 64)
+	if err != nil {
+		h.sendError(msg.Chat.ID, "Invalid user ID. Usage: /nukeuser <user_id>")
+		return
+	}
+
+	count, err := h.eraseUser(userID)
+	if err != nil {
+		h.sendError(msg.Chat.ID, "Failed to delete user data.")
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf("All data for user %d has been deleted (%d records).", userID, count))
+}
+
+// eraseUser deletes all stored and cached data for a user
+func (h *Handler) eraseUser(userID int64) (int64, error) {
+	count, err := h.eraser.EraseUser(userID)
+	if err != nil {
+		h.logger.Error("failed to erase user data", "error", err, "user_id", userID)
+		return 0, err
+	}
+
+	if h.adminStore != nil {
+		h.adminStore.ForgetApproval(userID)
+	}
+
+	h.suggestionsMu.Lock()
+	delete(h.suggestions, userID)
+	h.suggestionsMu.Unlock()
+
+	h.deepLinkMu.Lock()
+	delete(h.deepLinkPrompts, userID)
+	h.deepLinkMu.Unlock()
+
+	h.longPromptMu.Lock()
+	delete(h.longPrompts, userID)
+	h.longPromptMu.Unlock()
+
+	h.inlineMu.Lock()
+	for key := range h.inlineResults {
+		if key.userID == userID {
+			delete(h.inlineResults, key)
+		}
+	}
+	delete(h.inlineLatest, userID)
+	h.inlineMu.Unlock()
+
+	h.lastErrorsMu.Lock()
+	delete(h.lastErrors, userID)
+	h.lastErrorsMu.Unlock()
+
+	h.logger.Info("erased user data", "user_id", userID, "records", count)
+	return count, nil
+}
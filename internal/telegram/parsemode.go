@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// markdownV2Replacer escapes every character MarkdownV2 reserves
+var markdownV2Replacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]", "(", "\\(", ")", "\\)",
+	"~", "\\~", "`", "\\`", ">", "\\>", "#", "\\#", "+", "\\+", "-", "\\-",
+	"=", "\\=", "|", "\\|", "{", "\\{", "}", "\\}", ".", "\\.", "!", "\\!",
+)
+
+// codeV2Replacer escapes the characters MarkdownV2 reserves inside code
+var codeV2Replacer = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+
+// EscapeMarkdownV2 escapes text so Telegram renders it literally in MarkdownV2
+func EscapeMarkdownV2(text string) string {
+	return markdownV2Replacer.Replace(text)
+}
+
+// escapeForMode escapes plain text for the given Telegram parse mode
+func escapeForMode(text, parseMode string) string {
+	switch parseMode {
+	case tgbotapi.ModeMarkdownV2:
+		return EscapeMarkdownV2(text)
+	case tgbotapi.ModeHTML:
+		return html.EscapeString(text)
+	}
+	return text
+}
+
+// markup is message text already written in a parse mode's syntax; a
+// formatter inserts it without escaping
+type markup string
+
+// formatter writes message text for one Telegram parse mode. The bot's
+// formatting comes from Bold and Code; everything else, in particular
+// values from users and ComfyUI, is escaped so it is shown literally.
+type formatter struct {
+	mode string
+}
+
+// Bold renders text in bold
+func (f formatter) Bold(text string) markup {
+	switch f.mode {
+	case tgbotapi.ModeMarkdownV2:
+		return markup("*" + EscapeMarkdownV2(text) + "*")
+	case tgbotapi.ModeHTML:
+		return markup("<b>" + html.EscapeString(text) + "</b>")
+	}
+	return markup(text)
+}
+
+// Code renders text in a monospace font, e.g. a command
+func (f formatter) Code(text string) markup {
+	switch f.mode {
+	case tgbotapi.ModeMarkdownV2:
+		return markup("`" + codeV2Replacer.Replace(text) + "`")
+	case tgbotapi.ModeHTML:
+		return markup("<code>" + html.EscapeString(text) + "</code>")
+	}
+	return markup(text)
+}
+
+// formatVerb matches a fmt verb with its flags, width and precision
+var formatVerb = regexp.MustCompile(`%[-+# 0]*(?:\d+)?(?:\.\d*)?[a-zA-Z%]`)
+
+// Sprintf formats like fmt.Sprintf. The literal text of format and every
+// argument are escaped, except arguments that are already markup.
+func (f formatter) Sprintf(format string, args ...any) markup {
+	var b strings.Builder
+	last, next := 0, 0
+	for _, loc := range formatVerb.FindAllStringIndex(format, -1) {
+		b.WriteString(escapeForMode(format[last:loc[0]], f.mode))
+		last = loc[1]
+
+		verb := format[loc[0]:loc[1]]
+		if verb == "%%" {
+			b.WriteString(escapeForMode("%", f.mode))
+			continue
+		}
+		if next >= len(args) {
+			// A verb without an argument is kept as literal text
+			b.WriteString(escapeForMode(verb, f.mode))
+			continue
+		}
+		if m, ok := args[next].(markup); ok {
+			b.WriteString(string(m))
+		} else {
+			b.WriteString(escapeForMode(fmt.Sprintf(verb, args[next]), f.mode))
+		}
+		next++
+	}
+	b.WriteString(escapeForMode(format[last:], f.mode))
+	return markup(b.String())
+}
@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestFormatterSprintf(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		got  func(f formatter) markup
+		want string
+	}{
+		{
+			name: "plain keeps text",
+			mode: "",
+			got:  func(f formatter) markup { return f.Sprintf("%s said <hi> %.1fs", f.Bold("Bob"), 2.25) },
+			want: "Bob said <hi> 2.2s",
+		},
+		{
+			name: "html escapes values but not markup",
+			mode: tgbotapi.ModeHTML,
+			got: func(f formatter) markup {
+				return f.Sprintf("%s: %s", f.Bold("Prompt"), "<script> & co")
+			},
+			want: "<b>Prompt</b>: &lt;script&gt; &amp; co",
+		},
+		{
+			name: "markdownv2 escapes literal text and values",
+			mode: tgbotapi.ModeMarkdownV2,
+			got: func(f formatter) markup {
+				return f.Sprintf("Run %s (now). Took %.1fs, %d%%!", f.Code("/help"), 1.5, 50)
+			},
+			want: "Run `/help` \\(now\\)\\. Took 1\\.5s, 50%\\!",
+		},
+		{
+			name: "markdownv2 value cannot inject formatting",
+			mode: tgbotapi.ModeMarkdownV2,
+			got:  func(f formatter) markup { return f.Sprintf("%s %s", f.Bold("User:"), "*not bold*_[x](y)") },
+			want: "*User:* \\*not bold\\*\\_\\[x\\]\\(y\\)",
+		},
+		{
+			name: "markdownv2 code escapes backticks",
+			mode: tgbotapi.ModeMarkdownV2,
+			got:  func(f formatter) markup { return f.Code("a`b\\c") },
+			want: "`a\\`b\\\\c`",
+		},
+		{
+			name: "missing argument stays literal",
+			mode: tgbotapi.ModeHTML,
+			got:  func(f formatter) markup { return f.Sprintf("%s and %d", "<a>") },
+			want: "&lt;a&gt; and %d",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.got(formatter{mode: tt.mode}); string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// pendingPageSize is the number of requests shown per /pending and
+// /pendinggroups page
+const pendingPageSize = 5
+
+// Callback data prefixes for the /pending and /pendinggroups page buttons
+const (
+	pendingPagePrefix       = "admin:pending:page:"
+	pendingGroupsPagePrefix = "admin:pendinggroups:page:"
+)
+
+// handlePending handles the /pending command for admins. Each request is
+// sent as its own message with the usual Approve/Reject buttons.
+func (h *Handler) handlePending(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.adminStore == nil {
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
+		return
+	}
+
+	if err := h.sendPendingPage(msg.Chat.ID, 1); err != nil {
+		h.logger.Error("failed to list pending requests", "error", err)
+		h.sendError(msg.Chat.ID, "Failed to load pending requests.")
+	}
+}
+
+// handlePendingGroups handles the /pendinggroups command for admins
+func (h *Handler) handlePendingGroups(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.adminStore == nil {
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
+		return
+	}
+
+	if err := h.sendPendingGroupsPage(msg.Chat.ID, 1); err != nil {
+		h.logger.Error("failed to list pending group requests", "error", err)
+		h.sendError(msg.Chat.ID, "Failed to load pending group requests.")
+	}
+}
+
+// handlePendingPageCallback handles the Previous/Next buttons for /pending
+// and /pendinggroups: the pressed buttons are removed and the requested
+// page is sent below. The caller must have verified the sender is the admin.
+func (h *Handler) handlePendingPageCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	prefix, send := pendingPagePrefix, h.sendPendingPage
+	if strings.HasPrefix(query.Data, pendingGroupsPagePrefix) {
+		prefix, send = pendingGroupsPagePrefix, h.sendPendingGroupsPage
+	}
+
+	page, err := strconv.Atoi(strings.TrimPrefix(query.Data, prefix))
+	if err != nil || page < 1 {
+		h.answerCallback(query.ID, "Invalid page")
+		return
+	}
+
+	h.updateAdminMessage(query.Message.Chat.ID, query.Message.MessageID, query.Message.Text)
+	if err := send(query.Message.Chat.ID, page); err != nil {
+		h.logger.Error("failed to list pending requests", "error", err)
+		h.answerCallback(query.ID, "Failed to load pending requests")
+		return
+	}
+	h.answerCallback(query.ID, "")
+}
+
+// sendPendingPage sends a 1-based page of pending user requests. Pages past
+// the end are clamped to the last page.
+func (h *Handler) sendPendingPage(chatID int64, page int) error {
+	requests, total, err := h.adminStore.ListPending((page-1)*pendingPageSize, pendingPageSize)
+	if err != nil {
+		return err
+	}
+
+	pages := (total + pendingPageSize - 1) / pendingPageSize
+	if pages == 0 {
+		h.sendText(chatID, "No pending access requests.")
+		return nil
+	}
+	if page > pages {
+		// Requests were handled since the page was rendered
+		page = pages
+		requests, total, err = h.adminStore.ListPending((page-1)*pendingPageSize, pendingPageSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	h.sendText(chatID, fmt.Sprintf("Pending access requests: %d (page %d/%d)", total, page, pages))
+	for _, req := range requests {
+		name := req.FirstName
+		if name == "" {
+			name = "(none)"
+		}
+		text := fmt.Sprintf(
+			"User ID: %d\n"+
+				"Username: %s\n"+
+				"Name: %s\n"+
+				"Requested: %s",
+			req.UserID, formatUsername(req.Username), name,
+			req.RequestedAt.Format("2006-01-02 15:04"),
+		)
+		h.sendWithKeyboard(chatID, text, approvalKeyboard(req.UserID))
+	}
+
+	h.sendPageButtons(chatID, pendingPagePrefix, page, pages)
+	return nil
+}
+
+// sendPendingGroupsPage sends a 1-based page of pending group requests.
+// Pages past the end are clamped to the last page.
+func (h *Handler) sendPendingGroupsPage(chatID int64, page int) error {
+	requests, total, err := h.adminStore.ListPendingGroups((page-1)*pendingPageSize, pendingPageSize)
+	if err != nil {
+		return err
+	}
+
+	pages := (total + pendingPageSize - 1) / pendingPageSize
+	if pages == 0 {
+		h.sendText(chatID, "No pending group requests.")
+		return nil
+	}
+	if page > pages {
+		page = pages
+		requests, total, err = h.adminStore.ListPendingGroups((page-1)*pendingPageSize, pendingPageSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	h.sendText(chatID, fmt.Sprintf("Pending group requests: %d (page %d/%d)", total, page, pages))
+	for _, req := range requests {
+		title := req.Title
+		if title == "" {
+			title = "(unnamed group)"
+		}
+		text := fmt.Sprintf(
+			"Group ID: %d\n"+
+				"Title: %s\n"+
+				"Requested: %s",
+			req.GroupID, title, req.RequestedAt.Format("2006-01-02 15:04"),
+		)
+		h.sendWithKeyboard(chatID, text, groupApprovalKeyboard(req.GroupID))
+	}
+
+	h.sendPageButtons(chatID, pendingGroupsPagePrefix, page, pages)
+	return nil
+}
+
+// sendPageButtons sends Previous/Next buttons for a multi-page listing
+func (h *Handler) sendPageButtons(chatID int64, prefix string, page, pages int) {
+	if pages == 1 {
+		return
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Previous",
+			fmt.Sprintf("%s%d", prefix, page-1)))
+	}
+	if page < pages {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Next",
+			fmt.Sprintf("%s%d", prefix, page+1)))
+	}
+	h.sendWithKeyboard(chatID, fmt.Sprintf("Page %d/%d", page, pages), tgbotapi.NewInlineKeyboardMarkup(row))
+}
+
+// sendWithKeyboard sends text with an inline keyboard
+func (h *Handler) sendWithKeyboard(chatID int64, text string, keyboard tgbotapi.InlineKeyboardMarkup) {
+	msg := h.newMessage(chatID, text)
+	msg.ReplyMarkup = keyboard
+	if _, err := h.bot.Send(msg); err != nil {
+		h.logger.Error("failed to send message", "error", err)
+	}
+}
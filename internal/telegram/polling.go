@@ -0,0 +1,105 @@
+package telegram
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxPollingRestartDelay caps the exponential backoff between restarts
+const maxPollingRestartDelay = 5 * time.Minute
+
+// pollRetryDelay is how long to wait after a failed getUpdates call
+const pollRetryDelay = 3 * time.Second
+
+// pollUpdates long-polls Telegram for updates until ctx is cancelled, then
+// closes the returned channel. Unlike tgbotapi's GetUpdatesChan, which can
+// only be stopped once per BotAPI, a new poller can be started at any time.
+func (b *Bot) pollUpdates(ctx context.Context, config tgbotapi.UpdateConfig) <-chan tgbotapi.Update {
+	ch := make(chan tgbotapi.Update, b.api.Buffer)
+
+	go func() {
+		defer close(ch)
+
+		for ctx.Err() == nil {
+			updates, err := b.api.GetUpdates(config)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				b.logger.Warn("failed to get updates, retrying", "error", err, "retry_in", pollRetryDelay)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(pollRetryDelay):
+				}
+				continue
+			}
+
+			for _, update := range updates {
+				if update.UpdateID < config.Offset {
+					continue
+				}
+				config.Offset = update.UpdateID + 1
+				select {
+				case ch <- update:
+				case <-ctx.Done():
+					// Unsent updates stay unconfirmed and are fetched again
+					// by the next poller
+					return
+				}
+			}
+		}
+	}()
+
+	return ch
+}
+
+// updateOffsetKey stores the ID of the last update received by polling
+const updateOffsetKey = "telegram_update_offset"
+
+// savedUpdateOffset returns the offset to resume polling from: one past the
+// last update received before the bot stopped, or 0 if none was saved
+func (b *Bot) savedUpdateOffset() int {
+	if b.stateStore == nil {
+		return 0
+	}
+	value, err := b.stateStore.Get(updateOffsetKey)
+	if err != nil {
+		b.logger.Warn("failed to load update offset", "error", err)
+		return 0
+	}
+	if value == "" {
+		return 0
+	}
+	lastID, err := strconv.Atoi(value)
+	if err != nil {
+		b.logger.Warn("ignoring invalid saved update offset", "value", value)
+		return 0
+	}
+	b.logger.Info("resuming updates after saved offset", "update_id", lastID)
+	return lastID + 1
+}
+
+// saveUpdateOffset records updateID as received. It is saved when the
+// update is dispatched rather than once it is handled, since updates are
+// handled concurrently and a generation can take minutes.
+func (b *Bot) saveUpdateOffset(updateID int) {
+	if b.stateStore == nil {
+		return
+	}
+	if err := b.stateStore.Set(updateOffsetKey, strconv.Itoa(updateID)); err != nil {
+		b.logger.Warn("failed to save update offset", "error", err, "update_id", updateID)
+	}
+}
+
+// pollingRestartDelay returns the backoff before the nth consecutive restart
+func (b *Bot) pollingRestartDelay(failures int) time.Duration {
+	delay := b.cfg.PollingRestartDelay
+	for i := 1; i < failures && delay < maxPollingRestartDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxPollingRestartDelay)
+}
@@ -0,0 +1,116 @@
+package telegram
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/comfyui"
+)
+
+// minProgressInterval keeps progress edits under Telegram's rate limits
+// even if a shorter interval is configured
+const minProgressInterval = 1500 * time.Millisecond
+
+// progressBarWidth is the number of cells in the {bar} placeholder
+const progressBarWidth = 10
+
+// ProgressReporter edits a status message with generation progress,
+// throttled to avoid Telegram rate limits
+type ProgressReporter struct {
+	bot      *tgbotapi.BotAPI
+	chatID   int64
+	msgID    int
+	interval time.Duration
+	format   string
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	lastSent time.Time
+	lastText string
+	// spinner is the next frame shown when the step total is unknown
+	spinner int
+}
+
+// spinnerFrames are shown in turn while progress is indeterminate
+var spinnerFrames = []string{"◐", "◓", "◑", "◒"}
+
+// NewProgressReporter creates a reporter that edits msgID in chatID at
+// most once per interval (never more often than every 1.5s). format
+// supports the {bar}, {percent}, {current} and {total} placeholders.
+func NewProgressReporter(bot *tgbotapi.BotAPI, chatID int64, msgID int, interval time.Duration, format string, logger *slog.Logger) *ProgressReporter {
+	return &ProgressReporter{
+		bot:      bot,
+		chatID:   chatID,
+		msgID:    msgID,
+		interval: max(interval, minProgressInterval),
+		format:   format,
+		logger:   logger,
+		lastSent: time.Now(),
+	}
+}
+
+// progressCallback returns a callback that updates the given status
+// message, or nil if there is no message to edit
+func (h *Handler) progressCallback(chatID int64, msgID int) comfyui.ProgressCallback {
+	if msgID == 0 {
+		return nil
+	}
+
+	reporter := NewProgressReporter(h.bot, chatID, msgID,
+		time.Duration(h.cfg.StatusUpdateIntervalMs)*time.Millisecond,
+		h.cfg.StatusProgressFormat, h.logger)
+	return reporter.Update
+}
+
+// Update flushes the latest progress once the interval has elapsed or the
+// final step is reached; intermediate updates are dropped
+func (p *ProgressReporter) Update(current, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Since(p.lastSent) < p.interval && (total <= 0 || current < total) {
+		return
+	}
+
+	var text string
+	if total <= 0 {
+		text = "Generating… " + spinnerFrames[p.spinner%len(spinnerFrames)]
+		p.spinner++
+	} else {
+		text = formatProgress(p.format, current, total)
+	}
+	if text == p.lastText {
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(p.chatID, p.msgID, text)
+	if _, err := p.bot.Send(edit); err != nil {
+		p.logger.Debug("failed to update progress message", "error", err)
+	}
+
+	p.lastSent = time.Now()
+	p.lastText = text
+}
+
+// formatProgress expands {bar}, {percent}, {current} and {total} in format
+func formatProgress(format string, current, total int) string {
+	current = min(max(current, 0), total)
+	percent := current * 100 / total
+	return strings.NewReplacer(
+		"{bar}", progressBar(current, total),
+		"{percent}", strconv.Itoa(percent),
+		"{current}", strconv.Itoa(current),
+		"{total}", strconv.Itoa(total),
+	).Replace(format)
+}
+
+// progressBar renders current/total as block characters, e.g. █████░░░░░
+func progressBar(current, total int) string {
+	filled := current * progressBarWidth / total
+	return strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+}
@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/prompt"
+)
+
+// handleQuickAction dispatches quick-action keyboard presses.
+// Returns true if the message was a quick action.
+func (h *Handler) handleQuickAction(ctx context.Context, msg *tgbotapi.Message) bool {
+	switch msg.Text {
+	case quickRandom:
+		h.handleRandom(ctx, msg)
+	case quickRequeue:
+		h.handleRequeue(ctx, msg)
+	case quickSettings:
+		h.handleSettings(ctx, msg)
+	case quickStats:
+		h.handleMyStats(ctx, msg)
+	default:
+		return false
+	}
+	return true
+}
+
+// handleQuickKeys handles /showkeys and /hidekeys
+func (h *Handler) handleQuickKeys(ctx context.Context, msg *tgbotapi.Message, show bool) {
+	userID := msg.From.ID
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load settings. Please try again.")
+		return
+	}
+
+	userSettings.ShowQuickKeys = show
+	if err := h.settings.Save(userSettings); err != nil {
+		h.logger.Error("failed to save user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to save settings. Please try again.")
+		return
+	}
+
+	reply := h.newMessage(msg.Chat.ID, "Quick-action keyboard hidden. Use /showkeys to bring it back.")
+	reply.ReplyMarkup = tgbotapi.NewRemoveKeyboard(false)
+	if show {
+		reply.Text = "Quick-action keyboard enabled. Use /hidekeys to remove it."
+		reply.ReplyMarkup = buildReplyKeyboard()
+	}
+
+	if _, err := h.bot.Send(reply); err != nil {
+		h.logger.Error("failed to send quick keys message", "error", err)
+	}
+}
+
+// handleRandom generates an image from a random vocabulary prompt
+func (h *Handler) handleRandom(ctx context.Context, msg *tgbotapi.Message) {
+	if h.vocab == nil {
+		h.sendError(msg.Chat.ID, "Random prompts are not available.")
+		return
+	}
+
+	text := prompt.RandomPrompt(h.vocab)
+	h.sendText(msg.Chat.ID, "Random prompt: "+text)
+	h.generateForUser(ctx, msg.Chat.ID, msg.From.ID, text, genOptions{randomSeed: true})
+}
+
+// handleRequeue regenerates the user's most recent prompt
+func (h *Handler) handleRequeue(ctx context.Context, msg *tgbotapi.Message) {
+	if h.history == nil {
+		h.sendError(msg.Chat.ID, "History is not available.")
+		return
+	}
+
+	entries, err := h.history.Recent(msg.From.ID, 1)
+	if err != nil {
+		h.logger.Error("failed to load history", "error", err, "user_id", msg.From.ID)
+		h.sendError(msg.Chat.ID, "Failed to load your last prompt. Please try again.")
+		return
+	}
+	if len(entries) == 0 {
+		h.sendText(msg.Chat.ID, "You haven't generated anything yet. Send me a prompt!")
+		return
+	}
+
+	h.generateForUser(ctx, msg.Chat.ID, msg.From.ID, entries[0].Prompt, genOptions{randomSeed: true})
+}
+
+// handleMyStats shows the user's own generation statistics
+func (h *Handler) handleMyStats(ctx context.Context, msg *tgbotapi.Message) {
+	if h.stats == nil {
+		h.sendError(msg.Chat.ID, "Statistics are not available.")
+		return
+	}
+
+	entry, err := h.stats.UserStats(msg.From.ID)
+	if err != nil {
+		h.logger.Error("failed to get user stats", "error", err, "user_id", msg.From.ID)
+		h.sendError(msg.Chat.ID, "Failed to load your statistics.")
+		return
+	}
+
+	if entry.Count == 0 {
+		h.sendText(msg.Chat.ID, "You haven't generated any images yet.")
+		return
+	}
+
+	f := formatter{mode: h.cfg.ParseModes.Info}
+	h.sendMarkup(msg.Chat.ID, f.Sprintf(
+		"%s\n\n"+
+			"Generations: %s\n"+
+			"Average time: %s\n"+
+			"Success rate: %s",
+		f.Bold("Your Stats:"), f.Bold(fmt.Sprint(entry.Count)),
+		f.Bold(fmt.Sprintf("%.1fs", entry.AvgMs/1000)),
+		f.Bold(fmt.Sprintf("%.0f%%", entry.SuccessRate*100))), f.mode)
+}
@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	apperrors "comfy-tg-bot/internal/errors"
+)
+
+// maxReferenceBytes is the largest file the Bot API lets bots download
+const maxReferenceBytes = 20 * 1024 * 1024
+
+// referenceFileID returns the file ID of the image in msg: the largest size
+// of a photo, or a document with an image MIME type
+func referenceFileID(msg *tgbotapi.Message) (string, bool) {
+	if len(msg.Photo) > 0 {
+		// Telegram lists photo sizes from smallest to largest
+		return msg.Photo[len(msg.Photo)-1].FileID, true
+	}
+	if msg.Document != nil && strings.HasPrefix(msg.Document.MimeType, "image/") {
+		return msg.Document.FileID, true
+	}
+	return "", false
+}
+
+// handleReferenceImage handles a photo or image document sent in a private
+// chat, generating from it with the caption as the prompt. Returns false if
+// the message carries no image.
+func (h *Handler) handleReferenceImage(ctx context.Context, msg *tgbotapi.Message, userID int64) bool {
+	fileID, ok := referenceFileID(msg)
+	if !ok {
+		return false
+	}
+
+	if strings.TrimSpace(msg.Caption) == "" {
+		h.sendError(msg.Chat.ID, "Add a caption to the image describing what to generate from it.")
+		return true
+	}
+
+	if !h.comfy.SupportsReference(h.workflowName(userID)) {
+		h.sendError(msg.Chat.ID, apperrors.GetUserMessage(apperrors.ErrReferenceUnsupported))
+		return true
+	}
+
+	if msg.Document != nil && msg.Document.FileSize > maxReferenceBytes {
+		h.sendError(msg.Chat.ID, "That image is too large. Please send one under 20 MB.")
+		return true
+	}
+
+	data, err := h.downloadFile(ctx, fileID)
+	if err != nil {
+		h.logger.Error("failed to download reference image", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to download your image. Please try again.")
+		return true
+	}
+
+	h.generateForUser(ctx, msg.Chat.ID, userID, msg.Caption, genOptions{reference: data})
+	return true
+}
+
+// downloadFile fetches a file sent to the bot
+func (h *Handler) downloadFile(ctx context.Context, fileID string) ([]byte, error) {
+	fileURL, err := h.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("get file url: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The file URL embeds the bot token; keep it out of logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxReferenceBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if len(data) > maxReferenceBytes {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxReferenceBytes)
+	}
+	return data, nil
+}
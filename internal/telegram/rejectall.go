@@ -0,0 +1,97 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleRejectAll handles the /rejectall command for admins by asking
+// for confirmation before anything is deleted
+func (h *Handler) handleRejectAll(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.adminStore == nil {
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
+		return
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Reject all", "admin:confirm_rejectall"),
+			tgbotapi.NewInlineKeyboardButtonData("Cancel", "admin:cancel_rejectall"),
+		),
+	)
+
+	reply := h.newMessage(msg.Chat.ID,
+		"This will reject ALL pending user and group access requests. Are you sure?")
+	reply.ReplyMarkup = keyboard
+	if _, err := h.bot.Send(reply); err != nil {
+		h.logger.Error("failed to send rejectall confirmation", "error", err)
+	}
+}
+
+// handleRejectAllCallback handles the confirm/cancel buttons for /rejectall.
+// The caller must have verified the sender is the admin.
+func (h *Handler) handleRejectAllCallback(ctx context.Context, query *tgbotapi.CallbackQuery, confirmed bool) {
+	if !confirmed {
+		h.updateAdminMessage(query.Message.Chat.ID, query.Message.MessageID, "Reject all cancelled.")
+		h.answerCallback(query.ID, "Cancelled")
+		return
+	}
+
+	// Only the requesters whose rows this call deleted are told
+	users, groups, err := h.adminStore.RejectAllPending(query.From.ID)
+	if err != nil {
+		h.logger.Error("failed to reject all pending requests", "error", err)
+		h.answerCallback(query.ID, "Failed to reject requests")
+		return
+	}
+
+	var chatIDs []int64
+	for _, req := range users {
+		if req.ChatID != 0 {
+			chatIDs = append(chatIDs, req.ChatID)
+		}
+	}
+	count := len(users) + len(groups)
+
+	h.logger.Info("rejected all pending requests", "count", count, "admin_id", query.From.ID)
+
+	// Answer before notifying, which can take a while for many requests
+	text := fmt.Sprintf("Rejected %d pending requests.", count)
+	if len(chatIDs) > 0 {
+		text += fmt.Sprintf(" Notifying %d users in the background.", len(chatIDs))
+	}
+	h.updateAdminMessage(query.Message.Chat.ID, query.Message.MessageID, text)
+	h.answerCallback(query.ID, "Done")
+
+	go h.notifyRejected(h.runCtx, chatIDs)
+}
+
+// notifyRejected tells each chat its access request was not approved,
+// spaced like a broadcast to stay within Telegram's rate limit
+func (h *Handler) notifyRejected(ctx context.Context, chatIDs []int64) {
+	ticker := time.NewTicker(broadcastInterval)
+	defer ticker.Stop()
+
+	for i, chatID := range chatIDs {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				h.logger.Warn("rejection notices interrupted", "sent", i, "remaining", len(chatIDs)-i, "error", ctx.Err())
+				return
+			case <-ticker.C:
+			}
+		}
+		h.sendText(chatID, "Your access request was not approved.")
+	}
+	if len(chatIDs) > 0 {
+		h.logger.Info("rejection notices sent", "count", len(chatIDs))
+	}
+}
@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/admin"
+)
+
+func TestRejectAllNotifiesAfterAnswering(t *testing.T) {
+	h, api, store := newTestHandler(t, 10)
+	users := []int64{101, 102, 103}
+	for _, id := range users {
+		if err := store.AddPending(admin.PendingRequest{UserID: id, ChatID: id, RequestedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h.handleRejectAllCallback(context.Background(), &tgbotapi.CallbackQuery{
+		ID:      "q",
+		From:    &tgbotapi.User{ID: 10},
+		Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: 10}},
+		Data:    "admin:confirm_rejectall",
+	}, true)
+
+	if answers := api.callbackAnswers(); len(answers) != 1 || answers[0] != "Done" {
+		t.Fatalf("callback answers = %q, want Done", answers)
+	}
+
+	deadline := time.Now().Add(time.Second * 2)
+	for {
+		missing := 0
+		for _, id := range users {
+			if len(api.sentTo(id)) != 1 {
+				missing++
+			}
+		}
+		if missing == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d users were never notified", missing)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	methods := api.methods()
+	answered := slices.Index(methods, "answerCallbackQuery")
+	firstNotice := slices.Index(methods, "sendMessage")
+	if answered > firstNotice {
+		t.Errorf("callback answered after notifying users: %v", methods)
+	}
+}
@@ -0,0 +1,132 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// staleCheckInterval is how often pending requests are checked for staleness
+const staleCheckInterval = time.Hour
+
+// pendingExpiryInterval is how often pending requests are checked for expiry
+const pendingExpiryInterval = time.Hour
+
+// runStaleReminders periodically re-notifies the admin about pending
+// requests that have not been acted on. Blocks until ctx is cancelled.
+func (h *Handler) runStaleReminders(ctx context.Context, staleAfter time.Duration) {
+	if h.adminStore == nil || !h.whitelist.HasAdmins() || staleAfter <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(staleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.remindStaleRequests(staleAfter)
+		}
+	}
+}
+
+// remindStaleRequests sends a fresh approval message for each stale request
+// and marks the previous admin message as superseded
+func (h *Handler) remindStaleRequests(staleAfter time.Duration) {
+	stale, err := h.adminStore.GetStale(time.Now().Add(-staleAfter))
+	if err != nil {
+		h.logger.Error("failed to get stale pending requests", "error", err)
+		return
+	}
+
+	// Only the first admin's message is tracked, so only it is edited
+	primaryAdminID := h.whitelist.AdminUserIDs()[0]
+
+	for _, req := range stale {
+		text := fmt.Sprintf(
+			"Reminder: %s's request is still pending.\n\n"+
+				"User ID: %d\n"+
+				"Requested: %s",
+			formatUsername(req.Username), req.UserID,
+			req.RequestedAt.Format("2006-01-02 15:04"),
+		)
+
+		msgID := h.sendToAdmins(text, approvalKeyboard(req.UserID))
+		if msgID == 0 {
+			h.logger.Error("failed to send stale request reminder", "user_id", req.UserID)
+			continue
+		}
+
+		if req.AdminMsgID != 0 {
+			h.updateAdminMessage(primaryAdminID, req.AdminMsgID,
+				fmt.Sprintf("Access request from user %d (%s) - see reminder below", req.UserID, formatUsername(req.Username)))
+		}
+
+		if err := h.adminStore.UpdatePendingNotified(req.UserID, msgID); err != nil {
+			h.logger.Error("failed to update pending notified", "error", err, "user_id", req.UserID)
+		}
+
+		h.logger.Info("re-notified admins about stale request", "user_id", req.UserID)
+	}
+}
+
+// runPendingExpiry periodically deletes pending user and group requests
+// older than expireAfter. Blocks until ctx is cancelled.
+func (h *Handler) runPendingExpiry(ctx context.Context, expireAfter time.Duration) {
+	if h.adminStore == nil || expireAfter <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(pendingExpiryInterval)
+	defer ticker.Stop()
+
+	h.expirePendingRequests(expireAfter)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.expirePendingRequests(expireAfter)
+		}
+	}
+}
+
+// expirePendingRequests deletes expired requests, tells the requesters they
+// may ask again and marks the admin's approval message as expired
+func (h *Handler) expirePendingRequests(expireAfter time.Duration) {
+	users, groups, err := h.adminStore.ExpirePending(expireAfter)
+	if err != nil {
+		h.logger.Error("failed to expire pending requests", "error", err)
+		return
+	}
+
+	// Only the first admin's message is tracked, so only it is edited
+	var primaryAdminID int64
+	if admins := h.whitelist.AdminUserIDs(); len(admins) > 0 {
+		primaryAdminID = admins[0]
+	}
+
+	for _, req := range users {
+		h.sendText(req.ChatID, "Your access request has expired. You may request again.")
+		if primaryAdminID != 0 && req.AdminMsgID != 0 {
+			h.updateAdminMessage(primaryAdminID, req.AdminMsgID,
+				fmt.Sprintf("User %d (%s): Request expired.", req.UserID, formatUsername(req.Username)))
+		}
+		h.logger.Info("pending request expired", "user_id", req.UserID, "requested_at", req.RequestedAt)
+	}
+
+	for _, req := range groups {
+		h.sendText(req.GroupID, "This group's access request has expired. You may request again by mentioning me.")
+		if primaryAdminID != 0 && req.AdminMsgID != 0 {
+			title := req.Title
+			if title == "" {
+				title = "(unnamed)"
+			}
+			h.updateAdminMessage(primaryAdminID, req.AdminMsgID,
+				fmt.Sprintf("Group %d (%s): Request expired.", req.GroupID, title))
+		}
+		h.logger.Info("pending group request expired", "group_id", req.GroupID, "requested_at", req.RequestedAt)
+	}
+}
@@ -0,0 +1,107 @@
+package telegram
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/history"
+)
+
+const (
+	// captionPromptPrefix starts the caption of every generated image
+	captionPromptPrefix = "Prompt: "
+	// captionPromptLimit is the length captions truncate prompts to
+	captionPromptLimit = 200
+	// captionSeedPrefix starts the line after the prompt holding the seed
+	captionSeedPrefix = "\nSeed: "
+)
+
+// promptFromCaption extracts the prompt from a generated image's caption.
+// If the caption was truncated, the returned prompt is the remaining prefix
+// without the trailing "..." and truncated is set. ok is false for captions
+// the bot did not write for a generation.
+func promptFromCaption(caption string) (prompt string, truncated, ok bool) {
+	prompt, ok = strings.CutPrefix(caption, captionPromptPrefix)
+	if i := strings.LastIndex(prompt, captionSeedPrefix); i >= 0 {
+		if _, err := strconv.ParseInt(prompt[i+len(captionSeedPrefix):], 10, 64); err == nil {
+			prompt = prompt[:i]
+		}
+	}
+	if !ok || strings.TrimSpace(prompt) == "" {
+		return "", false, false
+	}
+	if len(prompt) == captionPromptLimit && strings.HasSuffix(prompt, "...") {
+		return strings.TrimSuffix(prompt, "..."), true, true
+	}
+	return prompt, false, true
+}
+
+// promptCaption formats the caption sent with a generated image, followed
+// by the seed it was generated with unless that is unknown (negative)
+func promptCaption(prompt string, seed int64) string {
+	caption := captionPromptPrefix + truncate(prompt, captionPromptLimit)
+	if seed >= 0 {
+		caption += captionSeedPrefix + strconv.FormatInt(seed, 10)
+	}
+	return caption
+}
+
+// handleReplyRegenerate regenerates the prompt of one of the bot's images
+// when the user replies to it without a new prompt, e.g. with "+" or an
+// emoji. Replies with their own prompt are left to the normal prompt
+// handling. Returns true if the message was handled.
+func (h *Handler) handleReplyRegenerate(ctx context.Context, msg *tgbotapi.Message, userID int64) bool {
+	replyTo := msg.ReplyToMessage
+	if replyTo == nil || replyTo.From == nil || replyTo.From.ID != h.bot.Self.ID {
+		return false
+	}
+	if replyTo.Photo == nil && replyTo.Document == nil && replyTo.Video == nil {
+		return false
+	}
+
+	if utf8.RuneCountInString(strings.TrimSpace(msg.Text)) >= 3 {
+		return false
+	}
+
+	prompt, truncated, ok := promptFromCaption(replyTo.Caption)
+	if !ok {
+		return false
+	}
+	if truncated {
+		full, found := h.findRecentPrompt(userID, prompt)
+		if !found {
+			h.sendError(msg.Chat.ID, "That image's caption is shortened and the full prompt is no longer in your history. Please send the prompt again.")
+			return true
+		}
+		prompt = full
+	}
+
+	regen := *msg
+	regen.Text = prompt
+	h.handlePrompt(ctx, &regen, userID)
+	return true
+}
+
+// findRecentPrompt returns the user's most recent prompt starting with
+// prefix
+func (h *Handler) findRecentPrompt(userID int64, prefix string) (string, bool) {
+	if h.history == nil {
+		return "", false
+	}
+
+	entries, err := h.history.Recent(userID, history.MaxEntriesPerUser)
+	if err != nil {
+		h.logger.Error("failed to load history", "error", err, "user_id", userID)
+		return "", false
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Prompt, prefix) {
+			return e.Prompt, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptFromCaption(t *testing.T) {
+	long := strings.Repeat("a cat in a hat ", 20)
+
+	tests := []struct {
+		name          string
+		caption       string
+		wantPrompt    string
+		wantTruncated bool
+		wantOK        bool
+	}{
+		{"plain", promptCaption("a red fox", -1), "a red fox", false, true},
+		{"with seed", promptCaption("a red fox", 42), "a red fox", false, true},
+		{"multiline prompt", promptCaption("line one\nline two", 7), "line one\nline two", false, true},
+		{"seed-like text kept", "Prompt: a fox\nSeed: tomorrow", "a fox\nSeed: tomorrow", false, true},
+		{"truncated", promptCaption(long, -1), long[:captionPromptLimit-3], true, true},
+		{"truncated with seed", promptCaption(long, 42), long[:captionPromptLimit-3], true, true},
+		{"exactly at limit", promptCaption(long[:captionPromptLimit], 1), long[:captionPromptLimit], false, true},
+		{"not a prompt caption", "Upscaled 2x", "", false, false},
+		{"empty prompt", "Prompt: \nSeed: 5", "", false, false},
+		{"empty caption", "", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, truncated, ok := promptFromCaption(tt.caption)
+			if prompt != tt.wantPrompt || truncated != tt.wantTruncated || ok != tt.wantOK {
+				t.Errorf("promptFromCaption(%q) = %q, %v, %v, want %q, %v, %v",
+					tt.caption, prompt, truncated, ok, tt.wantPrompt, tt.wantTruncated, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTruncatedCaptionIsPrefixOfPrompt(t *testing.T) {
+	// Multi-byte runes may be cut mid-way; the prefix must still match the
+	// full prompt in history
+	full := strings.Repeat("кот в шляпе ", 30)
+	prompt, truncated, ok := promptFromCaption(promptCaption(full, 3))
+	if !ok || !truncated {
+		t.Fatalf("promptFromCaption: truncated %v, ok %v, want both", truncated, ok)
+	}
+	if !strings.HasPrefix(full, prompt) {
+		t.Errorf("%q is not a prefix of the full prompt", prompt)
+	}
+}
@@ -0,0 +1,92 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/comfyui"
+	"comfy-tg-bot/internal/settings"
+)
+
+// handleSetSeed handles /setseed <number>, which fixes the sampler seed so
+// the same prompt reproduces the same image
+func (h *Handler) handleSetSeed(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+	args := strings.TrimSpace(msg.CommandArguments())
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load settings. Please try again.")
+		return
+	}
+
+	if args == "" {
+		h.sendText(msg.Chat.ID, fmt.Sprintf(
+			"Seed: %s\n\nUsage: /setseed <number>, e.g. /setseed 1234567890, or /randomseed to use a new seed each time.",
+			seedLabel(userSettings)))
+		return
+	}
+
+	seed, err := parseSeed(args)
+	if err != nil {
+		h.sendError(msg.Chat.ID, fmt.Sprintf("Invalid seed: %s\n\nUsage: /setseed <number>", err))
+		return
+	}
+
+	userSettings.Seed = &seed
+	if err := h.settings.Save(userSettings); err != nil {
+		h.logger.Error("failed to save user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to save settings. Please try again.")
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf(
+		"Seed set to %d. Repeating a prompt will now give the same image; regenerate buttons still use a new seed.", seed))
+}
+
+// handleRandomSeed handles /randomseed, going back to random seeds
+func (h *Handler) handleRandomSeed(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load settings. Please try again.")
+		return
+	}
+
+	userSettings.Seed = nil
+	if err := h.settings.Save(userSettings); err != nil {
+		h.logger.Error("failed to save user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to save settings. Please try again.")
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, "Seed reset to random.")
+}
+
+// parseSeed parses a non-negative seed that survives the workflow's JSON
+// encoding exactly
+func parseSeed(text string) (int64, error) {
+	seed, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected a whole number, got %q", text)
+	}
+	if seed < 0 || seed > comfyui.MaxSeed {
+		return 0, fmt.Errorf("seed must be between 0 and %d", int64(comfyui.MaxSeed))
+	}
+	return seed, nil
+}
+
+// seedLabel formats a user's seed setting for display
+func seedLabel(s *settings.UserSettings) string {
+	if s.Seed == nil {
+		return "random"
+	}
+	return strconv.FormatInt(*s.Seed, 10)
+}
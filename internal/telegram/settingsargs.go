@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/comfyui"
+)
+
+// resetParamValue removes a parameter override when given as its value
+const resetParamValue = "default"
+
+// parseSettingsArgs parses space-separated PARAM=VALUE pairs such as
+// "steps=30 cfg=7.5". Keys are lowercased; a later pair overrides an earlier one.
+func parseSettingsArgs(args string) (map[string]string, error) {
+	params := make(map[string]string)
+	for _, field := range strings.Fields(args) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("expected PARAM=VALUE, got %q", field)
+		}
+		params[strings.ToLower(key)] = value
+	}
+	return params, nil
+}
+
+// handleSettingsArgs applies /settings PARAM=VALUE arguments to the user's
+// workflow parameters
+func (h *Handler) handleSettingsArgs(msg *tgbotapi.Message, args string) {
+	userID := msg.From.ID
+
+	params, err := parseSettingsArgs(args)
+	if err != nil {
+		h.sendError(msg.Chat.ID, fmt.Sprintf(
+			"Invalid arguments: %s\n\nUsage: /settings steps=30 cfg=7.5 sampler=euler\nAvailable: %s",
+			err, strings.Join(comfyui.InjectableParamNames(), ", ")))
+		return
+	}
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load settings. Please try again.")
+		return
+	}
+	if userSettings.WorkflowParams == nil {
+		userSettings.WorkflowParams = make(map[string]string)
+	}
+
+	var applied, invalid []string
+	for _, key := range sortedKeys(params) {
+		value := params[key]
+		if value == resetParamValue {
+			if _, ok := comfyui.InjectableParams[key]; !ok {
+				invalid = append(invalid, fmt.Sprintf("%s (unknown parameter)", key))
+				continue
+			}
+			delete(userSettings.WorkflowParams, key)
+			applied = append(applied, key+"="+resetParamValue)
+			continue
+		}
+		if err := comfyui.ValidateParam(key, value); err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		userSettings.WorkflowParams[key] = value
+		applied = append(applied, key+"="+value)
+	}
+
+	if len(applied) > 0 {
+		if err := h.settings.Save(userSettings); err != nil {
+			h.logger.Error("failed to save user settings", "error", err, "user_id", userID)
+			h.sendError(msg.Chat.ID, "Failed to save settings. Please try again.")
+			return
+		}
+		h.logger.Info("workflow params updated", "user_id", userID, "params", applied)
+	}
+
+	var sb strings.Builder
+	if len(applied) > 0 {
+		sb.WriteString("Updated: " + strings.Join(applied, ", ") + "\n")
+	}
+	if len(invalid) > 0 {
+		sb.WriteString("Rejected:\n")
+		for _, reason := range invalid {
+			sb.WriteString("- " + reason + "\n")
+		}
+		sb.WriteString("Available: " + strings.Join(comfyui.InjectableParamNames(), ", ") + "\n")
+	}
+	sb.WriteString("Workflow params: " + formatWorkflowParams(userSettings.WorkflowParams))
+
+	if len(applied) == 0 {
+		h.sendError(msg.Chat.ID, sb.String())
+		return
+	}
+	h.sendSuccess(msg.Chat.ID, sb.String())
+}
+
+// formatWorkflowParams renders parameter overrides in a stable order
+func formatWorkflowParams(params map[string]string) string {
+	if len(params) == 0 {
+		return "workflow defaults"
+	}
+	pairs := make([]string, 0, len(params))
+	for _, key := range sortedKeys(params) {
+		pairs = append(pairs, key+"="+params[key])
+	}
+	return strings.Join(pairs, " ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
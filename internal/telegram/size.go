@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/comfyui"
+	"comfy-tg-bot/internal/config"
+	"comfy-tg-bot/internal/settings"
+)
+
+// imageSideMultiple is the step ComfyUI's latent sizes must be a multiple of
+const imageSideMultiple = 8
+
+// handleSetSize handles /setsize <width>x<height>, which fixes the output
+// size of workflows with {{WIDTH}} and {{HEIGHT}} placeholders
+func (h *Handler) handleSetSize(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+	args := strings.TrimSpace(msg.CommandArguments())
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load settings. Please try again.")
+		return
+	}
+
+	if args == "" {
+		h.sendText(msg.Chat.ID, fmt.Sprintf(
+			"Image size: %s\n\nUsage: /setsize <width>x<height>, e.g. /setsize 832x1216, or /resetsize to use the workflow's size.",
+			sizeLabel(userSettings)))
+		return
+	}
+
+	width, height, err := h.parseSize(args)
+	if err != nil {
+		h.sendError(msg.Chat.ID, fmt.Sprintf(
+			"Invalid size: %s\n\nUsage: /setsize <width>x<height>, e.g. /setsize 832x1216", err))
+		return
+	}
+
+	userSettings.PreferredWidth = width
+	userSettings.PreferredHeight = height
+	if err := h.settings.Save(userSettings); err != nil {
+		h.logger.Error("failed to save user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to save settings. Please try again.")
+		return
+	}
+
+	text := fmt.Sprintf("Image size set to %dx%d.", width, height)
+	if !h.comfy.SupportsDimensions(h.workflowName(userID)) {
+		text += " Your current workflow has no {{WIDTH}}/{{HEIGHT}} placeholders, so it will keep its own size."
+	}
+	h.sendSuccess(msg.Chat.ID, text)
+}
+
+// handleResetSize handles /resetsize, going back to the aspect ratio
+// choice and the workflow's defaults
+func (h *Handler) handleResetSize(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load settings. Please try again.")
+		return
+	}
+
+	userSettings.PreferredWidth = 0
+	userSettings.PreferredHeight = 0
+	if err := h.settings.Save(userSettings); err != nil {
+		h.logger.Error("failed to save user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to save settings. Please try again.")
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, "Image size reset to the workflow default.")
+}
+
+// parseSize parses "<width>x<height>" and checks it against the allowed
+// range
+func (h *Handler) parseSize(text string) (width, height int, err error) {
+	w, hgt, ok := strings.Cut(strings.ToLower(text), "x")
+	if ok {
+		width, err = strconv.Atoi(strings.TrimSpace(w))
+		if err == nil {
+			height, err = strconv.Atoi(strings.TrimSpace(hgt))
+		}
+	}
+	if !ok || err != nil {
+		return 0, 0, fmt.Errorf("expected <width>x<height>, got %q", text)
+	}
+
+	if width%imageSideMultiple != 0 || height%imageSideMultiple != 0 {
+		return 0, 0, fmt.Errorf("width and height must be multiples of %d", imageSideMultiple)
+	}
+	if width < config.MinImageSide || height < config.MinImageSide ||
+		width > h.maxImageWidth || height > h.maxImageHeight {
+		return 0, 0, fmt.Errorf("size must be between %dx%d and %dx%d",
+			config.MinImageSide, config.MinImageSide, h.maxImageWidth, h.maxImageHeight)
+	}
+	return width, height, nil
+}
+
+// preferredSize returns the user's output size as an aspect ratio for
+// GenerateOptions, or nil if they have not set one
+func preferredSize(s *settings.UserSettings) *comfyui.AspectRatio {
+	if !s.HasPreferredSize() {
+		return nil
+	}
+	return &comfyui.AspectRatio{
+		Name:   fmt.Sprintf("%dx%d", s.PreferredWidth, s.PreferredHeight),
+		Width:  s.PreferredWidth,
+		Height: s.PreferredHeight,
+	}
+}
+
+// sizeLabel formats a user's output size for display
+func sizeLabel(s *settings.UserSettings) string {
+	if !s.HasPreferredSize() {
+		return "workflow default"
+	}
+	return fmt.Sprintf("%dx%d", s.PreferredWidth, s.PreferredHeight)
+}
+
+// sizeButtonText labels the /settings button that resets the output size
+func sizeButtonText(s *settings.UserSettings) string {
+	if !s.HasPreferredSize() {
+		return "Image Size: workflow default"
+	}
+	return "Image Size: " + sizeLabel(s) + " (tap to reset)"
+}
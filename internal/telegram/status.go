@@ -0,0 +1,203 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// jsonFlag is the command argument that switches admin commands to raw JSON output
+const jsonFlag = "--json"
+
+// StatusReport is the structured status returned by /status and /debug
+type StatusReport struct {
+	Timestamp      time.Time    `json:"timestamp"`
+	Uptime         string       `json:"uptime"`
+	UptimeSeconds  int64        `json:"uptime_seconds"`
+	ComfyUIHealthy bool         `json:"comfyui_healthy"`
+	ComfyUIError   string       `json:"comfyui_error,omitempty"`
+	CircuitState   string       `json:"circuit_state"`
+	QueueRunning   int          `json:"queue_running"`
+	QueuePending   int          `json:"queue_pending"`
+	QueueError     string       `json:"queue_error,omitempty"`
+	ActiveCount    int          `json:"active_generations"`
+	Database       DBHealth     `json:"database"`
+	DiskUsage      []DiskFile   `json:"disk_usage,omitempty"`
+	DiskTotalBytes int64        `json:"disk_total_bytes"`
+	Memory         MemoryReport `json:"memory"`
+	Maintenance    bool         `json:"maintenance"`
+	// MaintenanceMessage is the admin's note while maintenance mode is on
+	MaintenanceMessage string `json:"maintenance_message,omitempty"`
+}
+
+// DBHealth reports reachability of the SQLite stores
+type DBHealth struct {
+	SettingsOK    bool   `json:"settings_ok"`
+	SettingsError string `json:"settings_error,omitempty"`
+	AdminOK       bool   `json:"admin_ok"`
+	AdminError    string `json:"admin_error,omitempty"`
+}
+
+// DiskFile is the on-disk size of one database file
+type DiskFile struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// MemoryReport contains a subset of runtime memory statistics
+type MemoryReport struct {
+	AllocBytes      uint64 `json:"alloc_bytes"`
+	TotalAllocBytes uint64 `json:"total_alloc_bytes"`
+	SysBytes        uint64 `json:"sys_bytes"`
+	HeapInuseBytes  uint64 `json:"heap_inuse_bytes"`
+	NumGC           uint32 `json:"num_gc"`
+	Goroutines      int    `json:"goroutines"`
+}
+
+// collectStatus gathers the current bot and backend status
+func (h *Handler) collectStatus(ctx context.Context) StatusReport {
+	uptime := time.Since(h.startedAt)
+	report := StatusReport{
+		Timestamp:     time.Now(),
+		Uptime:        uptime.Truncate(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+		ActiveCount:   h.limiter.ActiveCount(),
+		CircuitState:  h.comfy.CircuitState().String(),
+	}
+
+	if note := h.maintenance.Load(); note != nil {
+		report.Maintenance = true
+		report.MaintenanceMessage = *note
+	}
+
+	if err := h.comfy.CheckHealth(ctx); err != nil {
+		report.ComfyUIError = err.Error()
+	} else {
+		report.ComfyUIHealthy = true
+	}
+
+	if queue, err := h.comfy.GetQueue(ctx); err != nil {
+		report.QueueError = err.Error()
+	} else {
+		report.QueueRunning = len(queue.QueueRunning)
+		report.QueuePending = len(queue.QueuePending)
+	}
+
+	if err := h.settings.Ping(); err != nil {
+		report.Database.SettingsError = err.Error()
+	} else {
+		report.Database.SettingsOK = true
+	}
+
+	if h.adminStore != nil {
+		if err := h.adminStore.Ping(); err != nil {
+			report.Database.AdminError = err.Error()
+		} else {
+			report.Database.AdminOK = true
+		}
+	}
+
+	if h.disk != nil {
+		usage, total := h.disk.Usage()
+		for _, u := range usage {
+			report.DiskUsage = append(report.DiskUsage, DiskFile{Path: u.Path, Bytes: u.Bytes})
+		}
+		report.DiskTotalBytes = total
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	report.Memory = MemoryReport{
+		AllocBytes:      mem.Alloc,
+		TotalAllocBytes: mem.TotalAlloc,
+		SysBytes:        mem.Sys,
+		HeapInuseBytes:  mem.HeapInuse,
+		NumGC:           mem.NumGC,
+		Goroutines:      runtime.NumGoroutine(),
+	}
+
+	return report
+}
+
+// wantsJSON reports whether an admin requested raw JSON output
+func (h *Handler) wantsJSON(msg *tgbotapi.Message) bool {
+	return strings.TrimSpace(msg.CommandArguments()) == jsonFlag && h.whitelist.IsAdmin(msg.From.ID)
+}
+
+// sendJSONDocument marshals v and sends it as a .json document
+func (h *Handler) sendJSONDocument(chatID int64, name string, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		h.logger.Error("failed to marshal json document", "error", err)
+		h.sendError(chatID, "Failed to encode JSON output.")
+		return
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  name,
+		Bytes: data,
+	})
+	if _, err := h.bot.Send(doc); err != nil {
+		h.logger.Error("failed to send json document", "error", err, "chat_id", chatID)
+	}
+}
+
+// handleDebug handles the /debug command for admins
+func (h *Handler) handleDebug(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	report := h.collectStatus(ctx)
+
+	if h.wantsJSON(msg) {
+		h.sendJSONDocument(msg.Chat.ID, "debug.json", report)
+		return
+	}
+
+	diskSummary := "(not monitored)"
+	if h.disk != nil {
+		diskSummary = h.disk.Summary()
+	}
+
+	comfyStatus := "Online"
+	if !report.ComfyUIHealthy {
+		comfyStatus = "Offline (" + report.ComfyUIError + ")"
+	}
+
+	h.sendText(msg.Chat.ID, fmt.Sprintf(
+		"Debug Info:\n\n"+
+			"Uptime: %s\n"+
+			"ComfyUI: %s\n"+
+			"Queue: %d running, %d pending\n"+
+			"Active generations: %d\n"+
+			"Settings DB: %s\n"+
+			"Admin DB: %s\n"+
+			"Disk: %s\n"+
+			"Memory: %.1f MB alloc, %.1f MB sys\n"+
+			"Goroutines: %d",
+		report.Uptime,
+		comfyStatus,
+		report.QueueRunning, report.QueuePending,
+		report.ActiveCount,
+		okString(report.Database.SettingsOK),
+		okString(report.Database.AdminOK),
+		diskSummary,
+		float64(report.Memory.AllocBytes)/(1024*1024),
+		float64(report.Memory.SysBytes)/(1024*1024),
+		report.Memory.Goroutines,
+	))
+}
+
+func okString(ok bool) string {
+	if ok {
+		return "OK"
+	}
+	return "ERROR"
+}
@@ -0,0 +1,123 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/comfyui"
+	"comfy-tg-bot/internal/history"
+	"comfy-tg-bot/internal/prompt"
+)
+
+const (
+	// minSuggestHistory is how many past prompts are needed before /suggest works
+	minSuggestHistory = 3
+	// suggestHistoryWindow is how many recent prompts are analyzed
+	suggestHistoryWindow = history.MaxEntriesPerUser
+)
+
+// recordHistory stores a completed generation for /history and later
+// suggestions
+func (h *Handler) recordHistory(userID, chatID int64, promptText, derivedFrom string, output *comfyui.Output) {
+	if h.history == nil {
+		return
+	}
+
+	entry := history.Entry{
+		UserID:        userID,
+		ChatID:        chatID,
+		Prompt:        promptText,
+		DerivedFrom:   derivedFrom,
+		ImageFilename: output.Filename,
+		Seed:          output.Seed,
+		CreatedAt:     time.Now(),
+	}
+	if err := h.history.Add(entry); err != nil {
+		h.logger.Error("failed to record history", "error", err, "user_id", userID)
+	}
+}
+
+// handleSuggest handles the /suggest command
+func (h *Handler) handleSuggest(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+
+	if h.history == nil || h.vocab == nil {
+		h.sendError(msg.Chat.ID, "Suggestions are not available.")
+		return
+	}
+
+	entries, err := h.history.Recent(userID, suggestHistoryWindow)
+	if err != nil {
+		h.logger.Error("failed to load history", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load your prompt history. Please try again.")
+		return
+	}
+
+	if len(entries) < minSuggestHistory {
+		h.sendText(msg.Chat.ID, fmt.Sprintf(
+			"Generate at least %d images first so I can learn what you like.", minSuggestHistory))
+		return
+	}
+
+	recent := make([]string, len(entries))
+	for i, e := range entries {
+		recent[i] = e.Prompt
+	}
+
+	suggestions := prompt.SuggestPrompts(recent, h.vocab)
+	if len(suggestions) == 0 {
+		h.sendText(msg.Chat.ID, "I couldn't come up with any new ideas right now. Try again later.")
+		return
+	}
+
+	h.suggestionsMu.Lock()
+	h.suggestions[userID] = suggestions
+	h.suggestionsMu.Unlock()
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, s := range suggestions {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(truncate(s, 60), fmt.Sprintf("suggest:%d", i)),
+		))
+	}
+
+	var text strings.Builder
+	text.WriteString("Here are some ideas based on your recent prompts. Tap one to generate it:\n")
+	for i, s := range suggestions {
+		fmt.Fprintf(&text, "\n%d. %s", i+1, s)
+	}
+
+	reply := h.newMessage(msg.Chat.ID, text.String())
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := h.bot.Send(reply); err != nil {
+		h.logger.Error("failed to send suggestions", "error", err)
+	}
+}
+
+// handleSuggestCallback starts a generation for a tapped suggestion
+func (h *Handler) handleSuggestCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	userID := query.From.ID
+
+	idx, err := strconv.Atoi(strings.TrimPrefix(query.Data, "suggest:"))
+	if err != nil {
+		h.answerCallback(query.ID, "Invalid suggestion")
+		return
+	}
+
+	h.suggestionsMu.Lock()
+	suggestions := h.suggestions[userID]
+	h.suggestionsMu.Unlock()
+
+	if idx < 0 || idx >= len(suggestions) || query.Message == nil {
+		h.answerCallback(query.ID, "Suggestion expired, use /suggest again")
+		return
+	}
+
+	h.answerCallback(query.ID, "Generating...")
+	h.generateForUser(ctx, query.Message.Chat.ID, userID, suggestions[idx], genOptions{})
+}
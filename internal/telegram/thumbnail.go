@@ -0,0 +1,28 @@
+package telegram
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// thumbnailMaxSide is the longest side of the preview sent before the full image
+const thumbnailMaxSide = 256
+
+// sendThumbnailPreview sends a small JPEG preview of a generated image so
+// the user sees the result before the full-resolution versions are
+// uploaded. Returns the preview's message ID, or 0 if none was sent.
+func (h *Handler) sendThumbnailPreview(chatID int64, data []byte) int {
+	thumb, err := h.processor.Thumbnail(data, thumbnailMaxSide)
+	if err != nil {
+		h.logger.Warn("failed to create thumbnail preview", "error", err)
+		return 0
+	}
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "preview.jpg", Bytes: thumb})
+	photo.Caption = "Full resolution incoming…"
+	sent, err := h.bot.Send(photo)
+	if err != nil {
+		h.logger.Warn("failed to send thumbnail preview", "error", err)
+		return 0
+	}
+	return sent.MessageID
+}
@@ -0,0 +1,143 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/settings"
+)
+
+// Bounds on /settimeout. The upper bound applies to admins too, so the
+// duration cannot overflow; non-admins are further limited by
+// comfyui.max_user_timeout_seconds.
+const (
+	minUserTimeoutSeconds     = 10
+	absoluteMaxTimeoutSeconds = 24 * 60 * 60
+)
+
+// handleSetTimeout handles /settimeout <seconds>, which replaces the request
+// timeout for the user's generations
+func (h *Handler) handleSetTimeout(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+	args := strings.TrimSpace(msg.CommandArguments())
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load settings. Please try again.")
+		return
+	}
+
+	if args == "" {
+		h.sendText(msg.Chat.ID, fmt.Sprintf(
+			"Generation Timeout: %s\n\nUsage: /settimeout <seconds>, e.g. /settimeout 900, or /cleartimeout to use the default.",
+			h.timeoutLabel(userSettings)))
+		return
+	}
+
+	seconds, err := h.parseTimeout(args, h.whitelist.IsAdmin(userID))
+	if err != nil {
+		h.sendError(msg.Chat.ID, fmt.Sprintf("Invalid timeout: %s\n\nUsage: /settimeout <seconds>", err))
+		return
+	}
+
+	userSettings.GenerationTimeoutSeconds = &seconds
+	if err := h.settings.Save(userSettings); err != nil {
+		h.logger.Error("failed to save user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to save settings. Please try again.")
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf("Generation timeout set to %s.", time.Duration(seconds)*time.Second))
+}
+
+// handleClearTimeout handles /cleartimeout, going back to the request timeout
+func (h *Handler) handleClearTimeout(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to load settings. Please try again.")
+		return
+	}
+
+	userSettings.GenerationTimeoutSeconds = nil
+	if err := h.settings.Save(userSettings); err != nil {
+		h.logger.Error("failed to save user settings", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to save settings. Please try again.")
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf("Generation timeout reset to the default (%s).", h.cfg.RequestTimeout))
+}
+
+// parseTimeout parses a timeout in seconds. Admins may exceed
+// comfyui.max_user_timeout_seconds.
+func (h *Handler) parseTimeout(text string, isAdmin bool) (int, error) {
+	seconds, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("expected a whole number of seconds, got %q", text)
+	}
+
+	maxSeconds := absoluteMaxTimeoutSeconds
+	if !isAdmin {
+		if h.maxUserTimeout <= 0 {
+			return 0, errors.New("only admins can change their timeout")
+		}
+		maxSeconds = min(maxSeconds, h.maxUserTimeout)
+	}
+	if seconds < minUserTimeoutSeconds || seconds > maxSeconds {
+		return 0, fmt.Errorf("timeout must be between %d and %d seconds", minUserTimeoutSeconds, maxSeconds)
+	}
+	return seconds, nil
+}
+
+// userTimeout returns the user's generation timeout, or 0 to use the
+// request timeout. Falls back to the request timeout if settings cannot be
+// loaded.
+func (h *Handler) userTimeout(userID int64) time.Duration {
+	s, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Warn("failed to get user timeout", "error", err, "user_id", userID)
+		return 0
+	}
+	if s.GenerationTimeoutSeconds == nil {
+		return 0
+	}
+	return time.Duration(*s.GenerationTimeoutSeconds) * time.Second
+}
+
+// withUserTimeout derives a cancellable context that ends after timeout
+// instead of at ctx's deadline. Cancellation of ctx, e.g. on shutdown, is
+// still passed on.
+func withUserTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelCauseFunc) {
+	timeoutCtx, cancelTimeout := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	genCtx, cancel := context.WithCancelCause(timeoutCtx)
+
+	stop := context.AfterFunc(ctx, func() {
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			cancel(context.Cause(ctx))
+		}
+	})
+
+	return genCtx, func(cause error) {
+		stop()
+		cancel(cause)
+		cancelTimeout()
+	}
+}
+
+// timeoutLabel formats a user's generation timeout for display
+func (h *Handler) timeoutLabel(s *settings.UserSettings) string {
+	if s.GenerationTimeoutSeconds == nil {
+		return fmt.Sprintf("default (%s)", h.cfg.RequestTimeout)
+	}
+	return (time.Duration(*s.GenerationTimeoutSeconds) * time.Second).String()
+}
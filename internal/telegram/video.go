@@ -0,0 +1,58 @@
+package telegram
+
+import (
+	"path/filepath"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/comfyui"
+	"comfy-tg-bot/internal/settings"
+)
+
+// sendVideoOutput delivers a video generation. MP4s are sent as playable
+// videos when the user's SendVideo setting is on; everything else (GIF,
+// WebM, ...) is sent as a document. replyTo is 0 outside groups.
+func (h *Handler) sendVideoOutput(chatID, userID int64, prompt string, output *comfyui.Output, replyTo int) {
+	sendVideo := true
+	if us, err := h.settings.Get(userID); err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+	} else {
+		sendVideo = us.SendVideo
+	}
+
+	name := filepath.Base(output.Video.Filename)
+	file := tgbotapi.FileBytes{Name: name, Bytes: output.Data}
+	caption := promptCaption(prompt, output.Seed)
+
+	var chattable tgbotapi.Chattable
+	if sendVideo && isMP4(output.Video) {
+		video := tgbotapi.NewVideo(chatID, file)
+		video.Caption = caption
+		video.SupportsStreaming = true
+		video.ReplyToMessageID = replyTo
+		chattable = video
+	} else {
+		doc := tgbotapi.NewDocument(chatID, file)
+		doc.Caption = caption
+		doc.ReplyToMessageID = replyTo
+		chattable = doc
+	}
+
+	if _, err := h.bot.Send(chattable); err != nil {
+		h.logger.Error("failed to send video", "error", err, "chat_id", chatID)
+	}
+}
+
+// isMP4 reports whether a video output can be played inline by Telegram
+func isMP4(v *comfyui.VideoOutput) bool {
+	return strings.HasSuffix(v.Format, "mp4") || strings.EqualFold(filepath.Ext(v.Filename), ".mp4")
+}
+
+// videoStyle describes how video outputs are delivered
+func videoStyle(s *settings.UserSettings) string {
+	if s.SendVideo {
+		return "Playable"
+	}
+	return "File"
+}
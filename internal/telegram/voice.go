@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	apperrors "comfy-tg-bot/internal/errors"
+)
+
+// Transcriber turns speech into text for voice message prompts
+type Transcriber interface {
+	// Transcribe returns the text spoken in audio; filename carries the
+	// audio format
+	Transcribe(ctx context.Context, filename string, audio []byte) (string, error)
+}
+
+// handleVoice transcribes a voice message sent in a private chat and
+// generates the transcript as a prompt
+func (h *Handler) handleVoice(ctx context.Context, msg *tgbotapi.Message, userID int64) {
+	if h.transcriber == nil {
+		h.sendText(msg.Chat.ID, "Voice messages are not supported (no transcription service configured).")
+		return
+	}
+
+	// Don't spend a transcription on a prompt that would be turned away
+	if h.rejectIfMaintenance(msg.Chat.ID, userID) {
+		return
+	}
+	if err := h.checkQuota(userID); err != nil {
+		h.sendError(msg.Chat.ID, apperrors.GetUserMessage(err))
+		return
+	}
+	if err := h.limiter.CheckCooldown(userID); err != nil {
+		h.sendError(msg.Chat.ID, apperrors.GetUserMessage(err))
+		return
+	}
+
+	if msg.Voice.FileSize > maxReferenceBytes {
+		h.sendError(msg.Chat.ID, "That voice message is too long. Please keep it under 20 MB.")
+		return
+	}
+
+	stopAction := h.startChatAction(ctx, msg.Chat.ID, tgbotapi.ChatTyping)
+	transcript, err := h.transcribeVoice(ctx, msg.Voice.FileID)
+	stopAction()
+	if err != nil {
+		h.logger.Error("voice transcription failed", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to transcribe your voice message. Please try again or type the prompt.")
+		return
+	}
+	if transcript == "" {
+		h.sendError(msg.Chat.ID, "No speech was recognized in your voice message.")
+		return
+	}
+
+	h.logger.Info("voice message transcribed", "user_id", userID, "duration", msg.Voice.Duration, "prompt_length", len(transcript))
+	h.sendText(msg.Chat.ID, "Generating: "+transcript)
+
+	prompt := *msg
+	prompt.Text = transcript
+	h.handlePrompt(ctx, &prompt, userID)
+}
+
+// transcribeVoice downloads a voice message and transcribes it
+func (h *Handler) transcribeVoice(ctx context.Context, fileID string) (string, error) {
+	audio, err := h.downloadFile(ctx, fileID)
+	if err != nil {
+		return "", err
+	}
+	// Telegram voice messages are Opus in an OGG container
+	return h.transcriber.Transcribe(ctx, "voice.ogg", audio)
+}
@@ -0,0 +1,48 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/limiter"
+)
+
+// countingTranscriber counts Transcribe calls
+type countingTranscriber struct{ calls int }
+
+func (c *countingTranscriber) Transcribe(ctx context.Context, filename string, audio []byte) (string, error) {
+	c.calls++
+	return "a prompt", nil
+}
+
+func TestVoiceSkipsTranscriptionDuringCooldown(t *testing.T) {
+	h, api, _ := newTestHandler(t)
+	transcriber := &countingTranscriber{}
+	h.transcriber = transcriber
+
+	l := limiter.NewUserLimiter(0)
+	l.SetCooldown(time.Minute)
+	if err := l.TryAcquire(5); err != nil {
+		t.Fatal(err)
+	}
+	l.Release(5)
+	h.limiter = l
+
+	h.handleVoice(context.Background(), &tgbotapi.Message{
+		From:  &tgbotapi.User{ID: 5},
+		Chat:  &tgbotapi.Chat{ID: 5},
+		Voice: &tgbotapi.Voice{FileID: "voice", FileSize: 1000},
+	}, 5)
+
+	if transcriber.calls != 0 {
+		t.Errorf("transcribed %d times during the cooldown, want 0", transcriber.calls)
+	}
+	sent := api.sentTo(5)
+	if len(sent) != 1 || !strings.Contains(sent[0], "Please wait") {
+		t.Errorf("sent %q, want the cooldown message", sent)
+	}
+}
@@ -0,0 +1,116 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// webhookSecretHeader carries the secret_token given to setWebhook
+const webhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// runWebhook receives updates over HTTPS webhook calls until ctx is
+// cancelled, handling them on drainCtx. TLS is expected to be terminated by a reverse proxy in front
+// of WebhookListenAddr.
+func (b *Bot) runWebhook(ctx, drainCtx context.Context, cancelDrain context.CancelFunc) error {
+	webhookURL, err := url.Parse(b.cfg.WebhookURL)
+	if err != nil {
+		return fmt.Errorf("parse webhook url: %w", err)
+	}
+
+	if err := b.setWebhook(webhookURL); err != nil {
+		return err
+	}
+
+	path := webhookURL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, verifyWebhookSecret(b.cfg.WebhookSecret, b.logger, http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			update, err := b.api.HandleUpdate(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			b.dispatch(drainCtx, *update)
+		},
+	)))
+
+	srv := &http.Server{
+		Addr:              b.cfg.WebhookListenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	b.logger.Info("listening for webhook updates", "addr", b.cfg.WebhookListenAddr, "path", path)
+	b.healthy.Store(true)
+	defer b.healthy.Store(false)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("webhook server: %w", err)
+		}
+		return nil
+
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			b.logger.Warn("webhook server shutdown failed", "error", err)
+		}
+		b.waitForActiveRequests(cancelDrain)
+		return ctx.Err()
+	}
+}
+
+// setWebhook registers the webhook with Telegram. tgbotapi's WebhookConfig
+// predates secret_token, so the parameters are sent directly.
+func (b *Bot) setWebhook(webhookURL *url.URL) error {
+	params := tgbotapi.Params{
+		"url":          webhookURL.String(),
+		"secret_token": b.cfg.WebhookSecret,
+	}
+	if _, err := b.api.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("set webhook: %w", err)
+	}
+	return nil
+}
+
+// verifyWebhookSecret rejects requests whose secret token header does not
+// match the configured secret with 403 Forbidden
+func verifyWebhookSecret(secret string, logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := r.Header.Get(webhookSecretHeader)
+		if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(secret)) != 1 {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			logger.Warn("rejected webhook request with invalid secret token",
+				"remote_ip", host,
+				"header_present", got != "",
+			)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
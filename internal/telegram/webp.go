@@ -0,0 +1,36 @@
+package telegram
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/image"
+)
+
+// sendWebP sends the result as a WebP document. It returns false if
+// encoding failed or the WebP is no smaller than the JPEG, so the caller
+// sends the JPEG photo instead.
+func (h *Handler) sendWebP(chatID int64, prompt string, seed int64, result *image.Result) bool {
+	if err := h.processor.AddWebP(result); err != nil {
+		h.logger.Error("webp encoding failed, sending jpeg instead", "error", err)
+		return false
+	}
+	if len(result.CompressedWebP) == 0 {
+		h.logger.Debug("webp larger than jpeg, sending jpeg", "jpeg_size", result.CompressedSize)
+		return false
+	}
+
+	h.logger.Info("encoded webp",
+		"jpeg_size", result.CompressedSize,
+		"webp_size", result.CompressedWebPSize,
+	)
+
+	docMsg := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  "image.webp",
+		Bytes: result.CompressedWebP,
+	})
+	docMsg.Caption = promptCaption(prompt, seed)
+	if _, err := h.bot.Send(docMsg); err != nil {
+		h.logger.Error("failed to send webp document", "error", err)
+	}
+	return true
+}
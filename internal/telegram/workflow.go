@@ -0,0 +1,323 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"comfy-tg-bot/internal/comfyui"
+)
+
+// handleWorkflowReload handles the /wfreload and /reloadworkflow commands
+// for admins
+func (h *Handler) handleWorkflowReload(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if err := h.comfy.ReloadWorkflow(); err != nil {
+		h.logger.Error("failed to reload workflow", "error", err)
+		h.sendError(msg.Chat.ID, fmt.Sprintf("Failed to reload workflow: %v", err))
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, "Workflow reloaded.")
+}
+
+// handleWorkflowRollback handles the /wfrollback command for admins.
+// Without arguments it lists the available backups.
+func (h *Handler) handleWorkflowRollback(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	name := strings.TrimSpace(msg.CommandArguments())
+	if name == "" {
+		backups, err := h.comfy.WorkflowBackups()
+		if err != nil {
+			h.logger.Error("failed to list workflow backups", "error", err)
+			h.sendError(msg.Chat.ID, "Failed to list workflow backups.")
+			return
+		}
+		if len(backups) == 0 {
+			h.sendText(msg.Chat.ID, "No workflow backups available.")
+			return
+		}
+
+		var sb strings.Builder
+		sb.WriteString("Workflow backups (newest first):\n\n")
+		for _, b := range backups {
+			sb.WriteString(filepath.Base(b))
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\nUsage: /wfrollback <backup_name>")
+		h.sendText(msg.Chat.ID, sb.String())
+		return
+	}
+
+	if err := h.comfy.RollbackWorkflow(name); err != nil {
+		h.logger.Error("failed to roll back workflow", "error", err, "backup", name)
+		h.sendError(msg.Chat.ID, fmt.Sprintf("Failed to roll back workflow: %v", err))
+		return
+	}
+
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf("Workflow restored from %s.", name))
+}
+
+// canUseWorkflow reports whether a user may generate with the named workflow
+func (h *Handler) canUseWorkflow(userID int64, name string) bool {
+	return h.workflowPermitted(userID, name, h.userWorkflows(userID))
+}
+
+// workflowPermitted checks a workflow against telegram.workflow_access and
+// the user's /setuserworkflows list (nil = unrestricted)
+func (h *Handler) workflowPermitted(userID int64, name string, permitted []string) bool {
+	if !h.comfy.HasWorkflow(name) {
+		return false
+	}
+	if h.whitelist.IsAdmin(userID) {
+		return true
+	}
+	if permitted != nil && !slices.Contains(permitted, name) {
+		return false
+	}
+	allowed, restricted := h.cfg.WorkflowAccess[name]
+	return !restricted || slices.Contains(allowed, userID)
+}
+
+// userWorkflows returns the workflows an admin has restricted a user to, or
+// nil if the user is unrestricted
+func (h *Handler) userWorkflows(userID int64) []string {
+	if h.adminStore == nil {
+		return nil
+	}
+	workflows, err := h.adminStore.GetUserWorkflows(userID)
+	if err != nil {
+		h.logger.Error("failed to get user workflows", "error", err, "user_id", userID)
+		// Fail closed so a database error cannot widen access
+		return []string{}
+	}
+	return workflows
+}
+
+// availableWorkflows returns the workflow names a user may select
+func (h *Handler) availableWorkflows(userID int64) []string {
+	permitted := h.userWorkflows(userID)
+
+	var names []string
+	for _, name := range h.comfy.WorkflowNames() {
+		if h.workflowPermitted(userID, name, permitted) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// workflowName returns the workflow to generate with for a user, falling
+// back to the default if their choice was removed or is no longer allowed
+func (h *Handler) workflowName(userID int64) string {
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		return ""
+	}
+	return h.resolveWorkflow(userID, userSettings.WorkflowName)
+}
+
+// resolveWorkflow checks a stored workflow choice, returning "" (the
+// default) if it is unset or no longer available to the user
+func (h *Handler) resolveWorkflow(userID int64, name string) string {
+	if name == "" {
+		return ""
+	}
+	if !h.canUseWorkflow(userID, name) {
+		h.logger.Warn("selected workflow unavailable, using default",
+			"user_id", userID, "workflow", name)
+		return ""
+	}
+	return name
+}
+
+// handleWorkflow handles the /workflow command. Without arguments it shows
+// the available workflows as an inline keyboard.
+func (h *Handler) handleWorkflow(ctx context.Context, msg *tgbotapi.Message) {
+	userID := msg.From.ID
+
+	if name := strings.ToLower(strings.TrimSpace(msg.CommandArguments())); name != "" {
+		if !h.canUseWorkflow(userID, name) {
+			h.sendError(msg.Chat.ID, fmt.Sprintf(
+				"Unknown workflow %q. Available: %s", name, strings.Join(h.availableWorkflows(userID), ", ")))
+			return
+		}
+		if err := h.selectWorkflow(userID, name); err != nil {
+			h.sendError(msg.Chat.ID, "Failed to save settings. Please try again.")
+			return
+		}
+		h.sendSuccess(msg.Chat.ID, fmt.Sprintf("Now generating with the %s workflow.", name))
+		return
+	}
+
+	current := workflowLabel(h.workflowName(userID))
+
+	reply := h.newMessage(msg.Chat.ID, fmt.Sprintf("Current workflow: %s\n\nChoose a workflow:", current))
+	reply.ReplyMarkup = h.buildWorkflowKeyboard(userID, current)
+	if _, err := h.bot.Send(reply); err != nil {
+		h.logger.Error("failed to send workflow list", "error", err)
+	}
+}
+
+// handleWorkflowCallback switches workflows from the /workflow keyboard
+func (h *Handler) handleWorkflowCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	userID := query.From.ID
+	name := strings.TrimPrefix(query.Data, "workflow:")
+
+	if !h.canUseWorkflow(userID, name) {
+		h.answerCallback(query.ID, "Workflow not available")
+		return
+	}
+	if err := h.selectWorkflow(userID, name); err != nil {
+		h.answerCallback(query.ID, "Failed to save settings")
+		return
+	}
+
+	if query.Message != nil {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(
+			query.Message.Chat.ID,
+			query.Message.MessageID,
+			fmt.Sprintf("Current workflow: %s\n\nChoose a workflow:", name),
+			h.buildWorkflowKeyboard(userID, name),
+		)
+		if _, err := h.bot.Send(edit); err != nil {
+			h.logger.Error("failed to edit workflow message", "error", err)
+		}
+	}
+
+	h.answerCallback(query.ID, "Workflow updated")
+}
+
+// selectWorkflow stores the user's workflow choice
+func (h *Handler) selectWorkflow(userID int64, name string) error {
+	userSettings, err := h.settings.Get(userID)
+	if err != nil {
+		h.logger.Error("failed to get user settings", "error", err, "user_id", userID)
+		return err
+	}
+
+	userSettings.WorkflowName = name
+	if name == comfyui.DefaultWorkflow {
+		userSettings.WorkflowName = ""
+	}
+	if err := h.settings.Save(userSettings); err != nil {
+		h.logger.Error("failed to save user settings", "error", err, "user_id", userID)
+		return err
+	}
+
+	h.logger.Info("workflow selected", "user_id", userID, "workflow", name)
+	return nil
+}
+
+func (h *Handler) buildWorkflowKeyboard(userID int64, current string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, name := range h.availableWorkflows(userID) {
+		label := name
+		if name == current {
+			label = "✓ " + name
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, "workflow:"+name),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// workflowLabel renders a stored workflow choice for display
+func workflowLabel(name string) string {
+	if name == "" {
+		return comfyui.DefaultWorkflow
+	}
+	return name
+}
+
+// setUserWorkflowsUsage explains the /setuserworkflows arguments
+const setUserWorkflowsUsage = "Usage: /setuserworkflows <user_id> <workflow1,workflow2|all>"
+
+// handleSetUserWorkflows handles the /setuserworkflows command for admins,
+// restricting a user to the listed workflows. "all" lifts the restriction
+// and omitting the list shows the current one.
+func (h *Handler) handleSetUserWorkflows(ctx context.Context, msg *tgbotapi.Message) {
+	if !h.whitelist.IsAdmin(msg.From.ID) {
+		h.sendError(msg.Chat.ID, "This command is only available to admins.")
+		return
+	}
+
+	if h.adminStore == nil {
+		h.sendError(msg.Chat.ID, "Admin features are not configured.")
+		return
+	}
+
+	fields := strings.Fields(msg.CommandArguments())
+	if len(fields) == 0 || len(fields) > 2 {
+		h.sendError(msg.Chat.ID, setUserWorkflowsUsage)
+		return
+	}
+
+	userID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		h.sendError(msg.Chat.ID, "Invalid user ID. "+setUserWorkflowsUsage)
+		return
+	}
+
+	if len(fields) == 1 {
+		workflows, err := h.adminStore.GetUserWorkflows(userID)
+		if err != nil {
+			h.logger.Error("failed to get user workflows", "error", err, "user_id", userID)
+			h.sendError(msg.Chat.ID, "Failed to load workflow permissions.")
+			return
+		}
+		if len(workflows) == 0 {
+			h.sendText(msg.Chat.ID, fmt.Sprintf("User %d may use all workflows.", userID))
+			return
+		}
+		h.sendText(msg.Chat.ID, fmt.Sprintf("User %d may use: %s", userID, strings.Join(workflows, ", ")))
+		return
+	}
+
+	var workflows []string
+	if list := strings.ToLower(fields[1]); list != "all" {
+		for _, name := range strings.Split(list, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || slices.Contains(workflows, name) {
+				continue
+			}
+			if !h.comfy.HasWorkflow(name) {
+				h.sendError(msg.Chat.ID, fmt.Sprintf(
+					"Unknown workflow %q. Available: %s", name, strings.Join(h.comfy.WorkflowNames(), ", ")))
+				return
+			}
+			workflows = append(workflows, name)
+		}
+		if len(workflows) == 0 {
+			h.sendError(msg.Chat.ID, setUserWorkflowsUsage)
+			return
+		}
+	}
+
+	if err := h.adminStore.SetUserWorkflows(userID, workflows); err != nil {
+		h.logger.Error("failed to set user workflows", "error", err, "user_id", userID)
+		h.sendError(msg.Chat.ID, "Failed to save workflow permissions.")
+		return
+	}
+
+	h.logger.Info("user workflows updated", "admin_id", msg.From.ID, "user_id", userID, "workflows", workflows)
+	if len(workflows) == 0 {
+		h.sendSuccess(msg.Chat.ID, fmt.Sprintf("User %d may now use all workflows.", userID))
+		return
+	}
+	h.sendSuccess(msg.Chat.ID, fmt.Sprintf("User %d may now use: %s", userID, strings.Join(workflows, ", ")))
+}
@@ -0,0 +1,88 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	apperrors "comfy-tg-bot/internal/errors"
+)
+
+// Quota enforces a daily per-user generation limit
+type Quota struct {
+	store    UsageStore
+	maxDaily int
+	logger   *slog.Logger
+}
+
+// NewQuota creates a quota allowing maxDaily generations per user per UTC
+// day. maxDaily of 0 means unlimited; usage is still recorded.
+func NewQuota(store UsageStore, maxDaily int, logger *slog.Logger) *Quota {
+	return &Quota{
+		store:    store,
+		maxDaily: maxDaily,
+		logger:   logger,
+	}
+}
+
+// Check returns ErrQuotaExceeded if the user has used up today's quota.
+// Store errors are logged and allow the generation rather than blocking it.
+func (q *Quota) Check(userID int64) error {
+	return q.CheckN(userID, 1)
+}
+
+// CheckN returns ErrQuotaExceeded if the user has fewer than n generations
+// left today. Store errors are logged and allow the generations.
+func (q *Quota) CheckN(userID int64, n int) error {
+	if q.maxDaily <= 0 {
+		return nil
+	}
+
+	count, err := q.store.GetCount(userID, time.Now())
+	if err != nil {
+		q.logger.Warn("failed to read daily usage", "error", err, "user_id", userID)
+		return nil
+	}
+	remaining := q.maxDaily - count
+	if remaining >= n {
+		return nil
+	}
+
+	if remaining > 0 {
+		return apperrors.Wrap(apperrors.ErrQuotaExceeded,
+			fmt.Sprintf("You have %d of your %d daily generations left, not enough for %d. It resets at midnight UTC.",
+				remaining, q.maxDaily, n), false)
+	}
+	return apperrors.Wrap(apperrors.ErrQuotaExceeded,
+		fmt.Sprintf("You've reached your daily limit of %d generations. It resets at midnight UTC.", q.maxDaily), false)
+}
+
+// Record counts a generation against the user's daily quota
+func (q *Quota) Record(userID int64) {
+	if err := q.store.Increment(userID); err != nil {
+		q.logger.Warn("failed to record daily usage", "error", err, "user_id", userID)
+	}
+}
+
+// Run calls ResetDaily at every midnight UTC until ctx is done
+func (q *Quota) Run(ctx context.Context) {
+	for {
+		now := time.Now().UTC()
+		midnight := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+		timer := time.NewTimer(midnight.Sub(now))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := q.store.ResetDaily(); err != nil {
+			q.logger.Error("failed to reset daily usage", "error", err)
+			continue
+		}
+		q.logger.Info("daily usage reset")
+	}
+}
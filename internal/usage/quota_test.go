@@ -0,0 +1,52 @@
+package usage
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	apperrors "comfy-tg-bot/internal/errors"
+)
+
+type countStore struct {
+	count int
+	err   error
+}
+
+func (s *countStore) Increment(int64) error                  { s.count++; return nil }
+func (s *countStore) GetCount(int64, time.Time) (int, error) { return s.count, s.err }
+func (s *countStore) ResetDaily() error                      { s.count = 0; return nil }
+func (s *countStore) Close() error                           { return nil }
+
+func TestQuotaCheckN(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxDaily int
+		used     int
+		storeErr error
+		n        int
+		wantErr  bool
+	}{
+		{"unlimited", 0, 100, nil, 10, false},
+		{"enough left", 10, 6, nil, 4, false},
+		{"one short", 10, 7, nil, 4, true},
+		{"used up", 10, 10, nil, 1, true},
+		{"single fits", 10, 9, nil, 1, false},
+		{"store error allows", 10, 10, errors.New("db locked"), 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuota(&countStore{count: tt.used, err: tt.storeErr}, tt.maxDaily,
+				slog.New(slog.NewTextHandler(io.Discard, nil)))
+			err := q.CheckN(1, tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckN(%d) error = %v, wantErr %v", tt.n, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, apperrors.ErrQuotaExceeded) {
+				t.Errorf("error %v is not ErrQuotaExceeded", err)
+			}
+		})
+	}
+}
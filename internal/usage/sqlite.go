@@ -0,0 +1,98 @@
+package usage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	appdb "comfy-tg-bot/internal/db"
+)
+
+// migrations defines the usage schema; append new versions, never edit old ones
+var migrations = []appdb.Migration{
+	// One row per user per UTC day
+	{Version: 1, SQL: `
+		CREATE TABLE IF NOT EXISTS usage (
+			user_id INTEGER NOT NULL,
+			date TEXT NOT NULL,
+			count INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (user_id, date)
+		)
+	`},
+}
+
+// SQLiteStore implements UsageStore using SQLite for persistence
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore creates a new SQLite-backed usage store
+func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	// Ensure directory exists
+	dir := filepath.Dir(dbPath)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite works best with a single writer
+	db.SetMaxOpenConns(1)
+
+	if err := appdb.RunMigrations(db, "usage", migrations); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Increment adds one generation to the user's count for today (UTC)
+func (s *SQLiteStore) Increment(userID int64) error {
+	_, err := s.db.Exec(`
+		INSERT INTO usage (user_id, date, count)
+		VALUES (?, ?, 1)
+		ON CONFLICT(user_id, date) DO UPDATE SET count = count + 1
+	`, userID, dayKey(time.Now()))
+	if err != nil {
+		return fmt.Errorf("increment usage: %w", err)
+	}
+	return nil
+}
+
+// GetCount returns the user's generation count for the UTC day of date
+func (s *SQLiteStore) GetCount(userID int64, date time.Time) (int, error) {
+	var count int
+	err := s.db.QueryRow(`
+		SELECT count FROM usage WHERE user_id = ? AND date = ?
+	`, userID, dayKey(date)).Scan(&count)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("query usage: %w", err)
+	}
+	return count, nil
+}
+
+// ResetDaily zeroes counts by removing every day before today (UTC)
+func (s *SQLiteStore) ResetDaily() error {
+	if _, err := s.db.Exec(`DELETE FROM usage WHERE date < ?`, dayKey(time.Now())); err != nil {
+		return fmt.Errorf("reset usage: %w", err)
+	}
+	return nil
+}
+
+// Close releases database resources
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
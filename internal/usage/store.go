@@ -0,0 +1,23 @@
+package usage
+
+import "time"
+
+// dateFormat is how days are keyed in the usage table (always UTC)
+const dateFormat = "2006-01-02"
+
+// UsageStore defines the interface for daily per-user generation counts
+type UsageStore interface {
+	// Increment adds one generation to the user's count for today (UTC)
+	Increment(userID int64) error
+	// GetCount returns the user's generation count for the UTC day of date
+	GetCount(userID int64, date time.Time) (int, error)
+	// ResetDaily zeroes counts by removing every day before today (UTC)
+	ResetDaily() error
+	// Close releases resources
+	Close() error
+}
+
+// dayKey returns the usage table key for the UTC day containing t
+func dayKey(t time.Time) string {
+	return t.UTC().Format(dateFormat)
+}
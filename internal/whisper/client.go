@@ -0,0 +1,99 @@
+// Package whisper transcribes voice messages with an OpenAI-compatible
+// speech-to-text API
+package whisper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"comfy-tg-bot/internal/config"
+)
+
+// maxResponseBytes bounds how much of a transcription response is read
+const maxResponseBytes = 1 << 20
+
+// Client sends audio to a transcription endpoint
+type Client struct {
+	url        string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// transcriptionResponse is the JSON reply of a transcription request
+type transcriptionResponse struct {
+	Text string `json:"text"`
+}
+
+// NewClient creates a transcription client, or returns nil if no URL is
+// configured
+func NewClient(cfg config.WhisperConfig) *Client {
+	if cfg.URL == "" {
+		return nil
+	}
+	return &Client{
+		url:        cfg.URL,
+		apiKey:     cfg.APIKey,
+		model:      cfg.Model,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Transcribe returns the text spoken in audio. filename tells the server
+// the audio format, e.g. voice.ogg for Telegram voice messages.
+func (c *Client) Transcribe(ctx context.Context, filename string, audio []byte) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return "", fmt.Errorf("create form file: %w", err)
+	}
+	if _, err := part.Write(audio); err != nil {
+		return "", fmt.Errorf("write form file: %w", err)
+	}
+	if err := form.WriteField("model", c.model); err != nil {
+		return "", fmt.Errorf("write form field: %w", err)
+	}
+	if err := form.WriteField("response_format", "json"); err != nil {
+		return "", fmt.Errorf("write form field: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("close form: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, &body)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("server returned %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result transcriptionResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}